@@ -0,0 +1,4 @@
+-- 弹性伸缩策略增加持续时间单位，支持分钟/小时级滑动窗口评估
+-- 为空时保持历史兼容规则（duration_minutes 小于100视为天数，否则视为分钟）
+ALTER TABLE ng_elastic_scaling_strategy
+    ADD COLUMN duration_unit VARCHAR(10) NOT NULL DEFAULT '' COMMENT '持续时间单位（day/hour/minute）' AFTER duration_minutes;
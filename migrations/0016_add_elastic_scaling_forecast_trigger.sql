@@ -0,0 +1,10 @@
+-- 弹性伸缩策略增加预测触发模式
+ALTER TABLE ng_elastic_scaling_strategy
+    ADD COLUMN trigger_mode VARCHAR(20) NOT NULL DEFAULT '' COMMENT '触发模式（threshold/forecast），为空时为threshold' AFTER cooldown_minutes,
+    ADD COLUMN forecast_method VARCHAR(20) NOT NULL DEFAULT '' COMMENT '预测方法（linear/holt）' AFTER trigger_mode,
+    ADD COLUMN forecast_horizon_days INT NOT NULL DEFAULT 0 COMMENT '预测窗口（天）' AFTER forecast_method;
+
+-- 策略执行历史记录预测输入和预计越线时间
+ALTER TABLE ng_strategy_execution_history
+    ADD COLUMN forecast_input TEXT NULL COMMENT '预测输入与结果（JSON）',
+    ADD COLUMN projected_crossing_date DATETIME NULL COMMENT '预计越过阈值的时间';
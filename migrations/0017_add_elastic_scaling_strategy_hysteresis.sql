@@ -0,0 +1,3 @@
+-- 弹性伸缩策略增加反向订单防抖窗口
+ALTER TABLE ng_elastic_scaling_strategy
+    ADD COLUMN hysteresis_minutes INT NOT NULL DEFAULT 0 COMMENT '反向订单防抖窗口（分钟），为0时不启用反向订单防抖' AFTER cooldown_minutes;
@@ -0,0 +1,9 @@
+-- 策略集群关联增加集群级覆盖配置，字段为空时使用策略默认值
+ALTER TABLE ng_strategy_cluster_association
+    ADD COLUMN cpu_threshold_value DOUBLE NULL COMMENT '覆盖CPU阈值',
+    ADD COLUMN cpu_target_value DOUBLE NULL COMMENT '覆盖CPU目标值',
+    ADD COLUMN memory_threshold_value DOUBLE NULL COMMENT '覆盖内存阈值',
+    ADD COLUMN memory_target_value DOUBLE NULL COMMENT '覆盖内存目标值',
+    ADD COLUMN duration_minutes INT NULL COMMENT '覆盖持续时间，单位沿用策略配置',
+    ADD COLUMN cooldown_minutes INT NULL COMMENT '覆盖冷却时间（分钟）',
+    ADD COLUMN resource_types VARCHAR(255) NULL COMMENT '覆盖资源类型列表，逗号分隔';
@@ -0,0 +1,17 @@
+-- 变更冻结日历：冻结期间自动伸缩跳过评估且不创建订单
+CREATE TABLE IF NOT EXISTS ng_freeze_window (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    name VARCHAR(128) NOT NULL COMMENT '窗口名称',
+    description VARCHAR(500) NULL COMMENT '描述',
+    cluster_id BIGINT NULL COMMENT '关联集群ID，为空时全局生效',
+    rule_type VARCHAR(20) NOT NULL COMMENT '规则类型：range/weekly/dates',
+    start_time DATETIME NULL COMMENT 'range：开始时间',
+    end_time DATETIME NULL COMMENT 'range：结束时间',
+    weekdays VARCHAR(20) NULL COMMENT 'weekly：逗号分隔的星期，0为周日',
+    dates TEXT NULL COMMENT 'dates：逗号分隔的日期，YYYY-MM-DD 或每年重复的 MM-DD',
+    status VARCHAR(20) NOT NULL DEFAULT 'enabled' COMMENT '状态：enabled/disabled',
+    created_by VARCHAR(50) NULL COMMENT '创建人',
+    INDEX idx_ng_freeze_window_cluster_id (cluster_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='变更冻结窗口';
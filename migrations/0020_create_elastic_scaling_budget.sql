@@ -0,0 +1,16 @@
+-- 自动伸缩预算：按动作类型限制每日/每周自动创建订单的设备数和订单数
+CREATE TABLE IF NOT EXISTS ng_elastic_scaling_budget (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    name VARCHAR(128) NOT NULL COMMENT '预算名称',
+    cluster_id BIGINT NULL COMMENT '限定集群，为空时对所有集群生效',
+    resource_pool_type VARCHAR(50) NULL COMMENT '限定资源池类型，为空时对所有资源池生效',
+    action_type VARCHAR(20) NOT NULL COMMENT '动作类型：pool_entry/pool_exit',
+    period VARCHAR(10) NOT NULL COMMENT '预算周期：daily/weekly',
+    max_devices INT NOT NULL DEFAULT 0 COMMENT '周期内最大设备数，0为不限制',
+    max_orders INT NOT NULL DEFAULT 0 COMMENT '周期内最大订单数，0为不限制',
+    status VARCHAR(20) NOT NULL DEFAULT 'enabled' COMMENT '状态：enabled/disabled',
+    created_by VARCHAR(50) NULL COMMENT '创建人',
+    INDEX idx_ng_elastic_scaling_budget_cluster_id (cluster_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='自动伸缩预算';
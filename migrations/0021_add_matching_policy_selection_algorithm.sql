@@ -0,0 +1,3 @@
+-- 资源池设备匹配策略增加设备选择算法，为空时使用贪婪算法
+ALTER TABLE ng_resource_pool_device_matching_policy
+    ADD COLUMN selection_algorithm VARCHAR(20) NULL COMMENT '设备选择算法：greedy/balanced/min_waste';
@@ -0,0 +1,6 @@
+-- 资源池设备匹配策略增加故障域（机房/机柜）分散约束，为0或false时不限制
+ALTER TABLE ng_resource_pool_device_matching_policy
+    ADD COLUMN max_devices_per_cabinet INT NOT NULL DEFAULT 0 COMMENT '单次选择每个机柜最多设备数',
+    ADD COLUMN max_devices_per_room INT NOT NULL DEFAULT 0 COMMENT '单次选择每个机房最多设备数',
+    ADD COLUMN balance_across_rooms TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否在机房间均衡选择',
+    ADD COLUMN min_devices_per_cabinet INT NOT NULL DEFAULT 0 COMMENT '出池时每个机柜至少保留的集群设备数';
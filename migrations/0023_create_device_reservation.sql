@@ -0,0 +1,18 @@
+-- 设备预留表：device_id 唯一约束保证同一设备同时只能被一个进行中的订单占用
+CREATE TABLE IF NOT EXISTS ng_device_reservation (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    device_id BIGINT NOT NULL COMMENT '预留的设备ID',
+    order_id BIGINT NOT NULL COMMENT '占用设备的订单ID',
+    UNIQUE KEY uk_device_reservation_device (device_id),
+    KEY idx_ng_device_reservation_order_id (order_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='设备预留';
+
+-- 为现有进行中订单的设备补充预留（同一设备存在多个订单时保留最早的订单）
+INSERT IGNORE INTO ng_device_reservation (created_at, updated_at, device_id, order_id)
+SELECT NOW(), NOW(), od.device_id, od.order_id
+FROM ng_order_device od
+JOIN ng_orders o ON o.id = od.order_id
+WHERE o.status IN ('pending', 'processing', 'returning')
+ORDER BY od.order_id;
@@ -0,0 +1,3 @@
+-- 弹性伸缩订单详情增加设备匹配过程（各匹配策略的查询条件、候选设备数及未选中设备的原因）
+ALTER TABLE ng_elastic_scaling_order_details
+    ADD COLUMN matching_trace MEDIUMTEXT NULL COMMENT '设备匹配过程（JSON）';
@@ -0,0 +1,14 @@
+-- 集群位置映射表：配置集群所在的 IDC、机房、网络区域及允许使用的机柜，替代从集群名称中解析机房
+CREATE TABLE IF NOT EXISTS ng_cluster_location (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    cluster_id BIGINT NOT NULL COMMENT '关联集群ID',
+    idc VARCHAR(100) NULL COMMENT 'IDC',
+    rooms TEXT NULL COMMENT '逗号分隔的机房',
+    net_zones TEXT NULL COMMENT '逗号分隔的网络区域',
+    cabinets TEXT NULL COMMENT '逗号分隔的允许使用的机柜，为空时不限制',
+    description VARCHAR(500) NULL COMMENT '描述',
+    created_by VARCHAR(50) NULL COMMENT '创建人',
+    UNIQUE KEY uk_cluster_location_cluster (cluster_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='集群位置映射';
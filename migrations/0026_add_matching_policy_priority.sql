@@ -0,0 +1,7 @@
+-- 资源池设备匹配策略增加优先级和组合模式，订单设备记录选中该设备的匹配策略
+ALTER TABLE ng_resource_pool_device_matching_policy
+    ADD COLUMN priority INT NOT NULL DEFAULT 0 COMMENT '优先级，数值越小越先执行',
+    ADD COLUMN combine_mode VARCHAR(20) NULL COMMENT '多个策略的组合模式：union、first_wins 或 fill_remaining，为空时为 union';
+
+ALTER TABLE ng_order_device
+    ADD COLUMN matching_policy_id BIGINT NOT NULL DEFAULT 0 COMMENT '选中该设备的匹配策略ID，手动添加的设备为0';
@@ -0,0 +1,16 @@
+-- 资源池硬件兼容性规则表：入池设备匹配时作为硬性过滤条件，各条件为空时不限制
+CREATE TABLE IF NOT EXISTS ng_resource_pool_compatibility_rule (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    resource_pool_type VARCHAR(50) NOT NULL COMMENT '资源池类型',
+    arch_types TEXT NULL COMMENT '逗号分隔的允许CPU架构',
+    gpu_requirement VARCHAR(20) NULL COMMENT 'GPU要求：required 必须包含，forbidden 不能包含，为空时不限制',
+    min_disk_count INT NOT NULL DEFAULT 0 COMMENT '最少磁盘数',
+    min_network_speed INT NOT NULL DEFAULT 0 COMMENT '最低网卡速率（Mb/s）',
+    models TEXT NULL COMMENT '逗号分隔的允许型号前缀',
+    os_versions TEXT NULL COMMENT '逗号分隔的允许操作系统版本前缀',
+    description VARCHAR(500) NULL COMMENT '描述',
+    created_by VARCHAR(50) NULL COMMENT '创建人',
+    UNIQUE KEY uk_compatibility_rule_pool (resource_pool_type)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='资源池硬件兼容性规则';
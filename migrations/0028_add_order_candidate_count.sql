@@ -0,0 +1,3 @@
+-- 弹性伸缩订单详情记录设备匹配时查询到的候选设备总数
+ALTER TABLE ng_elastic_scaling_order_details
+    ADD COLUMN candidate_count INT NOT NULL DEFAULT 0 COMMENT '设备匹配时查询到的候选设备总数，手动创建的订单为0';
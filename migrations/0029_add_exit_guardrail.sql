@@ -0,0 +1,6 @@
+-- 出池护栏：策略配置出池后允许达到的资源池告警上限，订单记录设备是否被护栏裁剪
+ALTER TABLE ng_elastic_scaling_strategy
+    ADD COLUMN exit_ceiling_level VARCHAR(20) NULL COMMENT '出池护栏上限：warning 或 critical，为空时为 warning';
+
+ALTER TABLE ng_elastic_scaling_order_details
+    ADD COLUMN guardrail_trimmed TINYINT(1) NOT NULL DEFAULT 0 COMMENT '出池设备是否被出池护栏裁剪';
@@ -0,0 +1,13 @@
+-- 出池排空代价：排空保护规则按节点标签/污点禁止驱逐或增加排空代价
+CREATE TABLE IF NOT EXISTS ng_drain_protection_rule (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    kind VARCHAR(20) NOT NULL COMMENT '匹配的节点属性：label 或 taint',
+    `key` VARCHAR(191) NOT NULL COMMENT '标签或污点的 key',
+    value VARCHAR(191) NOT NULL DEFAULT '' COMMENT '标签或污点的 value，为空时匹配任意值',
+    action VARCHAR(20) NOT NULL COMMENT '处理方式：forbid 禁止驱逐，penalize 增加排空代价',
+    description VARCHAR(500) NULL COMMENT '描述',
+    created_by VARCHAR(50) NULL COMMENT '创建人',
+    UNIQUE KEY uk_drain_protection_rule (kind, `key`, value)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='出池排空保护规则';
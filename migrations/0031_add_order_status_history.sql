@@ -0,0 +1,14 @@
+-- 订单状态变更记录：每次订单或订单设备的状态变更都在同一事务中写入一条记录，用于展示订单时间线
+CREATE TABLE IF NOT EXISTS ng_order_status_history (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    order_id BIGINT NOT NULL COMMENT '关联订单ID',
+    device_id BIGINT NULL COMMENT '关联设备ID，订单状态变更时为空',
+    from_status VARCHAR(50) NULL COMMENT '变更前状态',
+    to_status VARCHAR(50) NULL COMMENT '变更后状态',
+    executor VARCHAR(50) NULL COMMENT '执行人',
+    reason TEXT NULL COMMENT '变更原因',
+    source VARCHAR(20) NULL COMMENT '变更来源：api 接口调用，event 事件处理器，monitor 后台监控',
+    INDEX idx_order_status_history_order (order_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='订单状态变更记录';
@@ -0,0 +1,6 @@
+-- 订单和订单设备的乐观锁版本号：状态更新以版本号为条件，版本不一致时视为并发冲突
+ALTER TABLE ng_orders
+    ADD COLUMN version INT NOT NULL DEFAULT 0 COMMENT '乐观锁版本号，每次更新加1';
+
+ALTER TABLE ng_order_device
+    ADD COLUMN version INT NOT NULL DEFAULT 0 COMMENT '乐观锁版本号，每次更新加1';
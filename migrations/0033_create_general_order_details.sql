@@ -0,0 +1,9 @@
+-- 通用订单详情表：通用订单注册到统一订单接口后，创建订单时写入订单摘要
+CREATE TABLE IF NOT EXISTS general_order_details (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    order_id BIGINT NOT NULL COMMENT '关联订单ID',
+    summary VARCHAR(255) NULL COMMENT '订单摘要',
+    UNIQUE KEY uk_general_order_details_order (order_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='通用订单详情';
@@ -0,0 +1,14 @@
+-- 应用部署订单详情表：部署订单关联执行部署的运维任务，订单状态随运维任务事件推进
+CREATE TABLE IF NOT EXISTS ng_deployment_order_details (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    order_id BIGINT NULL COMMENT '关联订单ID',
+    ops_job_id BIGINT NULL COMMENT '执行部署的运维任务ID',
+    cluster_id BIGINT NULL COMMENT '目标集群ID',
+    nodes TEXT NULL COMMENT '目标节点名称，逗号分隔',
+    job_status VARCHAR(50) NULL COMMENT '最近一次收到的运维任务状态',
+    job_progress INT DEFAULT 0 COMMENT '最近一次收到的运维任务进度（0-100）',
+    UNIQUE KEY uk_deployment_order_details_order (order_id),
+    INDEX idx_deployment_ops_job (ops_job_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='应用部署订单详情';
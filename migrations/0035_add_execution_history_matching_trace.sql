@@ -0,0 +1,3 @@
+-- 设备匹配后被冻结期、预算、出池护栏或防抖拦截时，执行历史记录本次设备匹配过程
+ALTER TABLE ng_strategy_execution_history
+    ADD COLUMN matching_trace MEDIUMTEXT NULL COMMENT '设备匹配后被拦截、未创建订单时的设备匹配过程（JSON）';
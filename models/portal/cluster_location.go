@@ -0,0 +1,19 @@
+package portal
+
+// ClusterLocation 集群位置映射，记录集群所在的IDC、机房、网络区域以及允许使用的机柜。
+// 设备匹配和集群资源统计优先使用该映射，未配置时才使用集群自身字段和集群名称解析。
+type ClusterLocation struct {
+	BaseModel
+	ClusterID   int    `gorm:"column:cluster_id;not null;uniqueIndex:uk_cluster_location_cluster"` // 关联集群ID
+	IDC         string `gorm:"column:idc;size:100"`                                                // IDC
+	Rooms       string `gorm:"column:rooms;type:text"`                                             // 逗号分隔的机房
+	NetZones    string `gorm:"column:net_zones;type:text"`                                         // 逗号分隔的网络区域
+	Cabinets    string `gorm:"column:cabinets;type:text"`                                          // 逗号分隔的允许使用的机柜，为空时不限制
+	Description string `gorm:"column:description;size:500"`
+	CreatedBy   string `gorm:"column:created_by;size:50"`
+}
+
+// TableName 指定表名
+func (ClusterLocation) TableName() string {
+	return "ng_cluster_location"
+}
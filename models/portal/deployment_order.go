@@ -0,0 +1,21 @@
+package portal
+
+// DeploymentOrderDetail 应用部署订单详情模型。部署由关联的运维任务执行，
+// 订单状态随运维任务的开始、完成和失败事件推进；目标设备记录在订单设备关联表中
+type DeploymentOrderDetail struct {
+	BaseModel
+	OrderID     int    `gorm:"column:order_id;type:bigint;unique"`                         // 关联订单ID（外键）
+	OpsJobID    int    `gorm:"column:ops_job_id;type:bigint;index:idx_deployment_ops_job"` // 执行部署的运维任务ID
+	ClusterID   int    `gorm:"column:cluster_id;type:bigint"`                              // 目标集群ID
+	Nodes       string `gorm:"column:nodes;type:text"`                                     // 目标节点名称，逗号分隔
+	JobStatus   string `gorm:"column:job_status;type:varchar(50)"`                         // 最近一次收到的运维任务状态
+	JobProgress int    `gorm:"column:job_progress;type:int;default:0"`                     // 最近一次收到的运维任务进度（0-100）
+
+	// 关联关系
+	Order *Order `gorm:"foreignKey:OrderID"` // 关联的基础订单
+}
+
+// TableName 指定表名
+func (DeploymentOrderDetail) TableName() string {
+	return "ng_deployment_order_details"
+}
@@ -0,0 +1,34 @@
+package portal
+
+// DeviceReservationActiveOrderStatuses 预留仍然有效的订单状态，订单进入其他状态后预留失效。
+// 维护订单在待确认、已安排维护和维护中时设备仍被占用
+var DeviceReservationActiveOrderStatuses = []OrderStatus{
+	OrderStatusPending,
+	OrderStatusProcessing,
+	OrderStatusReturning,
+	OrderStatus(MaintenanceStatusPendingConfirmation),
+	OrderStatus(MaintenanceStatusScheduled),
+	OrderStatus(MaintenanceStatusInProgress),
+}
+
+// DeviceReservation 设备预留，device_id 唯一约束保证同一设备同时只能被一个订单占用
+type DeviceReservation struct {
+	BaseModel
+	DeviceID int `gorm:"column:device_id;type:bigint;not null;uniqueIndex:uk_device_reservation_device"` // 预留的设备ID
+	OrderID  int `gorm:"column:order_id;type:bigint;not null;index"`                                     // 占用设备的订单ID
+}
+
+// TableName 指定表名
+func (DeviceReservation) TableName() string {
+	return "ng_device_reservation"
+}
+
+// IsDeviceReservationActive 判断订单状态下设备预留是否仍然有效
+func IsDeviceReservationActive(status OrderStatus) bool {
+	for _, active := range DeviceReservationActiveOrderStatuses {
+		if status == active {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package portal
+
+// 排空保护规则匹配的节点属性
+const (
+	DrainProtectionKindLabel = "label" // 节点标签
+	DrainProtectionKindTaint = "taint" // 节点污点
+)
+
+// 排空保护规则的处理方式
+const (
+	DrainProtectionActionForbid   = "forbid"   // 禁止驱逐：带有该标签/污点的节点不参与出池
+	DrainProtectionActionPenalize = "penalize" // 受保护：增加节点的排空代价，出池时靠后选择
+)
+
+// DrainProtectionRule 排空保护规则，出池设备匹配时按节点的标签和污点判断是否允许驱逐及排空代价。
+// Value 为空时匹配该 Key 的任意取值
+type DrainProtectionRule struct {
+	BaseModel
+	Kind        string `gorm:"column:kind;size:20;not null;uniqueIndex:uk_drain_protection_rule"` // label 或 taint
+	Key         string `gorm:"column:key;size:191;not null;uniqueIndex:uk_drain_protection_rule"` // 标签或污点的 key
+	Value       string `gorm:"column:value;size:191;uniqueIndex:uk_drain_protection_rule"`        // 标签或污点的 value，为空时匹配任意值
+	Action      string `gorm:"column:action;size:20;not null"`                                    // forbid 或 penalize
+	Description string `gorm:"column:description;size:500"`
+	CreatedBy   string `gorm:"column:created_by;size:50"`
+}
+
+// TableName 指定表名
+func (DrainProtectionRule) TableName() string {
+	return "ng_drain_protection_rule"
+}
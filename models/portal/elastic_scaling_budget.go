@@ -0,0 +1,27 @@
+package portal
+
+// 预算周期
+const (
+	ScalingBudgetPeriodDaily  = "daily"
+	ScalingBudgetPeriodWeekly = "weekly"
+)
+
+// ElasticScalingBudget 自动伸缩预算，限制自动创建订单的设备数和订单数
+// 集群和资源池均为空时为全局预算；只对系统自动创建的订单生效
+type ElasticScalingBudget struct {
+	BaseModel
+	Name             string `gorm:"column:name;size:128;not null"`
+	ClusterID        *int   `gorm:"column:cluster_id;index"`             // 限定集群，为空时对所有集群生效
+	ResourcePoolType string `gorm:"column:resource_pool_type;size:50"`   // 限定资源池类型，为空时对所有资源池生效
+	ActionType       string `gorm:"column:action_type;size:20;not null"` // pool_entry 或 pool_exit
+	Period           string `gorm:"column:period;size:10;not null"`      // daily 或 weekly（周一开始）
+	MaxDevices       int    `gorm:"column:max_devices;default:0"`        // 周期内最大设备数，为0时不限制
+	MaxOrders        int    `gorm:"column:max_orders;default:0"`         // 周期内最大订单数，为0时不限制
+	Status           string `gorm:"column:status;size:20;not null"`      // enabled 或 disabled
+	CreatedBy        string `gorm:"column:created_by;size:50"`
+}
+
+// TableName 指定表名
+func (ElasticScalingBudget) TableName() string {
+	return "ng_elastic_scaling_budget"
+}
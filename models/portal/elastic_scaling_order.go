@@ -3,9 +3,11 @@ package portal
 // OrderDevice 订单设备关联表
 type OrderDevice struct {
 	BaseModel
-	OrderID  int  `gorm:"primaryKey;column:order_id"`
-	DeviceID int  `gorm:"primaryKey;column:device_id"`
-	Status   string `gorm:"type:varchar(50);default:'pending'"`
+	OrderID          int    `gorm:"primaryKey;column:order_id"`
+	DeviceID         int    `gorm:"primaryKey;column:device_id"`
+	Status           string `gorm:"type:varchar(50);default:'pending'"`
+	MatchingPolicyID int    `gorm:"column:matching_policy_id;default:0"` // 选中该设备的匹配策略ID，手动添加的设备为0
+	Version          int    `gorm:"column:version;not null;default:0"`   // 乐观锁版本号，每次更新加1
 }
 
 // TableName 指定表名
@@ -25,6 +27,9 @@ type StrategyExecutionHistory struct {
 	Result         string   `gorm:"column:result;type:varchar(50)"`           // 执行结果
 	OrderID        *int   `gorm:"column:order_id;type:bigint"`              // 关联订单ID
 	Reason         string   `gorm:"column:reason;type:text"`                  // 执行结果的原因
+	ForecastInput         string    `gorm:"column:forecast_input;type:text"`            // 预测模式下的预测输入与结果（JSON）
+	ProjectedCrossingDate *NavyTime `gorm:"column:projected_crossing_date;type:datetime"` // 预测模式下预计越过阈值的时间
+	MatchingTrace         string    `gorm:"column:matching_trace;type:mediumtext"`        // 设备匹配后被拦截、未创建订单时的设备匹配过程（JSON）
 }
 
 // TableName 指定表名
@@ -18,8 +18,14 @@ type ElasticScalingStrategy struct {
 	MemoryThresholdType    string  `gorm:"column:memory_threshold_type;size:20"`             // usage 或 allocated
 	MemoryTargetValue      float64 `gorm:"column:memory_target_value;default:0"`             // 动作执行后内存目标使用率
 	ConditionLogic         string  `gorm:"column:condition_logic;size:10;default:'OR'"`      // AND 或 OR
-	DurationMinutes        int     `gorm:"column:duration_minutes;not null"`                 // 持续时间（分钟）
+	DurationMinutes        int     `gorm:"column:duration_minutes;not null"`                 // 持续时间，单位由 DurationUnit 决定
+	DurationUnit           string  `gorm:"column:duration_unit;size:10"`                     // day、hour 或 minute，为空时兼容历史配置
 	CooldownMinutes        int     `gorm:"column:cooldown_minutes;not null"`                 // 冷却时间（分钟）
+	HysteresisMinutes      int     `gorm:"column:hysteresis_minutes;default:0"`              // 反向订单防抖窗口（分钟），为0时不启用反向订单防抖
+	ExitCeilingLevel       string  `gorm:"column:exit_ceiling_level;size:20"`                // 出池护栏上限：warning 或 critical，为空时为 warning
+	TriggerMode            string  `gorm:"column:trigger_mode;size:20"`                      // threshold 或 forecast，为空时为 threshold
+	ForecastMethod         string  `gorm:"column:forecast_method;size:20"`                   // 预测方法：linear 或 holt
+	ForecastHorizonDays    int     `gorm:"column:forecast_horizon_days;default:0"`           // 预测窗口（天）
 	ResourceTypes          string  `gorm:"column:resource_types;size:255"`                   // 资源类型列表，逗号分隔（计算、存储、网络等）
 	Status                 string  `gorm:"column:status;size:20;not null"`                   // enabled 或 disabled
 	CreatedBy              string  `gorm:"column:created_by;size:50;not null"`
@@ -31,9 +37,18 @@ func (ElasticScalingStrategy) TableName() string {
 }
 
 // StrategyClusterAssociation 策略集群关联表
+// 覆盖字段为空时使用策略的默认配置，用于为不同规模的集群设置不同的阈值
 type StrategyClusterAssociation struct {
 	StrategyID int `gorm:"primaryKey;column:strategy_id"` // 策略ID
 	ClusterID  int `gorm:"primaryKey;column:cluster_id"`  // 集群ID
+
+	CPUThresholdValue    *float64 `gorm:"column:cpu_threshold_value"`     // 覆盖CPU阈值
+	CPUTargetValue       *float64 `gorm:"column:cpu_target_value"`        // 覆盖CPU目标值
+	MemoryThresholdValue *float64 `gorm:"column:memory_threshold_value"`  // 覆盖内存阈值
+	MemoryTargetValue    *float64 `gorm:"column:memory_target_value"`     // 覆盖内存目标值
+	DurationMinutes      *int     `gorm:"column:duration_minutes"`        // 覆盖持续时间，单位沿用策略配置
+	CooldownMinutes      *int     `gorm:"column:cooldown_minutes"`        // 覆盖冷却时间（分钟）
+	ResourceTypes        *string  `gorm:"column:resource_types;size:255"` // 覆盖资源类型列表，逗号分隔
 }
 
 // TableName 指定表名
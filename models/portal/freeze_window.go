@@ -0,0 +1,28 @@
+package portal
+
+// 冻结规则类型
+const (
+	FreezeRuleTypeRange  = "range"  // 一次性时间段
+	FreezeRuleTypeWeekly = "weekly" // 每周固定的星期（如周末）
+	FreezeRuleTypeDates  = "dates"  // 指定日期，YYYY-MM-DD 为单日，MM-DD 为每年重复
+)
+
+// FreezeWindow 变更冻结窗口，冻结期间不允许自动创建伸缩订单
+type FreezeWindow struct {
+	BaseModel
+	Name        string    `gorm:"column:name;size:128;not null"`
+	Description string    `gorm:"column:description;size:500"`
+	ClusterID   *int      `gorm:"column:cluster_id;index"`           // 关联集群ID，为空时全局生效
+	RuleType    string    `gorm:"column:rule_type;size:20;not null"` // range、weekly 或 dates
+	StartTime   *NavyTime `gorm:"column:start_time;type:datetime"`   // range：开始时间
+	EndTime     *NavyTime `gorm:"column:end_time;type:datetime"`     // range：结束时间
+	Weekdays    string    `gorm:"column:weekdays;size:20"`           // weekly：逗号分隔的星期，0为周日
+	Dates       string    `gorm:"column:dates;type:text"`            // dates：逗号分隔的日期
+	Status      string    `gorm:"column:status;size:20;not null"`    // enabled 或 disabled
+	CreatedBy   string    `gorm:"column:created_by;size:50"`
+}
+
+// TableName 指定表名
+func (FreezeWindow) TableName() string {
+	return "ng_freeze_window"
+}
@@ -40,6 +40,7 @@ type Order struct {
 	CreatedBy      string      `gorm:"column:created_by;type:varchar(100)"`         // 创建人
 	CompletionTime *NavyTime   `gorm:"column:completion_time;type:datetime"`        // 完成时间
 	FailureReason  string      `gorm:"column:failure_reason;type:text"`             // 失败原因
+	Version        int         `gorm:"column:version;not null;default:0"`           // 乐观锁版本号，每次更新加1
 
 	// 关联关系
 	ElasticScalingDetail *ElasticScalingOrderDetail `gorm:"foreignKey:OrderID"` // 弹性伸缩详情
@@ -63,6 +64,9 @@ type ElasticScalingOrderDetail struct {
 	DeviceCount            int    `gorm:"column:device_count;type:int"`                      // 请求的设备数量
 	StrategyTriggeredValue string `gorm:"column:strategy_triggered_value;type:varchar(255)"` // 策略触发时的具体指标值
 	StrategyThresholdValue string `gorm:"column:strategy_threshold_value;type:varchar(255)"` // 策略触发时的阈值设定
+	MatchingTrace          string `gorm:"column:matching_trace;type:mediumtext"`             // 设备匹配过程（JSON），策略自动创建的订单记录
+	CandidateCount         int    `gorm:"column:candidate_count;type:int;default:0"`         // 设备匹配时查询到的候选设备总数
+	GuardrailTrimmed       bool   `gorm:"column:guardrail_trimmed;default:false"`            // 出池设备是否被出池护栏裁剪
 
 	// 关联关系
 	Order *Order `gorm:"foreignKey:OrderID"` // 关联的基础订单
@@ -0,0 +1,26 @@
+package portal
+
+// 订单状态变更来源
+const (
+	OrderStatusSourceAPI     = "api"     // 接口调用（用户操作）
+	OrderStatusSourceEvent   = "event"   // 事件处理器
+	OrderStatusSourceMonitor = "monitor" // 后台监控任务
+)
+
+// OrderStatusHistory 订单状态变更记录，与状态更新在同一事务中写入。
+// DeviceID 为空时记录订单状态的变更，否则记录订单中该设备状态的变更
+type OrderStatusHistory struct {
+	BaseModel
+	OrderID    int    `gorm:"column:order_id;type:bigint;not null;index:idx_order_status_history_order"` // 关联订单ID
+	DeviceID   *int   `gorm:"column:device_id;type:bigint"`                                              // 关联设备ID，订单状态变更时为空
+	FromStatus string `gorm:"column:from_status;type:varchar(50)"`                                       // 变更前状态
+	ToStatus   string `gorm:"column:to_status;type:varchar(50)"`                                         // 变更后状态
+	Executor   string `gorm:"column:executor;type:varchar(50)"`                                          // 执行人
+	Reason     string `gorm:"column:reason;type:text"`                                                   // 变更原因
+	Source     string `gorm:"column:source;type:varchar(20)"`                                            // 变更来源：api/event/monitor
+}
+
+// TableName 指定表名
+func (OrderStatusHistory) TableName() string {
+	return "ng_order_status_history"
+}
@@ -0,0 +1,27 @@
+package portal
+
+// GPU 要求
+const (
+	GPURequirementRequired  = "required"  // 必须包含GPU
+	GPURequirementForbidden = "forbidden" // 不能包含GPU
+)
+
+// ResourcePoolCompatibilityRule 资源池硬件兼容性规则，入池设备匹配时作为硬性过滤条件，
+// 保存入池匹配策略时也会校验查询模板是否与规则冲突。每个资源池类型最多一条规则，各字段为空时不限制。
+type ResourcePoolCompatibilityRule struct {
+	BaseModel
+	ResourcePoolType string `gorm:"column:resource_pool_type;size:50;not null;uniqueIndex:uk_compatibility_rule_pool"` // 资源池类型
+	ArchTypes        string `gorm:"column:arch_types;type:text"`                                                       // 逗号分隔的允许CPU架构（不区分大小写）
+	GPURequirement   string `gorm:"column:gpu_requirement;size:20"`                                                    // required 或 forbidden，为空时不限制
+	MinDiskCount     int    `gorm:"column:min_disk_count;default:0"`                                                   // 最少磁盘数
+	MinNetworkSpeed  int    `gorm:"column:min_network_speed;default:0"`                                                // 最低网卡速率（Mb/s）
+	Models           string `gorm:"column:models;type:text"`                                                           // 逗号分隔的允许型号前缀（不区分大小写）
+	OSVersions       string `gorm:"column:os_versions;type:text"`                                                      // 逗号分隔的允许操作系统版本前缀
+	Description      string `gorm:"column:description;size:500"`
+	CreatedBy        string `gorm:"column:created_by;size:50"`
+}
+
+// TableName 指定表名
+func (ResourcePoolCompatibilityRule) TableName() string {
+	return "ng_resource_pool_compatibility_rule"
+}
@@ -3,15 +3,22 @@ package portal
 // ResourcePoolDeviceMatchingPolicy 资源池设备匹配策略.
 type ResourcePoolDeviceMatchingPolicy struct {
 	BaseModel
-	Name             string `gorm:"column:name;type:varchar(255);not null"`                    // 策略名称
-	Description      string `gorm:"column:description;type:text"`                              // 策略描述
-	ResourcePoolType string `gorm:"column:resource_pool_type;type:varchar(255);not null"`      // 资源池类型
-	ActionType       string `gorm:"column:action_type;type:varchar(50);not null"`              // 动作类型：pool_entry 或 pool_exit
-	QueryTemplateID  uint   `gorm:"column:query_template_id;not null"`                         // 关联的查询模板ID
-	Status           string `gorm:"column:status;type:varchar(50);not null;default:'enabled'"` // 状态：enabled 或 disabled
-	AdditionConds    string `gorm:"column:addition_conds;type:text"`                           // 额外动态条件，JSON格式存储
-	CreatedBy        string `gorm:"column:created_by;type:varchar(255)"`                       // 创建者
-	UpdatedBy        string `gorm:"column:updated_by;type:varchar(255)"`                       // 更新者
+	Name                 string `gorm:"column:name;type:varchar(255);not null"`                    // 策略名称
+	Description          string `gorm:"column:description;type:text"`                              // 策略描述
+	ResourcePoolType     string `gorm:"column:resource_pool_type;type:varchar(255);not null"`      // 资源池类型
+	ActionType           string `gorm:"column:action_type;type:varchar(50);not null"`              // 动作类型：pool_entry 或 pool_exit
+	QueryTemplateID      uint   `gorm:"column:query_template_id;not null"`                         // 关联的查询模板ID
+	Status               string `gorm:"column:status;type:varchar(50);not null;default:'enabled'"` // 状态：enabled 或 disabled
+	AdditionConds        string `gorm:"column:addition_conds;type:text"`                           // 额外动态条件，JSON格式存储
+	SelectionAlgorithm   string `gorm:"column:selection_algorithm;type:varchar(20)"`               // 设备选择算法：greedy、balanced 或 min_waste，为空时为 greedy
+	MaxDevicesPerCabinet int    `gorm:"column:max_devices_per_cabinet;default:0"`                  // 单次选择每个机柜最多设备数，为0时不限制
+	MaxDevicesPerRoom    int    `gorm:"column:max_devices_per_room;default:0"`                     // 单次选择每个机房最多设备数，为0时不限制
+	BalanceAcrossRooms   bool   `gorm:"column:balance_across_rooms;default:false"`                 // 是否优先在机房之间均衡选择
+	MinDevicesPerCabinet int    `gorm:"column:min_devices_per_cabinet;default:0"`                  // 出池时每个机柜至少保留的集群设备数，为0时不限制
+	Priority             int    `gorm:"column:priority;default:0"`                                 // 优先级，数值越小越先执行
+	CombineMode          string `gorm:"column:combine_mode;type:varchar(20)"`                      // 同一资源池和动作类型多个策略的组合模式：union、first_wins 或 fill_remaining，为空时为 union；多个策略时以优先级最高的策略为准
+	CreatedBy            string `gorm:"column:created_by;type:varchar(255)"`                       // 创建者
+	UpdatedBy            string `gorm:"column:updated_by;type:varchar(255)"`                       // 更新者
 
 	// 关联查询模板（非数据库字段）
 	QueryTemplate *QueryTemplate `gorm:"foreignKey:QueryTemplateID"` // 关联的查询模板
@@ -87,6 +87,11 @@ func getThresholds(clusterType, environment string) StyleThresholds {
 	return thresholds
 }
 
+// GetStyleThresholds 根据物理机节点数和环境获取样式阈值
+func GetStyleThresholds(bmCount int, environment string) StyleThresholds {
+	return getThresholds(getClusterType(bmCount), environment)
+}
+
 // GetCPUStyle 根据CPU使用率获取样式
 func GetCPUStyle(bmCount int, cpuUsage float64, environment string) Style {
 	clusterType := getClusterType(bmCount)
@@ -64,9 +64,19 @@ func InitDB() (*gorm.DB, error) {
 		&portal.DeviceApp{},
 		&portal.ElasticScalingStrategy{},
 		&portal.StrategyClusterAssociation{},
+		&portal.FreezeWindow{},
+		&portal.ElasticScalingBudget{},
+		&portal.DeviceReservation{},
+		&portal.ClusterLocation{},
+		&portal.ResourcePoolCompatibilityRule{},
+		&portal.DrainProtectionRule{},
+		&portal.OrderStatusHistory{},
 		// &portal.ElasticScalingOrder{},       // 旧表，已废弃，保留用于数据迁移
 		&portal.Order{},                     // 基础订单表
 		&portal.ElasticScalingOrderDetail{}, // 弹性伸缩订单详情表
+		&portal.MaintenanceOrderDetail{},    // 设备维护订单详情表
+		&portal.GeneralOrderDetail{},        // 通用订单详情表
+		&portal.DeploymentOrderDetail{},     // 应用部署订单详情表
 		&portal.OrderDevice{},
 		&portal.StrategyExecutionHistory{},
 		&portal.NotificationLog{},
@@ -57,7 +57,7 @@ func main() {
 
 	// 初始化路由处理器
 	f5Handler := routers.NewF5InfoHandler(db)
-	opsHandler := routers.NewOpsJobHandler(db)
+	opsHandler := routers.NewOpsJobHandler(db, logger, eventManager)
 	deviceHandler := routers.NewDeviceHandler(db)
 	deviceQueryHandler := routers.NewDeviceQueryHandler(db)
 	elasticScalingHandler := es.NewElasticScalingHandler(db)
@@ -66,10 +66,12 @@ func main() {
 	resourcePoolDeviceMatchingPolicyHandler := es.NewResourcePoolDeviceMatchingPolicyHandler(db)
 	clusterResourceHandler := routers.NewClusterResourceHandler(db)
 	k8sClusterHandler := routers.NewK8sClusterHandler(db)
+	freezeCalendarHandler := routers.NewFreezeCalendarHandler(db)
+	clusterLocationHandler := routers.NewClusterLocationHandler(db)
 	unifiedOrderHandler := order.NewUnifiedOrderHandler(db)
 
 	// 初始化并注册所有订单服务
-	unifiedOrderHandler.InitServices(logger)
+	unifiedOrderHandler.InitServices(logger, eventManager, elasticScalingOrderHandler.Service())
 
 	// 创建 Gin 引擎
 	r := gin.Default()
@@ -89,6 +91,8 @@ func main() {
 	resourcePoolDeviceMatchingPolicyHandler.RegisterRoutes(api)
 	clusterResourceHandler.RegisterRoutes(api)
 	k8sClusterHandler.RegisterRoutes(api)
+	freezeCalendarHandler.RegisterRoutes(api)
+	clusterLocationHandler.RegisterRoutes(api)
 	unifiedOrderHandler.RegisterRoutes(api)
 
 	// 注册 Swagger 路由
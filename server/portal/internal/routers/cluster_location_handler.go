@@ -0,0 +1,214 @@
+package routers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"navy-ng/pkg/middleware/render"
+	"navy-ng/server/portal/internal/service"
+)
+
+// ClusterLocationHandler 集群位置映射处理器
+type ClusterLocationHandler struct {
+	service *service.ClusterLocationService
+}
+
+// NewClusterLocationHandler 创建集群位置映射处理器
+func NewClusterLocationHandler(db *gorm.DB) *ClusterLocationHandler {
+	return &ClusterLocationHandler{
+		service: service.NewClusterLocationService(db),
+	}
+}
+
+// RegisterRoutes 注册路由
+func (h *ClusterLocationHandler) RegisterRoutes(router *gin.RouterGroup) {
+	locationGroup := router.Group(RouteGroupClusterLocations)
+	{
+		locationGroup.GET("", h.ListClusterLocations)
+		locationGroup.GET(RouteParamID, h.GetClusterLocation)
+		locationGroup.POST("", h.CreateClusterLocation)
+		locationGroup.PUT(RouteParamID, h.UpdateClusterLocation)
+		locationGroup.DELETE(RouteParamID, h.DeleteClusterLocation)
+	}
+}
+
+// ListClusterLocations 获取集群位置映射列表
+// @Summary 获取集群位置映射列表
+// @Description 获取集群到 IDC、机房、网络区域和允许使用机柜的映射列表
+// @Tags 集群位置映射
+// @Accept json
+// @Produce json
+// @Param page query int false "页码，默认1"
+// @Param size query int false "每页大小，默认10"
+// @Param cluster_id query int false "集群ID"
+// @Success 200 {object} service.ClusterLocationListResponse
+// @Failure 400 {object} render.ErrorResponse
+// @Failure 500 {object} render.ErrorResponse
+// @Router /fe-v1/cluster-locations [get]
+func (h *ClusterLocationHandler) ListClusterLocations(ctx *gin.Context) {
+	var query service.ClusterLocationQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		render.BadRequest(ctx, MsgInvalidQueryParams+err.Error())
+		return
+	}
+
+	// 设置默认值
+	if query.Page <= 0 {
+		query.Page = DefaultPageInt
+	}
+	if query.Size <= 0 {
+		query.Size = DefaultSizeInt
+	}
+
+	response, err := h.service.ListClusterLocations(ctx.Request.Context(), &query)
+	if err != nil {
+		render.InternalServerError(ctx, MsgFailedToGetClusterLocations+err.Error())
+		return
+	}
+
+	render.Success(ctx, response)
+}
+
+// GetClusterLocation 获取集群位置映射详情
+// @Summary 获取集群位置映射详情
+// @Description 根据ID获取集群位置映射
+// @Tags 集群位置映射
+// @Accept json
+// @Produce json
+// @Param id path int true "集群位置映射ID"
+// @Success 200 {object} service.ClusterLocationResponse
+// @Failure 400 {object} render.ErrorResponse
+// @Failure 404 {object} render.ErrorResponse
+// @Failure 500 {object} render.ErrorResponse
+// @Router /fe-v1/cluster-locations/{id} [get]
+func (h *ClusterLocationHandler) GetClusterLocation(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param(ParamID), Base10, BitSize64)
+	if err != nil {
+		render.BadRequest(ctx, MsgInvalidID+": "+err.Error())
+		return
+	}
+
+	response, err := h.service.GetClusterLocation(ctx.Request.Context(), int(id))
+	if err != nil {
+		if service.IsNotFound(err) {
+			render.NotFound(ctx, err.Error())
+			return
+		}
+		render.InternalServerError(ctx, MsgFailedToGetClusterLocation+err.Error())
+		return
+	}
+
+	render.Success(ctx, response)
+}
+
+// CreateClusterLocation 创建集群位置映射
+// @Summary 创建集群位置映射
+// @Description 为集群配置 IDC、机房、网络区域和允许使用的机柜，设备匹配和集群资源统计优先使用该映射，每个集群只能有一条映射
+// @Tags 集群位置映射
+// @Accept json
+// @Produce json
+// @Param location body service.ClusterLocationRequest true "集群位置映射信息"
+// @Success 201 {object} service.ClusterLocationResponse
+// @Failure 400 {object} render.ErrorResponse
+// @Failure 500 {object} render.ErrorResponse
+// @Router /fe-v1/cluster-locations [post]
+func (h *ClusterLocationHandler) CreateClusterLocation(ctx *gin.Context) {
+	var req service.ClusterLocationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		render.BadRequest(ctx, MsgInvalidRequestParams+err.Error())
+		return
+	}
+
+	response, err := h.service.CreateClusterLocation(ctx.Request.Context(), &req, GetCurrentUsername(ctx))
+	if err != nil {
+		if service.IsBadRequest(err) {
+			render.BadRequest(ctx, err.Error())
+			return
+		}
+		render.InternalServerError(ctx, MsgFailedToCreateClusterLocation+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, render.Response{
+		Code: http.StatusCreated,
+		Msg:  MsgSuccess,
+		Data: response,
+	})
+}
+
+// UpdateClusterLocation 更新集群位置映射
+// @Summary 更新集群位置映射
+// @Description 更新集群位置映射
+// @Tags 集群位置映射
+// @Accept json
+// @Produce json
+// @Param id path int true "集群位置映射ID"
+// @Param location body service.ClusterLocationRequest true "集群位置映射信息"
+// @Success 200 {object} service.ClusterLocationResponse
+// @Failure 400 {object} render.ErrorResponse
+// @Failure 404 {object} render.ErrorResponse
+// @Failure 500 {object} render.ErrorResponse
+// @Router /fe-v1/cluster-locations/{id} [put]
+func (h *ClusterLocationHandler) UpdateClusterLocation(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param(ParamID), Base10, BitSize64)
+	if err != nil {
+		render.BadRequest(ctx, MsgInvalidID+": "+err.Error())
+		return
+	}
+
+	var req service.ClusterLocationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		render.BadRequest(ctx, MsgInvalidRequestParams+err.Error())
+		return
+	}
+
+	response, err := h.service.UpdateClusterLocation(ctx.Request.Context(), int(id), &req)
+	if err != nil {
+		if service.IsNotFound(err) {
+			render.NotFound(ctx, err.Error())
+			return
+		}
+		if service.IsBadRequest(err) {
+			render.BadRequest(ctx, err.Error())
+			return
+		}
+		render.InternalServerError(ctx, MsgFailedToUpdateClusterLocation+err.Error())
+		return
+	}
+
+	render.Success(ctx, response)
+}
+
+// DeleteClusterLocation 删除集群位置映射
+// @Summary 删除集群位置映射
+// @Description 删除集群位置映射，删除后该集群恢复使用集群自身的 IDC、安全域字段和集群名称解析的机房
+// @Tags 集群位置映射
+// @Accept json
+// @Produce json
+// @Param id path int true "集群位置映射ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} render.ErrorResponse
+// @Failure 404 {object} render.ErrorResponse
+// @Failure 500 {object} render.ErrorResponse
+// @Router /fe-v1/cluster-locations/{id} [delete]
+func (h *ClusterLocationHandler) DeleteClusterLocation(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param(ParamID), Base10, BitSize64)
+	if err != nil {
+		render.BadRequest(ctx, MsgInvalidID+": "+err.Error())
+		return
+	}
+
+	if err := h.service.DeleteClusterLocation(ctx.Request.Context(), int(id)); err != nil {
+		if service.IsNotFound(err) {
+			render.NotFound(ctx, err.Error())
+			return
+		}
+		render.InternalServerError(ctx, MsgFailedToDeleteClusterLocation+err.Error())
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -13,6 +13,8 @@ const (
 	RouteGroupElasticScalingOrders = "/elastic-scaling/orders"
 	RouteGroupClusterResources     = "/cluster-resources"
 	RouteGroupDeviceMaintenance    = "/fe-v1/device-maintenance"
+	RouteGroupFreezeWindows        = "/freeze-windows"
+	RouteGroupClusterLocations     = "/cluster-locations"
 
 	// 路由参数路径
 	RouteParamID                = "/:id"
@@ -36,6 +38,7 @@ const (
 	SubRouteRemaining            = "/remaining"
 	SubRouteAllocationRate       = "/allocation-rate"
 	SubRouteRequest              = "/request"
+	SubRouteStatus               = "/status"
 )
 
 // HTTP 参数名常量
@@ -73,11 +76,6 @@ const (
 	BitSize64      = 64
 )
 
-// 订单类型常量
-const (
-	OrderTypeGeneral = "general"
-)
-
 // 状态常量
 const (
 	StatusEnabled   = "enabled"
@@ -153,13 +151,11 @@ const (
 	MsgFailedToGetClusterNodes = "获取集群节点失败: "
 
 	// 订单相关错误
-	MsgInvalidOrderType     = "无效或未注册的订单类型: %s"
-	MsgUnsupportedOrderType = "不支持的订单类型: %s"
-	MsgServiceTypeMismatch  = "服务接口类型不匹配"
-	MsgGetOrderFailed       = "获取订单失败: %s"
-	MsgCreateOrderFailed    = "创建订单失败: %s"
-	MsgListOrdersFailed     = "获取订单列表失败: %s"
-	MsgUpdateStatusFailed   = "更新订单状态失败: %s"
+	MsgInvalidOrderType   = "无效或未注册的订单类型: %s"
+	MsgGetOrderFailed     = "获取订单失败: %s"
+	MsgCreateOrderFailed  = "创建订单失败: %s"
+	MsgListOrdersFailed   = "获取订单列表失败: %s"
+	MsgUpdateStatusFailed = "更新订单状态失败: %s"
 
 	// 作业相关错误
 	MsgFailedToListJobs      = "failed to list operation jobs: %s"
@@ -192,6 +188,21 @@ const (
 	// WebSocket 相关错误
 	MsgWebSocketUpgradeError = "failed to upgrade to websocket: %s"
 
+	// 冻结日历相关错误
+	MsgFailedToGetFreezeWindows   = "获取冻结窗口列表失败: "
+	MsgFailedToGetFreezeWindow    = "获取冻结窗口失败: "
+	MsgFailedToCreateFreezeWindow = "创建冻结窗口失败: "
+	MsgFailedToUpdateFreezeWindow = "更新冻结窗口失败: "
+	MsgFailedToDeleteFreezeWindow = "删除冻结窗口失败: "
+	MsgFailedToGetFreezeStatus    = "查询冻结状态失败: "
+
+	// 集群位置映射相关错误
+	MsgFailedToGetClusterLocations   = "获取集群位置映射列表失败: "
+	MsgFailedToGetClusterLocation    = "获取集群位置映射失败: "
+	MsgFailedToCreateClusterLocation = "创建集群位置映射失败: "
+	MsgFailedToUpdateClusterLocation = "更新集群位置映射失败: "
+	MsgFailedToDeleteClusterLocation = "删除集群位置映射失败: "
+
 	// 维护相关错误
 	MsgInvalidMaintenanceRequest = "无效的请求格式: "
 	MsgDeviceIDOrCICodeRequired  = "设备ID或CI编码不能为空"
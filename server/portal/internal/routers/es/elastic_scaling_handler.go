@@ -1,6 +1,7 @@
 package es
 
 import (
+	"errors"
 	"fmt"
 	"navy-ng/pkg/middleware/render"
 	"navy-ng/pkg/redis" // Import redis package
@@ -61,6 +62,35 @@ func (h *ElasticScalingHandler) RegisterRoutes(api *gin.RouterGroup) {
 		strategyGroup.DELETE("/:id", h.DeleteStrategy)
 		strategyGroup.PUT("/:id/status", h.UpdateStrategyStatus)
 		strategyGroup.GET("/:id/execution-history", h.GetStrategyExecutionHistory)
+		strategyGroup.POST("/simulate", h.SimulateStrategy)
+		strategyGroup.POST("/:id/evaluate", h.EvaluateStrategy)
+	}
+
+	// 自动伸缩预算接口
+	budgetGroup := elasticGroup.Group("/budgets")
+	{
+		budgetGroup.GET("", h.ListScalingBudgets)
+		budgetGroup.POST("", h.CreateScalingBudget)
+		budgetGroup.PUT("/:id", h.UpdateScalingBudget)
+		budgetGroup.DELETE("/:id", h.DeleteScalingBudget)
+	}
+
+	// 资源池硬件兼容性规则接口
+	compatibilityGroup := elasticGroup.Group("/compatibility-rules")
+	{
+		compatibilityGroup.GET("", h.ListCompatibilityRules)
+		compatibilityGroup.POST("", h.CreateCompatibilityRule)
+		compatibilityGroup.PUT("/:id", h.UpdateCompatibilityRule)
+		compatibilityGroup.DELETE("/:id", h.DeleteCompatibilityRule)
+	}
+
+	// 出池排空保护规则接口
+	drainProtectionGroup := elasticGroup.Group("/drain-protection-rules")
+	{
+		drainProtectionGroup.GET("", h.ListDrainProtectionRules)
+		drainProtectionGroup.POST("", h.CreateDrainProtectionRule)
+		drainProtectionGroup.PUT("/:id", h.UpdateDrainProtectionRule)
+		drainProtectionGroup.DELETE("/:id", h.DeleteDrainProtectionRule)
 	}
 
 	// 统计接口
@@ -315,6 +345,393 @@ func (h *ElasticScalingHandler) GetStrategyExecutionHistory(c *gin.Context) {
 	render.Success(c, baseservice.ToPaginationResponseWithData(&pagination, total, histories))
 }
 
+// SimulateStrategy 策略模拟回放
+// @Summary 策略模拟回放
+// @Description 在历史资源快照上逐日回放策略（已保存或内联配置），返回每个会生成订单的时间点及因冷却期跳过的时间点，不会创建订单或写入执行历史
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Param request body es.StrategySimulationRequestDTO true "模拟参数"
+// @Success 200 {object} render.Response
+// @Router /fe-v1/elastic-scaling/strategies/simulate [post]
+func (h *ElasticScalingHandler) SimulateStrategy(c *gin.Context) {
+	var req es.StrategySimulationRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		render.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.service.SimulateStrategy(req)
+	if err != nil {
+		render.Fail(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.Success(c, result)
+}
+
+// EvaluateStrategy 手动立即评估策略
+// @Summary 手动立即评估策略
+// @Description 同步评估指定策略（可限定单个集群和资源类型），与定时任务共用分布式锁；force 为 true 时忽略冷却期。满足条件时会创建订单，返回各集群+资源池写入的执行历史
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Param id path int true "策略ID"
+// @Param request body es.StrategyEvaluateRequestDTO false "评估参数"
+// @Success 200 {object} render.Response
+// @Failure 409 {object} render.Response "策略正在评估中"
+// @Router /fe-v1/elastic-scaling/strategies/{id}/evaluate [post]
+func (h *ElasticScalingHandler) EvaluateStrategy(c *gin.Context) {
+	var req IDRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		render.BadRequest(c, routersconstants.MsgInvalidStrategyID)
+		return
+	}
+
+	var reqBody es.StrategyEvaluateRequestDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			render.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	result, err := h.service.EvaluateStrategyNow(req.ID, reqBody)
+	if err != nil {
+		if errors.Is(err, es.ErrStrategyEvaluationLocked) {
+			render.Fail(c, http.StatusConflict, err.Error())
+			return
+		}
+		render.Fail(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.Success(c, result)
+}
+
+// ListScalingBudgets 获取自动伸缩预算列表
+// @Summary 获取自动伸缩预算列表
+// @Description 获取所有自动伸缩预算及其当前周期（每日/每周）已用设备数和订单数
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Success 200 {object} render.Response
+// @Router /fe-v1/elastic-scaling/budgets [get]
+func (h *ElasticScalingHandler) ListScalingBudgets(c *gin.Context) {
+	budgets, err := h.service.ListScalingBudgets()
+	if err != nil {
+		render.Fail(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.Success(c, budgets)
+}
+
+// CreateScalingBudget 创建自动伸缩预算
+// @Summary 创建自动伸缩预算
+// @Description 按动作类型创建全局、集群或资源池级别的每日/每周设备数和订单数上限
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Param budget body es.ScalingBudgetDTO true "预算数据"
+// @Success 200 {object} render.Response
+// @Router /fe-v1/elastic-scaling/budgets [post]
+func (h *ElasticScalingHandler) CreateScalingBudget(c *gin.Context) {
+	var dto es.ScalingBudgetDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		render.BadRequest(c, err.Error())
+		return
+	}
+
+	// 设置创建者
+	dto.CreatedBy = routersconstants.DefaultExecutor // 实际环境中应该从认证信息获取
+
+	id, err := h.service.CreateScalingBudget(dto)
+	if err != nil {
+		render.Fail(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.Success(c, gin.H{"id": id})
+}
+
+// UpdateScalingBudget 更新自动伸缩预算
+// @Summary 更新自动伸缩预算
+// @Description 更新指定的自动伸缩预算
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Param id path int true "预算ID"
+// @Param budget body es.ScalingBudgetDTO true "预算数据"
+// @Success 200 {object} render.Response
+// @Router /fe-v1/elastic-scaling/budgets/{id} [put]
+func (h *ElasticScalingHandler) UpdateScalingBudget(c *gin.Context) {
+	var req IDRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		render.BadRequest(c, routersconstants.MsgInvalidID)
+		return
+	}
+
+	var dto es.ScalingBudgetDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		render.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.service.UpdateScalingBudget(req.ID, dto); err != nil {
+		render.Fail(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.Success(c, nil)
+}
+
+// DeleteScalingBudget 删除自动伸缩预算
+// @Summary 删除自动伸缩预算
+// @Description 删除指定的自动伸缩预算
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Param id path int true "预算ID"
+// @Success 200 {object} render.Response
+// @Router /fe-v1/elastic-scaling/budgets/{id} [delete]
+func (h *ElasticScalingHandler) DeleteScalingBudget(c *gin.Context) {
+	var req IDRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		render.BadRequest(c, routersconstants.MsgInvalidID)
+		return
+	}
+
+	if err := h.service.DeleteScalingBudget(req.ID); err != nil {
+		render.Fail(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.Success(c, nil)
+}
+
+// ListCompatibilityRules 获取资源池硬件兼容性规则列表
+// @Summary 获取资源池硬件兼容性规则列表
+// @Description 获取所有资源池的硬件兼容性规则
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Success 200 {object} render.Response{data=[]es.ResourcePoolCompatibilityRuleDTO}
+// @Router /fe-v1/elastic-scaling/compatibility-rules [get]
+func (h *ElasticScalingHandler) ListCompatibilityRules(c *gin.Context) {
+	rules, err := h.service.ListCompatibilityRules()
+	if err != nil {
+		render.Fail(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.Success(c, rules)
+}
+
+// CreateCompatibilityRule 创建资源池硬件兼容性规则
+// @Summary 创建资源池硬件兼容性规则
+// @Description 创建资源池硬件兼容性规则，资源池已启用的入池匹配策略的查询模板不能与规则冲突
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Param rule body es.ResourcePoolCompatibilityRuleDTO true "兼容性规则"
+// @Success 200 {object} render.Response
+// @Failure 400 {object} render.Response
+// @Router /fe-v1/elastic-scaling/compatibility-rules [post]
+func (h *ElasticScalingHandler) CreateCompatibilityRule(c *gin.Context) {
+	var dto es.ResourcePoolCompatibilityRuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		render.BadRequest(c, err.Error())
+		return
+	}
+
+	// 设置创建者
+	dto.CreatedBy = routersconstants.DefaultExecutor // 实际环境中应该从认证信息获取
+
+	id, err := h.service.CreateCompatibilityRule(dto)
+	if err != nil {
+		renderRuleError(c, err)
+		return
+	}
+
+	render.Success(c, gin.H{"id": id})
+}
+
+// UpdateCompatibilityRule 更新资源池硬件兼容性规则
+// @Summary 更新资源池硬件兼容性规则
+// @Description 更新指定的资源池硬件兼容性规则
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Param id path int true "规则ID"
+// @Param rule body es.ResourcePoolCompatibilityRuleDTO true "兼容性规则"
+// @Success 200 {object} render.Response
+// @Failure 400 {object} render.Response
+// @Failure 404 {object} render.Response
+// @Router /fe-v1/elastic-scaling/compatibility-rules/{id} [put]
+func (h *ElasticScalingHandler) UpdateCompatibilityRule(c *gin.Context) {
+	var req IDRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		render.BadRequest(c, routersconstants.MsgInvalidID)
+		return
+	}
+
+	var dto es.ResourcePoolCompatibilityRuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		render.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.service.UpdateCompatibilityRule(req.ID, dto); err != nil {
+		renderRuleError(c, err)
+		return
+	}
+
+	render.Success(c, nil)
+}
+
+// DeleteCompatibilityRule 删除资源池硬件兼容性规则
+// @Summary 删除资源池硬件兼容性规则
+// @Description 删除指定的资源池硬件兼容性规则
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Param id path int true "规则ID"
+// @Success 200 {object} render.Response
+// @Failure 404 {object} render.Response
+// @Router /fe-v1/elastic-scaling/compatibility-rules/{id} [delete]
+func (h *ElasticScalingHandler) DeleteCompatibilityRule(c *gin.Context) {
+	var req IDRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		render.BadRequest(c, routersconstants.MsgInvalidID)
+		return
+	}
+
+	if err := h.service.DeleteCompatibilityRule(req.ID); err != nil {
+		renderRuleError(c, err)
+		return
+	}
+
+	render.Success(c, nil)
+}
+
+// ListDrainProtectionRules 获取排空保护规则列表
+// @Summary 获取排空保护规则列表
+// @Description 获取出池时使用的节点标签/污点排空保护规则
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Success 200 {object} render.Response{data=[]es.DrainProtectionRuleDTO}
+// @Router /fe-v1/elastic-scaling/drain-protection-rules [get]
+func (h *ElasticScalingHandler) ListDrainProtectionRules(c *gin.Context) {
+	rules, err := h.service.ListDrainProtectionRules()
+	if err != nil {
+		render.Fail(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.Success(c, rules)
+}
+
+// CreateDrainProtectionRule 创建排空保护规则
+// @Summary 创建排空保护规则
+// @Description 创建排空保护规则，forbid 规则命中的节点不参与出池，penalize 规则命中的节点排空代价增加
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Param rule body es.DrainProtectionRuleDTO true "排空保护规则"
+// @Success 200 {object} render.Response
+// @Failure 400 {object} render.Response
+// @Router /fe-v1/elastic-scaling/drain-protection-rules [post]
+func (h *ElasticScalingHandler) CreateDrainProtectionRule(c *gin.Context) {
+	var dto es.DrainProtectionRuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		render.BadRequest(c, err.Error())
+		return
+	}
+
+	// 设置创建者
+	dto.CreatedBy = routersconstants.DefaultExecutor // 实际环境中应该从认证信息获取
+
+	id, err := h.service.CreateDrainProtectionRule(dto)
+	if err != nil {
+		renderRuleError(c, err)
+		return
+	}
+
+	render.Success(c, gin.H{"id": id})
+}
+
+// UpdateDrainProtectionRule 更新排空保护规则
+// @Summary 更新排空保护规则
+// @Description 更新指定的排空保护规则
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Param id path int true "规则ID"
+// @Param rule body es.DrainProtectionRuleDTO true "排空保护规则"
+// @Success 200 {object} render.Response
+// @Failure 400 {object} render.Response
+// @Failure 404 {object} render.Response
+// @Router /fe-v1/elastic-scaling/drain-protection-rules/{id} [put]
+func (h *ElasticScalingHandler) UpdateDrainProtectionRule(c *gin.Context) {
+	var req IDRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		render.BadRequest(c, routersconstants.MsgInvalidID)
+		return
+	}
+
+	var dto es.DrainProtectionRuleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		render.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.service.UpdateDrainProtectionRule(req.ID, dto); err != nil {
+		renderRuleError(c, err)
+		return
+	}
+
+	render.Success(c, nil)
+}
+
+// DeleteDrainProtectionRule 删除排空保护规则
+// @Summary 删除排空保护规则
+// @Description 删除指定的排空保护规则
+// @Tags 弹性伸缩
+// @Accept json
+// @Produce json
+// @Param id path int true "规则ID"
+// @Success 200 {object} render.Response
+// @Failure 404 {object} render.Response
+// @Router /fe-v1/elastic-scaling/drain-protection-rules/{id} [delete]
+func (h *ElasticScalingHandler) DeleteDrainProtectionRule(c *gin.Context) {
+	var req IDRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		render.BadRequest(c, routersconstants.MsgInvalidID)
+		return
+	}
+
+	if err := h.service.DeleteDrainProtectionRule(req.ID); err != nil {
+		renderRuleError(c, err)
+		return
+	}
+
+	render.Success(c, nil)
+}
+
+// renderRuleError 将兼容性规则、排空保护规则等规则接口的服务错误映射为HTTP状态码
+func renderRuleError(c *gin.Context, err error) {
+	switch {
+	case baseservice.IsBadRequest(err):
+		render.BadRequest(c, err.Error())
+	case baseservice.IsNotFound(err):
+		render.Fail(c, http.StatusNotFound, err.Error())
+	default:
+		render.Fail(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
 // GetDashboardStats 获取工作台统计数据
 // @Summary 获取工作台统计数据
 // @Description 获取工作台概览统计数据
@@ -5,15 +5,30 @@ import (
 
 	"navy-ng/pkg/middleware/render"
 	"navy-ng/pkg/redis"
+	routersconstants "navy-ng/server/portal/internal/routers"
 	"navy-ng/server/portal/internal/service"
 	"navy-ng/server/portal/internal/service/es"
 	"navy-ng/server/portal/internal/service/events"
+	"navy-ng/server/portal/internal/service/order"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// orderUpdateErrorStatus 返回订单状态更新错误对应的 HTTP 状态码：
+// 非法的状态转换和并发修改冲突返回 409，If-Match 版本过期返回 412
+func orderUpdateErrorStatus(err error) int {
+	switch {
+	case order.IsIllegalTransition(err), service.IsConflict(err):
+		return http.StatusConflict
+	case service.IsPreconditionFailed(err):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // OrderIDRequest 定义了从 URI 绑定订单 ID 参数的结构体
 type OrderIDRequest struct {
 	ID int `uri:"id" binding:"required"`
@@ -46,6 +61,11 @@ func NewElasticScalingOrderHandler(db *gorm.DB, logger *zap.Logger, eventManager
 	}
 }
 
+// Service 返回处理器使用的弹性伸缩服务，用于注册到统一订单接口
+func (h *ElasticScalingOrderHandler) Service() *es.ElasticScalingService {
+	return h.service
+}
+
 // RegisterRoutes 注册弹性伸缩订单路由
 func (h *ElasticScalingOrderHandler) RegisterRoutes(router *gin.RouterGroup) {
 	orderGroup := router.Group("/elastic-scaling/orders")
@@ -54,6 +74,7 @@ func (h *ElasticScalingOrderHandler) RegisterRoutes(router *gin.RouterGroup) {
 		orderGroup.GET("", h.ListOrders)
 		orderGroup.GET("/:id", h.GetOrder)
 		orderGroup.PUT("/:id/status", h.UpdateOrderStatus)
+		orderGroup.GET("/:id/timeline", h.GetOrderTimeline)
 		orderGroup.GET("/:id/devices", h.GetOrderDevices)
 		orderGroup.PUT("/:id/devices/:device_id/status", h.UpdateOrderDeviceStatus)
 	}
@@ -117,6 +138,7 @@ func (h *ElasticScalingOrderHandler) ListOrders(c *gin.Context) {
 // @Param order body es.OrderDTO true "订单数据"
 // @Success 200 {object} render.Response
 // @Failure 400 {object} render.ErrorResponse
+// @Failure 409 {object} render.ErrorResponse
 // @Failure 500 {object} render.ErrorResponse
 // @Router /fe-v1/elastic-scaling/orders [post]
 func (h *ElasticScalingOrderHandler) CreateOrder(c *gin.Context) {
@@ -127,6 +149,11 @@ func (h *ElasticScalingOrderHandler) CreateOrder(c *gin.Context) {
 	}
 	orderID, err := h.service.CreateOrder(dto)
 	if err != nil {
+		if service.IsConflict(err) {
+			// 设备已被其他进行中的订单预留
+			render.Fail(c, http.StatusConflict, err.Error())
+			return
+		}
 		render.Fail(c, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -141,6 +168,7 @@ func (h *ElasticScalingOrderHandler) CreateOrder(c *gin.Context) {
 // @Produce json
 // @Param id path int true "订单ID"
 // @Success 200 {object} render.Response
+// @Header 200 {string} ETag "订单版本号，更新状态时通过 If-Match 传回"
 // @Failure 400 {object} render.ErrorResponse
 // @Failure 500 {object} render.ErrorResponse
 // @Router /fe-v1/elastic-scaling/orders/{id} [get]
@@ -155,6 +183,7 @@ func (h *ElasticScalingOrderHandler) GetOrder(c *gin.Context) {
 		render.Fail(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	routersconstants.SetVersionETag(c, order.Version)
 	render.Success(c, order)
 }
 
@@ -166,8 +195,11 @@ func (h *ElasticScalingOrderHandler) GetOrder(c *gin.Context) {
 // @Produce json
 // @Param id path int true "订单ID"
 // @Param request body object{status=string,reason=string} true "状态更新请求"
+// @Param If-Match header string false "订单详情返回的 ETag，订单已被修改时返回 412"
 // @Success 200 {object} render.Response
 // @Failure 400 {object} render.ErrorResponse
+// @Failure 409 {object} render.ErrorResponse
+// @Failure 412 {object} render.ErrorResponse
 // @Failure 500 {object} render.ErrorResponse
 // @Router /fe-v1/elastic-scaling/orders/{id}/status [put]
 func (h *ElasticScalingOrderHandler) UpdateOrderStatus(c *gin.Context) {
@@ -184,15 +216,54 @@ func (h *ElasticScalingOrderHandler) UpdateOrderStatus(c *gin.Context) {
 		render.BadRequest(c, err.Error())
 		return
 	}
+	version, ifMatch, err := routersconstants.IfMatchVersion(c)
+	if err != nil {
+		render.BadRequest(c, err.Error())
+		return
+	}
 	executor := "admin"
-	err := h.service.UpdateOrderStatus(req.ID, reqBody.Status, executor, reqBody.Reason)
+	if ifMatch {
+		err = h.service.UpdateOrderStatusIfMatch(req.ID, version, reqBody.Status, executor, reqBody.Reason)
+	} else {
+		err = h.service.UpdateOrderStatus(req.ID, reqBody.Status, executor, reqBody.Reason)
+	}
 	if err != nil {
-		render.Fail(c, http.StatusInternalServerError, err.Error())
+		render.Fail(c, orderUpdateErrorStatus(err), err.Error())
 		return
 	}
 	render.Success(c, nil)
 }
 
+// GetOrderTimeline 获取订单时间线
+// @Summary 获取订单时间线
+// @Description 获取订单创建、订单及设备状态变更和关联策略执行记录组成的时间线，按时间排序
+// @Tags 弹性伸缩订单
+// @Accept json
+// @Produce json
+// @Param id path int true "订单ID"
+// @Success 200 {object} render.Response{data=[]order.OrderTimelineEntryDTO}
+// @Failure 400 {object} render.ErrorResponse
+// @Failure 404 {object} render.ErrorResponse
+// @Failure 500 {object} render.ErrorResponse
+// @Router /fe-v1/elastic-scaling/orders/{id}/timeline [get]
+func (h *ElasticScalingOrderHandler) GetOrderTimeline(c *gin.Context) {
+	var req OrderIDRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		render.BadRequest(c, "无效的订单ID")
+		return
+	}
+	timeline, err := h.service.GetOrderTimeline(req.ID)
+	if err != nil {
+		if service.IsNotFound(err) {
+			render.Fail(c, http.StatusNotFound, err.Error())
+			return
+		}
+		render.Fail(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	render.Success(c, timeline)
+}
+
 // GetOrderDevices 获取订单关联的设备
 // @Summary 获取订单关联的设备
 // @Description 获取指定订单关联的设备列表
@@ -229,6 +300,7 @@ func (h *ElasticScalingOrderHandler) GetOrderDevices(c *gin.Context) {
 // @Param request body object{status=string} true "设备状态更新请求"
 // @Success 200 {object} render.Response
 // @Failure 400 {object} render.ErrorResponse
+// @Failure 409 {object} render.ErrorResponse
 // @Failure 500 {object} render.ErrorResponse
 // @Router /fe-v1/elastic-scaling/orders/{id}/devices/{device_id}/status [put]
 func (h *ElasticScalingOrderHandler) UpdateOrderDeviceStatus(c *gin.Context) {
@@ -244,9 +316,10 @@ func (h *ElasticScalingOrderHandler) UpdateOrderDeviceStatus(c *gin.Context) {
 		render.BadRequest(c, err.Error())
 		return
 	}
-	err := h.service.UpdateOrderDeviceStatus(req.OrderID, req.DeviceID, reqBody.Status)
+	executor := "admin"
+	err := h.service.UpdateOrderDeviceStatus(req.OrderID, req.DeviceID, reqBody.Status, executor)
 	if err != nil {
-		render.Fail(c, http.StatusInternalServerError, err.Error())
+		render.Fail(c, orderUpdateErrorStatus(err), err.Error())
 		return
 	}
 	render.Success(c, nil)
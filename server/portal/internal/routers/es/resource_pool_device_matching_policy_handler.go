@@ -166,6 +166,11 @@ func (h *ResourcePoolDeviceMatchingPolicyHandler) CreateResourcePoolDeviceMatchi
 
 	// 调用服务创建策略
 	if err := h.policyService.CreateResourcePoolDeviceMatchingPolicy(c.Request.Context(), policy); err != nil {
+		if service.IsBadRequest(err) {
+			// 查询模板与资源池兼容性规则冲突
+			render.BadRequest(c, err.Error())
+			return
+		}
 		render.InternalServerError(c, err.Error())
 		return
 	}
@@ -209,6 +214,11 @@ func (h *ResourcePoolDeviceMatchingPolicyHandler) UpdateResourcePoolDeviceMatchi
 
 	// 调用服务更新策略
 	if err := h.policyService.UpdateResourcePoolDeviceMatchingPolicy(c.Request.Context(), policy); err != nil {
+		if service.IsBadRequest(err) {
+			// 查询模板与资源池兼容性规则冲突
+			render.BadRequest(c, err.Error())
+			return
+		}
 		render.InternalServerError(c, err.Error())
 		return
 	}
@@ -0,0 +1,35 @@
+package routers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 乐观锁版本相关的 HTTP 头
+const (
+	HeaderETag    = "ETag"
+	HeaderIfMatch = "If-Match"
+)
+
+// SetVersionETag 将记录的版本号写入响应的 ETag 头
+func SetVersionETag(c *gin.Context, version int) {
+	c.Header(HeaderETag, strconv.Quote(strconv.Itoa(version)))
+}
+
+// IfMatchVersion 解析请求的 If-Match 头中的版本号，支持 "3"、W/"3" 和 3 的形式。
+// 请求未携带 If-Match 或为 * 时 ok 为 false
+func IfMatchVersion(c *gin.Context) (version int, ok bool, err error) {
+	value := strings.TrimSpace(c.GetHeader(HeaderIfMatch))
+	if value == "" || value == "*" {
+		return 0, false, nil
+	}
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err = strconv.Atoi(value)
+	if err != nil {
+		return 0, false, fmt.Errorf("无效的 If-Match 头: %s", c.GetHeader(HeaderIfMatch))
+	}
+	return version, true, nil
+}
@@ -0,0 +1,259 @@
+package routers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"navy-ng/pkg/middleware/render"
+	"navy-ng/server/portal/internal/service"
+)
+
+// FreezeCalendarHandler 变更冻结日历处理器
+type FreezeCalendarHandler struct {
+	service *service.FreezeCalendarService
+}
+
+// NewFreezeCalendarHandler 创建变更冻结日历处理器
+func NewFreezeCalendarHandler(db *gorm.DB) *FreezeCalendarHandler {
+	return &FreezeCalendarHandler{
+		service: service.NewFreezeCalendarService(db),
+	}
+}
+
+// RegisterRoutes 注册路由
+func (h *FreezeCalendarHandler) RegisterRoutes(router *gin.RouterGroup) {
+	freezeGroup := router.Group(RouteGroupFreezeWindows)
+	{
+		freezeGroup.GET("", h.ListFreezeWindows)
+		freezeGroup.GET(SubRouteStatus, h.GetFreezeStatus)
+		freezeGroup.GET(RouteParamID, h.GetFreezeWindow)
+		freezeGroup.POST("", h.CreateFreezeWindow)
+		freezeGroup.PUT(RouteParamID, h.UpdateFreezeWindow)
+		freezeGroup.DELETE(RouteParamID, h.DeleteFreezeWindow)
+	}
+}
+
+// ListFreezeWindows 获取冻结窗口列表
+// @Summary 获取冻结窗口列表
+// @Description 获取变更冻结窗口列表，指定集群时返回该集群窗口及全局窗口
+// @Tags 变更冻结日历
+// @Accept json
+// @Produce json
+// @Param page query int false "页码，默认1"
+// @Param size query int false "每页大小，默认10"
+// @Param cluster_id query int false "集群ID"
+// @Param status query string false "状态"
+// @Success 200 {object} service.FreezeWindowListResponse
+// @Failure 400 {object} render.ErrorResponse
+// @Failure 500 {object} render.ErrorResponse
+// @Router /fe-v1/freeze-windows [get]
+func (h *FreezeCalendarHandler) ListFreezeWindows(ctx *gin.Context) {
+	var query service.FreezeWindowQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		render.BadRequest(ctx, MsgInvalidQueryParams+err.Error())
+		return
+	}
+
+	// 设置默认值
+	if query.Page <= 0 {
+		query.Page = DefaultPageInt
+	}
+	if query.Size <= 0 {
+		query.Size = DefaultSizeInt
+	}
+
+	response, err := h.service.ListFreezeWindows(ctx.Request.Context(), &query)
+	if err != nil {
+		render.InternalServerError(ctx, MsgFailedToGetFreezeWindows+err.Error())
+		return
+	}
+
+	render.Success(ctx, response)
+}
+
+// GetFreezeStatus 查询是否处于冻结期
+// @Summary 查询是否处于冻结期
+// @Description 查询当前（或指定时间）是否处于变更冻结期，可供自动伸缩和维护订单确认使用；不指定集群时只检查全局窗口
+// @Tags 变更冻结日历
+// @Accept json
+// @Produce json
+// @Param cluster_id query int false "集群ID"
+// @Param at query string false "检查时间（RFC3339），默认当前时间"
+// @Success 200 {object} service.FreezeStatusResponse
+// @Failure 400 {object} render.ErrorResponse
+// @Failure 500 {object} render.ErrorResponse
+// @Router /fe-v1/freeze-windows/status [get]
+func (h *FreezeCalendarHandler) GetFreezeStatus(ctx *gin.Context) {
+	var clusterID *int
+	if value := ctx.Query("cluster_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			render.BadRequest(ctx, MsgInvalidQueryParams+err.Error())
+			return
+		}
+		clusterID = &id
+	}
+
+	at := time.Now()
+	if value := ctx.Query("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			render.BadRequest(ctx, MsgInvalidQueryParams+err.Error())
+			return
+		}
+		at = parsed
+	}
+
+	response, err := h.service.CheckFreezeStatus(ctx.Request.Context(), clusterID, at)
+	if err != nil {
+		render.InternalServerError(ctx, MsgFailedToGetFreezeStatus+err.Error())
+		return
+	}
+
+	render.Success(ctx, response)
+}
+
+// GetFreezeWindow 获取冻结窗口详情
+// @Summary 获取冻结窗口详情
+// @Description 根据ID获取变更冻结窗口
+// @Tags 变更冻结日历
+// @Accept json
+// @Produce json
+// @Param id path int true "冻结窗口ID"
+// @Success 200 {object} service.FreezeWindowResponse
+// @Failure 400 {object} render.ErrorResponse
+// @Failure 404 {object} render.ErrorResponse
+// @Failure 500 {object} render.ErrorResponse
+// @Router /fe-v1/freeze-windows/{id} [get]
+func (h *FreezeCalendarHandler) GetFreezeWindow(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param(ParamID), Base10, BitSize64)
+	if err != nil {
+		render.BadRequest(ctx, MsgInvalidID+": "+err.Error())
+		return
+	}
+
+	response, err := h.service.GetFreezeWindow(ctx.Request.Context(), int(id))
+	if err != nil {
+		if service.IsNotFound(err) {
+			render.NotFound(ctx, err.Error())
+			return
+		}
+		render.InternalServerError(ctx, MsgFailedToGetFreezeWindow+err.Error())
+		return
+	}
+
+	render.Success(ctx, response)
+}
+
+// CreateFreezeWindow 创建冻结窗口
+// @Summary 创建冻结窗口
+// @Description 创建全局或集群级变更冻结窗口，规则类型为 range、weekly 或 dates
+// @Tags 变更冻结日历
+// @Accept json
+// @Produce json
+// @Param window body service.FreezeWindowRequest true "冻结窗口信息"
+// @Success 201 {object} service.FreezeWindowResponse
+// @Failure 400 {object} render.ErrorResponse
+// @Failure 500 {object} render.ErrorResponse
+// @Router /fe-v1/freeze-windows [post]
+func (h *FreezeCalendarHandler) CreateFreezeWindow(ctx *gin.Context) {
+	var req service.FreezeWindowRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		render.BadRequest(ctx, MsgInvalidRequestParams+err.Error())
+		return
+	}
+
+	response, err := h.service.CreateFreezeWindow(ctx.Request.Context(), &req, GetCurrentUsername(ctx))
+	if err != nil {
+		if service.IsBadRequest(err) {
+			render.BadRequest(ctx, err.Error())
+			return
+		}
+		render.InternalServerError(ctx, MsgFailedToCreateFreezeWindow+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, render.Response{
+		Code: http.StatusCreated,
+		Msg:  MsgSuccess,
+		Data: response,
+	})
+}
+
+// UpdateFreezeWindow 更新冻结窗口
+// @Summary 更新冻结窗口
+// @Description 更新变更冻结窗口
+// @Tags 变更冻结日历
+// @Accept json
+// @Produce json
+// @Param id path int true "冻结窗口ID"
+// @Param window body service.FreezeWindowRequest true "冻结窗口信息"
+// @Success 200 {object} service.FreezeWindowResponse
+// @Failure 400 {object} render.ErrorResponse
+// @Failure 404 {object} render.ErrorResponse
+// @Failure 500 {object} render.ErrorResponse
+// @Router /fe-v1/freeze-windows/{id} [put]
+func (h *FreezeCalendarHandler) UpdateFreezeWindow(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param(ParamID), Base10, BitSize64)
+	if err != nil {
+		render.BadRequest(ctx, MsgInvalidID+": "+err.Error())
+		return
+	}
+
+	var req service.FreezeWindowRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		render.BadRequest(ctx, MsgInvalidRequestParams+err.Error())
+		return
+	}
+
+	response, err := h.service.UpdateFreezeWindow(ctx.Request.Context(), int(id), &req)
+	if err != nil {
+		if service.IsNotFound(err) {
+			render.NotFound(ctx, err.Error())
+			return
+		}
+		if service.IsBadRequest(err) {
+			render.BadRequest(ctx, err.Error())
+			return
+		}
+		render.InternalServerError(ctx, MsgFailedToUpdateFreezeWindow+err.Error())
+		return
+	}
+
+	render.Success(ctx, response)
+}
+
+// DeleteFreezeWindow 删除冻结窗口
+// @Summary 删除冻结窗口
+// @Description 删除变更冻结窗口
+// @Tags 变更冻结日历
+// @Accept json
+// @Produce json
+// @Param id path int true "冻结窗口ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} render.ErrorResponse
+// @Failure 404 {object} render.ErrorResponse
+// @Failure 500 {object} render.ErrorResponse
+// @Router /fe-v1/freeze-windows/{id} [delete]
+func (h *FreezeCalendarHandler) DeleteFreezeWindow(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param(ParamID), Base10, BitSize64)
+	if err != nil {
+		render.BadRequest(ctx, MsgInvalidID+": "+err.Error())
+		return
+	}
+
+	if err := h.service.DeleteFreezeWindow(ctx.Request.Context(), int(id)); err != nil {
+		if service.IsNotFound(err) {
+			render.NotFound(ctx, err.Error())
+			return
+		}
+		render.InternalServerError(ctx, MsgFailedToDeleteFreezeWindow+err.Error())
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
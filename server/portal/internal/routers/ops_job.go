@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"navy-ng/pkg/middleware/render"
 	"navy-ng/server/portal/internal/service"
+	"navy-ng/server/portal/internal/service/events"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
 	"gorm.io/gorm" // Added import for gorm
 )
 
@@ -22,8 +24,9 @@ type OpsJobHandler struct {
 }
 
 // NewOpsJobHandler creates a new OpsJobHandler, instantiating the service internally.
-func NewOpsJobHandler(db *gorm.DB) *OpsJobHandler {
-	opsService := service.NewOpsJobService(db) // Instantiate service here
+// Job lifecycle events are published through eventManager so that deployment orders can follow their jobs.
+func NewOpsJobHandler(db *gorm.DB, logger *zap.Logger, eventManager *events.EventManager) *OpsJobHandler {
+	opsService := service.NewOpsJobService(db, logger).WithEventManager(eventManager) // Instantiate service here
 	return &OpsJobHandler{
 		service: opsService,
 		upgrader: websocket.Upgrader{
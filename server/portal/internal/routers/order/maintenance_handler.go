@@ -97,6 +97,7 @@ func (h *MaintenanceHandler) RequestMaintenance(c *gin.Context) {
 // @Param callback body order.MaintenanceCallbackDTO true "维护回调信息"
 // @Success 200 {object} render.Response
 // @Failure 400 {object} render.Response
+// @Failure 409 {object} render.Response
 // @Failure 500 {object} render.Response
 // @Router /fe-v1/device-maintenance/callback [post]
 func (h *MaintenanceHandler) MaintenanceCallback(c *gin.Context) {
@@ -119,7 +120,7 @@ func (h *MaintenanceHandler) MaintenanceCallback(c *gin.Context) {
 	case "completed":
 		response, err := h.service.CompleteMaintenance(callback.ExternalTicketID, callback.Message)
 		if err != nil {
-			render.Fail(c, http.StatusInternalServerError, "处理维护完成回调失败: "+err.Error())
+			render.Fail(c, orderErrorStatus(err), "处理维护完成回调失败: "+err.Error())
 			return
 		}
 		render.Success(c, response)
@@ -273,6 +274,7 @@ func (h *MaintenanceHandler) GetPendingUncordonRequests(c *gin.Context) {
 // @Param id path int true "维护订单ID"
 // @Success 200 {object} render.Response
 // @Failure 400 {object} render.Response
+// @Failure 409 {object} render.Response
 // @Failure 500 {object} render.Response
 // @Router /fe-v1/device-maintenance/confirm/{id} [post]
 func (h *MaintenanceHandler) ConfirmMaintenance(c *gin.Context) {
@@ -290,7 +292,7 @@ func (h *MaintenanceHandler) ConfirmMaintenance(c *gin.Context) {
 
 	err := h.service.ConfirmMaintenance(req.ID, operatorID)
 	if err != nil {
-		render.Fail(c, http.StatusInternalServerError, "确认维护请求失败: "+err.Error())
+		render.Fail(c, orderErrorStatus(err), "确认维护请求失败: "+err.Error())
 		return
 	}
 
@@ -306,6 +308,7 @@ func (h *MaintenanceHandler) ConfirmMaintenance(c *gin.Context) {
 // @Param id path int true "维护订单ID"
 // @Success 200 {object} render.Response
 // @Failure 400 {object} render.Response
+// @Failure 409 {object} render.Response
 // @Failure 500 {object} render.Response
 // @Router /fe-v1/device-maintenance/start/{id} [post]
 func (h *MaintenanceHandler) StartMaintenance(c *gin.Context) {
@@ -323,7 +326,7 @@ func (h *MaintenanceHandler) StartMaintenance(c *gin.Context) {
 
 	err := h.service.StartMaintenance(req.ID, operatorID)
 	if err != nil {
-		render.Fail(c, http.StatusInternalServerError, "执行Cordon操作失败: "+err.Error())
+		render.Fail(c, orderErrorStatus(err), "执行Cordon操作失败: "+err.Error())
 		return
 	}
 
@@ -339,6 +342,7 @@ func (h *MaintenanceHandler) StartMaintenance(c *gin.Context) {
 // @Param id path int true "Uncordon订单ID"
 // @Success 200 {object} render.Response
 // @Failure 400 {object} render.Response
+// @Failure 409 {object} render.Response
 // @Failure 500 {object} render.Response
 // @Router /fe-v1/device-maintenance/uncordon/{id} [post]
 func (h *MaintenanceHandler) ExecuteUncordon(c *gin.Context) {
@@ -356,7 +360,7 @@ func (h *MaintenanceHandler) ExecuteUncordon(c *gin.Context) {
 
 	err := h.service.ExecuteUncordon(req.ID, operatorID)
 	if err != nil {
-		render.Fail(c, http.StatusInternalServerError, "执行Uncordon操作失败: "+err.Error())
+		render.Fail(c, orderErrorStatus(err), "执行Uncordon操作失败: "+err.Error())
 		return
 	}
 
@@ -8,6 +8,9 @@ import (
 	"navy-ng/pkg/middleware/render"
 	"navy-ng/server/portal/internal/routers"
 	. "navy-ng/server/portal/internal/routers"
+	"navy-ng/server/portal/internal/service"
+	ses "navy-ng/server/portal/internal/service/es"
+	"navy-ng/server/portal/internal/service/events"
 	"navy-ng/server/portal/internal/service/order"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +20,23 @@ import (
 
 // Constants moved to constants.go
 
+// orderErrorStatus 返回订单操作错误对应的 HTTP 状态码：订单不存在返回 404，请求数据无效返回 400，
+// 非法的状态转换和并发修改冲突返回 409，If-Match 版本过期返回 412
+func orderErrorStatus(err error) int {
+	switch {
+	case service.IsNotFound(err):
+		return http.StatusNotFound
+	case service.IsBadRequest(err):
+		return http.StatusBadRequest
+	case order.IsIllegalTransition(err), service.IsConflict(err):
+		return http.StatusConflict
+	case service.IsPreconditionFailed(err):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // UnifiedOrderHandler 处理所有新类型订单的通用 Handler
 type UnifiedOrderHandler struct {
 	db *gorm.DB // db instance, may be needed for some operations
@@ -29,30 +49,26 @@ func NewUnifiedOrderHandler(db *gorm.DB) *UnifiedOrderHandler {
 	}
 }
 
-// InitServices 初始化并注册所有订单服务
-func (h *UnifiedOrderHandler) InitServices(logger *zap.Logger) {
-	// 注册 GeneralOrderService
-	generalOrderService := order.NewGeneralOrderService(h.db)
-	order.RegisterOrderService("general", generalOrderService)
-
-	// 注册维护订单服务
-	maintenanceService := order.NewMaintenanceOrderService(h.db, logger)
-	order.RegisterOrderService(string(portal.OrderTypeMaintenance), maintenanceService)
-
-	// 未来新的订单服务可以在这里继续注册
-	// e.g. elasticScalingService := service.NewElasticScalingService(db, redisHandler, logger, deviceCache)
-	//      service.RegisterOrderService(string(portal.OrderTypeElasticScaling), elasticScalingService)
+// InitServices 初始化并注册所有订单服务，弹性伸缩订单服务由弹性伸缩订单处理器创建后传入，
+// 部署订单服务通过 eventManager 订阅运维任务事件
+func (h *UnifiedOrderHandler) InitServices(logger *zap.Logger, eventManager *events.EventManager, elasticScalingService *ses.ElasticScalingService) {
+	order.InitializeOrderServices(h.db, logger, eventManager)
+	if elasticScalingService != nil {
+		order.RegisterOrderService(portal.OrderTypeElasticScaling, ses.NewUnifiedOrderService(elasticScalingService))
+	}
 }
 
 // RegisterRoutes 注册通用订单路由
-// 路由设计为 /orders/{orderType}/... 的格式
+// 路由设计为 /orders/{orderType}/... 的格式，按订单类型分发到注册的订单服务
 func (h *UnifiedOrderHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/orders/types", h.ListOrderTypes)
 	orderGroup := router.Group("/orders/:" + routers.ParamOrderType)
 	{
 		orderGroup.POST("", h.CreateOrder)
 		orderGroup.GET("", h.ListOrders)
 		orderGroup.GET("/:"+ParamID, h.GetOrder)
 		orderGroup.PUT("/:"+ParamID+"/status", h.UpdateOrderStatus)
+		orderGroup.GET("/:"+ParamID+"/timeline", h.GetOrderTimeline)
 		// 快捷操作路由
 		orderGroup.POST("/:"+ParamID+"/process", h.ProcessOrder)
 		orderGroup.POST("/:"+ParamID+"/complete", h.CompleteOrder)
@@ -61,6 +77,40 @@ func (h *UnifiedOrderHandler) RegisterRoutes(router *gin.RouterGroup) {
 	}
 }
 
+// lookupService 获取订单类型对应的服务，未注册时返回 400 并返回 false
+func (h *UnifiedOrderHandler) lookupService(c *gin.Context, orderType string) (order.OrderTypeService, bool) {
+	s, found := order.GetOrderService(portal.OrderType(orderType))
+	if !found {
+		render.BadRequest(c, fmt.Sprintf(MsgInvalidOrderType, orderType))
+		return nil, false
+	}
+	return s, true
+}
+
+// lookupOrder 校验订单属于路径中的订单类型并获取对应的服务，失败时写入错误响应并返回 false
+func (h *UnifiedOrderHandler) lookupOrder(c *gin.Context, orderType string, id int64) (order.OrderTypeService, bool) {
+	s, ok := h.lookupService(c, orderType)
+	if !ok {
+		return nil, false
+	}
+	if err := order.CheckOrderType(c.Request.Context(), h.db, int(id), s.OrderType()); err != nil {
+		render.Fail(c, orderErrorStatus(err), err.Error())
+		return nil, false
+	}
+	return s, true
+}
+
+// ListOrderTypes 获取已注册的订单类型
+// @Summary 获取已注册的订单类型
+// @Description 返回统一订单接口支持的订单类型，可作为 /orders/{orderType} 的路径参数
+// @Tags 统一订单
+// @Produce json
+// @Success 200 {object} render.Response{data=[]string} "已注册的订单类型"
+// @Router /fe-v1/orders/types [get]
+func (h *UnifiedOrderHandler) ListOrderTypes(c *gin.Context) {
+	render.Success(c, order.RegisteredOrderTypes())
+}
+
 // GetOrderRequest 定义了获取订单时从 URI 绑定的参数
 type GetOrderRequest struct {
 	OrderType string `uri:"orderType" binding:"required"`
@@ -73,9 +123,10 @@ type GetOrderRequest struct {
 // @Tags 统一订单
 // @Accept json
 // @Produce json
-// @Param orderType path string true "订单类型 (e.g., general)"
+// @Param orderType path string true "订单类型 (general, maintenance, elastic_scaling)"
 // @Param id path int true "订单ID"
-// @Success 200 {object} render.Response{data=order.GeneralOrderDTO} "成功时返回通用订单详情"
+// @Success 200 {object} render.Response "成功时返回对应订单类型的订单详情，如 order.GeneralOrderDTO"
+// @Header 200 {string} ETag "订单版本号，更新状态时通过 If-Match 传回"
 // @Failure 400 {object} render.ErrorResponse "请求参数错误"
 // @Failure 404 {object} render.ErrorResponse "订单不存在"
 // @Failure 500 {object} render.ErrorResponse "服务器内部错误"
@@ -87,38 +138,53 @@ func (h *UnifiedOrderHandler) GetOrder(c *gin.Context) {
 		return
 	}
 
-	serviceInstance, found := order.GetOrderService(req.OrderType)
-	if !found {
-		render.BadRequest(c, fmt.Sprintf(MsgInvalidOrderType, req.OrderType))
+	s, ok := h.lookupOrder(c, req.OrderType, req.ID)
+	if !ok {
 		return
 	}
 
-	switch req.OrderType {
-	case OrderTypeGeneral:
-		handleGetOrder[*order.GeneralOrderDTO, order.GeneralOrderCreateDTO](c, serviceInstance, req.ID)
-	default:
-		render.BadRequest(c, fmt.Sprintf(MsgUnsupportedOrderType, req.OrderType))
+	order, err := s.GetOrder(c.Request.Context(), int(req.ID))
+	if err != nil {
+		render.Fail(c, orderErrorStatus(err), fmt.Sprintf(MsgGetOrderFailed, err.Error()))
+		return
 	}
+	if base := order.GetBaseOrder(); base != nil {
+		SetVersionETag(c, base.Version)
+	}
+
+	render.Success(c, order)
 }
 
-// handleGetOrder 是一个泛型辅助函数，用于处理获取订单的通用逻辑
-func handleGetOrder[T order.RichOrder, C any](c *gin.Context, serviceInstance any, id int64) {
-	// 类型断言，确保服务实例实现了正确的泛型接口
-	s, ok := serviceInstance.(order.UnifiedOrderService[T, C])
-	if !ok {
-		render.Fail(c, http.StatusInternalServerError, MsgServiceTypeMismatch)
+// GetOrderTimeline 获取订单时间线
+// @Summary 获取订单时间线
+// @Description 获取订单创建、订单及设备状态变更和关联策略执行记录组成的时间线，适用于所有订单类型
+// @Tags 统一订单
+// @Accept json
+// @Produce json
+// @Param orderType path string true "订单类型"
+// @Param id path int true "订单ID"
+// @Success 200 {object} render.Response{data=[]order.OrderTimelineEntryDTO} "成功时返回按时间排序的时间线"
+// @Failure 400 {object} render.ErrorResponse "请求参数错误"
+// @Failure 404 {object} render.ErrorResponse "订单不存在"
+// @Failure 500 {object} render.ErrorResponse "服务器内部错误"
+// @Router /fe-v1/orders/{orderType}/{id}/timeline [get]
+func (h *UnifiedOrderHandler) GetOrderTimeline(c *gin.Context) {
+	var req GetOrderRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		render.BadRequest(c, fmt.Sprintf(MsgParamBindFailed, err.Error()))
 		return
 	}
 
-	// 调用具体的服务方法
-	order, err := s.GetOrder(c.Request.Context(), int(id))
-	if err != nil {
-		// 可以在这里处理特定的错误类型，例如 gorm.ErrRecordNotFound
-		render.Fail(c, http.StatusInternalServerError, fmt.Sprintf(MsgGetOrderFailed, err.Error()))
+	if _, ok := h.lookupOrder(c, req.OrderType, req.ID); !ok {
 		return
 	}
 
-	render.Success(c, order)
+	timeline, err := order.NewOrderService(h.db).GetOrderTimeline(c.Request.Context(), int(req.ID))
+	if err != nil {
+		render.Fail(c, orderErrorStatus(err), err.Error())
+		return
+	}
+	render.Success(c, timeline)
 }
 
 // --- CreateOrder ---
@@ -134,10 +200,11 @@ type CreateOrderRequest struct {
 // @Tags 统一订单
 // @Accept json
 // @Produce json
-// @Param orderType path string true "订单类型 (e.g., general)"
-// @Param order body order.GeneralOrderCreateDTO true "创建通用订单所需的数据"
-// @Success 201 {object} render.Response{data=order.GeneralOrderDTO} "成功时返回创建的订单详情"
+// @Param orderType path string true "订单类型 (general, maintenance, elastic_scaling)"
+// @Param order body object true "对应订单类型的创建数据，如 order.GeneralOrderCreateDTO、order.MaintenanceOrderDTO、es.OrderDTO"
+// @Success 201 {object} render.Response "成功时返回创建的订单详情"
 // @Failure 400 {object} render.ErrorResponse "请求参数错误"
+// @Failure 409 {object} render.ErrorResponse "设备已被其他进行中的订单预留"
 // @Failure 500 {object} render.ErrorResponse "服务器内部错误"
 // @Router /fe-v1/orders/{orderType} [post]
 func (h *UnifiedOrderHandler) CreateOrder(c *gin.Context) {
@@ -147,37 +214,16 @@ func (h *UnifiedOrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	serviceInstance, found := order.GetOrderService(req.OrderType)
-	if !found {
-		render.BadRequest(c, fmt.Sprintf(MsgInvalidOrderType, req.OrderType))
-		return
-	}
-
-	switch req.OrderType {
-	case OrderTypeGeneral:
-		handleCreateOrder[*order.GeneralOrderDTO, order.GeneralOrderCreateDTO](c, serviceInstance)
-	default:
-		render.BadRequest(c, fmt.Sprintf(MsgUnsupportedOrderType, req.OrderType))
-	}
-}
-
-// handleCreateOrder 是一个泛型辅助函数，用于处理创建订单的通用逻辑
-func handleCreateOrder[T order.RichOrder, C any](c *gin.Context, serviceInstance any) {
-	s, ok := serviceInstance.(order.UnifiedOrderService[T, C])
+	s, ok := h.lookupService(c, req.OrderType)
 	if !ok {
-		render.Fail(c, http.StatusInternalServerError, MsgServiceTypeMismatch)
-		return
-	}
-
-	var createDTO C
-	if err := c.ShouldBindJSON(&createDTO); err != nil {
-		render.BadRequest(c, fmt.Sprintf(MsgParamBindFailed, err.Error()))
 		return
 	}
 
-	order, err := s.CreateOrder(c.Request.Context(), createDTO)
+	order, err := s.CreateOrder(c.Request.Context(), func(createDTO any) error {
+		return c.ShouldBindJSON(createDTO)
+	})
 	if err != nil {
-		render.Fail(c, http.StatusInternalServerError, fmt.Sprintf(MsgCreateOrderFailed, err.Error()))
+		render.Fail(c, orderErrorStatus(err), fmt.Sprintf(MsgCreateOrderFailed, err.Error()))
 		return
 	}
 
@@ -197,13 +243,13 @@ type ListOrdersRequest struct {
 // @Tags 统一订单
 // @Accept json
 // @Produce json
-// @Param orderType path string true "订单类型 (e.g., general)"
+// @Param orderType path string true "订单类型 (general, maintenance, elastic_scaling)"
 // @Param page query int false "页码" default(1)
 // @Param pageSize query int false "每页数量" default(10)
 // @Param status query string false "订单状态"
 // @Param createdBy query string false "创建者"
 // @Param name query string false "订单名称，支持模糊查询"
-// @Success 200 {object} render.Response{data=order.GeneralOrderQueryDTO} "成功时返回订单列表和总数"
+// @Success 200 {object} render.Response "成功时返回订单列表和总数，查询参数由订单类型决定"
 // @Failure 400 {object} render.ErrorResponse "请求参数错误"
 // @Failure 500 {object} render.ErrorResponse "服务器内部错误"
 // @Router /fe-v1/orders/{orderType} [get]
@@ -214,32 +260,15 @@ func (h *UnifiedOrderHandler) ListOrders(c *gin.Context) {
 		return
 	}
 
-	serviceInstance, found := order.GetOrderService(req.OrderType)
-	if !found {
-		render.BadRequest(c, fmt.Sprintf(MsgInvalidOrderType, req.OrderType))
-		return
-	}
-
-	switch req.OrderType {
-	case OrderTypeGeneral:
-		handleListOrders[*order.GeneralOrderDTO, order.GeneralOrderCreateDTO](c, serviceInstance)
-	default:
-		render.BadRequest(c, fmt.Sprintf(MsgUnsupportedOrderType, req.OrderType))
-	}
-}
-
-// handleListOrders 是一个泛型辅助函数，用于处理获取订单列表的通用逻辑
-func handleListOrders[T order.RichOrder, C any](c *gin.Context, serviceInstance any) {
-	s, ok := serviceInstance.(order.UnifiedOrderService[T, C])
+	s, ok := h.lookupService(c, req.OrderType)
 	if !ok {
-		render.Fail(c, http.StatusInternalServerError, MsgServiceTypeMismatch)
 		return
 	}
 
 	// 服务层将从 'c' 中解析出自己需要的查询参数
 	orders, total, err := s.ListOrders(c.Request.Context(), c)
 	if err != nil {
-		render.Fail(c, http.StatusInternalServerError, fmt.Sprintf(MsgListOrdersFailed, err.Error()))
+		render.Fail(c, orderErrorStatus(err), fmt.Sprintf(MsgListOrdersFailed, err.Error()))
 		return
 	}
 
@@ -266,11 +295,14 @@ type UpdateOrderStatusURIRequest struct {
 // @Tags 统一订单
 // @Accept json
 // @Produce json
-// @Param orderType path string true "订单类型 (e.g., general)"
+// @Param orderType path string true "订单类型 (general, maintenance, elastic_scaling)"
 // @Param id path int true "订单ID"
 // @Param statusUpdate body UpdateOrderStatusRequest true "状态更新请求"
+// @Param If-Match header string false "订单详情返回的 ETag"
 // @Success 200 {object} render.Response "成功"
 // @Failure 400 {object} render.ErrorResponse "请求参数错误"
+// @Failure 409 {object} render.ErrorResponse "订单当前状态不允许转换为目标状态，或订单被并发修改"
+// @Failure 412 {object} render.ErrorResponse "If-Match 版本已过期"
 // @Failure 500 {object} render.ErrorResponse "服务器内部错误"
 // @Router /fe-v1/orders/{orderType}/{id}/status [put]
 func (h *UnifiedOrderHandler) UpdateOrderStatus(c *gin.Context) {
@@ -286,32 +318,25 @@ func (h *UnifiedOrderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	serviceInstance, found := order.GetOrderService(uriReq.OrderType)
-	if !found {
-		render.BadRequest(c, fmt.Sprintf(MsgInvalidOrderType, uriReq.OrderType))
+	s, ok := h.lookupOrder(c, uriReq.OrderType, uriReq.ID)
+	if !ok {
 		return
 	}
 
-	switch uriReq.OrderType {
-	case OrderTypeGeneral:
-		handleUpdateOrderStatus[*order.GeneralOrderDTO, order.GeneralOrderCreateDTO](c, serviceInstance, uriReq.ID, bodyReq.Status, bodyReq.Reason)
-	default:
-		render.BadRequest(c, fmt.Sprintf(MsgUnsupportedOrderType, uriReq.OrderType))
-	}
-}
-
-// handleUpdateOrderStatus 是一个泛型辅助函数，用于处理更新订单状态的通用逻辑
-func handleUpdateOrderStatus[T order.RichOrder, C any](c *gin.Context, serviceInstance any, id int64, status, reason string) {
-	s, ok := serviceInstance.(order.UnifiedOrderService[T, C])
-	if !ok {
-		render.Fail(c, http.StatusInternalServerError, MsgServiceTypeMismatch)
+	ctx := c.Request.Context()
+	version, ifMatch, err := IfMatchVersion(c)
+	if err != nil {
+		render.BadRequest(c, err.Error())
 		return
 	}
+	if ifMatch {
+		ctx = order.WithExpectedVersion(ctx, version)
+	}
 	// To-Do: Get executor from request context (e.g., JWT middleware)
 	executor := DefaultUsername
-	err := s.UpdateOrderStatus(c.Request.Context(), int(id), status, executor, reason)
+	err = s.UpdateOrderStatus(ctx, int(uriReq.ID), bodyReq.Status, executor, bodyReq.Reason)
 	if err != nil {
-		render.Fail(c, http.StatusInternalServerError, fmt.Sprintf(MsgUpdateStatusFailed, err.Error()))
+		render.Fail(c, orderErrorStatus(err), fmt.Sprintf(MsgUpdateStatusFailed, err.Error()))
 		return
 	}
 
@@ -343,7 +368,7 @@ type FailRequest struct {
 // @Success 200 {object} render.Response "成功"
 // @Router /fe-v1/orders/{orderType}/{id}/process [post]
 func (h *UnifiedOrderHandler) ProcessOrder(c *gin.Context) {
-	h.handleSimpleLifecycleAction(c, func(srv order.UnifiedOrderService[*order.GeneralOrderDTO, order.GeneralOrderCreateDTO], id int64, executor string) error {
+	h.handleSimpleLifecycleAction(c, func(srv order.OrderTypeService, id int64, executor string) error {
 		return srv.ProcessOrder(c.Request.Context(), int(id), executor)
 	})
 }
@@ -360,7 +385,7 @@ func (h *UnifiedOrderHandler) ProcessOrder(c *gin.Context) {
 // @Success 200 {object} render.Response "成功"
 // @Router /fe-v1/orders/{orderType}/{id}/complete [post]
 func (h *UnifiedOrderHandler) CompleteOrder(c *gin.Context) {
-	h.handleSimpleLifecycleAction(c, func(srv order.UnifiedOrderService[*order.GeneralOrderDTO, order.GeneralOrderCreateDTO], id int64, executor string) error {
+	h.handleSimpleLifecycleAction(c, func(srv order.OrderTypeService, id int64, executor string) error {
 		return srv.CompleteOrder(c.Request.Context(), int(id), executor)
 	})
 }
@@ -388,24 +413,17 @@ func (h *UnifiedOrderHandler) FailOrder(c *gin.Context) {
 		return
 	}
 
-	serviceInstance, found := order.GetOrderService(uriReq.OrderType)
-	if !found {
-		render.BadRequest(c, "不支持的订单类型")
+	s, ok := h.lookupOrder(c, uriReq.OrderType, uriReq.ID)
+	if !ok {
 		return
 	}
 
-	switch uriReq.OrderType {
-	case "general":
-		s, _ := serviceInstance.(order.UnifiedOrderService[*order.GeneralOrderDTO, order.GeneralOrderCreateDTO])
-		err := s.FailOrder(c.Request.Context(), int(uriReq.ID), bodyReq.Executor, bodyReq.Reason)
-		if err != nil {
-			render.Fail(c, http.StatusInternalServerError, err.Error())
-			return
-		}
-		render.Success(c, nil)
-	default:
-		render.BadRequest(c, "不支持的订单类型")
+	err := s.FailOrder(c.Request.Context(), int(uriReq.ID), bodyReq.Executor, bodyReq.Reason)
+	if err != nil {
+		render.Fail(c, orderErrorStatus(err), err.Error())
+		return
 	}
+	render.Success(c, nil)
 }
 
 // CancelOrder 取消订单
@@ -420,13 +438,13 @@ func (h *UnifiedOrderHandler) FailOrder(c *gin.Context) {
 // @Success 200 {object} render.Response "成功"
 // @Router /fe-v1/orders/{orderType}/{id}/cancel [post]
 func (h *UnifiedOrderHandler) CancelOrder(c *gin.Context) {
-	h.handleSimpleLifecycleAction(c, func(srv order.UnifiedOrderService[*order.GeneralOrderDTO, order.GeneralOrderCreateDTO], id int64, executor string) error {
+	h.handleSimpleLifecycleAction(c, func(srv order.OrderTypeService, id int64, executor string) error {
 		return srv.CancelOrder(c.Request.Context(), int(id), executor)
 	})
 }
 
 // handleSimpleLifecycleAction 是一个高阶函数，用于处理只需要 executor 的简单生命周期操作
-func (h *UnifiedOrderHandler) handleSimpleLifecycleAction(c *gin.Context, actionFunc func(srv order.UnifiedOrderService[*order.GeneralOrderDTO, order.GeneralOrderCreateDTO], id int64, executor string) error) {
+func (h *UnifiedOrderHandler) handleSimpleLifecycleAction(c *gin.Context, actionFunc func(srv order.OrderTypeService, id int64, executor string) error) {
 	var uriReq UpdateOrderStatusURIRequest
 	if err := c.ShouldBindUri(&uriReq); err != nil {
 		render.BadRequest(c, err.Error())
@@ -438,26 +456,15 @@ func (h *UnifiedOrderHandler) handleSimpleLifecycleAction(c *gin.Context, action
 		return
 	}
 
-	serviceInstance, found := order.GetOrderService(uriReq.OrderType)
-	if !found {
-		render.BadRequest(c, "不支持的订单类型")
+	srv, ok := h.lookupOrder(c, uriReq.OrderType, uriReq.ID)
+	if !ok {
 		return
 	}
 
-	switch uriReq.OrderType {
-	case "general":
-		srv, ok := serviceInstance.(order.UnifiedOrderService[*order.GeneralOrderDTO, order.GeneralOrderCreateDTO])
-		if !ok {
-			render.Fail(c, http.StatusInternalServerError, "服务接口类型不匹配")
-			return
-		}
-		err := actionFunc(srv, uriReq.ID, bodyReq.Executor)
-		if err != nil {
-			render.Fail(c, http.StatusInternalServerError, err.Error())
-			return
-		}
-		render.Success(c, nil)
-	default:
-		render.BadRequest(c, "不支持的订单类型")
+	err := actionFunc(srv, uriReq.ID, bodyReq.Executor)
+	if err != nil {
+		render.Fail(c, orderErrorStatus(err), err.Error())
+		return
 	}
+	render.Success(c, nil)
 }
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"navy-ng/models/portal"
+)
+
+// 集群位置来源
+const (
+	ClusterLocationSourceMapping = "mapping" // 集群位置映射
+	ClusterLocationSourceCluster = "cluster" // 集群自身字段及集群名称解析
+)
+
+// legacyClusterRoomPattern 未配置位置映射时从集群名称中解析机房的命名规则
+var legacyClusterRoomPattern = regexp.MustCompile(`^[^-]+-.*?(\d+)-(?:calico|flannel)`)
+
+// ResolvedClusterLocation 集群解析后的位置信息
+type ResolvedClusterLocation struct {
+	IDC      string
+	Rooms    []string
+	NetZones []string
+	Cabinets []string // 允许使用的机柜，为空时不限制
+	Source   string   // mapping 或 cluster
+}
+
+// ClusterLocationService 集群位置映射服务
+type ClusterLocationService struct {
+	db *gorm.DB
+}
+
+// NewClusterLocationService 创建集群位置映射服务实例
+func NewClusterLocationService(db *gorm.DB) *ClusterLocationService {
+	return &ClusterLocationService{db: db}
+}
+
+// ListClusterLocations 获取集群位置映射列表
+func (s *ClusterLocationService) ListClusterLocations(ctx context.Context, query *ClusterLocationQuery) (*ClusterLocationListResponse, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	db := s.db.WithContext(timeoutCtx).Model(&portal.ClusterLocation{})
+	if query.ClusterID != nil {
+		db = db.Where("cluster_id = ?", *query.ClusterID)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, NewServerError("获取集群位置映射总数失败", err)
+	}
+
+	var locations []portal.ClusterLocation
+	offset := (query.Page - 1) * query.Size
+	if err := db.Offset(offset).Limit(query.Size).Order("cluster_id").Find(&locations).Error; err != nil {
+		return nil, NewServerError("获取集群位置映射列表失败", err)
+	}
+
+	clusterIDs := make([]int, len(locations))
+	for i, location := range locations {
+		clusterIDs[i] = location.ClusterID
+	}
+	clusterNames, err := s.clusterNames(timeoutCtx, clusterIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*ClusterLocationResponse, len(locations))
+	for i := range locations {
+		responses[i] = convertToClusterLocationResponse(&locations[i], clusterNames[locations[i].ClusterID])
+	}
+
+	return &ClusterLocationListResponse{
+		List:  responses,
+		Total: total,
+		Page:  query.Page,
+		Size:  query.Size,
+	}, nil
+}
+
+// GetClusterLocation 根据ID获取集群位置映射
+func (s *ClusterLocationService) GetClusterLocation(ctx context.Context, id int) (*ClusterLocationResponse, error) {
+	var location portal.ClusterLocation
+	if err := s.db.WithContext(ctx).First(&location, id).Error; err != nil {
+		return nil, HandleDBError(err, "集群位置映射", id)
+	}
+	clusterNames, err := s.clusterNames(ctx, []int{location.ClusterID})
+	if err != nil {
+		return nil, err
+	}
+	return convertToClusterLocationResponse(&location, clusterNames[location.ClusterID]), nil
+}
+
+// CreateClusterLocation 创建集群位置映射，每个集群只能有一条映射
+func (s *ClusterLocationService) CreateClusterLocation(ctx context.Context, req *ClusterLocationRequest, username string) (*ClusterLocationResponse, error) {
+	clusterName, err := s.validateClusterLocationRequest(ctx, req, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	location := req.toModel()
+	location.CreatedBy = username
+	if err := s.db.WithContext(ctx).Create(&location).Error; err != nil {
+		return nil, NewServerError("创建集群位置映射失败", err)
+	}
+	return convertToClusterLocationResponse(&location, clusterName), nil
+}
+
+// UpdateClusterLocation 更新集群位置映射
+func (s *ClusterLocationService) UpdateClusterLocation(ctx context.Context, id int, req *ClusterLocationRequest) (*ClusterLocationResponse, error) {
+	var existing portal.ClusterLocation
+	if err := s.db.WithContext(ctx).First(&existing, id).Error; err != nil {
+		return nil, HandleDBError(err, "集群位置映射", id)
+	}
+
+	clusterName, err := s.validateClusterLocationRequest(ctx, req, id)
+	if err != nil {
+		return nil, err
+	}
+
+	location := req.toModel()
+	location.BaseModel = existing.BaseModel
+	location.CreatedBy = existing.CreatedBy
+	if err := s.db.WithContext(ctx).Save(&location).Error; err != nil {
+		return nil, NewServerError("更新集群位置映射失败", err)
+	}
+	return convertToClusterLocationResponse(&location, clusterName), nil
+}
+
+// DeleteClusterLocation 删除集群位置映射，删除后该集群恢复使用集群自身字段
+func (s *ClusterLocationService) DeleteClusterLocation(ctx context.Context, id int) error {
+	result := s.db.WithContext(ctx).Delete(&portal.ClusterLocation{}, id)
+	if result.Error != nil {
+		return NewServerError("删除集群位置映射失败", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return NewNotFoundError("集群位置映射", id)
+	}
+	return nil
+}
+
+// validateClusterLocationRequest 校验请求：集群必须存在且没有其他映射，返回集群名称
+func (s *ClusterLocationService) validateClusterLocationRequest(ctx context.Context, req *ClusterLocationRequest, id int) (string, error) {
+	req.IDC = strings.TrimSpace(req.IDC)
+	req.Rooms = normalizeLocationList(req.Rooms)
+	req.NetZones = normalizeLocationList(req.NetZones)
+	req.Cabinets = normalizeLocationList(req.Cabinets)
+	if req.IDC == "" && len(req.Rooms) == 0 && len(req.NetZones) == 0 && len(req.Cabinets) == 0 {
+		return "", NewBadRequestError("IDC、机房、网络区域和机柜至少需要设置一项")
+	}
+	if len(req.Cabinets) > 0 && len(req.Rooms) == 0 {
+		return "", NewBadRequestError("设置允许使用的机柜时必须同时设置机房")
+	}
+
+	var cluster portal.K8sCluster
+	if err := s.db.WithContext(ctx).Select("id", "clustername").First(&cluster, req.ClusterID).Error; err != nil {
+		if IsNotFound(err) {
+			return "", NewBadRequestError(fmt.Sprintf("集群不存在: %d", req.ClusterID))
+		}
+		return "", NewServerError("查询集群失败", err)
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&portal.ClusterLocation{}).
+		Where("cluster_id = ? AND id <> ?", req.ClusterID, id).Count(&count).Error; err != nil {
+		return "", NewServerError("查询集群位置映射失败", err)
+	}
+	if count > 0 {
+		return "", NewBadRequestError(fmt.Sprintf("集群 %s 已存在位置映射", cluster.ClusterName))
+	}
+	return cluster.ClusterName, nil
+}
+
+// clusterNames 批量获取集群名称
+func (s *ClusterLocationService) clusterNames(ctx context.Context, clusterIDs []int) (map[int]string, error) {
+	names := make(map[int]string, len(clusterIDs))
+	if len(clusterIDs) == 0 {
+		return names, nil
+	}
+	var clusters []portal.K8sCluster
+	if err := s.db.WithContext(ctx).Select("id", "clustername").Where("id IN ?", clusterIDs).Find(&clusters).Error; err != nil {
+		return nil, NewServerError("获取集群信息失败", err)
+	}
+	for _, cluster := range clusters {
+		names[cluster.ID] = cluster.ClusterName
+	}
+	return names, nil
+}
+
+// ResolveClusterLocation 解析集群位置：优先使用集群位置映射（映射未设置 IDC 时使用集群的 IDC），
+// 未配置映射时使用集群的 IDC、安全域字段，机房按集群命名规则从集群名称中解析。
+func ResolveClusterLocation(db *gorm.DB, cluster *portal.K8sCluster) (*ResolvedClusterLocation, error) {
+	var location portal.ClusterLocation
+	err := db.Where("cluster_id = ?", cluster.ID).First(&location).Error
+	if err == nil {
+		resolved := resolvedFromMapping(&location)
+		if resolved.IDC == "" {
+			resolved.IDC = cluster.Idc
+		}
+		return resolved, nil
+	}
+	if !IsNotFound(err) {
+		return nil, err
+	}
+
+	resolved := &ResolvedClusterLocation{IDC: cluster.Idc, Source: ClusterLocationSourceCluster}
+	if cluster.Zone != "" {
+		resolved.NetZones = []string{cluster.Zone}
+	}
+	if room := parseRoomFromClusterName(cluster.ClusterName); room != "" {
+		resolved.Rooms = []string{room}
+	}
+	return resolved, nil
+}
+
+// LoadClusterLocations 批量获取集群位置映射，只返回已配置映射的集群
+func LoadClusterLocations(db *gorm.DB, clusterIDs []int) (map[int]*ResolvedClusterLocation, error) {
+	resolved := make(map[int]*ResolvedClusterLocation)
+	if len(clusterIDs) == 0 {
+		return resolved, nil
+	}
+	var locations []portal.ClusterLocation
+	if err := db.Where("cluster_id IN ?", clusterIDs).Find(&locations).Error; err != nil {
+		return nil, err
+	}
+	for i := range locations {
+		resolved[locations[i].ClusterID] = resolvedFromMapping(&locations[i])
+	}
+	return resolved, nil
+}
+
+// resolvedFromMapping 将位置映射转换为解析结果
+func resolvedFromMapping(location *portal.ClusterLocation) *ResolvedClusterLocation {
+	return &ResolvedClusterLocation{
+		IDC:      location.IDC,
+		Rooms:    splitLocationList(location.Rooms),
+		NetZones: splitLocationList(location.NetZones),
+		Cabinets: splitLocationList(location.Cabinets),
+		Source:   ClusterLocationSourceMapping,
+	}
+}
+
+// parseRoomFromClusterName 按集群命名规则从集群名称中解析机房，测试集群和不符合规则的名称返回空
+func parseRoomFromClusterName(clusterName string) string {
+	if clusterName == "" || strings.Contains(clusterName, "-test") {
+		return ""
+	}
+	if matches := legacyClusterRoomPattern.FindStringSubmatch(clusterName); len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+// toModel 将请求转换为数据模型
+func (r *ClusterLocationRequest) toModel() portal.ClusterLocation {
+	return portal.ClusterLocation{
+		ClusterID:   r.ClusterID,
+		IDC:         r.IDC,
+		Rooms:       strings.Join(r.Rooms, ","),
+		NetZones:    strings.Join(r.NetZones, ","),
+		Cabinets:    strings.Join(r.Cabinets, ","),
+		Description: r.Description,
+	}
+}
+
+// convertToClusterLocationResponse 将数据模型转换为响应
+func convertToClusterLocationResponse(location *portal.ClusterLocation, clusterName string) *ClusterLocationResponse {
+	return &ClusterLocationResponse{
+		ID:          location.ID,
+		ClusterID:   location.ClusterID,
+		ClusterName: clusterName,
+		IDC:         location.IDC,
+		Rooms:       splitLocationList(location.Rooms),
+		NetZones:    splitLocationList(location.NetZones),
+		Cabinets:    splitLocationList(location.Cabinets),
+		Description: location.Description,
+		CreatedBy:   location.CreatedBy,
+		CreatedAt:   time.Time(location.CreatedAt),
+		UpdatedAt:   time.Time(location.UpdatedAt),
+	}
+}
+
+// normalizeLocationList 去除空白和重复项，保持原有顺序
+func normalizeLocationList(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var result []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		result = append(result, value)
+	}
+	return result
+}
+
+// splitLocationList 拆分逗号分隔的位置列表
+func splitLocationList(value string) []string {
+	return normalizeLocationList(strings.Split(value, ","))
+}
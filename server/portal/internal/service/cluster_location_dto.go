@@ -0,0 +1,43 @@
+package service
+
+import "time"
+
+// ClusterLocationQuery 集群位置映射查询参数
+type ClusterLocationQuery struct {
+	Page      int  `form:"page" json:"page" binding:"omitempty,min=1"`
+	Size      int  `form:"size" json:"size" binding:"omitempty,min=1,max=100"`
+	ClusterID *int `form:"cluster_id" json:"clusterId"`
+}
+
+// ClusterLocationRequest 创建/更新集群位置映射请求
+type ClusterLocationRequest struct {
+	ClusterID   int      `json:"clusterId" binding:"required,min=1"`
+	IDC         string   `json:"idc"`
+	Rooms       []string `json:"rooms"`    // 机房
+	NetZones    []string `json:"netZones"` // 网络区域
+	Cabinets    []string `json:"cabinets"` // 允许使用的机柜，为空时不限制
+	Description string   `json:"description"`
+}
+
+// ClusterLocationResponse 集群位置映射响应
+type ClusterLocationResponse struct {
+	ID          int       `json:"id"`
+	ClusterID   int       `json:"clusterId"`
+	ClusterName string    `json:"clusterName"`
+	IDC         string    `json:"idc"`
+	Rooms       []string  `json:"rooms"`
+	NetZones    []string  `json:"netZones"`
+	Cabinets    []string  `json:"cabinets"`
+	Description string    `json:"description"`
+	CreatedBy   string    `json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ClusterLocationListResponse 集群位置映射列表响应
+type ClusterLocationListResponse struct {
+	List  []*ClusterLocationResponse `json:"list"`
+	Total int64                      `json:"total"`
+	Page  int                        `json:"page"`
+	Size  int                        `json:"size"`
+}
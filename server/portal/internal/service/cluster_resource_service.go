@@ -101,10 +101,29 @@ func (s *ClusterResourceService) calculateClusteredResources(queryDate time.Time
 		return nil, fmt.Errorf("failed to fetch resource snapshots with cluster info: %w", err)
 	}
 
+	// Apply configured cluster location mappings (IDC and network zone) over the cluster fields
+	clusterIDs := make([]int, 0, len(results))
+	for _, result := range results {
+		clusterIDs = append(clusterIDs, int(result.ClusterID))
+	}
+	locations, err := LoadClusterLocations(s.db, clusterIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cluster locations: %w", err)
+	}
+
 	// Calculate remaining resources for each resource pool
 	var calculations []ResourcePoolCalculation
 
 	for _, result := range results {
+		if location, ok := locations[int(result.ClusterID)]; ok {
+			if location.IDC != "" {
+				result.IDC = location.IDC
+			}
+			if len(location.NetZones) > 0 {
+				result.Zone = location.NetZones[0]
+			}
+		}
+
 		// Business logic: Total capacity * 0.75 must be greater than request
 		capacityThreshold := result.MemoryCapacity * 0.75
 
@@ -1178,6 +1178,7 @@ func mapDevicesToResponse(devices []portal.Device) []DeviceResponse {
 			Status:         device.Status,
 			Role:           device.Role,
 			Cluster:        device.Cluster,
+			ClusterID:      device.ClusterID,
 			AcceptanceTime: device.AcceptanceTime,
 			DiskCount:      device.DiskCount,
 			DiskDetail:     device.DiskDetail,
@@ -1246,6 +1247,58 @@ func (s *DeviceQueryService) QueryDevices(ctx context.Context, req *DeviceQueryR
 	return response, nil
 }
 
+// DefaultDeviceStreamBatchSize 流式查询设备时每批读取的设备数
+const DefaultDeviceStreamBatchSize = 500
+
+// StreamDevices 按设备ID顺序分批读取满足筛选条件的全部设备（键集分页），每读取一批调用一次 fn。
+// 与 QueryDevices 不同，结果不受分页大小限制且不使用缓存；fn 返回错误时停止读取。返回已读取的设备总数。
+func (s *DeviceQueryService) StreamDevices(ctx context.Context, groups []FilterGroup, batchSize int, fn func(batch []DeviceResponse) error) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultDeviceStreamBatchSize
+	}
+
+	// 筛选条件作为一个整体嵌套到查询中，避免组间的 OR 与分页条件组合时优先级错误
+	var filters *gorm.DB
+	if hasFilterBlocks(groups) {
+		filters = s.applyFilterGroups(s.db.Session(&gorm.Session{NewDB: true}), groups)
+	}
+
+	total, lastID := 0, 0
+	for {
+		query := s.buildDeviceQuery(ctx)
+		if filters != nil {
+			query = query.Where(filters)
+		}
+
+		var devices []portal.Device
+		if err := query.Where("device.id > ?", lastID).Order("device.id").Limit(batchSize).Find(&devices).Error; err != nil {
+			return total, fmt.Errorf("failed to stream devices after id %d: %w", lastID, err)
+		}
+		if len(devices) == 0 {
+			return total, nil
+		}
+
+		total += len(devices)
+		lastID = devices[len(devices)-1].ID
+		if err := fn(mapDevicesToResponse(devices)); err != nil {
+			return total, err
+		}
+		if len(devices) < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// hasFilterBlocks 判断筛选组中是否包含筛选块
+func hasFilterBlocks(groups []FilterGroup) bool {
+	for _, group := range groups {
+		if len(group.Blocks) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // escapeValue 转义特殊字符
 func escapeValue(value string) string {
 	escaped := strings.ReplaceAll(value, "%", "\\%")
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"navy-ng/models/portal"
+)
+
+// activeReservationOrderIDs 返回预留仍然有效的订单ID子查询
+func activeReservationOrderIDs(db *gorm.DB) *gorm.DB {
+	return db.Model(&portal.Order{}).Select("id").
+		Where("status IN ?", portal.DeviceReservationActiveOrderStatuses)
+}
+
+// ReserveDevices 在订单创建事务中预留设备。
+// 依赖 device_id 唯一约束保证同一设备同时只被一个订单预留：先清理属于已结束订单的旧预留，
+// 再插入预留记录，任一设备已被其他活跃订单预留时返回冲突错误，调用方回滚事务即可释放本次预留。
+func ReserveDevices(tx *gorm.DB, orderID int, deviceIDs []int) error {
+	if len(deviceIDs) == 0 {
+		return nil
+	}
+
+	// 订单已结束但未释放的预留不再占用设备
+	if err := tx.Where("device_id IN ? AND order_id NOT IN (?)", deviceIDs, activeReservationOrderIDs(tx)).
+		Delete(&portal.DeviceReservation{}).Error; err != nil {
+		return NewServerError("清理过期设备预留失败", err)
+	}
+
+	seen := make(map[int]bool, len(deviceIDs))
+	reservations := make([]portal.DeviceReservation, 0, len(deviceIDs))
+	for _, deviceID := range deviceIDs {
+		if seen[deviceID] {
+			continue
+		}
+		seen[deviceID] = true
+		reservations = append(reservations, portal.DeviceReservation{DeviceID: deviceID, OrderID: orderID})
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reservations)
+	if result.Error != nil {
+		return NewServerError("预留设备失败", result.Error)
+	}
+	if int(result.RowsAffected) == len(reservations) {
+		return nil
+	}
+
+	// 存在冲突，找出被其他订单占用的设备
+	var conflicts []portal.DeviceReservation
+	if err := tx.Where("device_id IN ? AND order_id <> ?", deviceIDs, orderID).Find(&conflicts).Error; err != nil {
+		return NewServerError("查询设备预留失败", err)
+	}
+	if len(conflicts) == 0 {
+		return nil // 重复预留同一订单的设备
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].DeviceID < conflicts[j].DeviceID })
+	occupied := make([]string, len(conflicts))
+	for i, conflict := range conflicts {
+		occupied[i] = fmt.Sprintf("%d(订单%d)", conflict.DeviceID, conflict.OrderID)
+	}
+	return NewConflictError(fmt.Sprintf("设备已被其他进行中的订单预留：%v", occupied))
+}
+
+// ReleaseDeviceReservations 释放订单预留的所有设备
+func ReleaseDeviceReservations(tx *gorm.DB, orderID int) error {
+	if err := tx.Where("order_id = ?", orderID).Delete(&portal.DeviceReservation{}).Error; err != nil {
+		return NewServerError("释放设备预留失败", err)
+	}
+	return nil
+}
+
+// ReservedDeviceIDs 返回指定设备中已被活跃订单预留的设备，值为占用设备的订单ID
+func ReservedDeviceIDs(db *gorm.DB, deviceIDs []int) (map[int]int, error) {
+	reserved := make(map[int]int)
+	if len(deviceIDs) == 0 {
+		return reserved, nil
+	}
+	var reservations []portal.DeviceReservation
+	if err := db.Where("device_id IN ? AND order_id IN (?)", deviceIDs, activeReservationOrderIDs(db)).
+		Find(&reservations).Error; err != nil {
+		return nil, err
+	}
+	for _, reservation := range reservations {
+		reserved[reservation.DeviceID] = reservation.OrderID
+	}
+	return reserved, nil
+}
+
+// ExcludeReservedDevices 从候选设备中排除已被活跃订单预留的设备
+func ExcludeReservedDevices(db *gorm.DB, devices []DeviceResponse) ([]DeviceResponse, map[int]int, error) {
+	deviceIDs := make([]int, len(devices))
+	for i, device := range devices {
+		deviceIDs[i] = device.ID
+	}
+	reserved, err := ReservedDeviceIDs(db, deviceIDs)
+	if err != nil || len(reserved) == 0 {
+		return devices, reserved, err
+	}
+	available := make([]DeviceResponse, 0, len(devices)-len(reserved))
+	for _, device := range devices {
+		if _, ok := reserved[device.ID]; !ok {
+			available = append(available, device)
+		}
+	}
+	return available, reserved, nil
+}
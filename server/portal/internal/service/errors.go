@@ -33,6 +33,8 @@ const (
 	ErrCodeBadRequest   = 400
 	ErrCodeServerError  = 500
 	ErrCodeUnauthorized = 401
+	ErrCodeConflict     = 409
+	ErrCodePrecondition = 412
 
 	// 错误消息模板
 	ErrRecordNotFoundMsg = "%s with ID %d not found"
@@ -54,6 +56,22 @@ func NewBadRequestError(message string) error {
 	}
 }
 
+// NewConflictError 创建并发冲突错误，记录已被其他请求修改
+func NewConflictError(message string) error {
+	return &ServiceError{
+		Code:    ErrCodeConflict,
+		Message: message,
+	}
+}
+
+// NewPreconditionFailedError 创建前置条件失败错误，客户端持有的版本已过期
+func NewPreconditionFailedError(message string) error {
+	return &ServiceError{
+		Code:    ErrCodePrecondition,
+		Message: message,
+	}
+}
+
 // NewServerError 创建服务器错误
 func NewServerError(message string, err error) error {
 	return &ServiceError{
@@ -72,6 +90,24 @@ func IsNotFound(err error) bool {
 	return errors.Is(err, gorm.ErrRecordNotFound)
 }
 
+// IsBadRequest 判断是否是请求错误
+func IsBadRequest(err error) bool {
+	var serviceErr *ServiceError
+	return errors.As(err, &serviceErr) && serviceErr.Code == ErrCodeBadRequest
+}
+
+// IsConflict 判断是否是并发冲突错误
+func IsConflict(err error) bool {
+	var serviceErr *ServiceError
+	return errors.As(err, &serviceErr) && serviceErr.Code == ErrCodeConflict
+}
+
+// IsPreconditionFailed 判断是否是前置条件失败错误
+func IsPreconditionFailed(err error) bool {
+	var serviceErr *ServiceError
+	return errors.As(err, &serviceErr) && serviceErr.Code == ErrCodePrecondition
+}
+
 // HandleDBError 处理数据库错误
 func HandleDBError(err error, resource string, id int) error {
 	if errors.Is(err, gorm.ErrRecordNotFound) {
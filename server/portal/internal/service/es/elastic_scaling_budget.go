@@ -0,0 +1,350 @@
+package es
+
+import (
+	"errors"
+	"fmt"
+	"navy-ng/models/portal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/now"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 预算告警通知
+const (
+	NotificationTypeBudgetExceeded = "budget_exceeded"
+	NotificationStatusPending      = "pending"
+)
+
+// 预算锁：同一动作的预算检查和订单创建串行执行，避免并发评估的策略同时占用同一预算
+const (
+	budgetLockKeyFormat     = "elastic_scaling:budget:%s:lock"
+	budgetLockExpiry        = 30 * time.Second
+	budgetLockWait          = 10 * time.Second
+	budgetLockRetryInterval = 100 * time.Millisecond
+)
+
+// budgetMutex 本实例内串行化预算检查和订单创建，定时评估和手动评估共用
+var budgetMutex sync.Mutex
+
+// scalingBudgetUsage 预算在当前周期内的使用情况
+type scalingBudgetUsage struct {
+	budget      portal.ElasticScalingBudget
+	usedDevices int
+	usedOrders  int
+}
+
+// remainingDevices 返回剩余可用设备数，-1 表示不限制
+func (u *scalingBudgetUsage) remainingDevices() int {
+	if u.budget.MaxDevices <= 0 {
+		return -1
+	}
+	return max(u.budget.MaxDevices-u.usedDevices, 0)
+}
+
+// ordersExhausted 判断订单数预算是否已用尽
+func (u *scalingBudgetUsage) ordersExhausted() bool {
+	return u.budget.MaxOrders > 0 && u.usedOrders >= u.budget.MaxOrders
+}
+
+// describe 生成预算使用情况的中文描述
+func (u *scalingBudgetUsage) describe() string {
+	limit := func(v int) string {
+		if v <= 0 {
+			return "不限"
+		}
+		return fmt.Sprintf("%d", v)
+	}
+	return fmt.Sprintf("预算「%s」（%s，%s）：设备 %d/%s 台，订单 %d/%s 个",
+		u.budget.Name, budgetPeriodName(u.budget.Period), budgetScopeName(&u.budget),
+		u.usedDevices, limit(u.budget.MaxDevices), u.usedOrders, limit(u.budget.MaxOrders))
+}
+
+// budgetPeriodStart 返回预算周期的起始时间，按周预算从周一开始计算
+func budgetPeriodStart(period string, t time.Time) time.Time {
+	if period == portal.ScalingBudgetPeriodWeekly {
+		return now.With(t).Monday()
+	}
+	return now.With(t).BeginningOfDay()
+}
+
+// budgetPeriodName 返回预算周期的中文名称
+func budgetPeriodName(period string) string {
+	if period == portal.ScalingBudgetPeriodWeekly {
+		return "每周"
+	}
+	return "每日"
+}
+
+// budgetScopeName 返回预算作用范围的中文描述
+func budgetScopeName(budget *portal.ElasticScalingBudget) string {
+	var parts []string
+	if budget.ClusterID != nil {
+		parts = append(parts, fmt.Sprintf("集群 %d", *budget.ClusterID))
+	}
+	if budget.ResourcePoolType != "" {
+		parts = append(parts, fmt.Sprintf("资源池 %s", budget.ResourcePoolType))
+	}
+	if len(parts) == 0 {
+		return "全局"
+	}
+	return strings.Join(parts, "，")
+}
+
+// getScalingBudgetUsage 统计预算在 evalTime 所在周期内、evalTime 之前已被自动创建订单占用的设备数和订单数（已取消的订单不计入）。
+// 只统计 evalTime 之前的订单，使模拟回放历史时间点时不计入之后创建的订单
+func (s *ElasticScalingService) getScalingBudgetUsage(budget portal.ElasticScalingBudget, evalTime time.Time) (*scalingBudgetUsage, error) {
+	var usage struct {
+		Orders  int
+		Devices int
+	}
+	query := s.db.Table("ng_orders o").
+		Select("COUNT(*) AS orders, COALESCE(SUM(esd.device_count), 0) AS devices").
+		Joins("JOIN ng_elastic_scaling_order_details esd ON o.id = esd.order_id").
+		Where("esd.action_type = ? AND o.created_by = ? AND o.status != ? AND o.created_at >= ? AND o.created_at <= ?",
+			budget.ActionType, SystemAutoCreator, portal.OrderStatusCancelled, budgetPeriodStart(budget.Period, evalTime), evalTime)
+	if budget.ClusterID != nil {
+		query = query.Where("esd.cluster_id = ?", *budget.ClusterID)
+	}
+	if budget.ResourcePoolType != "" {
+		query = query.Where("esd.resource_pool_type = ?", budget.ResourcePoolType)
+	}
+	if err := query.Scan(&usage).Error; err != nil {
+		return nil, fmt.Errorf("failed to query usage of budget %d: %w", budget.ID, err)
+	}
+	return &scalingBudgetUsage{budget: budget, usedDevices: usage.Devices, usedOrders: usage.Orders}, nil
+}
+
+// applyScalingBudgets 按适用于该集群+资源池+动作的所有预算裁剪设备选择。
+// 返回裁剪后的设备列表和裁剪说明；预算已用尽时 exceeded 为预算使用详情，调用方不应创建订单。
+func (s *ElasticScalingService) applyScalingBudgets(
+	actionType string,
+	clusterID int,
+	resourceType string,
+	selectedDeviceIDs []int,
+	evalTime time.Time,
+) (capped []int, note string, exceeded string, err error) {
+	var budgets []portal.ElasticScalingBudget
+	if err := s.db.Where("status = ? AND action_type = ?", StrategyStatusEnabled, actionType).
+		Where("cluster_id IS NULL OR cluster_id = ?", clusterID).
+		Where("resource_pool_type = '' OR resource_pool_type IS NULL OR resource_pool_type = ?", resourceType).
+		Order("id").Find(&budgets).Error; err != nil {
+		return nil, "", "", fmt.Errorf("failed to get scaling budgets: %w", err)
+	}
+
+	capped = selectedDeviceIDs
+	var exhausted, limiting []string
+	for _, budget := range budgets {
+		usage, err := s.getScalingBudgetUsage(budget, evalTime)
+		if err != nil {
+			return nil, "", "", err
+		}
+
+		remaining := usage.remainingDevices()
+		if usage.ordersExhausted() || (remaining == 0 && len(selectedDeviceIDs) > 0) {
+			exhausted = append(exhausted, usage.describe())
+			continue
+		}
+		if remaining >= 0 && remaining < len(capped) {
+			capped = capped[:remaining]
+			limiting = append(limiting, usage.describe())
+		}
+	}
+
+	if len(exhausted) > 0 {
+		return nil, "", strings.Join(exhausted, "；"), nil
+	}
+	if len(capped) < len(selectedDeviceIDs) {
+		note = fmt.Sprintf("受预算限制，设备数由 %d 台调整为 %d 台（%s）", len(selectedDeviceIDs), len(capped), strings.Join(limiting, "；"))
+	}
+	return capped, note, "", nil
+}
+
+// lockScalingBudgets 获取动作的预算锁，返回释放函数。本实例内使用互斥锁，
+// 配置了 Redis 时再获取分布式锁，覆盖多实例部署；分布式锁被占用时等待，超时返回错误
+func (s *ElasticScalingService) lockScalingBudgets(actionType string) (func(), error) {
+	budgetMutex.Lock()
+	if s.redisHandler == nil {
+		return budgetMutex.Unlock, nil
+	}
+
+	lockKey := fmt.Sprintf(budgetLockKeyFormat, actionType)
+	lockValue := fmt.Sprintf("budget:%s:%d", actionType, time.Now().UnixNano())
+	deadline := time.Now().Add(budgetLockWait)
+	for {
+		locked, err := s.redisHandler.AcquireLock(lockKey, lockValue, budgetLockExpiry)
+		if err != nil {
+			budgetMutex.Unlock()
+			return nil, fmt.Errorf("failed to acquire budget lock %s: %w", lockKey, err)
+		}
+		if locked {
+			return func() {
+				s.redisHandler.Delete(lockKey)
+				budgetMutex.Unlock()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			budgetMutex.Unlock()
+			return nil, fmt.Errorf("timed out waiting for budget lock %s", lockKey)
+		}
+		time.Sleep(budgetLockRetryInterval)
+	}
+}
+
+// sendBudgetExceededAlert 预算用尽时通知值班人员，写入通知日志等待发送
+func (s *ElasticScalingService) sendBudgetExceededAlert(evalCtx *evaluationContext, strategy *portal.ElasticScalingStrategy, clusterID int, resourceType string, requested int, details string) {
+	recipients, ccRecipients := s.getOnDutyPersons()
+	content := fmt.Sprintf("策略「%s」在集群 %d（%s类型）需要%s %d 台设备，但自动伸缩预算已用尽，未创建订单，请人工评估。%s",
+		strategy.Name, clusterID, resourceType, s.getActionName(strategy.ThresholdTriggerAction), requested, details)
+
+	s.logger.Warn("Elastic scaling budget exceeded",
+		zap.Int("strategyID", strategy.ID),
+		zap.Int("clusterID", clusterID),
+		zap.String("resourceType", resourceType),
+		zap.Strings("recipients", recipients),
+		zap.String("content", content))
+
+	if evalCtx.DryRun {
+		return
+	}
+	strategyID := strategy.ID
+	notification := portal.NotificationLog{
+		StrategyID:       &strategyID,
+		NotificationType: NotificationTypeBudgetExceeded,
+		Recipient:        strings.Join(append(recipients, ccRecipients...), ","),
+		Content:          content,
+		Status:           NotificationStatusPending,
+		SendTime:         portal.NavyTime(time.Now()),
+	}
+	if err := s.db.Create(&notification).Error; err != nil {
+		s.logger.Error("Failed to record budget exceeded notification", zap.Error(err), zap.Int("strategyID", strategy.ID))
+	}
+}
+
+// ListScalingBudgets 获取所有自动伸缩预算及其当前周期用量
+func (s *ElasticScalingService) ListScalingBudgets() ([]ScalingBudgetDTO, error) {
+	var budgets []portal.ElasticScalingBudget
+	if err := s.db.Order("id").Find(&budgets).Error; err != nil {
+		return nil, err
+	}
+
+	evalTime := time.Now()
+	result := make([]ScalingBudgetDTO, 0, len(budgets))
+	for _, budget := range budgets {
+		usage, err := s.getScalingBudgetUsage(budget, evalTime)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, toScalingBudgetDTO(usage))
+	}
+	return result, nil
+}
+
+// CreateScalingBudget 创建自动伸缩预算
+func (s *ElasticScalingService) CreateScalingBudget(dto ScalingBudgetDTO) (int, error) {
+	if err := validateScalingBudgetDTO(&dto); err != nil {
+		return 0, err
+	}
+	budget := newScalingBudgetModel(&dto)
+	budget.CreatedBy = dto.CreatedBy
+	if err := s.db.Create(&budget).Error; err != nil {
+		return 0, err
+	}
+	return budget.ID, nil
+}
+
+// UpdateScalingBudget 更新自动伸缩预算
+func (s *ElasticScalingService) UpdateScalingBudget(id int, dto ScalingBudgetDTO) error {
+	if err := validateScalingBudgetDTO(&dto); err != nil {
+		return err
+	}
+
+	var existing portal.ElasticScalingBudget
+	if err := s.db.First(&existing, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("预算不存在: %d", id)
+		}
+		return err
+	}
+
+	budget := newScalingBudgetModel(&dto)
+	budget.BaseModel = existing.BaseModel
+	budget.CreatedBy = existing.CreatedBy
+	return s.db.Save(&budget).Error
+}
+
+// DeleteScalingBudget 删除自动伸缩预算
+func (s *ElasticScalingService) DeleteScalingBudget(id int) error {
+	result := s.db.Delete(&portal.ElasticScalingBudget{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("预算不存在: %d", id)
+	}
+	return nil
+}
+
+// validateScalingBudgetDTO 校验预算配置
+func validateScalingBudgetDTO(dto *ScalingBudgetDTO) error {
+	if dto.Name == "" {
+		return errors.New("预算名称不能为空")
+	}
+	if dto.ActionType != TriggerActionPoolEntry && dto.ActionType != TriggerActionPoolExit {
+		return errors.New("无效的动作类型")
+	}
+	if dto.Period != portal.ScalingBudgetPeriodDaily && dto.Period != portal.ScalingBudgetPeriodWeekly {
+		return errors.New("预算周期必须为 daily 或 weekly")
+	}
+	if dto.MaxDevices < 0 || dto.MaxOrders < 0 {
+		return errors.New("预算上限不能为负数")
+	}
+	if dto.MaxDevices == 0 && dto.MaxOrders == 0 {
+		return errors.New("至少需要设置设备数或订单数上限")
+	}
+	if dto.Status == "" {
+		dto.Status = StrategyStatusEnabled
+	}
+	if dto.Status != StrategyStatusEnabled && dto.Status != StrategyStatusDisabled {
+		return errors.New("状态必须为 enabled 或 disabled")
+	}
+	return nil
+}
+
+// newScalingBudgetModel 根据DTO构建预算模型
+func newScalingBudgetModel(dto *ScalingBudgetDTO) portal.ElasticScalingBudget {
+	return portal.ElasticScalingBudget{
+		Name:             dto.Name,
+		ClusterID:        dto.ClusterID,
+		ResourcePoolType: dto.ResourcePoolType,
+		ActionType:       dto.ActionType,
+		Period:           dto.Period,
+		MaxDevices:       dto.MaxDevices,
+		MaxOrders:        dto.MaxOrders,
+		Status:           dto.Status,
+	}
+}
+
+// toScalingBudgetDTO 将预算及其用量转换为DTO
+func toScalingBudgetDTO(usage *scalingBudgetUsage) ScalingBudgetDTO {
+	budget := usage.budget
+	return ScalingBudgetDTO{
+		ID:               budget.ID,
+		Name:             budget.Name,
+		ClusterID:        budget.ClusterID,
+		ResourcePoolType: budget.ResourcePoolType,
+		ActionType:       budget.ActionType,
+		Period:           budget.Period,
+		MaxDevices:       budget.MaxDevices,
+		MaxOrders:        budget.MaxOrders,
+		Status:           budget.Status,
+		CreatedBy:        budget.CreatedBy,
+		UsedDevices:      usage.usedDevices,
+		UsedOrders:       usage.usedOrders,
+		CreatedAt:        time.Time(budget.CreatedAt),
+		UpdatedAt:        time.Time(budget.UpdatedAt),
+	}
+}
@@ -0,0 +1,167 @@
+package es
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// createAutoScalingOrder 创建一个系统自动创建的弹性伸缩订单（集群1，total 资源池）
+func createAutoScalingOrder(t *testing.T, db *gorm.DB, number, action string, status portal.OrderStatus, createdAt time.Time, deviceCount int) {
+	t.Helper()
+	createScalingOrder(t, db, number, action, status, createdAt)
+	var order portal.Order
+	require.NoError(t, db.Where("order_number = ?", number).First(&order).Error)
+	require.NoError(t, db.Model(&order).Update("created_by", SystemAutoCreator).Error)
+	require.NoError(t, db.Model(&portal.ElasticScalingOrderDetail{}).Where("order_id = ?", order.ID).
+		Update("device_count", deviceCount).Error)
+}
+
+func TestBudgetPeriodStart(t *testing.T) {
+	wednesday := time.Date(2024, 11, 13, 15, 30, 0, 0, time.Local)
+	sunday := time.Date(2024, 11, 17, 8, 0, 0, 0, time.Local)
+
+	assert.Equal(t, time.Date(2024, 11, 13, 0, 0, 0, 0, time.Local), budgetPeriodStart(portal.ScalingBudgetPeriodDaily, wednesday))
+	assert.Equal(t, time.Date(2024, 11, 11, 0, 0, 0, 0, time.Local), budgetPeriodStart(portal.ScalingBudgetPeriodWeekly, wednesday))
+	assert.Equal(t, time.Date(2024, 11, 11, 0, 0, 0, 0, time.Local), budgetPeriodStart(portal.ScalingBudgetPeriodWeekly, sunday))
+}
+
+func TestApplyScalingBudgets(t *testing.T) {
+	s, db := newTestService(t)
+	evalTime := time.Now()
+
+	// 今天已自动入池 6 台；昨天的订单、已取消的订单和人工订单不计入每日预算
+	createAutoScalingOrder(t, db, "ES-1", TriggerActionPoolEntry, portal.OrderStatusCompleted, evalTime.Add(-time.Minute), 6)
+	createAutoScalingOrder(t, db, "ES-2", TriggerActionPoolEntry, portal.OrderStatusCancelled, evalTime.Add(-time.Minute), 50)
+	createAutoScalingOrder(t, db, "ES-3", TriggerActionPoolEntry, portal.OrderStatusCompleted, evalTime.AddDate(0, 0, -1).Add(-time.Hour), 50)
+	createScalingOrder(t, db, "ES-4", TriggerActionPoolEntry, portal.OrderStatusCompleted, evalTime.Add(-time.Minute))
+
+	clusterID := 1
+	require.NoError(t, db.Create(&portal.ElasticScalingBudget{
+		Name: "集群每日", ClusterID: &clusterID, ActionType: TriggerActionPoolEntry,
+		Period: portal.ScalingBudgetPeriodDaily, MaxDevices: 10, Status: StrategyStatusEnabled,
+	}).Error)
+	// 其他集群、其他动作、停用的预算不生效
+	otherCluster := 2
+	require.NoError(t, db.Create(&portal.ElasticScalingBudget{
+		Name: "其他集群", ClusterID: &otherCluster, ActionType: TriggerActionPoolEntry,
+		Period: portal.ScalingBudgetPeriodDaily, MaxDevices: 1, Status: StrategyStatusEnabled,
+	}).Error)
+	require.NoError(t, db.Create(&portal.ElasticScalingBudget{
+		Name: "退池", ActionType: TriggerActionPoolExit,
+		Period: portal.ScalingBudgetPeriodDaily, MaxDevices: 1, Status: StrategyStatusEnabled,
+	}).Error)
+	require.NoError(t, db.Create(&portal.ElasticScalingBudget{
+		Name: "停用", ActionType: TriggerActionPoolEntry,
+		Period: portal.ScalingBudgetPeriodDaily, MaxDevices: 1, Status: StrategyStatusDisabled,
+	}).Error)
+
+	t.Run("caps selection to the remaining devices", func(t *testing.T) {
+		capped, note, exceeded, err := s.applyScalingBudgets(TriggerActionPoolEntry, 1, "total", []int{11, 12, 13, 14, 15, 16}, evalTime)
+		require.NoError(t, err)
+		assert.Empty(t, exceeded)
+		assert.Equal(t, []int{11, 12, 13, 14}, capped)
+		assert.Contains(t, note, "集群每日")
+	})
+
+	t.Run("within budget is untouched", func(t *testing.T) {
+		capped, note, exceeded, err := s.applyScalingBudgets(TriggerActionPoolEntry, 1, "total", []int{11, 12}, evalTime)
+		require.NoError(t, err)
+		assert.Empty(t, exceeded)
+		assert.Empty(t, note)
+		assert.Equal(t, []int{11, 12}, capped)
+	})
+
+	t.Run("global order budget exhausted", func(t *testing.T) {
+		require.NoError(t, db.Create(&portal.ElasticScalingBudget{
+			Name: "全局每周", ActionType: TriggerActionPoolEntry,
+			Period: portal.ScalingBudgetPeriodWeekly, MaxOrders: 1, Status: StrategyStatusEnabled,
+		}).Error)
+
+		_, _, exceeded, err := s.applyScalingBudgets(TriggerActionPoolEntry, 1, "total", []int{11}, evalTime)
+		require.NoError(t, err)
+		assert.Contains(t, exceeded, "全局每周")
+	})
+}
+
+func TestGenerateOrderRecordsBudgetExceeded(t *testing.T) {
+	s, db := newTestService(t)
+	var records []portal.StrategyExecutionHistory
+
+	strategy := &portal.ElasticScalingStrategy{Name: "entry", ThresholdTriggerAction: TriggerActionPoolEntry, Status: StrategyStatusEnabled}
+	require.NoError(t, db.Create(strategy).Error)
+	require.NoError(t, db.Create(&portal.ElasticScalingBudget{
+		Name: "全局每日", ActionType: TriggerActionPoolEntry,
+		Period: portal.ScalingBudgetPeriodDaily, MaxDevices: 5, Status: StrategyStatusEnabled,
+	}).Error)
+	createAutoScalingOrder(t, db, "ES-1", TriggerActionPoolEntry, portal.OrderStatusProcessing, time.Now().Add(-time.Minute), 5)
+
+	evalCtx := &evaluationContext{ExecutionRecords: &records, Trace: newDeviceMatchingTrace(strategy, 1, "total", 0, 0)}
+	evalCtx.Trace.CandidateCount = 3
+	err := s.generateElasticScalingOrder(evalCtx, strategy, 1, "total", []int{1, 2, 3}, "90%", "80%", 0, 0, nil)
+	require.NoError(t, err)
+
+	require.Len(t, records, 1)
+	assert.Equal(t, StrategyExecutionResultBudgetExceeded, records[0].Result)
+	assert.Contains(t, records[0].Reason, "设备 5/5 台")
+
+	// 被预算拦截时，执行历史保存本次设备匹配过程
+	var history portal.StrategyExecutionHistory
+	require.NoError(t, db.First(&history, records[0].ID).Error)
+	trace := s.historyMatchingTrace(history)
+	require.NotNil(t, trace)
+	assert.Equal(t, 3, trace.CandidateCount)
+	assert.Contains(t, trace.Notes, records[0].Reason)
+
+	var orders int64
+	require.NoError(t, db.Model(&portal.Order{}).Count(&orders).Error)
+	assert.Equal(t, int64(1), orders)
+
+	var alert portal.NotificationLog
+	require.NoError(t, db.Where("notification_type = ?", NotificationTypeBudgetExceeded).First(&alert).Error)
+	assert.Equal(t, strategy.ID, *alert.StrategyID)
+	assert.Contains(t, alert.Content, "全局每日")
+}
+
+func TestGenerateOrderSerializesBudgetUsage(t *testing.T) {
+	s, db := newTestService(t)
+	redis := &fakeLockRedis{held: map[string]bool{}}
+	s.redisHandler = redis
+	require.NoError(t, db.Create(&portal.ElasticScalingBudget{
+		Name: "全局每日", ActionType: TriggerActionPoolEntry,
+		Period: portal.ScalingBudgetPeriodDaily, MaxOrders: 1, Status: StrategyStatusEnabled,
+	}).Error)
+
+	// 两个策略同时评估，只有一个能占用唯一的订单预算
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		strategy := &portal.ElasticScalingStrategy{Name: fmt.Sprintf("entry-%d", i), ThresholdTriggerAction: TriggerActionPoolEntry, Status: StrategyStatusEnabled}
+		require.NoError(t, db.Create(strategy).Error)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.generateElasticScalingOrder(&evaluationContext{}, strategy, 1, "total", []int{}, "90%", "80%", 0, 0, nil)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	var orders int64
+	require.NoError(t, db.Model(&portal.Order{}).Count(&orders).Error)
+	assert.Equal(t, int64(1), orders)
+
+	var results []string
+	require.NoError(t, db.Model(&portal.StrategyExecutionHistory{}).Order("result").Pluck("result", &results).Error)
+	assert.ElementsMatch(t, []string{StrategyExecutionResultBudgetExceeded, StrategyExecutionResultOrderCreatedNoDevices}, results)
+	assert.Empty(t, redis.held, "预算锁应在订单创建后释放")
+}
@@ -0,0 +1,159 @@
+package es
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	. "navy-ng/server/portal/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// maxRetainedCandidates 每个匹配策略最多保留进入选择算法的候选设备数
+const maxRetainedCandidates = 2000
+
+// errCandidateFilter 流式读取候选设备时排除规则查询失败
+var errCandidateFilter = errors.New("筛选候选设备失败")
+
+// candidatePool 流式读取候选设备时的有限候选集：每批设备先排除已预留、不兼容、禁止驱逐和
+// 已被前序策略选中的设备，再按选择偏好只保留最优的 limit 台，其余设备记为未选中。
+type candidatePool struct {
+	db              *gorm.DB
+	trace           *PolicyMatchingTrace
+	action          string
+	clusterID       int
+	resourceType    string
+	limit           int
+	checker         *compatibilityChecker // 入池时的兼容性规则，为空时不检查
+	protectionRules drainProtectionRules  // 出池时的排空保护规则
+	claimed         map[int]int           // fill_remaining 模式下前序策略已选中的设备，为空时不排除
+	seen            map[int]bool          // 各策略查询到的设备，用于统计候选设备总数
+
+	devices    []DeviceResponse
+	costs      drainCosts // 保留设备的排空代价
+	candidates int        // 通过排除规则的候选设备数（含超出上限未保留的设备）
+}
+
+// newCandidatePool 创建匹配策略的候选集，limit 不大于0时使用 maxRetainedCandidates
+func newCandidatePool(db *gorm.DB, trace *PolicyMatchingTrace, action string, clusterID int, resourceType string, limit int) *candidatePool {
+	if limit <= 0 {
+		limit = maxRetainedCandidates
+	}
+	return &candidatePool{
+		db:           db,
+		trace:        trace,
+		action:       action,
+		clusterID:    clusterID,
+		resourceType: resourceType,
+		limit:        limit,
+		costs:        make(drainCosts),
+	}
+}
+
+// add 处理一批查询到的设备，作为 StreamDevices 的回调使用
+func (p *candidatePool) add(batch []DeviceResponse) error {
+	for _, device := range batch {
+		if p.seen != nil {
+			p.seen[device.ID] = true
+		}
+	}
+
+	excluded := make(excludedDevices)
+	devices, reserved, err := ExcludeReservedDevices(p.db, batch)
+	if err != nil {
+		return fmt.Errorf("%w：查询设备预留失败：%v", errCandidateFilter, err)
+	}
+	excluded.addReserved(reserved)
+
+	devices, incompatible, err := excludeIncompatibleDevices(p.db, p.checker, devices)
+	if err != nil {
+		return fmt.Errorf("%w：检查硬件兼容性失败：%v", errCandidateFilter, err)
+	}
+	excluded.addIncompatible(incompatible)
+
+	if p.action == TriggerActionPoolExit {
+		var (
+			costs     drainCosts
+			forbidden map[int]string
+		)
+		devices, costs, forbidden, err = evaluateDrainCosts(p.db, p.protectionRules, devices, p.clusterID, p.resourceType)
+		if err != nil {
+			return fmt.Errorf("%w：计算排空代价失败：%v", errCandidateFilter, err)
+		}
+		excluded.addDoNotEvict(forbidden)
+		for id, cost := range costs {
+			p.costs[id] = cost
+		}
+	}
+
+	if p.claimed != nil {
+		var claimed map[int]int
+		devices, claimed = excludeClaimedDevices(devices, p.claimed)
+		excluded.addClaimed(claimed)
+	}
+
+	p.trace.recordQueried(batch, excluded)
+	p.candidates += len(devices)
+	for _, device := range devices {
+		// 出池时不属于目标集群的设备不会被选择，不占用候选集
+		if p.action == TriggerActionPoolExit && device.ClusterID != p.clusterID {
+			p.trace.recordRejected(device, MatchingRejectClusterMismatch, fmt.Sprintf("设备属于集群 %d", device.ClusterID))
+			continue
+		}
+		p.devices = append(p.devices, device)
+	}
+	p.trim()
+	return nil
+}
+
+// trim 候选设备超过上限时按选择偏好保留最优的 limit 台
+func (p *candidatePool) trim() {
+	if len(p.devices) <= p.limit {
+		return
+	}
+	sort.SliceStable(p.devices, func(i, j int) bool {
+		return p.prefer(p.devices[i], p.devices[j])
+	})
+	for _, device := range p.devices[p.limit:] {
+		delete(p.costs, device.ID)
+		p.trace.recordRejected(device, MatchingRejectNotSelected, fmt.Sprintf("候选设备超过 %d 台，按选择偏好未保留", p.limit))
+	}
+	p.devices = p.devices[:p.limit]
+}
+
+// prefer 判断设备 a 是否比设备 b 更应保留：入池时优先未分配、CPU和内存大的设备，
+// 出池时优先排空代价低、CPU和内存小的设备，均相同时按设备ID
+func (p *candidatePool) prefer(a, b DeviceResponse) bool {
+	if p.action == TriggerActionPoolEntry {
+		unassignedA, unassignedB := a.ClusterID == 0 || a.Cluster == "", b.ClusterID == 0 || b.Cluster == ""
+		switch {
+		case unassignedA != unassignedB:
+			return unassignedA
+		case a.CPU != b.CPU:
+			return a.CPU > b.CPU
+		case a.Memory != b.Memory:
+			return a.Memory > b.Memory
+		}
+		return a.ID < b.ID
+	}
+
+	if less, decided := p.costs.less(a.ID, b.ID); decided {
+		return less
+	}
+	switch {
+	case a.CPU != b.CPU:
+		return a.CPU < b.CPU
+	case a.Memory != b.Memory:
+		return a.Memory < b.Memory
+	}
+	return a.ID < b.ID
+}
+
+// result 返回保留的候选设备（按设备ID排序，与查询顺序一致）
+func (p *candidatePool) result() []DeviceResponse {
+	sort.Slice(p.devices, func(i, j int) bool {
+		return p.devices[i].ID < p.devices[j].ID
+	})
+	return p.devices
+}
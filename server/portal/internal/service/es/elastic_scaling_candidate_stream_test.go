@@ -0,0 +1,146 @@
+package es
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// createStreamTestDevices 创建 count 台设备，奇数ID位于 idc-a，偶数ID位于 idc-b
+func createStreamTestDevices(t *testing.T, db *gorm.DB, count int) {
+	t.Helper()
+	devices := make([]portal.Device, 0, count)
+	for i := 1; i <= count; i++ {
+		idc := "idc-a"
+		if i%2 == 0 {
+			idc = "idc-b"
+		}
+		devices = append(devices, portal.Device{BaseModel: portal.BaseModel{ID: i}, CICode: fmt.Sprintf("device-%d", i), IDC: idc, CPU: 10, Memory: 10})
+	}
+	require.NoError(t, db.CreateInBatches(devices, 200).Error)
+}
+
+func TestStreamDevicesReadsAllBatches(t *testing.T) {
+	_, db := newMatchingTestService(t)
+	createStreamTestDevices(t, db, 25)
+	querySvc := service.NewDeviceQueryService(db, nil)
+
+	var batches []int
+	var ids []int
+	total, err := querySvc.StreamDevices(context.Background(), nil, 10, func(batch []service.DeviceResponse) error {
+		batches = append(batches, len(batch))
+		for _, device := range batch {
+			ids = append(ids, device.ID)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 25, total)
+	assert.Equal(t, []int{10, 10, 5}, batches)
+	assert.Len(t, ids, 25)
+	assert.IsIncreasing(t, ids)
+}
+
+func TestStreamDevicesKeepsOrGroupsTogether(t *testing.T) {
+	_, db := newMatchingTestService(t)
+	createStreamTestDevices(t, db, 12)
+	querySvc := service.NewDeviceQueryService(db, nil)
+
+	// 组间为 OR 关系，分页条件必须作用于整个筛选条件
+	groups := []service.FilterGroup{
+		{ID: "g1", Operator: service.LogicalOperatorOr, Blocks: []service.FilterBlock{{ID: "b1", Type: service.FilterTypeDevice, Key: "ciCode", ConditionType: service.ConditionTypeEqual, Value: "device-1"}}},
+		{ID: "g2", Operator: service.LogicalOperatorAnd, Blocks: []service.FilterBlock{{ID: "b2", Type: service.FilterTypeDevice, Key: "idc", ConditionType: service.ConditionTypeEqual, Value: "idc-b"}}},
+	}
+	var ids []int
+	total, err := querySvc.StreamDevices(context.Background(), groups, 2, func(batch []service.DeviceResponse) error {
+		for _, device := range batch {
+			ids = append(ids, device.ID)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 7, total)
+	assert.Equal(t, []int{1, 2, 4, 6, 8, 10, 12}, ids)
+}
+
+func TestStreamDevicesStopsOnCallbackError(t *testing.T) {
+	_, db := newMatchingTestService(t)
+	createStreamTestDevices(t, db, 5)
+	querySvc := service.NewDeviceQueryService(db, nil)
+
+	stop := errors.New("stop")
+	calls := 0
+	total, err := querySvc.StreamDevices(context.Background(), nil, 2, func([]service.DeviceResponse) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 2, total)
+}
+
+func TestMatchingConsidersEveryCandidateAndRecordsCount(t *testing.T) {
+	s, db := newMatchingTestService(t)
+	createStreamTestDevices(t, db, 1200)
+	createIDCTemplate(t, db, 1, "idc-a")
+	createIDCTemplate(t, db, 2, "idc-b")
+	require.NoError(t, db.Create(&portal.ResourcePoolDeviceMatchingPolicy{Name: "idc-a", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 1, Status: "enabled"}).Error)
+	require.NoError(t, db.Create(&portal.ResourcePoolDeviceMatchingPolicy{Name: "idc-b", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 2, Status: "enabled", Priority: 1}).Error)
+
+	strategy := &portal.ElasticScalingStrategy{BaseModel: portal.BaseModel{ID: 1}, ThresholdTriggerAction: TriggerActionPoolEntry}
+	result, err := s.collectMatchedDevices(&evaluationContext{}, strategy, 1, "total", "", "", 10, 10)
+	require.NoError(t, err)
+
+	assert.Equal(t, 600, result.Trace.Policies[0].QueriedCount)
+	assert.Equal(t, 600, result.Trace.Policies[1].QueriedCount)
+	assert.Equal(t, 1200, result.Trace.CandidateCount)
+
+	orderID, err := s.CreateOrder(OrderDTO{ClusterID: 1, ActionType: TriggerActionPoolEntry, Devices: result.SelectedDeviceIDs[:1], MatchingTrace: result.Trace})
+	require.NoError(t, err)
+	order, err := s.GetOrder(orderID)
+	require.NoError(t, err)
+	assert.Equal(t, 1200, order.CandidateCount)
+}
+
+func TestCandidatePoolRetainsPreferredCandidates(t *testing.T) {
+	_, db := newMatchingTestService(t)
+	devices := make([]portal.Device, 0, 30)
+	for i := 1; i <= 30; i++ {
+		devices = append(devices, portal.Device{BaseModel: portal.BaseModel{ID: i}, CICode: fmt.Sprintf("device-%d", i), CPU: float64(i), Memory: 10})
+	}
+	require.NoError(t, db.Create(&devices).Error)
+
+	trace := &PolicyMatchingTrace{}
+	pool := newCandidatePool(db, trace, TriggerActionPoolEntry, 1, "total", 5)
+	pool.seen = make(map[int]bool)
+	pool.claimed = map[int]int{29: 7}
+	total, err := service.NewDeviceQueryService(db, nil).StreamDevices(context.Background(), nil, 4, pool.add)
+	require.NoError(t, err)
+	assert.Equal(t, 30, total)
+
+	// 逐批筛选时只保留CPU最大的5台设备，已被前序策略选中的设备不进入候选集
+	var retained []int
+	for _, device := range pool.result() {
+		retained = append(retained, device.ID)
+	}
+	assert.Equal(t, []int{25, 26, 27, 28, 30}, retained)
+	assert.Equal(t, 29, pool.candidates)
+	assert.Len(t, pool.seen, 30)
+
+	assert.Equal(t, 30, trace.QueriedCount)
+	assert.Equal(t, 29, trace.CandidateCount)
+	assert.Equal(t, 25, trace.RejectedCount)
+	reasons := make(map[string]int)
+	for _, rejected := range trace.RejectedDevices {
+		reasons[rejected.Reason]++
+	}
+	assert.Equal(t, map[string]int{MatchingRejectClaimed: 1, MatchingRejectNotSelected: 24}, reasons)
+}
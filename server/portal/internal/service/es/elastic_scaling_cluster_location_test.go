@@ -0,0 +1,126 @@
+package es
+
+import (
+	"context"
+	"testing"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// locationBlocks 返回组装的额外位置条件，按 key 索引
+func locationBlocks(groups []service.FilterGroup) map[string]service.FilterBlock {
+	blocks := make(map[string]service.FilterBlock)
+	for _, group := range groups {
+		if group.ID != "additional_location_conditions" {
+			continue
+		}
+		for _, block := range group.Blocks {
+			blocks[block.Key] = block
+		}
+	}
+	return blocks
+}
+
+func TestAssembleQueryParametersUsesClusterLocation(t *testing.T) {
+	s, db := newTestService(t)
+	require.NoError(t, db.Create(&portal.QueryTemplate{BaseModel: portal.BaseModel{ID: 1}, Name: "all", Groups: "[]"}).Error)
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 1}, ClusterID: "c1", ClusterName: "bj-prod01-calico", Idc: "idc-bj", Zone: "zone-a"}).Error)
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 2}, ClusterID: "c2", ClusterName: "payment-core", Idc: "idc-sh", Zone: "zone-b"}).Error)
+
+	policy := service.ResourcePoolDeviceMatchingPolicy{ID: 1, QueryTemplateID: 1, AdditionConds: []string{"idc", "zone", "room"}}
+	assemble := func(clusterID int) map[string]service.FilterBlock {
+		groups, err := s.assembleQueryParameters(&evaluationContext{}, policy, 1, clusterID, "total", "", "", TriggerActionPoolEntry, nil)
+		require.NoError(t, err)
+		return locationBlocks(groups)
+	}
+
+	t.Run("falls back to cluster fields and cluster name", func(t *testing.T) {
+		blocks := assemble(1)
+		assert.Equal(t, "idc-bj", blocks["idc"].Value)
+		assert.Equal(t, "zone-a", blocks["zone"].Value)
+		assert.Equal(t, "01", blocks["room"].Value)
+		assert.NotContains(t, blocks, "cabinet")
+	})
+
+	t.Run("non-conforming cluster name without mapping has no room condition", func(t *testing.T) {
+		blocks := assemble(2)
+		assert.Equal(t, "idc-sh", blocks["idc"].Value)
+		assert.NotContains(t, blocks, "room")
+	})
+
+	t.Run("mapping overrides cluster name parsing", func(t *testing.T) {
+		require.NoError(t, db.Create(&portal.ClusterLocation{
+			ClusterID: 2,
+			Rooms:     "R1,R2",
+			NetZones:  "nz-core",
+			Cabinets:  "A01",
+		}).Error)
+
+		blocks := assemble(2)
+		assert.Equal(t, "idc-sh", blocks["idc"].Value, "mapping without IDC keeps the cluster IDC")
+		assert.Equal(t, service.ConditionTypeIn, blocks["room"].ConditionType)
+		assert.Equal(t, []string{"R1", "R2"}, blocks["room"].Value)
+		assert.Equal(t, "nz-core", blocks["netZone"].Value)
+		assert.NotContains(t, blocks, "zone")
+		assert.Equal(t, service.ConditionTypeEqual, blocks["cabinet"].ConditionType)
+		assert.Equal(t, "A01", blocks["cabinet"].Value)
+	})
+
+	t.Run("mapping cabinets apply to idc or zone only policies", func(t *testing.T) {
+		for _, conds := range [][]string{{"idc"}, {"zone"}} {
+			locationPolicy := policy
+			locationPolicy.AdditionConds = conds
+			groups, err := s.assembleQueryParameters(&evaluationContext{}, locationPolicy, 1, 2, "total", "", "", TriggerActionPoolEntry, nil)
+			require.NoError(t, err)
+
+			blocks := locationBlocks(groups)
+			assert.NotContains(t, blocks, "room", conds)
+			assert.Equal(t, "A01", blocks["cabinet"].Value, conds)
+		}
+	})
+}
+
+func TestClusterLocationServiceValidation(t *testing.T) {
+	_, db := newTestService(t)
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 1}, ClusterName: "cluster-a"}).Error)
+	svc := service.NewClusterLocationService(db)
+	ctx := context.Background()
+
+	_, err := svc.CreateClusterLocation(ctx, &service.ClusterLocationRequest{ClusterID: 1}, "tester")
+	assert.True(t, service.IsBadRequest(err), "empty mapping should be rejected")
+
+	_, err = svc.CreateClusterLocation(ctx, &service.ClusterLocationRequest{ClusterID: 1, Cabinets: []string{"A01"}}, "tester")
+	assert.True(t, service.IsBadRequest(err), "cabinets without rooms should be rejected")
+
+	_, err = svc.CreateClusterLocation(ctx, &service.ClusterLocationRequest{ClusterID: 99, Rooms: []string{"R1"}}, "tester")
+	assert.True(t, service.IsBadRequest(err), "unknown cluster should be rejected")
+
+	created, err := svc.CreateClusterLocation(ctx, &service.ClusterLocationRequest{
+		ClusterID: 1,
+		Rooms:     []string{" R1 ", "R2", "R1", ""},
+	}, "tester")
+	require.NoError(t, err)
+	assert.Equal(t, "cluster-a", created.ClusterName)
+	assert.Equal(t, []string{"R1", "R2"}, created.Rooms)
+
+	_, err = svc.CreateClusterLocation(ctx, &service.ClusterLocationRequest{ClusterID: 1, Rooms: []string{"R3"}}, "tester")
+	assert.True(t, service.IsBadRequest(err), "a cluster can only have one mapping")
+
+	updated, err := svc.UpdateClusterLocation(ctx, created.ID, &service.ClusterLocationRequest{ClusterID: 1, IDC: "idc-1", Rooms: []string{"R3"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"R3"}, updated.Rooms)
+	assert.Equal(t, "tester", updated.CreatedBy)
+
+	locations, err := service.LoadClusterLocations(db, []int{1, 2})
+	require.NoError(t, err)
+	require.Contains(t, locations, 1)
+	assert.Equal(t, "idc-1", locations[1].IDC)
+	assert.NotContains(t, locations, 2)
+
+	require.NoError(t, svc.DeleteClusterLocation(ctx, created.ID))
+	assert.True(t, service.IsNotFound(svc.DeleteClusterLocation(ctx, created.ID)))
+}
@@ -0,0 +1,229 @@
+package es
+
+import (
+	"errors"
+	"fmt"
+	"navy-ng/models/portal"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// applyClusterOverride 将集群级覆盖配置合并到策略默认配置上，返回合并后的策略副本
+func applyClusterOverride(strategy *portal.ElasticScalingStrategy, assoc *portal.StrategyClusterAssociation) *portal.ElasticScalingStrategy {
+	effective := *strategy
+	if assoc == nil {
+		return &effective
+	}
+
+	if assoc.CPUThresholdValue != nil {
+		effective.CPUThresholdValue = *assoc.CPUThresholdValue
+	}
+	if assoc.CPUTargetValue != nil {
+		effective.CPUTargetValue = *assoc.CPUTargetValue
+	}
+	if assoc.MemoryThresholdValue != nil {
+		effective.MemoryThresholdValue = *assoc.MemoryThresholdValue
+	}
+	if assoc.MemoryTargetValue != nil {
+		effective.MemoryTargetValue = *assoc.MemoryTargetValue
+	}
+	if assoc.DurationMinutes != nil {
+		effective.DurationMinutes = *assoc.DurationMinutes
+	}
+	if assoc.CooldownMinutes != nil {
+		effective.CooldownMinutes = *assoc.CooldownMinutes
+	}
+	if assoc.ResourceTypes != nil {
+		effective.ResourceTypes = *assoc.ResourceTypes
+	}
+	return &effective
+}
+
+// overriddenFields 返回关联中设置了覆盖值的字段（JSON字段名）
+func overriddenFields(assoc *portal.StrategyClusterAssociation) []string {
+	fields := []string{}
+	if assoc.CPUThresholdValue != nil {
+		fields = append(fields, "cpuThresholdValue")
+	}
+	if assoc.CPUTargetValue != nil {
+		fields = append(fields, "cpuTargetValue")
+	}
+	if assoc.MemoryThresholdValue != nil {
+		fields = append(fields, "memoryThresholdValue")
+	}
+	if assoc.MemoryTargetValue != nil {
+		fields = append(fields, "memoryTargetValue")
+	}
+	if assoc.DurationMinutes != nil {
+		fields = append(fields, "durationMinutes")
+	}
+	if assoc.CooldownMinutes != nil {
+		fields = append(fields, "cooldownMinutes")
+	}
+	if assoc.ResourceTypes != nil {
+		fields = append(fields, "resourceTypes")
+	}
+	return fields
+}
+
+// effectiveStrategyForCluster 返回策略在指定集群上的生效配置。
+// 未找到关联或查询失败时使用策略默认配置。
+func (s *ElasticScalingService) effectiveStrategyForCluster(strategy *portal.ElasticScalingStrategy, clusterID int) *portal.ElasticScalingStrategy {
+	var assoc portal.StrategyClusterAssociation
+	err := s.db.Where("strategy_id = ? AND cluster_id = ?", strategy.ID, clusterID).Take(&assoc).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Warn("Failed to get cluster override, using strategy defaults",
+				zap.Int("strategyID", strategy.ID),
+				zap.Int("clusterID", clusterID),
+				zap.Error(err))
+		}
+		return strategy
+	}
+	return applyClusterOverride(strategy, &assoc)
+}
+
+// newStrategyClusterAssociations 根据DTO构建策略的集群关联，包含各集群的覆盖配置
+func newStrategyClusterAssociations(strategyID int, dto *StrategyDTO) []portal.StrategyClusterAssociation {
+	overrides := make(map[int]StrategyClusterOverrideDTO, len(dto.ClusterOverrides))
+	for _, override := range dto.ClusterOverrides {
+		overrides[override.ClusterID] = override
+	}
+
+	associations := make([]portal.StrategyClusterAssociation, len(dto.ClusterIDs))
+	for i, clusterID := range dto.ClusterIDs {
+		associations[i] = portal.StrategyClusterAssociation{
+			StrategyID: strategyID,
+			ClusterID:  clusterID,
+		}
+		if override, ok := overrides[clusterID]; ok {
+			associations[i].CPUThresholdValue = override.CPUThresholdValue
+			associations[i].CPUTargetValue = override.CPUTargetValue
+			associations[i].MemoryThresholdValue = override.MemoryThresholdValue
+			associations[i].MemoryTargetValue = override.MemoryTargetValue
+			associations[i].DurationMinutes = override.DurationMinutes
+			associations[i].CooldownMinutes = override.CooldownMinutes
+			associations[i].ResourceTypes = override.ResourceTypes
+		}
+	}
+	return associations
+}
+
+// clusterOverrideDTOs 将集群关联中的覆盖配置转换为DTO，未设置任何覆盖值的集群不返回
+func clusterOverrideDTOs(associations []portal.StrategyClusterAssociation) []StrategyClusterOverrideDTO {
+	var overrides []StrategyClusterOverrideDTO
+	for i := range associations {
+		assoc := &associations[i]
+		if len(overriddenFields(assoc)) == 0 {
+			continue
+		}
+		overrides = append(overrides, StrategyClusterOverrideDTO{
+			ClusterID:            assoc.ClusterID,
+			CPUThresholdValue:    assoc.CPUThresholdValue,
+			CPUTargetValue:       assoc.CPUTargetValue,
+			MemoryThresholdValue: assoc.MemoryThresholdValue,
+			MemoryTargetValue:    assoc.MemoryTargetValue,
+			DurationMinutes:      assoc.DurationMinutes,
+			CooldownMinutes:      assoc.CooldownMinutes,
+			ResourceTypes:        assoc.ResourceTypes,
+		})
+	}
+	return overrides
+}
+
+// buildClusterConfigs 构建策略在各关联集群上的生效配置
+func (s *ElasticScalingService) buildClusterConfigs(strategy *portal.ElasticScalingStrategy, associations []portal.StrategyClusterAssociation) []StrategyClusterConfigDTO {
+	clusterIDs := make([]int, len(associations))
+	for i, assoc := range associations {
+		clusterIDs[i] = assoc.ClusterID
+	}
+	clusterNames := s.getClusterNameMap(clusterIDs)
+
+	configs := make([]StrategyClusterConfigDTO, len(associations))
+	for i := range associations {
+		effective := applyClusterOverride(strategy, &associations[i])
+		config := StrategyClusterConfigDTO{
+			ClusterID:        associations[i].ClusterID,
+			ClusterName:      clusterNames[associations[i].ClusterID],
+			DurationMinutes:  effective.DurationMinutes,
+			DurationUnit:     effective.DurationUnit,
+			CooldownMinutes:  effective.CooldownMinutes,
+			ResourceTypes:    effective.ResourceTypes,
+			OverriddenFields: overriddenFields(&associations[i]),
+		}
+
+		if effective.CPUThresholdValue > 0 {
+			cpuValue, cpuType := effective.CPUThresholdValue, effective.CPUThresholdType
+			config.CPUThresholdValue = &cpuValue
+			config.CPUThresholdType = &cpuType
+			if effective.CPUTargetValue > 0 {
+				cpuTarget := effective.CPUTargetValue
+				config.CPUTargetValue = &cpuTarget
+			}
+		}
+		if effective.MemoryThresholdValue > 0 {
+			memValue, memType := effective.MemoryThresholdValue, effective.MemoryThresholdType
+			config.MemoryThresholdValue = &memValue
+			config.MemoryThresholdType = &memType
+			if effective.MemoryTargetValue > 0 {
+				memTarget := effective.MemoryTargetValue
+				config.MemoryTargetValue = &memTarget
+			}
+		}
+		configs[i] = config
+	}
+	return configs
+}
+
+// validateClusterOverrides 验证集群级覆盖配置：覆盖的集群必须已关联到策略，
+// 且合并后的配置需要满足与策略相同的校验规则
+func (s *ElasticScalingService) validateClusterOverrides(dto *StrategyDTO) error {
+	associated := make(map[int]bool, len(dto.ClusterIDs))
+	for _, clusterID := range dto.ClusterIDs {
+		associated[clusterID] = true
+	}
+
+	seen := make(map[int]bool, len(dto.ClusterOverrides))
+	for _, override := range dto.ClusterOverrides {
+		if !associated[override.ClusterID] {
+			return fmt.Errorf("集群 %d 未关联到策略，不能设置覆盖配置", override.ClusterID)
+		}
+		if seen[override.ClusterID] {
+			return fmt.Errorf("集群 %d 的覆盖配置重复", override.ClusterID)
+		}
+		seen[override.ClusterID] = true
+
+		merged := *dto
+		merged.ClusterOverrides = nil
+		if override.CPUThresholdValue != nil {
+			merged.CPUThresholdValue = override.CPUThresholdValue
+		}
+		if override.CPUTargetValue != nil {
+			merged.CPUTargetValue = override.CPUTargetValue
+		}
+		if override.MemoryThresholdValue != nil {
+			merged.MemoryThresholdValue = override.MemoryThresholdValue
+		}
+		if override.MemoryTargetValue != nil {
+			merged.MemoryTargetValue = override.MemoryTargetValue
+		}
+		if override.DurationMinutes != nil {
+			merged.DurationMinutes = *override.DurationMinutes
+		}
+		if override.CooldownMinutes != nil {
+			merged.CooldownMinutes = *override.CooldownMinutes
+		}
+		if override.ResourceTypes != nil {
+			if *override.ResourceTypes == "" {
+				return fmt.Errorf("集群 %d 的覆盖资源类型不能为空", override.ClusterID)
+			}
+			merged.ResourceTypes = *override.ResourceTypes
+		}
+
+		if err := s.validateStrategyDTO(&merged); err != nil {
+			return fmt.Errorf("集群 %d 的覆盖配置无效: %w", override.ClusterID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package es
+
+import (
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyClusterOverride(t *testing.T) {
+	strategy := &portal.ElasticScalingStrategy{
+		CPUThresholdValue: 80,
+		CPUTargetValue:    70,
+		DurationMinutes:   3,
+		CooldownMinutes:   60,
+		ResourceTypes:     "total",
+	}
+	threshold, cooldown, resourceTypes := 60.0, 120, "compute,total"
+	assoc := &portal.StrategyClusterAssociation{
+		CPUThresholdValue: &threshold,
+		CooldownMinutes:   &cooldown,
+		ResourceTypes:     &resourceTypes,
+	}
+
+	effective := applyClusterOverride(strategy, assoc)
+	assert.Equal(t, 60.0, effective.CPUThresholdValue)
+	assert.Equal(t, 70.0, effective.CPUTargetValue)
+	assert.Equal(t, 3, effective.DurationMinutes)
+	assert.Equal(t, 120, effective.CooldownMinutes)
+	assert.Equal(t, "compute,total", effective.ResourceTypes)
+	assert.Equal(t, []string{"cpuThresholdValue", "cooldownMinutes", "resourceTypes"}, overriddenFields(assoc))
+
+	// 策略本身不应被修改
+	assert.Equal(t, 80.0, strategy.CPUThresholdValue)
+}
+
+func TestEvaluateAssociationUsesClusterOverride(t *testing.T) {
+	s, db := newTestService(t)
+
+	strategy := &portal.ElasticScalingStrategy{
+		Name:                   "entry",
+		ThresholdTriggerAction: TriggerActionPoolEntry,
+		CPUThresholdValue:      80,
+		CPUThresholdType:       ThresholdTypeAllocated,
+		DurationMinutes:        1,
+		DurationUnit:           DurationUnitDay,
+		Status:                 StrategyStatusEnabled,
+	}
+	require.NoError(t, db.Create(strategy).Error)
+	threshold := 50.0
+	require.NoError(t, db.Create(&portal.StrategyClusterAssociation{StrategyID: strategy.ID, ClusterID: 1, CPUThresholdValue: &threshold}).Error)
+	require.NoError(t, db.Create(&portal.StrategyClusterAssociation{StrategyID: strategy.ID, ClusterID: 2}).Error)
+
+	for _, clusterID := range []int{1, 2} {
+		require.NoError(t, db.Create(&portal.ResourceSnapshot{
+			BaseModel:   portal.BaseModel{CreatedAt: portal.NavyTime(time.Now().Add(-time.Minute))},
+			ClusterID:   uint(clusterID),
+			CpuRequest:  60,
+			CpuCapacity: 100,
+		}).Error)
+	}
+
+	s.evaluateAssociation(&evaluationContext{}, strategy, 1)
+	s.evaluateAssociation(&evaluationContext{}, strategy, 2)
+
+	// 集群1覆盖阈值为50%，60%触发后进入设备匹配；集群2使用策略默认阈值80%，未触发
+	var histories []portal.StrategyExecutionHistory
+	require.NoError(t, db.Order("cluster_id").Find(&histories).Error)
+	require.Len(t, histories, 2)
+	assert.Equal(t, StrategyExecutionResultFailureInvalidTemplateID, histories[0].Result)
+	assert.Contains(t, histories[0].ThresholdValue, "50.00")
+	assert.Equal(t, StrategyExecutionResultFailureThresholdNotMet, histories[1].Result)
+}
+
+func TestStrategyClusterOverridesRoundTrip(t *testing.T) {
+	s, _ := newTestService(t)
+	threshold, allocated := 80.0, ThresholdTypeAllocated
+	overrideThreshold, overrideCooldown := 60.0, 30
+
+	dto := StrategyDTO{
+		Name:                   "entry",
+		Status:                 StrategyStatusEnabled,
+		ClusterIDs:             []int{1, 2},
+		ThresholdTriggerAction: TriggerActionPoolEntry,
+		CPUThresholdValue:      &threshold,
+		CPUThresholdType:       &allocated,
+		CooldownMinutes:        60,
+		ResourceTypes:          "total",
+		ClusterOverrides: []StrategyClusterOverrideDTO{
+			{ClusterID: 1, CPUThresholdValue: &overrideThreshold, CooldownMinutes: &overrideCooldown},
+		},
+	}
+	id, err := s.CreateStrategy(dto)
+	require.NoError(t, err)
+
+	detail, err := s.GetStrategy(int64(id))
+	require.NoError(t, err)
+	require.Len(t, detail.ClusterOverrides, 1)
+	assert.Equal(t, 1, detail.ClusterOverrides[0].ClusterID)
+
+	require.Len(t, detail.ClusterConfigs, 2)
+	configs := make(map[int]StrategyClusterConfigDTO)
+	for _, config := range detail.ClusterConfigs {
+		configs[config.ClusterID] = config
+	}
+	assert.Equal(t, 60.0, *configs[1].CPUThresholdValue)
+	assert.Equal(t, 30, configs[1].CooldownMinutes)
+	assert.Equal(t, []string{"cpuThresholdValue", "cooldownMinutes"}, configs[1].OverriddenFields)
+	assert.Equal(t, 80.0, *configs[2].CPUThresholdValue)
+	assert.Equal(t, 60, configs[2].CooldownMinutes)
+	assert.Empty(t, configs[2].OverriddenFields)
+}
+
+func TestValidateClusterOverrides(t *testing.T) {
+	s := &ElasticScalingService{}
+	threshold, target, allocated := 80.0, 70.0, ThresholdTypeAllocated
+
+	newDTO := func(overrides ...StrategyClusterOverrideDTO) *StrategyDTO {
+		return &StrategyDTO{
+			Name:                   "entry",
+			Status:                 StrategyStatusEnabled,
+			ClusterIDs:             []int{1},
+			ThresholdTriggerAction: TriggerActionPoolEntry,
+			CPUThresholdValue:      &threshold,
+			CPUThresholdType:       &allocated,
+			CPUTargetValue:         &target,
+			ClusterOverrides:       overrides,
+		}
+	}
+
+	valid := 90.0
+	assert.NoError(t, s.validateStrategyDTO(newDTO(StrategyClusterOverrideDTO{ClusterID: 1, CPUThresholdValue: &valid})))
+
+	// 覆盖后的阈值低于策略目标值，入池目标值必须小于阈值
+	tooLow := 60.0
+	assert.Error(t, s.validateStrategyDTO(newDTO(StrategyClusterOverrideDTO{ClusterID: 1, CPUThresholdValue: &tooLow})))
+
+	// 覆盖未关联的集群
+	assert.Error(t, s.validateStrategyDTO(newDTO(StrategyClusterOverrideDTO{ClusterID: 2, CPUThresholdValue: &valid})))
+
+	// 覆盖内存阈值但策略未配置内存阈值类型
+	assert.Error(t, s.validateStrategyDTO(newDTO(StrategyClusterOverrideDTO{ClusterID: 1, MemoryThresholdValue: &valid})))
+}
@@ -47,6 +47,25 @@ const (
 	ThresholdTypeAllocated = "allocated"
 )
 
+// 持续时间单位
+const (
+	DurationUnitDay    = "day"    // 按天评估：每天取最新快照，要求连续N天满足条件
+	DurationUnitHour   = "hour"   // 按小时评估：基于原始快照的滑动窗口
+	DurationUnitMinute = "minute" // 按分钟评估：基于原始快照的滑动窗口
+)
+
+// 触发模式
+const (
+	TriggerModeThreshold = "threshold" // 阈值模式：阈值持续被突破后触发（默认）
+	TriggerModeForecast  = "forecast"  // 预测模式：预测指标将在预测窗口内越过阈值时提前触发
+)
+
+// 预测方法
+const (
+	ForecastMethodLinear = "linear" // 线性回归
+	ForecastMethodHolt   = "holt"   // Holt 线性趋势（无季节项的 Holt-Winters）
+)
+
 // 条件逻辑
 const (
 	ConditionLogicAnd = "AND"
@@ -6,9 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"navy-ng/models/portal"
-	"regexp"
 	"sort"
-	"strings"
 	"time"
 
 	. "navy-ng/server/portal/internal/service"
@@ -19,6 +17,7 @@ import (
 
 // matchDevicesForStrategy 根据策略匹配设备并生成订单
 func (s *ElasticScalingService) matchDevicesForStrategy(
+	evalCtx *evaluationContext,
 	strategy *portal.ElasticScalingStrategy,
 	clusterID int,
 	resourceType string,
@@ -34,46 +33,15 @@ func (s *ElasticScalingService) matchDevicesForStrategy(
 		zap.String("resourceType", resourceType),
 		zap.String("action", strategy.ThresholdTriggerAction))
 
-	currentTime := portal.NavyTime(time.Now())
-
-	// 步骤1: 根据资源类型和动作类型获取设备匹配策略
-	policies, err := s.getDeviceMatchingPolicies(resourceType, strategy.ThresholdTriggerAction)
+	matchResult, err := s.collectMatchedDevices(evalCtx, strategy, clusterID, resourceType, triggeredValueStr, thresholdValueStr, cpuDelta, memDelta)
 	if err != nil {
-		reason := fmt.Sprintf("获取设备匹配策略失败: %s", err.Error())
-		s.logger.Error(reason, zap.Int("strategyID", int(strategy.ID)))
-		s.recordStrategyExecution(int(strategy.ID), clusterID, resourceType, StrategyExecutionResultFailureInvalidTemplateID, nil, reason, triggeredValueStr, thresholdValueStr, &currentTime)
 		return err
 	}
+	allSelectedDeviceIDs := matchResult.SelectedDeviceIDs
+	totalCandidateCount := matchResult.CandidateCount
 
-	var allSelectedDeviceIDs []int
-	var totalCandidateCount int
-
-	// 步骤2: 遍历所有匹配策略，执行设备查询和选择
-	for _, policy := range policies {
-		// 组装查询参数
-		filterGroups, err := s.assembleQueryParameters(policy, int(strategy.ID), clusterID, resourceType, triggeredValueStr, thresholdValueStr, strategy.ThresholdTriggerAction, &currentTime)
-		if err != nil {
-			continue // 继续尝试下一个策略
-		}
-
-		// 查询候选设备
-		candidateDevices, err := s.findCandidateDevices(policy.QueryTemplateID, filterGroups, int(strategy.ID), clusterID, resourceType, triggeredValueStr, thresholdValueStr, &currentTime)
-		if err != nil {
-			continue // 继续尝试下一个策略
-		}
-
-		totalCandidateCount += len(candidateDevices)
-
-		// 筛选和选择设备
-		selectedDeviceIDs := s.filterAndSelectDevices(candidateDevices, strategy, clusterID, cpuDelta, memDelta)
-		allSelectedDeviceIDs = append(allSelectedDeviceIDs, selectedDeviceIDs...)
-
-		s.logger.Info("Processed device matching policy",
-			zap.Int("policyID", policy.ID),
-			zap.String("policyName", policy.Name),
-			zap.Int("candidateCount", len(candidateDevices)),
-			zap.Int("selectedCount", len(selectedDeviceIDs)))
-	}
+	// 本次评估的匹配状态随上下文传递：订单描述记录各匹配策略的选择算法和得分，匹配过程随订单保存
+	evalCtx.Selections, evalCtx.Trace = matchResult.Selections, matchResult.Trace
 
 	// 步骤3: 处理结果
 	if totalCandidateCount == 0 {
@@ -86,8 +54,9 @@ func (s *ElasticScalingService) matchDevicesForStrategy(
 
 		reason := fmt.Sprintf("集群 %s（%s类型）未找到候选设备", clusterName, resourceType)
 		s.logger.Info(reason, zap.Int("strategyID", int(strategy.ID)))
+		matchResult.Trace.addNote(reason)
 		// 无设备时仍然生成订单，作为提醒，不记录为失败
-		return s.generateElasticScalingOrder(strategy, clusterID, resourceType, []int{}, triggeredValueStr, thresholdValueStr, cpuDelta, memDelta, latestSnapshot)
+		return s.generateElasticScalingOrder(evalCtx, strategy, clusterID, resourceType, []int{}, triggeredValueStr, thresholdValueStr, cpuDelta, memDelta, latestSnapshot)
 	}
 
 	if len(allSelectedDeviceIDs) == 0 {
@@ -102,8 +71,9 @@ func (s *ElasticScalingService) matchDevicesForStrategy(
 		reason := fmt.Sprintf("集群 %s 执行%s操作时，经过筛选后无合适设备，查询到候选设备 %d 台",
 			clusterName, actionName, totalCandidateCount)
 		s.logger.Info(reason, zap.Int("strategyID", int(strategy.ID)))
+		matchResult.Trace.addNote(reason)
 		// 无合适设备时仍然生成订单，作为提醒，不记录为失败
-		return s.generateElasticScalingOrder(strategy, clusterID, resourceType, []int{}, triggeredValueStr, thresholdValueStr, cpuDelta, memDelta, latestSnapshot)
+		return s.generateElasticScalingOrder(evalCtx, strategy, clusterID, resourceType, []int{}, triggeredValueStr, thresholdValueStr, cpuDelta, memDelta, latestSnapshot)
 	}
 
 	// 去重选中的设备ID
@@ -115,7 +85,217 @@ func (s *ElasticScalingService) matchDevicesForStrategy(
 		zap.Int("totalCandidateCount", totalCandidateCount),
 		zap.Int("finalSelectedCount", len(uniqueDeviceIDs)))
 
-	return s.generateElasticScalingOrder(strategy, clusterID, resourceType, uniqueDeviceIDs, triggeredValueStr, thresholdValueStr, cpuDelta, memDelta, latestSnapshot)
+	// 出池护栏：出池后的预测指标不能超过资源池告警上限，超过时裁剪设备，第一台即超过时拒绝出池
+	guardrail, err := s.applyExitGuardrail(strategy, clusterID, uniqueDeviceIDs, latestSnapshot)
+	if err != nil {
+		return err
+	}
+	if guardrail.Refused != "" {
+		s.logger.Info(guardrail.Refused, zap.Int("strategyID", strategy.ID), zap.Int("clusterID", clusterID))
+		s.recordBlockedExecution(evalCtx, strategy, clusterID, resourceType, StrategyExecutionResultBlockedExitGuardrail, guardrail.Refused, triggeredValueStr, thresholdValueStr)
+		return nil
+	}
+	if guardrail.Trimmed {
+		s.logger.Warn(guardrail.Note, zap.Int("strategyID", strategy.ID), zap.Int("clusterID", clusterID))
+		uniqueDeviceIDs = guardrail.DeviceIDs
+		evalCtx.GuardrailNote = guardrail.Note
+	}
+
+	// 出池防抖：出池后的预测指标不能达到该资源池任一入池策略的阈值
+	blocked, err := s.checkExitAgainstEntryThresholds(evalCtx, strategy, clusterID, resourceType, uniqueDeviceIDs, triggeredValueStr, thresholdValueStr, latestSnapshot)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return nil
+	}
+
+	return s.generateElasticScalingOrder(evalCtx, strategy, clusterID, resourceType, uniqueDeviceIDs, triggeredValueStr, thresholdValueStr, cpuDelta, memDelta, latestSnapshot)
+}
+
+// evaluationContext 策略评估的选项，以及单次集群+资源池评估在设备匹配和订单创建之间传递的状态。
+// 由评估入口（定时评估、手动评估、模拟）创建并显式传递，不保存在共享的服务实例上，避免并发评估之间互相影响
+type evaluationContext struct {
+	DryRun           bool                               // 模拟模式：不写入策略执行历史和通知
+	ForceEvaluation  bool                               // 手动强制评估：忽略冷却期
+	ExecutionRecords *[]portal.StrategyExecutionHistory // 不为空时收集本次评估写入的执行历史
+	StatusSource     string                             // 创建订单时记录的来源：定时评估为 monitor，手动评估为 api
+
+	Forecast      *forecastResult      // 预测模式下本次评估的预测结果，用于记录执行历史和生成订单描述
+	Selections    []deviceSelection    // 被采用的各匹配策略使用的选择算法及得分，用于生成订单描述
+	Trace         *DeviceMatchingTrace // 设备匹配过程，随订单保存
+	GuardrailNote string               // 出池因护栏裁剪设备的说明
+}
+
+// derive 派生单次集群+资源池评估使用的上下文：保留入口的评估选项，预测结果和匹配状态重新开始
+func (c *evaluationContext) derive() *evaluationContext {
+	return &evaluationContext{
+		DryRun:           c.DryRun,
+		ForceEvaluation:  c.ForceEvaluation,
+		ExecutionRecords: c.ExecutionRecords,
+		StatusSource:     c.StatusSource,
+	}
+}
+
+// deviceMatchResult 设备匹配流水线的结果（未去重的选中设备及候选设备信息）
+type deviceMatchResult struct {
+	CandidateCount     int               // 所有策略查询到的候选设备总数
+	CandidateDeviceIDs []int             // 保留进入选择算法的候选设备ID列表
+	SelectedDeviceIDs  []int             // 选中的设备ID列表（可能包含重复）
+	Selections         []deviceSelection // 被采用的各匹配策略使用的选择算法及得分
+	DevicePolicies     map[int]int       // 设备ID -> 选中该设备的匹配策略ID
+	Trace              *DeviceMatchingTrace
+	faultDomainUsage   *faultDomainUsage // 已采用设备在各机柜、机房的分布，后续策略的故障域上限基于合并后的选择计算
+}
+
+// collectMatchedDevices 执行设备匹配流水线：获取匹配策略、组装查询条件、查询候选设备并进行筛选。
+// 匹配策略按优先级执行，并按组合模式（union、first_wins、fill_remaining）合并各策略的选择结果。
+// 它不会创建订单，由调用方决定如何处理匹配结果。
+func (s *ElasticScalingService) collectMatchedDevices(
+	evalCtx *evaluationContext,
+	strategy *portal.ElasticScalingStrategy,
+	clusterID int,
+	resourceType string,
+	triggeredValueStr string,
+	thresholdValueStr string,
+	cpuDelta float64,
+	memDelta float64,
+) (*deviceMatchResult, error) {
+	currentTime := portal.NavyTime(time.Now())
+	action := strategy.ThresholdTriggerAction
+
+	// 步骤1: 根据资源类型和动作类型获取设备匹配策略（已按优先级排序）
+	policies, err := s.getDeviceMatchingPolicies(resourceType, action)
+	if err != nil {
+		reason := fmt.Sprintf("获取设备匹配策略失败: %s", err.Error())
+		s.logger.Error(reason, zap.Int("strategyID", int(strategy.ID)))
+		s.recordStrategyExecution(evalCtx, int(strategy.ID), clusterID, resourceType, StrategyExecutionResultFailureInvalidTemplateID, nil, reason, triggeredValueStr, thresholdValueStr, &currentTime)
+		return nil, err
+	}
+
+	// 入池时按资源池兼容性规则过滤候选设备
+	var checker *compatibilityChecker
+	if action == TriggerActionPoolEntry {
+		if checker, err = loadCompatibilityChecker(s.db, resourceType); err != nil {
+			s.logger.Error("Failed to load compatibility rule", zap.String("resourceType", resourceType), zap.Error(err))
+			return nil, err
+		}
+	}
+
+	// 出池时按排空保护规则排除禁止驱逐的节点，并按排空代价选择设备
+	var protectionRules drainProtectionRules
+	if action == TriggerActionPoolExit {
+		if protectionRules, err = loadDrainProtectionRules(s.db); err != nil {
+			s.logger.Error("Failed to load drain protection rules", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	mode := policyCombineMode(policies)
+	result := &deviceMatchResult{
+		DevicePolicies:   make(map[int]int),
+		Trace:            newDeviceMatchingTrace(strategy, clusterID, resourceType, cpuDelta, memDelta),
+		faultDomainUsage: newFaultDomainUsage(),
+	}
+	result.Trace.CombineMode = mode
+	result.Trace.DevicePolicies = result.DevicePolicies
+
+	var (
+		considered                 = make(map[int]bool) // 各策略查询到的设备，用于统计候选设备总数
+		satisfied                  bool                 // 按优先级组合时，资源增量是否已满足
+		cpuFulfilled, memFulfilled float64              // fill_remaining 模式下已采用设备的容量
+		unadopted                  []policyOutcome      // first_wins 模式下未满足增量的策略结果
+	)
+
+	// 步骤2: 按优先级遍历匹配策略，执行设备查询和选择
+	for _, policy := range policies {
+		policyTrace := result.Trace.addPolicy(policy)
+		if satisfied {
+			policyTrace.Note = "前序策略已满足资源增量，未执行"
+			continue
+		}
+
+		// 补足模式下只需满足前序策略未满足的增量
+		policyCPUDelta, policyMemDelta := cpuDelta, memDelta
+		if mode == PolicyCombineModeFillRemaining {
+			policyCPUDelta, policyMemDelta = remainingDelta(cpuDelta, cpuFulfilled), remainingDelta(memDelta, memFulfilled)
+		}
+		policyTrace.CPUDelta, policyTrace.MemDelta = policyCPUDelta, policyMemDelta
+
+		// 组装查询参数
+		filterGroups, err := s.assembleQueryParameters(evalCtx, policy, int(strategy.ID), clusterID, resourceType, triggeredValueStr, thresholdValueStr, action, &currentTime)
+		if err != nil {
+			policyTrace.Error = fmt.Sprintf("组装查询条件失败：%v", err)
+			continue // 继续尝试下一个策略
+		}
+		policyTrace.FilterGroups = filterGroups
+
+		// 流式查询候选设备，逐批排除不可用的设备并只保留选择偏好最优的候选设备
+		pool := newCandidatePool(s.db, policyTrace, action, clusterID, resourceType, maxRetainedCandidates)
+		pool.checker, pool.protectionRules, pool.seen = checker, protectionRules, considered
+		if mode == PolicyCombineModeFillRemaining {
+			pool.claimed = result.DevicePolicies
+		}
+		if err := s.findCandidateDevices(evalCtx, policy.QueryTemplateID, filterGroups, pool, int(strategy.ID), clusterID, resourceType, triggeredValueStr, thresholdValueStr, &currentTime); err != nil {
+			policyTrace.Error = fmt.Sprintf("查询候选设备失败：%v", err)
+			continue // 继续尝试下一个策略
+		}
+		candidateDevices := pool.result()
+
+		result.CandidateCount += pool.candidates
+		for _, device := range candidateDevices {
+			result.CandidateDeviceIDs = append(result.CandidateDeviceIDs, device.ID)
+		}
+
+		// 筛选和选择设备
+		selection := s.filterAndSelectDevicesWithPolicy(candidateDevices, strategy, clusterID, policyCPUDelta, policyMemDelta, &policy, pool.costs, result.faultDomainUsage)
+		selection.PolicyName = policy.Name
+		policyTrace.recordSelection(candidateDevices, selection, action, clusterID)
+		policyTrace.recordDrainCosts(selection.DeviceIDs, pool.costs)
+
+		outcome := policyOutcome{policyID: policy.ID, selection: selection, trace: policyTrace, candidates: candidateDevices}
+		cpu, mem := selectionCapacity(candidateDevices, selection.DeviceIDs)
+		switch mode {
+		case PolicyCombineModeFillRemaining:
+			result.adopt(outcome)
+			cpuFulfilled += cpu
+			memFulfilled += mem
+			satisfied = selectionSatisfied(cpuDelta, memDelta, action, cpuFulfilled, memFulfilled, len(result.SelectedDeviceIDs))
+		case PolicyCombineModeFirstWins:
+			if selectionSatisfied(cpuDelta, memDelta, action, cpu, mem, len(selection.DeviceIDs)) {
+				result.adopt(outcome)
+				satisfied = true
+			} else {
+				policyTrace.Note = "未满足资源增量，选择结果未采用"
+				unadopted = append(unadopted, outcome)
+			}
+		default:
+			result.adopt(outcome)
+		}
+
+		s.logger.Info("Processed device matching policy",
+			zap.Int("policyID", policy.ID),
+			zap.String("policyName", policy.Name),
+			zap.Int("priority", policy.Priority),
+			zap.String("combineMode", mode),
+			zap.String("selectionAlgorithm", selection.Algorithm),
+			zap.Float64("selectionScore", selection.Score),
+			zap.Int("candidateCount", len(candidateDevices)),
+			zap.Int("selectedCount", len(selection.DeviceIDs)),
+			zap.Bool("satisfied", satisfied))
+	}
+
+	result.Trace.CandidateCount = len(considered)
+
+	// 没有策略能单独满足增量时，采用得分最优的选择结果
+	if mode == PolicyCombineModeFirstWins && !satisfied {
+		if best := bestPartialOutcome(unadopted); best != nil {
+			result.adopt(*best)
+			best.trace.Note = "没有策略能单独满足资源增量，采用得分最优的选择结果"
+		}
+	}
+
+	return result, nil
 }
 
 // GetDeviceMatchingPoliciesPublic is a public wrapper for testing.
@@ -154,6 +334,7 @@ func (s *ElasticScalingService) getDeviceMatchingPolicies(resourceType, actionTy
 
 // assembleQueryParameters 组装查询参数，包含查询模板和额外动态条件
 func (s *ElasticScalingService) assembleQueryParameters(
+	evalCtx *evaluationContext,
 	policy ResourcePoolDeviceMatchingPolicy,
 	strategyID, clusterID int,
 	resourceType, triggeredValueStr, thresholdValueStr, actionType string,
@@ -173,7 +354,7 @@ func (s *ElasticScalingService) assembleQueryParameters(
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			result = StrategyExecutionResultFailureTemplateNotFound
 		}
-		s.recordStrategyExecution(strategyID, clusterID, resourceType, result, nil, reason, triggeredValueStr, thresholdValueStr, currentTime)
+		s.recordStrategyExecution(evalCtx, strategyID, clusterID, resourceType, result, nil, reason, triggeredValueStr, thresholdValueStr, currentTime)
 		return nil, err
 	}
 
@@ -182,7 +363,7 @@ func (s *ElasticScalingService) assembleQueryParameters(
 	if err := json.Unmarshal([]byte(queryTemplateModel.Groups), &filterGroups); err != nil {
 		reason := fmt.Sprintf("查询模板 ID %d 的过滤组解析失败：%v", policy.QueryTemplateID, err)
 		s.logger.Error(reason, zap.Int("strategyID", strategyID), zap.Error(err))
-		s.recordStrategyExecution(strategyID, clusterID, resourceType, StrategyExecutionResultFailureTemplateUnmarshal, nil, reason, triggeredValueStr, thresholdValueStr, currentTime)
+		s.recordStrategyExecution(evalCtx, strategyID, clusterID, resourceType, StrategyExecutionResultFailureTemplateUnmarshal, nil, reason, triggeredValueStr, thresholdValueStr, currentTime)
 		return nil, err
 	}
 
@@ -234,22 +415,34 @@ func (s *ElasticScalingService) assembleQueryParameters(
 		if len(policy.AdditionConds) > 0 {
 			s.logger.Info("Processing additional dynamic conditions for pool entry",
 				zap.Strings("conditions", policy.AdditionConds))
+
+			// 优先使用集群位置映射，未配置时使用集群字段并从集群名称解析机房
+			location, err := ResolveClusterLocation(s.db, &cluster)
+			if err != nil {
+				s.logger.Warn("Failed to resolve cluster location, falling back to cluster fields",
+					zap.Int("clusterID", clusterID),
+					zap.Error(err))
+				location = &ResolvedClusterLocation{IDC: cluster.Idc, Source: ClusterLocationSourceCluster}
+			}
 			var additionalBlocks []FilterBlock
 
 			for _, conditionType := range policy.AdditionConds {
 				switch conditionType {
 				case "idc", "same_idc":
-					if cluster.Idc != "" {
+					if location.IDC != "" {
 						additionalBlocks = append(additionalBlocks, FilterBlock{
 							Key:           "idc",
 							ConditionType: ConditionType("equal"),
-							Value:         cluster.Idc,
+							Value:         location.IDC,
 							Operator:      LogicalOperator("and"),
 						})
-						s.logger.Info("Added IDC condition", zap.String("idc", cluster.Idc))
+						s.logger.Info("Added IDC condition", zap.String("idc", location.IDC), zap.String("source", location.Source))
 					}
 				case "zone", "same_zone":
-					if cluster.Zone != "" {
+					if location.Source == ClusterLocationSourceMapping && len(location.NetZones) > 0 {
+						additionalBlocks = append(additionalBlocks, locationFilterBlock("netZone", location.NetZones))
+						s.logger.Info("Added network zone condition", zap.Strings("netZones", location.NetZones))
+					} else if cluster.Zone != "" {
 						additionalBlocks = append(additionalBlocks, FilterBlock{
 							Key:           "zone",
 							ConditionType: ConditionType("equal"),
@@ -259,35 +452,21 @@ func (s *ElasticScalingService) assembleQueryParameters(
 						s.logger.Info("Added Zone condition", zap.String("zone", cluster.Zone))
 					}
 				case "room", "same_room":
-					// 参考前端逻辑处理room信息
-					// 注意：K8sCluster模型中没有Room字段，需要从其他地方获取或解析
-					var roomValue string
-					// 尝试从集群名称中解析room信息
-					clusterName := cluster.ClusterName
-					if clusterName != "" && !strings.Contains(clusterName, "-test") {
-						// 正则表达式匹配：^[^-]+-.*?(\d+)-(?:calico|flannel)
-						roomRegex := `^[^-]+-.*?(\d+)-(?:calico|flannel)`
-						re := regexp.MustCompile(roomRegex)
-						matches := re.FindStringSubmatch(clusterName)
-						if len(matches) > 1 {
-							roomValue = matches[1]
-						}
-					}
-					if roomValue != "" {
-						additionalBlocks = append(additionalBlocks, FilterBlock{
-							Type:          "device",
-							Key:           "room",
-							ConditionType: ConditionType("equal"),
-							Value:         roomValue,
-							Operator:      LogicalOperator("and"),
-						})
-						s.logger.Info("Added Room condition", zap.String("room", roomValue))
+					if len(location.Rooms) > 0 {
+						additionalBlocks = append(additionalBlocks, locationFilterBlock("room", location.Rooms))
+						s.logger.Info("Added Room condition", zap.Strings("rooms", location.Rooms), zap.String("source", location.Source))
 					}
 				default:
 					s.logger.Warn("Unknown additional condition type", zap.String("condition", conditionType))
 				}
 			}
 
+			// 位置映射限定了可用机柜时，无论按哪种位置条件匹配，都只选择这些机柜中的设备
+			if location.Source == ClusterLocationSourceMapping && len(location.Cabinets) > 0 {
+				additionalBlocks = append(additionalBlocks, locationFilterBlock("cabinet", location.Cabinets))
+				s.logger.Info("Added Cabinet condition", zap.Strings("cabinets", location.Cabinets))
+			}
+
 			// 如果有额外的位置条件，创建一个新的FilterGroup
 			if len(additionalBlocks) > 0 {
 				additionalGroup := FilterGroup{
@@ -305,12 +484,29 @@ func (s *ElasticScalingService) assembleQueryParameters(
 	return filterGroups, nil
 }
 
+// locationFilterBlock 生成设备位置条件，单个值使用等于，多个值使用在列表中
+func locationFilterBlock(key string, values []string) FilterBlock {
+	block := FilterBlock{
+		Type:     "device",
+		Key:      key,
+		Operator: LogicalOperator("and"),
+	}
+	if len(values) == 1 {
+		block.ConditionType = ConditionTypeEqual
+		block.Value = values[0]
+	} else {
+		block.ConditionType = ConditionTypeIn
+		block.Value = values
+	}
+	return block
+}
+
 // FetchAndUnmarshalQueryTemplatePublic is a public wrapper for testing.
 func (s *ElasticScalingService) FetchAndUnmarshalQueryTemplatePublic(queryTemplateID, strategyID int, triggeredValueStr, thresholdValueStr string, currentTime *portal.NavyTime) ([]FilterGroup, error) {
-	return s.fetchAndUnmarshalQueryTemplate(queryTemplateID, strategyID, 0, "", triggeredValueStr, thresholdValueStr, currentTime)
+	return s.fetchAndUnmarshalQueryTemplate(&evaluationContext{}, queryTemplateID, strategyID, 0, "", triggeredValueStr, thresholdValueStr, currentTime)
 }
 
-func (s *ElasticScalingService) fetchAndUnmarshalQueryTemplate(queryTemplateID, strategyID, clusterID int, resourceType, triggeredValueStr, thresholdValueStr string, currentTime *portal.NavyTime) ([]FilterGroup, error) {
+func (s *ElasticScalingService) fetchAndUnmarshalQueryTemplate(evalCtx *evaluationContext, queryTemplateID, strategyID, clusterID int, resourceType, triggeredValueStr, thresholdValueStr string, currentTime *portal.NavyTime) ([]FilterGroup, error) {
 	s.logger.Info("Using query template for device matching", zap.Int("templateID", queryTemplateID), zap.Int("strategyID", strategyID))
 
 	var queryTemplateModel portal.QueryTemplate
@@ -321,7 +517,7 @@ func (s *ElasticScalingService) fetchAndUnmarshalQueryTemplate(queryTemplateID,
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			result = StrategyExecutionResultFailureTemplateNotFound
 		}
-		s.recordStrategyExecution(strategyID, clusterID, resourceType, result, nil, reason, triggeredValueStr, thresholdValueStr, currentTime)
+		s.recordStrategyExecution(evalCtx, strategyID, clusterID, resourceType, result, nil, reason, triggeredValueStr, thresholdValueStr, currentTime)
 		return nil, err
 	}
 
@@ -329,48 +525,48 @@ func (s *ElasticScalingService) fetchAndUnmarshalQueryTemplate(queryTemplateID,
 	if err := json.Unmarshal([]byte(queryTemplateModel.Groups), &filterGroups); err != nil {
 		reason := fmt.Sprintf("查询模板 ID %d 的过滤组解析失败：%v", queryTemplateID, err)
 		s.logger.Error(reason, zap.Int("strategyID", strategyID), zap.Error(err))
-		s.recordStrategyExecution(strategyID, clusterID, resourceType, StrategyExecutionResultFailureTemplateUnmarshal, nil, reason, triggeredValueStr, thresholdValueStr, currentTime)
+		s.recordStrategyExecution(evalCtx, strategyID, clusterID, resourceType, StrategyExecutionResultFailureTemplateUnmarshal, nil, reason, triggeredValueStr, thresholdValueStr, currentTime)
 		return nil, err
 	}
 	return filterGroups, nil
 }
 
-func (s *ElasticScalingService) findCandidateDevices(queryTemplateID int, filterGroups []FilterGroup, strategyID, clusterID int, resourceType, triggeredValueStr, thresholdValueStr string, currentTime *portal.NavyTime) ([]DeviceResponse, error) {
+// findCandidateDevices 按查询条件流式读取候选设备并交给 pool 逐批筛选
+func (s *ElasticScalingService) findCandidateDevices(evalCtx *evaluationContext, queryTemplateID int, filterGroups []FilterGroup, pool *candidatePool, strategyID, clusterID int, resourceType, triggeredValueStr, thresholdValueStr string, currentTime *portal.NavyTime) error {
 	// 创建设备查询服务
 	var deviceCache DeviceCacheInterface
 	if s.cache != nil {
 		deviceCache = s.cache
 	}
 	deviceQuerySvc := NewDeviceQueryService(s.db, deviceCache)
-	deviceRequest := &DeviceQueryRequest{
-		Groups: filterGroups,
-		Page:   1,
-		Size:   1000, // Fetch a large number of candidates
-	}
 
 	s.logger.Info("Querying candidate devices using template",
 		zap.Int("strategyID", strategyID),
 		zap.Int("templateID", queryTemplateID),
-		zap.Any("requestGroups", deviceRequest.Groups))
+		zap.Any("requestGroups", filterGroups))
 
-	candidateDevicesResponse, err := deviceQuerySvc.QueryDevices(context.Background(), deviceRequest)
+	// 分批读取全部候选设备，避免分页截断导致选择结果受查询排序影响；每批在回调中筛选，只保留有限的候选集
+	total, err := deviceQuerySvc.StreamDevices(context.Background(), filterGroups, DefaultDeviceStreamBatchSize, pool.add)
 	if err != nil {
+		result := StrategyExecutionResultFailureDeviceQuery
 		reason := fmt.Sprintf("使用模板 ID %d 查询设备失败：%v", queryTemplateID, err)
+		if errors.Is(err, errCandidateFilter) {
+			result = StrategyExecutionResultFailureDBError
+			reason = err.Error()
+		}
 		s.logger.Error(reason, zap.Int("strategyID", strategyID), zap.Error(err))
-		s.recordStrategyExecution(strategyID, clusterID, resourceType, StrategyExecutionResultFailureDeviceQuery, nil, reason, triggeredValueStr, thresholdValueStr, currentTime)
-		return nil, err
-	}
-
-	if candidateDevicesResponse == nil {
-		return []DeviceResponse{}, nil
+		s.recordStrategyExecution(evalCtx, strategyID, clusterID, resourceType, result, nil, reason, triggeredValueStr, thresholdValueStr, currentTime)
+		return err
 	}
 
 	s.logger.Info("Successfully queried candidate devices",
 		zap.Int("strategyID", strategyID),
 		zap.Int("templateID", queryTemplateID),
-		zap.Int("candidateCount", len(candidateDevicesResponse.List)))
+		zap.Int("queriedCount", total),
+		zap.Int("candidateCount", pool.candidates),
+		zap.Int("retainedCount", len(pool.devices)))
 
-	return candidateDevicesResponse.List, nil
+	return nil
 }
 
 // FilterAndSelectDevicesPublic is a public wrapper for testing.
@@ -379,6 +575,20 @@ func (s *ElasticScalingService) FilterAndSelectDevicesPublic(candidates []Device
 }
 
 func (s *ElasticScalingService) filterAndSelectDevices(candidates []DeviceResponse, strategy *portal.ElasticScalingStrategy, clusterID int, cpuDelta, memDelta float64) []int {
+	return s.filterAndSelectDevicesWithPolicy(candidates, strategy, clusterID, cpuDelta, memDelta, nil, nil, nil).DeviceIDs
+}
+
+// filterAndSelectDevicesWithPolicy 筛选适用的候选设备，使用匹配策略配置的算法选择设备，
+// 并按策略的故障域约束（机柜/机房上限、机房均衡、出池机柜保留数）调整选择结果。
+// policy 为空时使用贪婪算法且不设故障域约束；出池时优先选择排空代价（costs）低的设备。
+// usage 为多个匹配策略组合时前序策略已采用的设备分布，为空时只按本次选择计算故障域上限。
+func (s *ElasticScalingService) filterAndSelectDevicesWithPolicy(candidates []DeviceResponse, strategy *portal.ElasticScalingStrategy, clusterID int, cpuDelta, memDelta float64, policy *ResourcePoolDeviceMatchingPolicy, costs drainCosts, usage *faultDomainUsage) deviceSelection {
+	algorithm := SelectionAlgorithmGreedy
+	if policy != nil {
+		algorithm = policy.SelectionAlgorithm
+	}
+	constraints := newFaultDomainConstraints(policy)
+
 	var suitableCandidates []DeviceResponse
 	if strategy.ThresholdTriggerAction == TriggerActionPoolEntry {
 		var unassignedDevices, assignedDevices []DeviceResponse
@@ -396,11 +606,13 @@ func (s *ElasticScalingService) filterAndSelectDevices(candidates []DeviceRespon
 				suitableCandidates = append(suitableCandidates, device)
 			}
 		}
+		sortByDrainCost(suitableCandidates, costs)
 	}
 
-	// 如果是基于资源增量，则使用贪婪算法
+	// 如果是基于资源增量（已按策略目标值计算），则使用配置的选择算法
 	if cpuDelta > 0 || memDelta > 0 || cpuDelta < 0 || memDelta < 0 {
-		return s.greedySelectDevices(suitableCandidates, cpuDelta, memDelta, strategy.ThresholdTriggerAction)
+		selection := s.selectDevicesWithAlgorithm(algorithm, suitableCandidates, cpuDelta, memDelta, strategy.ThresholdTriggerAction, costs)
+		return s.applyFaultDomainConstraints(selection, suitableCandidates, clusterID, cpuDelta, memDelta, strategy.ThresholdTriggerAction, constraints, usage)
 	}
 
 	// 否则，使用动态计算的设备数量
@@ -417,7 +629,12 @@ func (s *ElasticScalingService) filterAndSelectDevices(candidates []DeviceRespon
 		selectedDeviceIDs = append(selectedDeviceIDs, int(suitableCandidates[i].ID))
 	}
 
-	return selectedDeviceIDs
+	selection := deviceSelection{
+		Algorithm: selectionAlgorithmDeviceCount,
+		DeviceIDs: selectedDeviceIDs,
+		Score:     float64(len(selectedDeviceIDs)),
+	}
+	return s.applyFaultDomainConstraints(selection, suitableCandidates, clusterID, cpuDelta, memDelta, strategy.ThresholdTriggerAction, constraints, usage)
 }
 
 // calculateRequiredDeviceCount 根据资源需求动态计算所需设备数量
@@ -481,18 +698,21 @@ func (s *ElasticScalingService) deduplicateDeviceIDs(deviceIDs []int) []int {
 	return unique
 }
 
-// greedySelectDevices 贪婪算法选择设备
-func (s *ElasticScalingService) greedySelectDevices(devices []DeviceResponse, cpuDemand, memDemand float64, action string) []int {
+// greedySelectDevices 贪婪算法选择设备，出池时有排空代价的情况下优先选择代价低的设备
+func (s *ElasticScalingService) greedySelectDevices(devices []DeviceResponse, cpuDemand, memDemand float64, action string, costs drainCosts) []int {
 	var selectedDeviceIDs []int
 	var cpuFulfilled, memFulfilled float64
 
 	// 对于入池，我们希望用最少的设备满足最大的需求，所以按CPU或内存（取决于哪个需求更大）降序排序
-	// 对于出池，我们希望移除最空闲的设备，所以按CPU或内存升序排序
+	// 对于出池，我们希望移除排空代价最低、最空闲的设备，所以按排空代价、CPU升序排序
 	sort.Slice(devices, func(i, j int) bool {
 		// 简单的排序逻辑：优先考虑CPU，可以根据策略进行扩展
 		if action == TriggerActionPoolEntry {
 			return devices[i].CPU > devices[j].CPU
 		}
+		if less, decided := costs.less(devices[i].ID, devices[j].ID); decided {
+			return less
+		}
 		return devices[i].CPU < devices[j].CPU
 	})
 
@@ -509,6 +729,14 @@ func (s *ElasticScalingService) greedySelectDevices(devices []DeviceResponse, cp
 			if cpuFulfilled <= cpuDemand && memFulfilled <= memDemand {
 				break // 需求已满足
 			}
+			// 需求按目标值计算，移除超过需求的容量会使指标越过目标值、逼近入池阈值，因此不允许超额移除。
+			// 设备按CPU升序排列时后续设备只会更大，直接结束；按排空代价排序时继续尝试后续更小的设备
+			if (cpuDemand < 0 && cpuFulfilled-device.CPU < cpuDemand) || (memDemand < 0 && memFulfilled-device.Memory < memDemand) {
+				if len(costs) == 0 {
+					break
+				}
+				continue
+			}
 			selectedDeviceIDs = append(selectedDeviceIDs, int(device.ID))
 			cpuFulfilled -= device.CPU
 			memFulfilled -= device.Memory
@@ -528,15 +756,16 @@ func (s *ElasticScalingService) greedySelectDevices(devices []DeviceResponse, cp
 
 // GreedySelectDevicesPublic is a public wrapper for testing.
 func (s *ElasticScalingService) GreedySelectDevicesPublic(devices []DeviceResponse, cpuDemand, memDemand float64, action string) []int {
-	return s.greedySelectDevices(devices, cpuDemand, memDemand, action)
+	return s.greedySelectDevices(devices, cpuDemand, memDemand, action, nil)
 }
 
 // generateElasticScalingOrder creates an order based on a successful strategy evaluation and device selection.
 // 在更新后的设计中，此函数在连续多天阈值被突破后被调用，而不是在分钟级别的阈值突破后。
 func (s *ElasticScalingService) generateElasticScalingOrder(
+	evalCtx *evaluationContext,
 	strategy *portal.ElasticScalingStrategy,
 	clusterID int,
-	resourceType string, // Keep for logging/reason context
+	resourceType string,
 	selectedDeviceIDs []int,
 	triggeredValueStr string,
 	thresholdValueStr string,
@@ -550,16 +779,72 @@ func (s *ElasticScalingService) generateElasticScalingOrder(
 		zap.String("actionType", strategy.ThresholdTriggerAction),
 		zap.Int("deviceCount", len(selectedDeviceIDs)))
 
+	// 设备匹配耗时期间可能进入冻结期，创建订单前再次检查
+	if frozen, reason := s.checkFreezeCalendar(clusterID, time.Now()); frozen {
+		s.logger.Info(reason, zap.Int("strategyID", strategy.ID), zap.Int("clusterID", clusterID))
+		s.recordBlockedExecution(evalCtx, strategy, clusterID, resourceType, StrategyExecutionResultSkippedFreeze, reason, triggeredValueStr, thresholdValueStr)
+		return nil
+	}
+
+	// 预算检查和订单创建在预算锁内完成，避免并发评估的策略同时占用同一预算
+	release, err := s.lockScalingBudgets(strategy.ThresholdTriggerAction)
+	if err != nil {
+		s.logger.Error("Failed to lock scaling budgets", zap.Error(err), zap.Int("strategyID", strategy.ID), zap.Int("clusterID", clusterID))
+		currentTime := portal.NavyTime(time.Now())
+		s.recordStrategyExecution(evalCtx, strategy.ID, clusterID, resourceType, StrategyExecutionResultFailureDBError, nil, fmt.Sprintf("获取自动伸缩预算锁失败：%v", err), triggeredValueStr, thresholdValueStr, &currentTime)
+		return err
+	}
+	defer release()
+
+	// 按自动伸缩预算裁剪设备选择，预算用尽时不创建订单并告警
+	requestedCount := len(selectedDeviceIDs)
+	selectedDeviceIDs, budgetNote, budgetExceeded, err := s.applyScalingBudgets(strategy.ThresholdTriggerAction, clusterID, resourceType, selectedDeviceIDs, time.Now())
+	if err != nil {
+		s.logger.Error("Failed to check scaling budgets", zap.Error(err), zap.Int("strategyID", strategy.ID), zap.Int("clusterID", clusterID))
+		currentTime := portal.NavyTime(time.Now())
+		s.recordStrategyExecution(evalCtx, strategy.ID, clusterID, resourceType, StrategyExecutionResultFailureDBError, nil, fmt.Sprintf("检查自动伸缩预算失败：%v", err), triggeredValueStr, thresholdValueStr, &currentTime)
+		return err
+	}
+	if budgetExceeded != "" {
+		// 获取集群名称用于中文描述
+		var cluster portal.K8sCluster
+		clusterName := "未知集群"
+		if err := s.db.Select("clustername").First(&cluster, clusterID).Error; err == nil {
+			clusterName = cluster.ClusterName
+		}
+
+		reason := fmt.Sprintf("集群 %s（%s类型）需要%s %d 台设备，自动伸缩预算已用尽，未创建订单：%s",
+			clusterName, resourceType, s.getActionName(strategy.ThresholdTriggerAction), requestedCount, budgetExceeded)
+		s.recordBlockedExecution(evalCtx, strategy, clusterID, resourceType, StrategyExecutionResultBudgetExceeded, reason, triggeredValueStr, thresholdValueStr)
+		s.sendBudgetExceededAlert(evalCtx, strategy, clusterID, resourceType, requestedCount, budgetExceeded)
+		return nil
+	}
+	if budgetNote != "" {
+		s.logger.Warn(budgetNote, zap.Int("strategyID", strategy.ID), zap.Int("clusterID", clusterID))
+		evalCtx.Trace.addNote(budgetNote)
+	}
+	if evalCtx.GuardrailNote != "" {
+		evalCtx.Trace.addNote(evalCtx.GuardrailNote)
+	}
+	evalCtx.Trace.setOrderDevices(selectedDeviceIDs)
+
 	// 生成订单名称
 	orderName := s.generateOrderName(strategy, len(selectedDeviceIDs))
 
 	// 生成订单描述
-	orderDescription := s.generateOrderDescription(strategy, clusterID, resourceType, selectedDeviceIDs, latestSnapshot)
+	orderDescription := s.generateOrderDescription(evalCtx, strategy, clusterID, resourceType, selectedDeviceIDs, latestSnapshot)
+	if budgetNote != "" {
+		orderDescription += fmt.Sprintf("<p><strong>预算限制：</strong>%s</p>", budgetNote)
+	}
+	if evalCtx.GuardrailNote != "" {
+		orderDescription += fmt.Sprintf("<p><strong>出池护栏：</strong>%s</p>", evalCtx.GuardrailNote)
+	}
 
 	orderDTO := OrderDTO{
 		Name:                   orderName,
 		Description:            orderDescription,
 		ClusterID:              clusterID,
+		ResourcePoolType:       resourceType,
 		StrategyID:             func(i int) *int { v := int(i); return &v }(strategy.ID),
 		ActionType:             strategy.ThresholdTriggerAction,
 		DeviceCount:            len(selectedDeviceIDs),
@@ -567,10 +852,12 @@ func (s *ElasticScalingService) generateElasticScalingOrder(
 		StrategyTriggeredValue: triggeredValueStr,
 		StrategyThresholdValue: thresholdValueStr,
 		CreatedBy:              SystemAutoCreator,
+		MatchingTrace:          evalCtx.Trace,
+		GuardrailTrimmed:       evalCtx.GuardrailNote != "",
 		// Status will be set by CreateOrder, typically to "pending"
 	}
 
-	orderID, err := s.CreateOrder(orderDTO)
+	orderID, err := s.CreateOrderFrom(evalCtx.StatusSource, orderDTO)
 	currentTime := portal.NavyTime(time.Now())
 
 	if err != nil {
@@ -587,7 +874,7 @@ func (s *ElasticScalingService) generateElasticScalingOrder(
 		}
 
 		reason := fmt.Sprintf("为集群 %s（%s类型）创建订单失败：%v", clusterName, resourceType, err)
-		s.recordStrategyExecution(int(strategy.ID), clusterID, resourceType, StrategyExecutionResultOrderFailed, nil, reason, triggeredValueStr, thresholdValueStr, &currentTime)
+		s.recordStrategyExecution(evalCtx, int(strategy.ID), clusterID, resourceType, StrategyExecutionResultOrderFailed, nil, reason, triggeredValueStr, thresholdValueStr, &currentTime)
 		return err
 	}
 
@@ -619,7 +906,14 @@ func (s *ElasticScalingService) generateElasticScalingOrder(
 		reason = fmt.Sprintf("已为集群 %s（%s类型）成功创建%s订单 %d，涉及设备 %d 台", clusterName, resourceType, actionName, orderID, len(selectedDeviceIDs))
 	}
 
-	s.recordStrategyExecution(int(strategy.ID), clusterID, resourceType, executionResult, &orderID, reason, triggeredValueStr, thresholdValueStr, &currentTime)
+	if budgetNote != "" {
+		reason += "；" + budgetNote
+	}
+	if evalCtx.GuardrailNote != "" {
+		reason += "；" + evalCtx.GuardrailNote
+	}
+
+	s.recordStrategyExecution(evalCtx, int(strategy.ID), clusterID, resourceType, executionResult, &orderID, reason, triggeredValueStr, thresholdValueStr, &currentTime)
 
 	// TODO: 根据设计文档，需要查询当周值班人员并向其发送运维通知
 	s.logger.Info("Placeholder: Trigger notification to duty roster about the new order.", zap.Int("orderID", orderID))
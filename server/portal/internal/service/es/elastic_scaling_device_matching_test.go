@@ -37,6 +37,13 @@ var _ = Describe("ElasticScalingDeviceMatching", func() {
 		err = db.AutoMigrate(
 			&portal.ElasticScalingStrategy{},
 			&portal.StrategyClusterAssociation{},
+			&portal.FreezeWindow{},
+			&portal.ElasticScalingBudget{},
+			&portal.DeviceReservation{},
+			&portal.ClusterLocation{},
+			&portal.ResourcePoolCompatibilityRule{},
+			&portal.DrainProtectionRule{},
+			&portal.OrderStatusHistory{},
 			&portal.ResourceSnapshot{},
 			&portal.StrategyExecutionHistory{},
 			&portal.Device{},
@@ -190,16 +197,16 @@ var _ = Describe("ElasticScalingDeviceMatching", func() {
 		})
 
 		Context("for pool exit (scale-in)", func() {
-			It("should select smallest devices to meet negative demand", func() {
+			It("should select smallest devices without removing more than the demand", func() {
 				devices := []DeviceResponse{
 					{ID: 1, CPU: 32, Memory: 128},
 					{ID: 2, CPU: 64, Memory: 256},
 					{ID: 3, CPU: 16, Memory: 64},
 				}
-				// Demand: remove 40 CPU, 150 Memory (represented by negative numbers)
-				selectedIDs := ess.GreedySelectDevicesPublic(devices, -40, -150, es.TriggerActionPoolExit)
+				// Demand: remove 50 CPU, 200 Memory (represented by negative numbers)
+				selectedIDs := ess.GreedySelectDevicesPublic(devices, -50, -200, es.TriggerActionPoolExit)
 				Expect(selectedIDs).To(HaveLen(2))
-				Expect(selectedIDs).To(ConsistOf(int64(3), int64(1))) // 16+32=48 CPU, 64+128=192 Mem
+				Expect(selectedIDs).To(ConsistOf(int64(3), int64(1))) // 16+32=48 CPU, 64+128=192 Mem，再移除64核会越过目标值
 			})
 		})
 	})
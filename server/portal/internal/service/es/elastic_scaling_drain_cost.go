@@ -0,0 +1,339 @@
+package es
+
+import (
+	"fmt"
+	"navy-ng/models/portal"
+	"sort"
+	"strings"
+	"time"
+
+	. "navy-ng/server/portal/internal/service"
+
+	"github.com/jinzhu/now"
+	"gorm.io/gorm"
+)
+
+// 排空代价权重：代价 = 估算Pod数 × 1 + 估算CPU request(核) × 1 + 估算内存 request(GiB) × 0.25 + 受保护标签/污点数 × 20
+const (
+	drainCostPodWeight       = 1.0
+	drainCostCPUWeight       = 1.0
+	drainCostMemWeight       = 0.25
+	drainCostProtectedWeight = 20.0
+)
+
+// drainCosts 出池候选设备的排空代价（设备ID -> 代价），既没有资源池快照也没有节点数据的设备不在其中
+type drainCosts map[int]float64
+
+// poolWorkload 资源池最新快照中的负载密度。快照只采集资源池整体的Pod数和 request 总和，
+// 节点上的负载按节点容量占资源池容量的比例估算
+type poolWorkload struct {
+	podsPerCPU      float64 // 每核CPU容量上的Pod数
+	cpuRequestRatio float64 // CPU request / CPU 容量
+	memRequestRatio float64 // 内存 request / 内存容量
+}
+
+// loadPoolWorkload 获取集群资源池最新快照的负载密度，没有快照时返回 nil
+func loadPoolWorkload(db *gorm.DB, clusterID int, resourceType string) (*poolWorkload, error) {
+	var snapshot portal.ResourceSnapshot
+	if err := db.Where("cluster_id = ? AND resource_type = ?", clusterID, resourceType).
+		Order("created_at DESC").First(&snapshot).Error; err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get latest resource snapshot: %w", err)
+	}
+	workload := &poolWorkload{}
+	if snapshot.CpuCapacity > 0 {
+		workload.podsPerCPU = float64(snapshot.PodCount) / snapshot.CpuCapacity
+		workload.cpuRequestRatio = snapshot.CpuRequest / snapshot.CpuCapacity
+	}
+	if snapshot.MemoryCapacity > 0 {
+		workload.memRequestRatio = snapshot.MemRequest / snapshot.MemoryCapacity
+	}
+	return workload, nil
+}
+
+// cost 按设备容量估算排空该设备需要迁移的负载，w 为空时返回0
+func (w *poolWorkload) cost(device DeviceResponse) float64 {
+	if w == nil {
+		return 0
+	}
+	return device.CPU*w.podsPerCPU*drainCostPodWeight +
+		device.CPU*w.cpuRequestRatio*drainCostCPUWeight +
+		device.Memory*w.memRequestRatio*drainCostMemWeight
+}
+
+// less 比较两台设备的排空代价：有节点数据的设备优先于没有数据的设备，代价低的优先。
+// decided 为 false 时两台设备无法区分，由调用方按其他条件排序
+func (c drainCosts) less(a, b int) (less, decided bool) {
+	costA, knownA := c[a]
+	costB, knownB := c[b]
+	switch {
+	case knownA && knownB && costA != costB:
+		return costA < costB, true
+	case knownA != knownB:
+		return knownA, true
+	default:
+		return false, false
+	}
+}
+
+// cheaperToDrain 判断设备 a 的排空代价是否低于设备 b
+func cheaperToDrain(costs drainCosts, a, b int) bool {
+	less, _ := costs.less(a, b)
+	return less
+}
+
+// sortByDrainCost 按排空代价稳定排序，costs 为空时保持原顺序
+func sortByDrainCost(devices []DeviceResponse, costs drainCosts) {
+	if len(costs) == 0 {
+		return
+	}
+	sort.SliceStable(devices, func(i, j int) bool {
+		return cheaperToDrain(costs, devices[i].ID, devices[j].ID)
+	})
+}
+
+// drainProtectionRules 已配置的排空保护规则
+type drainProtectionRules []portal.DrainProtectionRule
+
+// match 返回标签或污点命中的规则，未命中时返回 nil
+func (r drainProtectionRules) match(kind, key, value string) *portal.DrainProtectionRule {
+	for i := range r {
+		if r[i].Kind == kind && r[i].Key == key && (r[i].Value == "" || r[i].Value == value) {
+			return &r[i]
+		}
+	}
+	return nil
+}
+
+// loadDrainProtectionRules 获取所有排空保护规则
+func loadDrainProtectionRules(db *gorm.DB) (drainProtectionRules, error) {
+	var rules []portal.DrainProtectionRule
+	if err := db.Order("id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get drain protection rules: %w", err)
+	}
+	return rules, nil
+}
+
+// evaluateDrainCosts 计算目标集群内出池候选设备的排空代价，并排除带有禁止驱逐标签/污点的设备。
+// 负载按资源池最新快照和设备容量估算，受保护标签/污点按当天采集的节点数据匹配。
+// 返回剩余候选设备、排空代价以及被排除设备的原因；其他集群的设备原样保留，由后续筛选处理
+func evaluateDrainCosts(db *gorm.DB, rules drainProtectionRules, devices []DeviceResponse, clusterID int, resourceType string) ([]DeviceResponse, drainCosts, map[int]string, error) {
+	costs := make(drainCosts)
+	forbidden := make(map[int]string)
+
+	nodeNames := make([]string, 0, len(devices))
+	for _, device := range devices {
+		if device.ClusterID == clusterID {
+			nodeNames = append(nodeNames, strings.ToLower(device.CICode))
+		}
+	}
+	if len(nodeNames) == 0 {
+		return devices, costs, forbidden, nil
+	}
+
+	workload, err := loadPoolWorkload(db, clusterID, resourceType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var nodes []portal.K8sNode
+	if err := db.Select("id", "nodename").
+		Where("LOWER(nodename) IN ?", nodeNames).
+		Where("status != ? OR status IS NULL", "Offline").
+		Find(&nodes).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get node drain info: %w", err)
+	}
+	nodeMap := make(map[string]*portal.K8sNode, len(nodes))
+	nodeIDs := make([]int, 0, len(nodes))
+	for i := range nodes {
+		nodeMap[strings.ToLower(nodes[i].NodeName)] = &nodes[i]
+		nodeIDs = append(nodeIDs, nodes[i].ID)
+	}
+
+	hits, err := matchDrainProtectionRules(db, rules, nodeIDs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	available := make([]DeviceResponse, 0, len(devices))
+	for _, device := range devices {
+		if device.ClusterID != clusterID {
+			available = append(available, device)
+			continue
+		}
+		node, ok := nodeMap[strings.ToLower(device.CICode)]
+		if !ok && workload == nil {
+			available = append(available, device)
+			continue
+		}
+
+		var protected int
+		var forbidReasons []string
+		var nodeHits []drainProtectionHit
+		if ok {
+			nodeHits = hits[node.ID]
+		}
+		for _, hit := range nodeHits {
+			if hit.action == portal.DrainProtectionActionForbid {
+				forbidReasons = append(forbidReasons, hit.description)
+			} else {
+				protected++
+			}
+		}
+		if len(forbidReasons) > 0 {
+			forbidden[device.ID] = "节点带有禁止驱逐的" + strings.Join(forbidReasons, "、")
+			continue
+		}
+
+		costs[device.ID] = workload.cost(device) + float64(protected)*drainCostProtectedWeight
+		available = append(available, device)
+	}
+	return available, costs, forbidden, nil
+}
+
+// drainProtectionHit 节点命中的排空保护规则
+type drainProtectionHit struct {
+	action      string
+	description string // 例如「标签 app=db」
+}
+
+// matchDrainProtectionRules 按当天采集的节点标签和污点匹配排空保护规则，返回节点ID -> 命中的规则
+func matchDrainProtectionRules(db *gorm.DB, rules drainProtectionRules, nodeIDs []int) (map[int][]drainProtectionHit, error) {
+	hits := make(map[int][]drainProtectionHit)
+	if len(rules) == 0 || len(nodeIDs) == 0 {
+		return hits, nil
+	}
+
+	todayStart := now.New(time.Now()).BeginningOfDay()
+	todayEnd := now.New(time.Now()).EndOfDay()
+
+	var labels []portal.K8sNodeLabel
+	if err := db.Select("node_id", "key", "value").
+		Where("node_id IN ? AND created_at BETWEEN ? AND ?", nodeIDs, todayStart, todayEnd).
+		Find(&labels).Error; err != nil {
+		return nil, fmt.Errorf("failed to get node labels: %w", err)
+	}
+	for _, label := range labels {
+		if rule := rules.match(portal.DrainProtectionKindLabel, label.Key, label.Value); rule != nil {
+			hits[label.NodeID] = append(hits[label.NodeID], drainProtectionHit{action: rule.Action, description: fmt.Sprintf("标签 %s=%s", label.Key, label.Value)})
+		}
+	}
+
+	var taints []portal.K8sNodeTaint
+	if err := db.Select("node_id", "key", "value").
+		Where("node_id IN ? AND created_at BETWEEN ? AND ?", nodeIDs, todayStart, todayEnd).
+		Find(&taints).Error; err != nil {
+		return nil, fmt.Errorf("failed to get node taints: %w", err)
+	}
+	for _, taint := range taints {
+		if rule := rules.match(portal.DrainProtectionKindTaint, taint.Key, taint.Value); rule != nil {
+			hits[taint.NodeID] = append(hits[taint.NodeID], drainProtectionHit{action: rule.Action, description: fmt.Sprintf("污点 %s=%s", taint.Key, taint.Value)})
+		}
+	}
+	return hits, nil
+}
+
+// ListDrainProtectionRules 获取所有排空保护规则
+func (s *ElasticScalingService) ListDrainProtectionRules() ([]DrainProtectionRuleDTO, error) {
+	rules, err := loadDrainProtectionRules(s.db)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]DrainProtectionRuleDTO, 0, len(rules))
+	for i := range rules {
+		result = append(result, toDrainProtectionRuleDTO(&rules[i]))
+	}
+	return result, nil
+}
+
+// CreateDrainProtectionRule 创建排空保护规则
+func (s *ElasticScalingService) CreateDrainProtectionRule(dto DrainProtectionRuleDTO) (int, error) {
+	rule := newDrainProtectionRuleModel(&dto)
+	if err := s.validateDrainProtectionRule(&rule, 0); err != nil {
+		return 0, err
+	}
+	rule.CreatedBy = dto.CreatedBy
+	if err := s.db.Create(&rule).Error; err != nil {
+		return 0, err
+	}
+	return rule.ID, nil
+}
+
+// UpdateDrainProtectionRule 更新排空保护规则
+func (s *ElasticScalingService) UpdateDrainProtectionRule(id int, dto DrainProtectionRuleDTO) error {
+	var existing portal.DrainProtectionRule
+	if err := s.db.First(&existing, id).Error; err != nil {
+		return HandleDBError(err, "排空保护规则", id)
+	}
+
+	rule := newDrainProtectionRuleModel(&dto)
+	if err := s.validateDrainProtectionRule(&rule, id); err != nil {
+		return err
+	}
+	rule.BaseModel = existing.BaseModel
+	rule.CreatedBy = existing.CreatedBy
+	return s.db.Save(&rule).Error
+}
+
+// DeleteDrainProtectionRule 删除排空保护规则
+func (s *ElasticScalingService) DeleteDrainProtectionRule(id int) error {
+	result := s.db.Delete(&portal.DrainProtectionRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return NewNotFoundError("排空保护规则", id)
+	}
+	return nil
+}
+
+// validateDrainProtectionRule 校验排空保护规则，同一标签/污点的 key 和 value 只能配置一条规则
+func (s *ElasticScalingService) validateDrainProtectionRule(rule *portal.DrainProtectionRule, id int) error {
+	if rule.Kind != portal.DrainProtectionKindLabel && rule.Kind != portal.DrainProtectionKindTaint {
+		return NewBadRequestError("规则类型必须为 label 或 taint")
+	}
+	if rule.Key == "" {
+		return NewBadRequestError("标签或污点的 key 不能为空")
+	}
+	if rule.Action != portal.DrainProtectionActionForbid && rule.Action != portal.DrainProtectionActionPenalize {
+		return NewBadRequestError("处理方式必须为 forbid 或 penalize")
+	}
+
+	var count int64
+	if err := s.db.Model(&portal.DrainProtectionRule{}).
+		Where("kind = ? AND `key` = ? AND value = ? AND id <> ?", rule.Kind, rule.Key, rule.Value, id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return NewBadRequestError(fmt.Sprintf("%s %s=%s 已存在排空保护规则", rule.Kind, rule.Key, rule.Value))
+	}
+	return nil
+}
+
+// newDrainProtectionRuleModel 根据DTO构建排空保护规则模型
+func newDrainProtectionRuleModel(dto *DrainProtectionRuleDTO) portal.DrainProtectionRule {
+	return portal.DrainProtectionRule{
+		Kind:        dto.Kind,
+		Key:         strings.TrimSpace(dto.Key),
+		Value:       strings.TrimSpace(dto.Value),
+		Action:      dto.Action,
+		Description: dto.Description,
+	}
+}
+
+// toDrainProtectionRuleDTO 将排空保护规则转换为DTO
+func toDrainProtectionRuleDTO(rule *portal.DrainProtectionRule) DrainProtectionRuleDTO {
+	return DrainProtectionRuleDTO{
+		ID:          rule.ID,
+		Kind:        rule.Kind,
+		Key:         rule.Key,
+		Value:       rule.Value,
+		Action:      rule.Action,
+		Description: rule.Description,
+		CreatedBy:   rule.CreatedBy,
+		CreatedAt:   time.Time(rule.CreatedAt),
+		UpdatedAt:   time.Time(rule.UpdatedAt),
+	}
+}
@@ -0,0 +1,130 @@
+package es
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitSelectionPrefersCheapToDrainNodes(t *testing.T) {
+	s, db := newMatchingTestService(t)
+	// 资源池最新快照：每核2个Pod，CPU 和内存 request 均占容量的一半
+	require.NoError(t, db.Create(&portal.ResourceSnapshot{ClusterID: 1, ResourceType: "total", CpuCapacity: 100, CpuRequest: 50, MemoryCapacity: 400, MemRequest: 200, PodCount: 200}).Error)
+	// 前一天的快照负载更高，不参与计算
+	require.NoError(t, db.Create(&portal.ResourceSnapshot{
+		BaseModel: portal.BaseModel{CreatedAt: portal.NavyTime(time.Now().Add(-24 * time.Hour))},
+		ClusterID: 1, ResourceType: "total", CpuCapacity: 100, CpuRequest: 100, MemoryCapacity: 400, MemRequest: 400, PodCount: 1000,
+	}).Error)
+
+	// device-1 容量最大、估算负载最多；device-2 带禁止驱逐标签；device-3 带受保护污点；device-4、device-5 排空代价低
+	capacities := [][2]float64{{40, 160}, {10, 40}, {10, 40}, {10, 40}, {8, 32}}
+	for i, capacity := range capacities {
+		require.NoError(t, db.Create(&portal.Device{
+			BaseModel: portal.BaseModel{ID: i + 1},
+			CICode:    fmt.Sprintf("DEVICE-%d", i+1),
+			IDC:       "idc-a",
+			Cluster:   "cluster-a",
+			ClusterID: 1,
+			CPU:       capacity[0],
+			Memory:    capacity[1],
+		}).Error)
+	}
+	// 只有采集到标签/污点的节点有节点记录，其余设备按快照估算代价
+	protectedNodes := []portal.K8sNode{{NodeName: "device-2", K8sClusterID: 1}, {NodeName: "device-3", K8sClusterID: 1}}
+	require.NoError(t, db.Create(&protectedNodes).Error)
+	require.NoError(t, db.Create(&portal.K8sNodeLabel{NodeID: protectedNodes[0].ID, Key: "app", Value: "database"}).Error)
+	require.NoError(t, db.Create(&portal.K8sNodeTaint{NodeID: protectedNodes[1].ID, Key: "dedicated", Value: "middleware", Effect: "NoSchedule"}).Error)
+	require.NoError(t, db.Create(&portal.DrainProtectionRule{Kind: portal.DrainProtectionKindLabel, Key: "app", Value: "database", Action: portal.DrainProtectionActionForbid}).Error)
+	require.NoError(t, db.Create(&portal.DrainProtectionRule{Kind: portal.DrainProtectionKindTaint, Key: "dedicated", Action: portal.DrainProtectionActionPenalize}).Error)
+
+	createIDCTemplate(t, db, 1, "idc-a")
+	require.NoError(t, db.Create(&portal.ResourcePoolDeviceMatchingPolicy{Name: "exit", ResourcePoolType: "total", ActionType: TriggerActionPoolExit, QueryTemplateID: 1, Status: "enabled"}).Error)
+
+	strategy := &portal.ElasticScalingStrategy{BaseModel: portal.BaseModel{ID: 1}, ThresholdTriggerAction: TriggerActionPoolExit}
+	result, err := s.collectMatchedDevices(&evaluationContext{}, strategy, 1, "total", "", "", -18, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{5, 4}, result.SelectedDeviceIDs)
+
+	// 代价 = CPU × 2（Pod）+ CPU × 0.5 + 内存 × 0.5 × 0.25
+	policyTrace := result.Trace.Policies[0]
+	assert.Equal(t, map[int]float64{5: 24, 4: 30}, policyTrace.DrainCosts)
+	reasons := make(map[int]RejectedDeviceTrace)
+	for _, rejected := range policyTrace.RejectedDevices {
+		reasons[rejected.DeviceID] = rejected
+	}
+	assert.Equal(t, MatchingRejectDoNotEvict, reasons[2].Reason)
+	assert.Contains(t, reasons[2].Detail, "标签 app=database")
+	assert.Equal(t, MatchingRejectNotSelected, reasons[1].Reason)
+	assert.Equal(t, MatchingRejectNotSelected, reasons[3].Reason)
+
+	t.Run("penalized and larger nodes cost more", func(t *testing.T) {
+		devices := []service.DeviceResponse{
+			{ID: 1, CICode: "DEVICE-1", ClusterID: 1, CPU: 40, Memory: 160},
+			{ID: 3, CICode: "DEVICE-3", ClusterID: 1, CPU: 10, Memory: 40},
+		}
+		rules, err := loadDrainProtectionRules(db)
+		require.NoError(t, err)
+		_, costs, _, err := evaluateDrainCosts(db, rules, devices, 1, "total")
+		require.NoError(t, err)
+		assert.Equal(t, drainCosts{1: 120, 3: 50}, costs)
+	})
+
+	t.Run("no snapshot and no node data leaves the cost unknown", func(t *testing.T) {
+		devices := []service.DeviceResponse{{ID: 4, CICode: "DEVICE-4", ClusterID: 1, CPU: 10, Memory: 40}}
+		_, costs, _, err := evaluateDrainCosts(db, nil, devices, 1, "compute")
+		require.NoError(t, err)
+		assert.Empty(t, costs)
+	})
+}
+
+func TestGreedyExitOrdersByDrainCost(t *testing.T) {
+	s, _ := newTestService(t)
+	devices := []service.DeviceResponse{
+		{ID: 1, CPU: 10, Memory: 10},
+		{ID: 2, CPU: 30, Memory: 30},
+		{ID: 3, CPU: 10, Memory: 10},
+		{ID: 4, CPU: 5, Memory: 5},
+	}
+	// 设备2代价最低但移除后超过需求，继续尝试后续设备；设备4没有节点数据，排在最后
+	costs := drainCosts{1: 8, 2: 1, 3: 4}
+	selected := s.greedySelectDevices(devices, -20, 0, TriggerActionPoolExit, costs)
+	assert.Equal(t, []int{3, 1}, selected)
+
+	// 没有排空代价时保持按CPU升序选择
+	selected = s.greedySelectDevices(devices, -20, 0, TriggerActionPoolExit, nil)
+	require.Len(t, selected, 2)
+	assert.Equal(t, 4, selected[0])
+}
+
+func TestDrainProtectionRuleValidation(t *testing.T) {
+	s, _ := newTestService(t)
+
+	_, err := s.CreateDrainProtectionRule(DrainProtectionRuleDTO{Kind: "annotation", Key: "app", Action: portal.DrainProtectionActionForbid})
+	assert.True(t, service.IsBadRequest(err))
+	_, err = s.CreateDrainProtectionRule(DrainProtectionRuleDTO{Kind: portal.DrainProtectionKindLabel, Key: " ", Action: portal.DrainProtectionActionForbid})
+	assert.True(t, service.IsBadRequest(err))
+	_, err = s.CreateDrainProtectionRule(DrainProtectionRuleDTO{Kind: portal.DrainProtectionKindLabel, Key: "app", Action: "skip"})
+	assert.True(t, service.IsBadRequest(err))
+
+	id, err := s.CreateDrainProtectionRule(DrainProtectionRuleDTO{Kind: portal.DrainProtectionKindLabel, Key: " app ", Value: "database", Action: portal.DrainProtectionActionForbid})
+	require.NoError(t, err)
+	_, err = s.CreateDrainProtectionRule(DrainProtectionRuleDTO{Kind: portal.DrainProtectionKindLabel, Key: "app", Value: "database", Action: portal.DrainProtectionActionPenalize})
+	assert.True(t, service.IsBadRequest(err), "duplicate label rule")
+
+	rules, err := s.ListDrainProtectionRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "app", rules[0].Key)
+
+	require.NoError(t, s.UpdateDrainProtectionRule(id, DrainProtectionRuleDTO{Kind: portal.DrainProtectionKindLabel, Key: "app", Action: portal.DrainProtectionActionPenalize}))
+	assert.True(t, service.IsNotFound(s.UpdateDrainProtectionRule(id+1, DrainProtectionRuleDTO{Kind: portal.DrainProtectionKindLabel, Key: "app", Action: portal.DrainProtectionActionPenalize})))
+	require.NoError(t, s.DeleteDrainProtectionRule(id))
+	assert.True(t, service.IsNotFound(s.DeleteDrainProtectionRule(id)))
+}
@@ -19,8 +19,14 @@ type StrategyDTO struct {
 	MemoryThresholdType    *string  `json:"memoryThresholdType"` // usage 或 allocated
 	MemoryTargetValue      *float64 `json:"memoryTargetValue"`   // 动作执行后内存目标使用率
 	ConditionLogic         string   `json:"conditionLogic"`      // AND 或 OR
-	DurationMinutes        int      `json:"durationMinutes"`
+	DurationMinutes        int      `json:"durationMinutes"`     // 持续时间，单位由 durationUnit 决定
+	DurationUnit           string   `json:"durationUnit"`        // day、hour 或 minute，为空时兼容历史配置
 	CooldownMinutes        int      `json:"cooldownMinutes"`
+	HysteresisMinutes      int      `json:"hysteresisMinutes"`   // 反向订单防抖窗口（分钟），为0时不启用反向订单防抖
+	ExitCeilingLevel       string   `json:"exitCeilingLevel"`    // 出池护栏上限：warning 或 critical，为空时为 warning
+	TriggerMode            string   `json:"triggerMode"`         // threshold 或 forecast，为空时为 threshold
+	ForecastMethod         string   `json:"forecastMethod"`      // 预测方法：linear 或 holt
+	ForecastHorizonDays    int      `json:"forecastHorizonDays"` // 预测窗口（天）
 
 	ResourceTypes string    `json:"resourceTypes"` // 资源类型列表，逗号分隔
 	Status        string    `json:"status"`        // enabled 或 disabled
@@ -28,6 +34,37 @@ type StrategyDTO struct {
 	CreatedAt     time.Time `json:"createdAt,omitempty"`
 	UpdatedAt     time.Time `json:"updatedAt,omitempty"`
 	ClusterIDs    []int     `json:"clusterIds"` // 关联的集群ID列表
+
+	ClusterOverrides []StrategyClusterOverrideDTO `json:"clusterOverrides,omitempty"` // 集群级覆盖配置，未设置的字段使用策略默认值
+}
+
+// StrategyClusterOverrideDTO 策略在单个集群上的覆盖配置，字段为空时使用策略默认值
+type StrategyClusterOverrideDTO struct {
+	ClusterID            int      `json:"clusterId"`
+	CPUThresholdValue    *float64 `json:"cpuThresholdValue,omitempty"`
+	CPUTargetValue       *float64 `json:"cpuTargetValue,omitempty"`
+	MemoryThresholdValue *float64 `json:"memoryThresholdValue,omitempty"`
+	MemoryTargetValue    *float64 `json:"memoryTargetValue,omitempty"`
+	DurationMinutes      *int     `json:"durationMinutes,omitempty"` // 单位沿用策略的 durationUnit
+	CooldownMinutes      *int     `json:"cooldownMinutes,omitempty"`
+	ResourceTypes        *string  `json:"resourceTypes,omitempty"`
+}
+
+// StrategyClusterConfigDTO 策略在单个集群上合并覆盖配置后的生效配置
+type StrategyClusterConfigDTO struct {
+	ClusterID            int      `json:"clusterId"`
+	ClusterName          string   `json:"clusterName"`
+	CPUThresholdValue    *float64 `json:"cpuThresholdValue"`
+	CPUThresholdType     *string  `json:"cpuThresholdType"`
+	CPUTargetValue       *float64 `json:"cpuTargetValue"`
+	MemoryThresholdValue *float64 `json:"memoryThresholdValue"`
+	MemoryThresholdType  *string  `json:"memoryThresholdType"`
+	MemoryTargetValue    *float64 `json:"memoryTargetValue"`
+	DurationMinutes      int      `json:"durationMinutes"`
+	DurationUnit         string   `json:"durationUnit"`
+	CooldownMinutes      int      `json:"cooldownMinutes"`
+	ResourceTypes        string   `json:"resourceTypes"`
+	OverriddenFields     []string `json:"overriddenFields"` // 被集群覆盖的字段（JSON字段名）
 }
 
 // StrategyListItemDTO 策略列表项
@@ -44,8 +81,12 @@ type StrategyListItemDTO struct {
 	MemoryTargetValue      *float64  `json:"memoryTargetValue"`
 	ConditionLogic         string    `json:"conditionLogic"`
 	ResourceTypes          string    `json:"resourceTypes"`
-	DurationMinutes        int       `json:"durationMinutes"` // 持续时间（分钟）
+	DurationMinutes        int       `json:"durationMinutes"` // 持续时间，单位由 durationUnit 决定
+	DurationUnit           string    `json:"durationUnit"`
 	CooldownMinutes        int       `json:"cooldownMinutes"`
+	HysteresisMinutes      int       `json:"hysteresisMinutes"`
+	TriggerMode            string    `json:"triggerMode"`
+	ForecastHorizonDays    int       `json:"forecastHorizonDays"`
 	Status                 string    `json:"status"`
 	CreatedAt              time.Time `json:"createdAt"`
 	UpdatedAt              time.Time `json:"updatedAt"`
@@ -55,6 +96,7 @@ type StrategyListItemDTO struct {
 // StrategyDetailDTO 策略详情
 type StrategyDetailDTO struct {
 	StrategyDTO
+	ClusterConfigs   []StrategyClusterConfigDTO    `json:"clusterConfigs"` // 各集群的生效配置
 	ExecutionHistory []StrategyExecutionHistoryDTO `json:"executionHistory"`
 	RelatedOrders    []OrderListItemDTO            `json:"relatedOrders"`
 }
@@ -70,6 +112,11 @@ type StrategyExecutionHistoryDTO struct {
 	Result         string    `json:"result"`
 	OrderID        *int      `json:"orderId"`
 	Reason         string    `json:"reason"`
+
+	ForecastInput         string     `json:"forecastInput,omitempty"`         // 预测输入与结果（JSON）
+	ProjectedCrossingDate *time.Time `json:"projectedCrossingDate,omitempty"` // 预计越过阈值的时间
+
+	MatchingTrace *DeviceMatchingTrace `json:"matchingTrace,omitempty"` // 设备匹配后被拦截时的匹配过程
 }
 
 // StrategyExecutionHistoryDetailDTO 策略执行历史详情（包含策略名和集群名）
@@ -87,6 +134,118 @@ type StrategyExecutionHistoryDetailDTO struct {
 	OrderID        *int      `json:"orderId"`
 	HasOrder       bool      `json:"hasOrder"`
 	Reason         string    `json:"reason"`
+
+	ForecastInput         string     `json:"forecastInput,omitempty"`
+	ProjectedCrossingDate *time.Time `json:"projectedCrossingDate,omitempty"`
+
+	MatchingTrace *DeviceMatchingTrace `json:"matchingTrace,omitempty"`
+}
+
+// StrategyEvaluateRequestDTO 手动立即评估策略请求
+type StrategyEvaluateRequestDTO struct {
+	ClusterID    *int   `json:"clusterId"`    // 仅评估指定集群，为空时评估策略关联的所有集群
+	ResourceType string `json:"resourceType"` // 仅评估指定资源类型，为空时评估策略配置的所有资源类型
+	Force        bool   `json:"force"`        // 忽略冷却期
+}
+
+// StrategyEvaluateResultDTO 手动立即评估策略的结果
+type StrategyEvaluateResultDTO struct {
+	StrategyID   int                           `json:"strategyId"`
+	StrategyName string                        `json:"strategyName"`
+	Force        bool                          `json:"force"`
+	Results      []StrategyExecutionHistoryDTO `json:"results"` // 各集群+资源池写入的执行历史
+}
+
+// ScalingBudgetDTO 自动伸缩预算
+// 集群和资源池均为空时为全局预算；UsedDevices/UsedOrders 为当前周期已用量，仅在查询时返回
+type ScalingBudgetDTO struct {
+	ID               int       `json:"id"`
+	Name             string    `json:"name"`
+	ClusterID        *int      `json:"clusterId"`        // 限定集群，为空时对所有集群生效
+	ResourcePoolType string    `json:"resourcePoolType"` // 限定资源池类型，为空时对所有资源池生效
+	ActionType       string    `json:"actionType"`       // pool_entry 或 pool_exit
+	Period           string    `json:"period"`           // daily 或 weekly
+	MaxDevices       int       `json:"maxDevices"`       // 周期内最大设备数，为0时不限制
+	MaxOrders        int       `json:"maxOrders"`        // 周期内最大订单数，为0时不限制
+	Status           string    `json:"status"`
+	CreatedBy        string    `json:"createdBy"`
+	UsedDevices      int       `json:"usedDevices"`
+	UsedOrders       int       `json:"usedOrders"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// ResourcePoolCompatibilityRuleDTO 资源池硬件兼容性规则
+// 入池设备匹配时作为硬性过滤条件；各条件为空时不限制，型号和操作系统版本按前缀匹配，均不区分大小写
+type ResourcePoolCompatibilityRuleDTO struct {
+	ID               int       `json:"id"`
+	ResourcePoolType string    `json:"resourcePoolType"`
+	ArchTypes        []string  `json:"archTypes"`       // 允许的CPU架构
+	GPURequirement   string    `json:"gpuRequirement"`  // required 或 forbidden，为空时不限制
+	MinDiskCount     int       `json:"minDiskCount"`    // 最少磁盘数
+	MinNetworkSpeed  int       `json:"minNetworkSpeed"` // 最低网卡速率（Mb/s）
+	Models           []string  `json:"models"`          // 允许的型号前缀
+	OSVersions       []string  `json:"osVersions"`      // 允许的操作系统版本前缀
+	Description      string    `json:"description"`
+	CreatedBy        string    `json:"createdBy"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// DrainProtectionRuleDTO 排空保护规则
+// 出池设备匹配时，节点带有 forbid 规则命中的标签/污点时不参与出池，命中 penalize 规则时增加排空代价
+type DrainProtectionRuleDTO struct {
+	ID          int       `json:"id"`
+	Kind        string    `json:"kind"` // label 或 taint
+	Key         string    `json:"key"`
+	Value       string    `json:"value"`  // 为空时匹配任意值
+	Action      string    `json:"action"` // forbid 或 penalize
+	Description string    `json:"description"`
+	CreatedBy   string    `json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// StrategySimulationRequestDTO 策略模拟（回放）请求
+// StrategyID 与 Strategy 二选一：前者回放已保存的策略，后者回放请求中内联的策略配置
+type StrategySimulationRequestDTO struct {
+	StrategyID *int         `json:"strategyId"`                   // 已保存的策略ID
+	Strategy   *StrategyDTO `json:"strategy"`                     // 内联策略配置
+	ClusterIDs []int        `json:"clusterIds"`                   // 回放的集群ID列表，为空时使用策略关联的集群
+	StartDate  string       `json:"startDate" binding:"required"` // 开始日期（YYYY-MM-DD）
+	EndDate    string       `json:"endDate" binding:"required"`   // 结束日期（YYYY-MM-DD）
+}
+
+// StrategySimulationResultDTO 策略模拟结果
+type StrategySimulationResultDTO struct {
+	StrategyID        int                          `json:"strategyId,omitempty"`
+	StrategyName      string                       `json:"strategyName"`
+	StartDate         string                       `json:"startDate"`
+	EndDate           string                       `json:"endDate"`
+	OrderCount        int                          `json:"orderCount"`        // 模拟期间将会生成的订单数
+	CooldownSkipCount int                          `json:"cooldownSkipCount"` // 因冷却期跳过的次数
+	BlockedCount      int                          `json:"blockedCount"`      // 因冻结期、防抖、出池护栏或预算拦截的次数
+	Points            []StrategySimulationPointDTO `json:"points"`            // 触发点列表（按时间升序）
+}
+
+// StrategySimulationPointDTO 策略模拟中的单个触发点
+type StrategySimulationPointDTO struct {
+	Date               string      `json:"date"` // 回放时间点（按天模式为YYYY-MM-DD，分钟/小时模式为YYYY-MM-DD HH:mm:ss）
+	ClusterID          int         `json:"clusterId"`
+	ClusterName        string      `json:"clusterName"`
+	ResourceType       string      `json:"resourceType"`
+	Result             string      `json:"result"` // 与策略执行结果一致，如 order_created、skipped_cooldown、skipped_freeze、budget_exceeded
+	ConsecutiveDays    int         `json:"consecutiveDays"`
+	SustainedMinutes   int         `json:"sustainedMinutes"`            // 分钟/小时模式下持续满足条件的分钟数
+	ProjectedCrossing  string      `json:"projectedCrossing,omitempty"` // 预测模式下预计越过阈值的日期
+	TriggeredValue     string      `json:"triggeredValue"`
+	ThresholdValue     string      `json:"thresholdValue"`
+	CPUDelta           float64     `json:"cpuDelta"`
+	MemDelta           float64     `json:"memDelta"`
+	CandidateCount     int         `json:"candidateCount"`
+	CandidateDeviceIDs []int       `json:"candidateDeviceIds"`
+	SelectedDevices    []DeviceDTO `json:"selectedDevices"`
+	Reason             string      `json:"reason"`
 }
 
 // OrderDTO 弹性伸缩订单DTO
@@ -118,6 +277,10 @@ type OrderDTO struct {
 	ExtraInfo              map[string]interface{} `json:"extraInfo,omitempty"` // 额外信息，用于存储维护原因等
 	StrategyTriggeredValue string                 `json:"strategyTriggeredValue,omitempty"`
 	StrategyThresholdValue string                 `json:"strategyThresholdValue,omitempty"`
+	MatchingTrace          *DeviceMatchingTrace   `json:"-"`                        // 自动伸缩的设备匹配过程，仅由策略评估写入
+	CandidateCount         int                    `json:"candidateCount,omitempty"` // 设备匹配时查询到的候选设备总数
+	GuardrailTrimmed       bool                   `json:"guardrailTrimmed"`         // 出池设备是否被出池护栏裁剪
+	Version                int                    `json:"version"`                  // 订单版本号，作为状态更新的 ETag
 }
 
 // OrderListItemDTO 订单列表项
@@ -141,7 +304,8 @@ type OrderListItemDTO struct {
 // OrderDetailDTO 订单详情
 type OrderDetailDTO struct {
 	OrderDTO
-	Devices []DeviceDTO `json:"devices"` // 涉及设备的详细信息
+	Devices       []DeviceDTO          `json:"devices"`                 // 涉及设备的详细信息
+	MatchingTrace *DeviceMatchingTrace `json:"matchingTrace,omitempty"` // 设备匹配过程，手动创建的订单为空
 }
 
 // DeviceDTO 设备DTO
@@ -159,6 +323,9 @@ type DeviceDTO struct {
 	IsSpecial    bool    `json:"isSpecial"`
 	FeatureCount int     `json:"featureCount"`
 	OrderStatus  string  `json:"orderStatus,omitempty"` // 在订单中的状态
+
+	MatchingPolicyID   int    `json:"matchingPolicyId,omitempty"`   // 选中该设备的匹配策略ID
+	MatchingPolicyName string `json:"matchingPolicyName,omitempty"` // 选中该设备的匹配策略名称
 }
 
 // DashboardStatsDTO 工作台概览统计
@@ -4,12 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"navy-ng/models/portal"
-	. "navy-ng/server/portal/internal/service"
-	"sort"
 	"strings"
 	"time"
 
-	"github.com/jinzhu/now"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -52,8 +49,21 @@ const (
 // 该函数是策略评估的入口点，通常由定时任务调用。
 func (s *ElasticScalingService) EvaluateStrategies() error {
 	s.logger.Info(logEvaluatingStrategies)
+	return s.evaluateEnabledStrategies(&evaluationContext{StatusSource: portal.OrderStatusSourceMonitor}, s.db.Where(queryStatusEnabled, portal.StrategyStatusEnabled))
+}
+
+// EvaluateSlidingWindowStrategies 仅评估按分钟/小时滑动窗口评估的启用策略。
+// 由更高频率的定时任务调用，使突发负载在当天即可触发伸缩。
+func (s *ElasticScalingService) EvaluateSlidingWindowStrategies() error {
+	s.logger.Info("Starting to evaluate enabled sliding window strategies")
+	return s.evaluateEnabledStrategies(&evaluationContext{StatusSource: portal.OrderStatusSourceMonitor}, s.db.Where(queryStatusEnabled, portal.StrategyStatusEnabled).
+		Where("duration_unit IN ?", []string{DurationUnitMinute, DurationUnitHour}))
+}
+
+// evaluateEnabledStrategies 查询并逐个评估策略
+func (s *ElasticScalingService) evaluateEnabledStrategies(evalCtx *evaluationContext, query *gorm.DB) error {
 	var strategies []portal.ElasticScalingStrategy
-	if err := s.db.Where(queryStatusEnabled, portal.StrategyStatusEnabled).Find(&strategies).Error; err != nil {
+	if err := query.Find(&strategies).Error; err != nil {
 		s.logger.Error(logFailedToFetchStrategies, zap.Error(err))
 		return err
 	}
@@ -61,7 +71,7 @@ func (s *ElasticScalingService) EvaluateStrategies() error {
 
 	for _, strategy := range strategies {
 		// 为每个策略单独评估，记录错误但继续处理其他策略
-		if err := s.evaluateStrategy(&strategy); err != nil {
+		if err := s.evaluateStrategy(evalCtx, &strategy); err != nil {
 			s.logger.Error("Error evaluating strategy",
 				zap.Int("strategyID", strategy.ID),
 				zap.String("strategyName", strategy.Name),
@@ -73,7 +83,7 @@ func (s *ElasticScalingService) EvaluateStrategies() error {
 
 // evaluateStrategy 评估单个策略的完整流程。
 // 它负责锁、数据获取和触发评估。冷却期检查已移至资源池级别。
-func (s *ElasticScalingService) evaluateStrategy(strategy *portal.ElasticScalingStrategy) error {
+func (s *ElasticScalingService) evaluateStrategy(evalCtx *evaluationContext, strategy *portal.ElasticScalingStrategy) error {
 	s.logger.Info("Starting single strategy evaluation", zap.Int("strategyID", strategy.ID), zap.String("strategyName", strategy.Name))
 
 	// 1. 尝试获取分布式锁
@@ -101,127 +111,163 @@ func (s *ElasticScalingService) evaluateStrategy(strategy *portal.ElasticScaling
 
 	// 3. 循环评估每个关联
 	for _, assoc := range associations {
-		s.evaluateAssociation(strategy, assoc.ClusterID)
+		s.evaluateAssociation(evalCtx, strategy, assoc.ClusterID)
 	}
 
 	return nil
 }
 
 // evaluateAssociation 评估策略与单个集群的关联。
-func (s *ElasticScalingService) evaluateAssociation(strategy *portal.ElasticScalingStrategy, clusterID int) {
+// 集群级覆盖配置（阈值、目标值、持续时间、冷却时间、资源类型）会合并到策略默认配置上。
+func (s *ElasticScalingService) evaluateAssociation(evalCtx *evaluationContext, strategy *portal.ElasticScalingStrategy, clusterID int) {
+	strategy = s.effectiveStrategyForCluster(strategy, clusterID)
 	resourceTypes := parseResourceTypes(strategy.ResourceTypes)
 
 	for _, resourceType := range resourceTypes {
-		s.logger.Info("Evaluating for resource type",
+		s.evaluateResourcePool(evalCtx, strategy, clusterID, resourceType)
+	}
+}
+
+// evaluateResourcePool 评估策略（已合并集群覆盖配置）在单个集群+资源池上的触发情况，
+// 满足条件时进行设备匹配并创建订单，评估结果记录到策略执行历史。
+// evalCtx 携带入口的评估选项，每个资源池基于它派生独立的上下文。
+func (s *ElasticScalingService) evaluateResourcePool(evalCtx *evaluationContext, strategy *portal.ElasticScalingStrategy, clusterID int, resourceType string) {
+	evalCtx = evalCtx.derive()
+	s.logger.Info("Evaluating for resource type",
+		zap.Int("strategyID", strategy.ID),
+		zap.Int("clusterID", clusterID),
+		zap.String("resourceType", resourceType))
+
+	// 变更冻结期内不评估，也不创建订单
+	if frozen, reason := s.checkFreezeCalendar(clusterID, time.Now()); frozen {
+		s.logger.Info(reason,
 			zap.Int("strategyID", strategy.ID),
 			zap.Int("clusterID", clusterID),
 			zap.String("resourceType", resourceType))
+		currentTime := portal.NavyTime(time.Now())
+		s.recordStrategyExecution(evalCtx, strategy.ID, clusterID, resourceType, StrategyExecutionResultSkippedFreeze, nil, reason, "", "", &currentTime)
+		return
+	}
 
-		// 检查该集群+资源池是否在冷却期内
-		inCooldown, err := s.isClusterResourcePoolInCooldown(strategy, clusterID, resourceType)
-		if err != nil {
-			s.logger.Error("Failed to check cooldown for cluster resource pool",
-				zap.Error(err),
-				zap.Int("strategyID", strategy.ID),
-				zap.Int("clusterID", clusterID),
-				zap.String("resourceType", resourceType))
-			continue
+	// 检查该集群+资源池是否在冷却期内
+	inCooldown, err := s.isClusterResourcePoolInCooldown(evalCtx, strategy, clusterID, resourceType)
+	if err != nil {
+		s.logger.Error("Failed to check cooldown for cluster resource pool",
+			zap.Error(err),
+			zap.Int("strategyID", strategy.ID),
+			zap.Int("clusterID", clusterID),
+			zap.String("resourceType", resourceType))
+		return
+	}
+	if inCooldown {
+		// 获取集群名称用于中文描述
+		var cluster portal.K8sCluster
+		clusterName := "未知集群"
+		if err := s.db.Select("clustername").First(&cluster, clusterID).Error; err == nil {
+			clusterName = cluster.ClusterName
 		}
-		if inCooldown {
-			// 获取集群名称用于中文描述
-			var cluster portal.K8sCluster
-			clusterName := "未知集群"
-			if err := s.db.Select("clustername").First(&cluster, clusterID).Error; err == nil {
-				clusterName = cluster.ClusterName
-			}
 
-			reason := fmt.Sprintf("集群 %s（%s类型）处于冷却期内，跳过本次评估", clusterName, resourceType)
-			s.logger.Info(reason,
-				zap.Int("strategyID", strategy.ID),
-				zap.Int("clusterID", clusterID),
-				zap.String("resourceType", resourceType))
+		reason := fmt.Sprintf("集群 %s（%s类型）处于冷却期内，跳过本次评估", clusterName, resourceType)
+		s.logger.Info(reason,
+			zap.Int("strategyID", strategy.ID),
+			zap.Int("clusterID", clusterID),
+			zap.String("resourceType", resourceType))
 
-			// 记录冷却期执行历史
-			currentTime := portal.NavyTime(time.Now())
-			s.recordStrategyExecution(strategy.ID, clusterID, resourceType, StrategyExecutionResultSkippedCooldown, nil, reason, "", "", &currentTime)
-			continue
-		}
+		// 记录冷却期执行历史
+		currentTime := portal.NavyTime(time.Now())
+		s.recordStrategyExecution(evalCtx, strategy.ID, clusterID, resourceType, StrategyExecutionResultSkippedCooldown, nil, reason, "", "", &currentTime)
+		return
+	}
 
-		// 获取每日快照
-		requiredDays := getRequiredConsecutiveDays(strategy)
-		daysToCheck := requiredDays + 3 // 多查询几天以确保有足够的历史数据进行判断
-		snapshots, err := s.getOrderedDailySnapshots(clusterID, resourceType, daysToCheck)
-		if err != nil {
-			s.logger.Error("Failed to get daily snapshots", zap.Error(err), zap.Int("clusterID", clusterID))
-			continue
+	// 获取快照并评估：按天模式取每日最新快照，分钟/小时模式基于原始快照滑动窗口
+	evalTime := time.Now()
+	rawSnapshots, err := s.getSnapshotsBetween(clusterID, resourceType, evaluationLookbackStart(strategy, evalTime), evalTime)
+	if err != nil {
+		s.logger.Error("Failed to get resource snapshots", zap.Error(err), zap.Int("clusterID", clusterID))
+		return
+	}
+	evaluation := s.evaluateSnapshotsAt(strategy, rawSnapshots, evalTime)
+	latestSnapshot := evaluation.latestSnapshot()
+
+	if latestSnapshot == nil {
+		// 获取集群名称用于中文描述
+		var cluster portal.K8sCluster
+		clusterName := "未知集群"
+		if err := s.db.Select("clustername").First(&cluster, clusterID).Error; err == nil {
+			clusterName = cluster.ClusterName
 		}
 
-		if len(snapshots) == 0 {
-			// 获取集群名称用于中文描述
-			var cluster portal.K8sCluster
-			clusterName := "未知集群"
-			if err := s.db.Select("clustername").First(&cluster, clusterID).Error; err == nil {
-				clusterName = cluster.ClusterName
-			}
-
-			logMsg := fmt.Sprintf("集群 %s（%s类型）在过去 %d 天内未找到资源快照数据", clusterName, resourceType, daysToCheck)
-			s.logger.Info(logMsg, zap.Int("strategyID", strategy.ID))
-			currentTime := portal.NavyTime(time.Now())
-			s.recordStrategyExecution(strategy.ID, clusterID, resourceType, StrategyExecutionResultFailureNoSnapshots, nil, logMsg, "", "", &currentTime)
-			continue
-		}
+		logMsg := fmt.Sprintf("集群 %s（%s类型）在 %s 之后未找到资源快照数据",
+			clusterName, resourceType, evaluationLookbackStart(strategy, evalTime).Format(time.DateTime))
+		s.logger.Info(logMsg, zap.Int("strategyID", strategy.ID))
+		currentTime := portal.NavyTime(evalTime)
+		s.recordStrategyExecution(evalCtx, strategy.ID, clusterID, resourceType, StrategyExecutionResultFailureNoSnapshots, nil, logMsg, "", "", &currentTime)
+		return
+	}
 
-		// 核心评估逻辑
-		breached, consecutiveDays, triggeredValue, thresholdValue := s.EvaluateSnapshots(snapshots, strategy)
+	// 预测模式下的预测结果随上下文传递，使执行历史和订单描述记录预测信息
+	evalCtx.Forecast = evaluation.Forecast
 
-		// 根据评估结果执行操作
-		currentTime := portal.NavyTime(time.Now())
-		if breached {
-			s.logger.Info("Threshold consistently breached for strategy",
-				zap.Int("strategyID", strategy.ID),
-				zap.Int("clusterID", clusterID),
-				zap.Int("consecutiveDays", consecutiveDays),
-				zap.Int("requiredDays", requiredDays))
+	// 根据评估结果执行操作
+	currentTime := portal.NavyTime(evalTime)
+	if evaluation.Breached {
+		s.logger.Info("Threshold consistently breached for strategy",
+			zap.Int("strategyID", strategy.ID),
+			zap.Int("clusterID", clusterID),
+			zap.Int("consecutiveDays", evaluation.ConsecutiveDays),
+			zap.Int("sustainedMinutes", evaluation.SustainedMinutes),
+			zap.String("duration", formatStrategyDuration(strategy)))
 
-			// 计算资源增量
-			cpuDelta, memDelta := s.calculateResourceDelta(snapshots[len(snapshots)-1], strategy)
+		// 反向防抖：窗口内同一集群+资源池已有反向订单时不生成订单，避免入池/出池来回抖动
+		blocked, err := s.checkOppositeOrderHysteresis(evalCtx, strategy, clusterID, resourceType, evaluation.TriggeredValue, evaluation.ThresholdValue, evalTime)
+		if err != nil {
+			s.logger.Error("Failed to check opposite order hysteresis", zap.Error(err), zap.Int("strategyID", strategy.ID))
+			return
+		}
+		if blocked {
+			return
+		}
 
-			s.logger.Info("Calculated resource delta",
-				zap.Int("strategyID", strategy.ID),
-				zap.Int("clusterID", clusterID),
-				zap.Float64("cpuDelta", cpuDelta),
-				zap.Float64("memDelta", memDelta))
+		// 计算资源增量
+		cpuDelta, memDelta := s.calculateResourceDelta(evaluation.deltaSnapshot(), strategy)
 
-			// 触发设备匹配和订单创建
-			if err := s.matchDevicesForStrategyFunc(strategy, clusterID, resourceType, triggeredValue, thresholdValue, cpuDelta, memDelta, &snapshots[len(snapshots)-1]); err != nil {
-				s.logger.Error("Error during device matching for strategy", zap.Error(err), zap.Int("strategyID", strategy.ID))
-			}
-		} else {
-			s.logger.Info("Threshold not consistently breached for strategy",
-				zap.Int("strategyID", strategy.ID),
-				zap.Int("clusterID", clusterID),
-				zap.Int("consecutiveDays", consecutiveDays),
-				zap.Int("requiredDays", requiredDays))
-
-			// 获取集群名称用于中文描述
-			var cluster portal.K8sCluster
-			clusterName := "未知集群"
-			if err := s.db.Select("clustername").First(&cluster, clusterID).Error; err == nil {
-				clusterName = cluster.ClusterName
-			}
+		s.logger.Info("Calculated resource delta",
+			zap.Int("strategyID", strategy.ID),
+			zap.Int("clusterID", clusterID),
+			zap.Float64("cpuDelta", cpuDelta),
+			zap.Float64("memDelta", memDelta))
 
-			reason := fmt.Sprintf("集群 %s（%s类型）阈值未连续满足条件，当前连续天数 %d 天（需要 %d 天）",
-				clusterName, resourceType, consecutiveDays, requiredDays)
-			s.recordStrategyExecution(strategy.ID, clusterID, resourceType, StrategyExecutionResultFailureThresholdNotMet, nil, reason, triggeredValue, thresholdValue, &currentTime)
+		// 触发设备匹配和订单创建
+		if err := s.matchDevices(evalCtx, strategy, clusterID, resourceType, evaluation.TriggeredValue, evaluation.ThresholdValue, cpuDelta, memDelta, latestSnapshot); err != nil {
+			s.logger.Error("Error during device matching for strategy", zap.Error(err), zap.Int("strategyID", strategy.ID))
+		}
+	} else {
+		s.logger.Info("Threshold not consistently breached for strategy",
+			zap.Int("strategyID", strategy.ID),
+			zap.Int("clusterID", clusterID),
+			zap.Int("consecutiveDays", evaluation.ConsecutiveDays),
+			zap.Int("sustainedMinutes", evaluation.SustainedMinutes),
+			zap.String("duration", formatStrategyDuration(strategy)))
+
+		// 获取集群名称用于中文描述
+		var cluster portal.K8sCluster
+		clusterName := "未知集群"
+		if err := s.db.Select("clustername").First(&cluster, clusterID).Error; err == nil {
+			clusterName = cluster.ClusterName
 		}
+
+		reason := fmt.Sprintf("集群 %s（%s类型）阈值未持续满足条件，%s",
+			clusterName, resourceType, evaluation.progressDescription(strategy))
+		s.recordStrategyExecution(evalCtx, strategy.ID, clusterID, resourceType, StrategyExecutionResultFailureThresholdNotMet, nil, reason, evaluation.TriggeredValue, evaluation.ThresholdValue, &currentTime)
 	}
 }
 
 // isClusterResourcePoolInCooldown 检查指定集群+资源池是否在冷却期内
 // 冷却期基于订单：如果该集群+资源池生成了非取消状态的订单，
 // 则该资源池在冷却期内不会重复生成订单
-func (s *ElasticScalingService) isClusterResourcePoolInCooldown(strategy *portal.ElasticScalingStrategy, clusterID int, resourceType string) (bool, error) {
-	if strategy.CooldownMinutes <= 0 {
+func (s *ElasticScalingService) isClusterResourcePoolInCooldown(evalCtx *evaluationContext, strategy *portal.ElasticScalingStrategy, clusterID int, resourceType string) (bool, error) {
+	// 手动强制评估时忽略冷却期
+	if evalCtx.ForceEvaluation || strategy.CooldownMinutes <= 0 {
 		return false, nil
 	}
 
@@ -278,44 +324,6 @@ func (s *ElasticScalingService) getStrategyClusterAssociations(strategyID int) (
 	return associations, nil
 }
 
-// getOrderedDailySnapshots 获取并处理每日资源快照。
-func (s *ElasticScalingService) getOrderedDailySnapshots(clusterID int, resourceType string, days int) ([]portal.ResourceSnapshot, error) {
-	endDate := now.EndOfDay()
-	startDate := endDate.AddDate(0, 0, -days+1) // 包含今天在内的过去N天
-
-	query := s.db.Where("cluster_id = ? AND created_at between ? and ?", clusterID, startDate, endDate)
-
-	if resourceType != resourceTypeTotal {
-		query = query.Where(queryResourceTypeAndPool, resourceType, resourceType)
-	}
-
-	var snapshots []portal.ResourceSnapshot
-	if err := query.Order(OrderByCreatedAtDesc).Find(&snapshots).Error; err != nil {
-		return nil, err
-	}
-
-	// 按天分组，每天只取最新的一个快照
-	dailySnapshotMap := make(map[string]portal.ResourceSnapshot)
-	for _, snapshot := range snapshots {
-		day := time.Time(snapshot.CreatedAt).Format(dateFormat)
-		if _, exists := dailySnapshotMap[day]; !exists {
-			dailySnapshotMap[day] = snapshot
-		}
-	}
-
-	var orderedDailySnapshots []portal.ResourceSnapshot
-	for _, snapshot := range dailySnapshotMap {
-		orderedDailySnapshots = append(orderedDailySnapshots, snapshot)
-	}
-
-	// 按创建时间升序排序
-	sort.Slice(orderedDailySnapshots, func(i, j int) bool {
-		return time.Time(orderedDailySnapshots[i].CreatedAt).Before(time.Time(orderedDailySnapshots[j].CreatedAt))
-	})
-
-	return orderedDailySnapshots, nil
-}
-
 // EvaluateSnapshots 是核心评估逻辑，无副作用，易于测试。
 // 它接收快照和策略，返回是否触发、连续天数以及相关的监控值。
 func (s *ElasticScalingService) EvaluateSnapshots(
@@ -363,17 +371,17 @@ func (s *ElasticScalingService) checkSingleSnapshotBreach(snapshot portal.Resour
 	var cpuMet, memMet bool
 	var cpuVal, memVal float64 = -1.0, -1.0
 
-	// CPU检查 - 统一使用分配率计算（cpuRequest/cpuCapacity）
+	// CPU检查 - 根据阈值类型使用真实使用率或分配率（cpuRequest/cpuCapacity）
 	if strategy.CPUThresholdValue > 0 {
-		cpuVal = safePercentage(snapshot.CpuRequest, snapshot.CpuCapacity)
+		cpuVal = cpuMetricValue(snapshot, strategy.CPUThresholdType)
 		cpuMet = compare(cpuVal, float64(strategy.CPUThresholdValue), strategy.ThresholdTriggerAction)
 	} else {
 		cpuMet = true // 没有定义CPU阈值，则默认满足
 	}
 
-	// 内存检查 - 统一使用分配率计算（memRequest/memoryCapacity）
+	// 内存检查 - 根据阈值类型使用真实使用率或分配率（memRequest/memoryCapacity）
 	if strategy.MemoryThresholdValue > 0 {
-		memVal = safePercentage(snapshot.MemRequest, snapshot.MemoryCapacity)
+		memVal = memMetricValue(snapshot, strategy.MemoryThresholdType)
 		memMet = compare(memVal, float64(strategy.MemoryThresholdValue), strategy.ThresholdTriggerAction)
 	} else {
 		memMet = true // 没有定义内存阈值，则默认满足
@@ -402,57 +410,75 @@ func (s *ElasticScalingService) checkSingleSnapshotBreach(snapshot portal.Resour
 
 // calculateResourceDelta 计算需要调整的资源量
 func (s *ElasticScalingService) calculateResourceDelta(latestSnapshot portal.ResourceSnapshot, strategy *portal.ElasticScalingStrategy) (cpuDelta float64, memDelta float64) {
-	// 目标是调整资源容量使分配率达到阈值水平
-	// 入池：当分配率超过阈值时，需要增加容量以降低分配率
-	// 出池：当分配率低于阈值时，需要减少容量以提高分配率
-	// 计算基于分配率：Request/Capacity
-
-	if strategy.ThresholdTriggerAction == TriggerActionPoolEntry {
-		if strategy.CPUThresholdValue > 0 {
-			currentCPUAllocation := safePercentage(latestSnapshot.CpuRequest, latestSnapshot.CpuCapacity)
-			if currentCPUAllocation > strategy.CPUThresholdValue {
-				// 我们希望将分配率降至目标值，例如阈值本身
-				targetCPUAllocation := strategy.CPUThresholdValue
-				// (currentRequest/currentCapacity - targetRequest/newCapacity)
-				// 假设 targetRequest = currentRequest, 求解 newCapacity
-				// currentRequest / targetCPUAllocation = newCapacity
-				newCapacity := latestSnapshot.CpuRequest / (targetCPUAllocation / 100)
-				cpuDelta = newCapacity - latestSnapshot.CpuCapacity
-			}
-		}
-		if strategy.MemoryThresholdValue > 0 {
-			currentMemAllocation := safePercentage(latestSnapshot.MemRequest, latestSnapshot.MemoryCapacity)
-			if currentMemAllocation > strategy.MemoryThresholdValue {
-				targetMemAllocation := strategy.MemoryThresholdValue
-				newCapacity := latestSnapshot.MemRequest / (targetMemAllocation / 100)
-				memDelta = newCapacity - latestSnapshot.MemoryCapacity
-			}
-		}
-	} else if strategy.ThresholdTriggerAction == TriggerActionPoolExit {
-		if strategy.CPUThresholdValue > 0 {
-			currentCPUAllocation := safePercentage(latestSnapshot.CpuRequest, latestSnapshot.CpuCapacity)
-			if currentCPUAllocation < strategy.CPUThresholdValue {
-				// 我们希望将分配率提升至目标值
-				targetCPUAllocation := strategy.CPUThresholdValue
-				// 假设 targetRequest = currentRequest, 求解 newCapacity
-				// currentRequest / targetCPUAllocation = newCapacity
-				newCapacity := latestSnapshot.CpuRequest / (targetCPUAllocation / 100)
-				cpuDelta = newCapacity - latestSnapshot.CpuCapacity // Delta will be negative
-			}
+	// 目标是调整资源容量使指标（使用率或分配率，取决于阈值类型）达到策略配置的目标值
+	// 入池：当指标超过阈值时，增加容量使指标降至目标值（目标值低于阈值，避免冷却期结束后立即再次触发）
+	// 出池：当指标低于阈值时，减少容量使指标升至目标值（目标值高于阈值）
+	// 假设负载（实际使用量或Request）不变，求解新的容量：newCapacity = load / target
+	// 未配置目标值的历史策略回退为以阈值作为目标
+
+	if strategy.CPUThresholdValue > 0 {
+		currentCPU := cpuMetricValue(latestSnapshot, strategy.CPUThresholdType)
+		if compare(currentCPU, strategy.CPUThresholdValue, strategy.ThresholdTriggerAction) {
+			cpuTarget := effectiveTargetValue(strategy.CPUTargetValue, strategy.CPUThresholdValue)
+			cpuDelta = capacityDeltaForTarget(currentCPU, latestSnapshot.CpuCapacity, cpuTarget)
 		}
-		if strategy.MemoryThresholdValue > 0 {
-			currentMemAllocation := safePercentage(latestSnapshot.MemRequest, latestSnapshot.MemoryCapacity)
-			if currentMemAllocation < strategy.MemoryThresholdValue {
-				targetMemAllocation := strategy.MemoryThresholdValue
-				newCapacity := latestSnapshot.MemRequest / (targetMemAllocation / 100)
-				memDelta = newCapacity - latestSnapshot.MemoryCapacity // Delta will be negative
-			}
+	}
+	if strategy.MemoryThresholdValue > 0 {
+		currentMem := memMetricValue(latestSnapshot, strategy.MemoryThresholdType)
+		if compare(currentMem, strategy.MemoryThresholdValue, strategy.ThresholdTriggerAction) {
+			memTarget := effectiveTargetValue(strategy.MemoryTargetValue, strategy.MemoryThresholdValue)
+			memDelta = capacityDeltaForTarget(currentMem, latestSnapshot.MemoryCapacity, memTarget)
 		}
 	}
 
+	// 入池时 delta 为正，出池时 delta 为负
 	return cpuDelta, memDelta
 }
 
+// effectiveTargetValue 返回动作执行后的目标值，未配置目标值时回退为阈值
+func effectiveTargetValue(target, threshold float64) float64 {
+	if target > 0 {
+		return target
+	}
+	return threshold
+}
+
+// capacityDeltaForTarget 计算使指标从当前值调整到目标值所需的容量变化量。
+// currentRatio 与 targetRatio 均为百分比；负载 = currentRatio * capacity 保持不变。
+func capacityDeltaForTarget(currentRatio, capacity, targetRatio float64) float64 {
+	if targetRatio <= 0 || capacity <= 0 {
+		return 0
+	}
+	load := currentRatio / 100 * capacity
+	newCapacity := load / (targetRatio / 100)
+	return newCapacity - capacity
+}
+
+// cpuMetricValue 根据阈值类型返回快照的CPU指标（百分比）：
+// usage 使用快照中的最大使用率，allocated（默认）使用 Request/Capacity 计算分配率。
+func cpuMetricValue(snapshot portal.ResourceSnapshot, thresholdType string) float64 {
+	if thresholdType == ThresholdTypeUsage {
+		return snapshot.MaxCpuUsageRatio
+	}
+	return safePercentage(snapshot.CpuRequest, snapshot.CpuCapacity)
+}
+
+// memMetricValue 根据阈值类型返回快照的内存指标（百分比）。
+func memMetricValue(snapshot portal.ResourceSnapshot, thresholdType string) float64 {
+	if thresholdType == ThresholdTypeUsage {
+		return snapshot.MaxMemoryUsageRatio
+	}
+	return safePercentage(snapshot.MemRequest, snapshot.MemoryCapacity)
+}
+
+// metricName 返回阈值类型对应的指标中文名称
+func metricName(thresholdType string) string {
+	if thresholdType == ThresholdTypeUsage {
+		return "使用率"
+	}
+	return "分配率"
+}
+
 // compare 辅助函数，根据扩容或缩容操作比较值。
 func compare(current, threshold float64, action string) bool {
 	if action == TriggerActionPoolEntry { // 扩容：当前值 > 阈值
@@ -473,9 +499,17 @@ func parseResourceTypes(resourceTypesStr string) []string {
 	return types
 }
 
-// getRequiredConsecutiveDays 从策略中计算需要的天数。
+// getRequiredConsecutiveDays 从策略中计算按天模式需要的天数。
 func getRequiredConsecutiveDays(strategy *portal.ElasticScalingStrategy) int {
-	// DurationMinutes 字段可能被误用为天数，这里做兼容处理
+	// 显式指定按天评估时，持续时间即为天数
+	if strategy.DurationUnit == DurationUnitDay {
+		if strategy.DurationMinutes < 0 {
+			return 0
+		}
+		return strategy.DurationMinutes
+	}
+
+	// 未指定单位的历史策略：DurationMinutes 字段可能被误用为天数，这里做兼容处理
 	// 假设如果值小于100，它代表天数；否则代表分钟
 	if strategy.DurationMinutes > 0 && strategy.DurationMinutes < 100 {
 		return strategy.DurationMinutes
@@ -497,10 +531,10 @@ func (s *ElasticScalingService) buildThresholdString(strategy *portal.ElasticSca
 	}
 
 	if strategy.CPUThresholdValue > 0 {
-		parts = append(parts, fmt.Sprintf("CPU 分配率 %s %.2f%%", actionStr, strategy.CPUThresholdValue))
+		parts = append(parts, fmt.Sprintf("CPU %s %s %.2f%%", metricName(strategy.CPUThresholdType), actionStr, strategy.CPUThresholdValue))
 	}
 	if strategy.MemoryThresholdValue > 0 {
-		parts = append(parts, fmt.Sprintf("Memory 分配率 %s %.2f%%", actionStr, strategy.MemoryThresholdValue))
+		parts = append(parts, fmt.Sprintf("Memory %s %s %.2f%%", metricName(strategy.MemoryThresholdType), actionStr, strategy.MemoryThresholdValue))
 	}
 
 	logic := " "
@@ -508,25 +542,30 @@ func (s *ElasticScalingService) buildThresholdString(strategy *portal.ElasticSca
 		logic = fmt.Sprintf(" %s ", strategy.ConditionLogic)
 	}
 
-	return fmt.Sprintf("%s for %d days", strings.Join(parts, logic), getRequiredConsecutiveDays(strategy))
+	if isForecastStrategy(strategy) {
+		return fmt.Sprintf("%s within %d days (forecast)", strings.Join(parts, logic), strategy.ForecastHorizonDays)
+	}
+	return fmt.Sprintf("%s for %s", strings.Join(parts, logic), formatStrategyDurationEnglish(strategy))
 }
 
 // buildTriggeredValueString 构建实际触发值的字符串表示。
 func (s *ElasticScalingService) buildTriggeredValueString(cpuValue, memValue float64, strategy *portal.ElasticScalingStrategy) string {
 	var parts []string
 	if strategy.CPUThresholdValue > 0 {
+		cpuName := metricName(strategy.CPUThresholdType)
 		if cpuValue >= 0 {
-			parts = append(parts, fmt.Sprintf("CPU 分配率: %.2f%%", cpuValue))
+			parts = append(parts, fmt.Sprintf("CPU %s: %.2f%%", cpuName, cpuValue))
 		} else {
-			parts = append(parts, fmt.Sprintf("CPU 分配率: N/A"))
+			parts = append(parts, fmt.Sprintf("CPU %s: N/A", cpuName))
 		}
 	}
 
 	if strategy.MemoryThresholdValue > 0 {
+		memName := metricName(strategy.MemoryThresholdType)
 		if memValue >= 0 {
-			parts = append(parts, fmt.Sprintf("Memory 分配率: %.2f%%", memValue))
+			parts = append(parts, fmt.Sprintf("Memory %s: %.2f%%", memName, memValue))
 		} else {
-			parts = append(parts, fmt.Sprintf("Memory 分配率: N/A"))
+			parts = append(parts, fmt.Sprintf("Memory %s: N/A", memName))
 		}
 	}
 
@@ -538,6 +577,7 @@ func (s *ElasticScalingService) buildTriggeredValueString(cpuValue, memValue flo
 
 // recordStrategyExecution 记录策略执行历史。
 func (s *ElasticScalingService) recordStrategyExecution(
+	evalCtx *evaluationContext,
 	strategyID int,
 	clusterID int,
 	resourceType string,
@@ -548,6 +588,50 @@ func (s *ElasticScalingService) recordStrategyExecution(
 	thresholdValue string,
 	specificExecutionTime *portal.NavyTime,
 ) error {
+	return s.recordStrategyExecutionWithTrace(evalCtx, nil, strategyID, clusterID, resourceType, result, orderID, reason, triggeredValue, thresholdValue, specificExecutionTime)
+}
+
+// recordBlockedExecution 记录设备匹配后被拦截、未创建订单的执行历史，并附带本次设备匹配过程，
+// 便于排查被拦截时原本会选中哪些设备
+func (s *ElasticScalingService) recordBlockedExecution(
+	evalCtx *evaluationContext,
+	strategy *portal.ElasticScalingStrategy,
+	clusterID int,
+	resourceType string,
+	result string,
+	reason string,
+	triggeredValue string,
+	thresholdValue string,
+) error {
+	evalCtx.Trace.addNote(reason)
+	currentTime := portal.NavyTime(time.Now())
+	return s.recordStrategyExecutionWithTrace(evalCtx, evalCtx.Trace, strategy.ID, clusterID, resourceType, result, nil, reason, triggeredValue, thresholdValue, &currentTime)
+}
+
+// recordStrategyExecutionWithTrace 记录策略执行历史，trace 不为空时一并保存设备匹配过程。
+func (s *ElasticScalingService) recordStrategyExecutionWithTrace(
+	evalCtx *evaluationContext,
+	trace *DeviceMatchingTrace,
+	strategyID int,
+	clusterID int,
+	resourceType string,
+	result string,
+	orderID *int,
+	reason string,
+	triggeredValue string,
+	thresholdValue string,
+	specificExecutionTime *portal.NavyTime,
+) error {
+	if evalCtx.DryRun {
+		// 模拟模式下不落库，仅输出调试日志
+		s.logger.Debug("Dry run: skip recording strategy execution",
+			zap.Int("strategyID", strategyID),
+			zap.Int("clusterID", clusterID),
+			zap.String("result", result),
+			zap.String("reason", reason))
+		return nil
+	}
+
 	execTime := portal.NavyTime(time.Now())
 	if specificExecutionTime != nil {
 		execTime = *specificExecutionTime
@@ -565,13 +649,38 @@ func (s *ElasticScalingService) recordStrategyExecution(
 		Reason:         reason,
 	}
 
+	// 预测模式下记录预测输入和预计越线时间
+	if evalCtx.Forecast != nil {
+		history.ForecastInput = evalCtx.Forecast.inputJSON()
+		history.ProjectedCrossingDate = evalCtx.Forecast.projectedCrossingTime()
+	}
+
+	matchingTrace, err := marshalMatchingTrace(trace)
+	if err != nil {
+		// 匹配过程仅用于排查，序列化失败不影响执行历史的记录
+		s.logger.Warn("Failed to marshal device matching trace for strategy execution history", zap.Error(err), zap.Int("strategyID", strategyID))
+	}
+	history.MatchingTrace = matchingTrace
+
 	if err := s.db.Create(&history).Error; err != nil {
 		s.logger.Error("Failed to create strategy execution history entry in DB", zap.Error(err), zap.Int("strategyID", strategyID))
 		return err
 	}
+	if evalCtx.ExecutionRecords != nil {
+		*evalCtx.ExecutionRecords = append(*evalCtx.ExecutionRecords, history)
+	}
 	return nil
 }
 
+// navyTimePtrToTimePtr 将可空的 NavyTime 转换为可空的 time.Time
+func navyTimePtrToTimePtr(t *portal.NavyTime) *time.Time {
+	if t == nil {
+		return nil
+	}
+	converted := time.Time(*t)
+	return &converted
+}
+
 // isGormRecordNotFoundError 检查错误是否为gorm.ErrRecordNotFound
 func isGormRecordNotFoundError(err error) bool {
 	return err != nil && err == gorm.ErrRecordNotFound
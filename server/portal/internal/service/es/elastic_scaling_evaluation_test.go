@@ -106,6 +106,13 @@ var _ = Describe("ElasticScalingEvaluation", func() {
 		err = db.AutoMigrate(
 			&portal.ElasticScalingStrategy{},
 			&portal.StrategyClusterAssociation{},
+			&portal.FreezeWindow{},
+			&portal.ElasticScalingBudget{},
+			&portal.DeviceReservation{},
+			&portal.ClusterLocation{},
+			&portal.ResourcePoolCompatibilityRule{},
+			&portal.DrainProtectionRule{},
+			&portal.OrderStatusHistory{},
 			&portal.ResourceSnapshot{},
 			&portal.StrategyExecutionHistory{},
 			&portal.Device{},
@@ -145,9 +152,9 @@ var _ = Describe("ElasticScalingEvaluation", func() {
 
 	Describe("EvaluateSnapshots", func() {
 		Context("for pool entry (scale-out) strategies", func() {
-			It("should trigger when CPU usage is consistently above threshold", func() {
+			It("should trigger when CPU allocation is consistently above threshold", func() {
 				strategy := &portal.ElasticScalingStrategy{
-					CPUThresholdType:       es.ThresholdTypeUsage,
+					CPUThresholdType:       es.ThresholdTypeAllocated,
 					CPUThresholdValue:      80,
 					ThresholdTriggerAction: es.TriggerActionPoolEntry,
 					DurationMinutes:        3, // 需要连续3天
@@ -163,9 +170,9 @@ var _ = Describe("ElasticScalingEvaluation", func() {
 				Expect(consecutiveDays).To(Equal(3))
 			})
 
-			It("should not trigger if usage drops below threshold", func() {
+			It("should not trigger if allocation drops below threshold", func() {
 				strategy := &portal.ElasticScalingStrategy{
-					CPUThresholdType:       es.ThresholdTypeUsage,
+					CPUThresholdType:       es.ThresholdTypeAllocated,
 					CPUThresholdValue:      80,
 					ThresholdTriggerAction: es.TriggerActionPoolEntry,
 					DurationMinutes:        3,
@@ -183,9 +190,9 @@ var _ = Describe("ElasticScalingEvaluation", func() {
 
 			It("should trigger with AND logic if both CPU and Memory are above threshold", func() {
 				strategy := &portal.ElasticScalingStrategy{
-					CPUThresholdType:       es.ThresholdTypeUsage,
+					CPUThresholdType:       es.ThresholdTypeAllocated,
 					CPUThresholdValue:      80,
-					MemoryThresholdType:    es.ThresholdTypeUsage,
+					MemoryThresholdType:    es.ThresholdTypeAllocated,
 					MemoryThresholdValue:   70,
 					ConditionLogic:         es.ConditionLogicAnd,
 					ThresholdTriggerAction: es.TriggerActionPoolEntry,
@@ -203,9 +210,9 @@ var _ = Describe("ElasticScalingEvaluation", func() {
 
 			It("should trigger with OR logic if either CPU or Memory is above threshold", func() {
 				strategy := &portal.ElasticScalingStrategy{
-					CPUThresholdType:       es.ThresholdTypeUsage,
+					CPUThresholdType:       es.ThresholdTypeAllocated,
 					CPUThresholdValue:      80,
-					MemoryThresholdType:    es.ThresholdTypeUsage,
+					MemoryThresholdType:    es.ThresholdTypeAllocated,
 					MemoryThresholdValue:   70,
 					ConditionLogic:         es.ConditionLogicOr,
 					ThresholdTriggerAction: es.TriggerActionPoolEntry,
@@ -261,7 +268,7 @@ var _ = Describe("ElasticScalingEvaluation", func() {
 		Context("general behavior", func() {
 			It("should return correct consecutive days when breach happens at the start", func() {
 				strategy := &portal.ElasticScalingStrategy{
-					CPUThresholdType:       es.ThresholdTypeUsage,
+					CPUThresholdType:       es.ThresholdTypeAllocated,
 					CPUThresholdValue:      80,
 					ThresholdTriggerAction: es.TriggerActionPoolEntry,
 					DurationMinutes:        2,
@@ -280,7 +287,7 @@ var _ = Describe("ElasticScalingEvaluation", func() {
 
 			It("should return zero consecutive days if no breach occurs", func() {
 				strategy := &portal.ElasticScalingStrategy{
-					CPUThresholdType:       es.ThresholdTypeUsage,
+					CPUThresholdType:       es.ThresholdTypeAllocated,
 					CPUThresholdValue:      80,
 					ThresholdTriggerAction: es.TriggerActionPoolEntry,
 					DurationMinutes:        2,
@@ -357,7 +364,7 @@ var _ = Describe("ElasticScalingEvaluation", func() {
 				}
 				db.Create(&snapshot)
 
-				strategy.CPUThresholdType = es.ThresholdTypeUsage
+				strategy.CPUThresholdType = es.ThresholdTypeAllocated
 				strategy.CPUThresholdValue = 80
 				strategy.ThresholdTriggerAction = es.TriggerActionPoolEntry
 				strategy.DurationMinutes = 1
@@ -398,7 +405,7 @@ var _ = Describe("ElasticScalingEvaluation", func() {
 		Context("when threshold is consistently breached", func() {
 			It("should fail when no device matching policies exist", func() {
 				// Arrange - 设置策略但不创建ResourcePoolDeviceMatchingPolicy
-				strategy.CPUThresholdType = es.ThresholdTypeUsage
+				strategy.CPUThresholdType = es.ThresholdTypeAllocated
 				strategy.CPUThresholdValue = 80
 				strategy.ThresholdTriggerAction = es.TriggerActionPoolEntry
 				strategy.DurationMinutes = 2 // Require 2 days
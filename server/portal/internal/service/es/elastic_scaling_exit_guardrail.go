@@ -0,0 +1,102 @@
+package es
+
+import (
+	"fmt"
+	"navy-ng/models/portal"
+	"strings"
+)
+
+// 出池护栏上限级别，对应资源池样式阈值中的警告和危险阈值
+const (
+	ExitCeilingLevelWarning  = "warning"
+	ExitCeilingLevelCritical = "critical"
+)
+
+// exitGuardrailResult 出池护栏的检查结果
+type exitGuardrailResult struct {
+	DeviceIDs []int  // 裁剪后的设备
+	Trimmed   bool   // 是否因护栏裁剪了设备
+	Note      string // 裁剪说明
+	Refused   string // 第一台设备出池后即超过上限时的拒绝原因，不为空时不应创建订单
+}
+
+// exitCeilingLevelName 返回出池护栏上限级别的中文名称
+func exitCeilingLevelName(level string) string {
+	if level == ExitCeilingLevelCritical {
+		return "危险"
+	}
+	return "警告"
+}
+
+// exitCeiling 返回出池护栏的上限（百分比）：按集群物理机数量和环境获取资源池样式阈值，
+// 使用策略配置的级别，未配置时使用警告阈值
+func exitCeiling(level string, bmCount int, environment string) float64 {
+	thresholds := portal.GetStyleThresholds(bmCount, environment)
+	if level == ExitCeilingLevelCritical {
+		return thresholds.Critical
+	}
+	return thresholds.Warning
+}
+
+// clusterEnvironment 根据集群名称判断环境，测试集群使用测试环境阈值
+func clusterEnvironment(clusterName string) string {
+	if strings.Contains(clusterName, "-test") {
+		return portal.EnvTest
+	}
+	return portal.EnvProduction
+}
+
+// applyExitGuardrail 出池护栏：按选择顺序逐台累计出池设备，保证出池后预测的CPU和内存指标始终低于上限，
+// 超过上限的设备及其后的设备被裁剪；第一台设备即超过上限时拒绝出池。入池或没有快照时不做检查
+func (s *ElasticScalingService) applyExitGuardrail(
+	strategy *portal.ElasticScalingStrategy,
+	clusterID int,
+	selectedDeviceIDs []int,
+	latestSnapshot *portal.ResourceSnapshot,
+) (*exitGuardrailResult, error) {
+	result := &exitGuardrailResult{DeviceIDs: selectedDeviceIDs}
+	if strategy.ThresholdTriggerAction != TriggerActionPoolExit || len(selectedDeviceIDs) == 0 || latestSnapshot == nil {
+		return result, nil
+	}
+
+	var devices []portal.Device
+	if err := s.db.Select("id", "cpu", "memory").Where("id IN ?", selectedDeviceIDs).Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch selected devices: %w", err)
+	}
+	deviceMap := make(map[int]portal.Device, len(devices))
+	for _, device := range devices {
+		deviceMap[device.ID] = device
+	}
+
+	var cluster portal.K8sCluster
+	clusterName := "未知集群"
+	if err := s.db.Select("clustername").First(&cluster, clusterID).Error; err == nil {
+		clusterName = cluster.ClusterName
+	}
+	ceiling := exitCeiling(strategy.ExitCeilingLevel, latestSnapshot.BMCount, clusterEnvironment(cluster.ClusterName))
+	levelName := exitCeilingLevelName(strategy.ExitCeilingLevel)
+
+	var totalCPU, totalMemory float64
+	for i, deviceID := range selectedDeviceIDs {
+		device := deviceMap[deviceID]
+		cpuRate, memRate := s.calculateProjectedAllocation(latestSnapshot, strategy, totalCPU+device.CPU, totalMemory+device.Memory)
+		if cpuRate < ceiling && memRate < ceiling {
+			totalCPU += device.CPU
+			totalMemory += device.Memory
+			continue
+		}
+
+		breach := fmt.Sprintf("CPU%s %.2f%%、内存%s %.2f%%，%s上限 %.2f%%",
+			metricName(strategy.CPUThresholdType), cpuRate, metricName(strategy.MemoryThresholdType), memRate, levelName, ceiling)
+		if i == 0 {
+			result.DeviceIDs = nil
+			result.Refused = fmt.Sprintf("集群 %s 出池 1 台设备后预计将超过资源池%s上限（%s），拒绝出池", clusterName, levelName, breach)
+			return result, nil
+		}
+		result.DeviceIDs = selectedDeviceIDs[:i]
+		result.Trimmed = true
+		result.Note = fmt.Sprintf("受出池护栏限制，设备数由 %d 台调整为 %d 台（再出池 1 台后预计 %s）", len(selectedDeviceIDs), i, breach)
+		return result, nil
+	}
+	return result, nil
+}
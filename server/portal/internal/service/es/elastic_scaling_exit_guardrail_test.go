@@ -0,0 +1,108 @@
+package es
+
+import (
+	"testing"
+
+	"navy-ng/models/portal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyExitGuardrail(t *testing.T) {
+	s, db := newTestService(t)
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 1}, ClusterID: "c1", ClusterName: "cluster-a"}).Error)
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 2}, ClusterID: "c2", ClusterName: "cluster-b-test"}).Error)
+	var deviceIDs []int
+	for i := 1; i <= 5; i++ {
+		device := portal.Device{BaseModel: portal.BaseModel{ID: i}, CICode: "device", CPU: 10, Memory: 10}
+		require.NoError(t, db.Create(&device).Error)
+		deviceIDs = append(deviceIDs, device.ID)
+	}
+
+	exit := &portal.ElasticScalingStrategy{ThresholdTriggerAction: TriggerActionPoolExit, CPUThresholdType: ThresholdTypeAllocated, MemoryThresholdType: ThresholdTypeAllocated}
+	// 小集群生产环境：警告 70%，危险 75%；每出池 1 台 CPU 分配率依次为 55.6%、62.5%、71.4%、83.3%
+	snapshot := &portal.ResourceSnapshot{CpuRequest: 50, CpuCapacity: 100, MemRequest: 10, MemoryCapacity: 100, BMCount: 10}
+
+	t.Run("trims at the warning ceiling by default", func(t *testing.T) {
+		result, err := s.applyExitGuardrail(exit, 1, deviceIDs, snapshot)
+		require.NoError(t, err)
+		assert.True(t, result.Trimmed)
+		assert.Equal(t, deviceIDs[:2], result.DeviceIDs)
+		assert.Contains(t, result.Note, "由 5 台调整为 2 台")
+		assert.Empty(t, result.Refused)
+	})
+
+	t.Run("critical ceiling allows more devices", func(t *testing.T) {
+		critical := *exit
+		critical.ExitCeilingLevel = ExitCeilingLevelCritical
+		result, err := s.applyExitGuardrail(&critical, 1, deviceIDs, snapshot)
+		require.NoError(t, err)
+		assert.Equal(t, deviceIDs[:3], result.DeviceIDs)
+	})
+
+	t.Run("test clusters use the relaxed thresholds", func(t *testing.T) {
+		result, err := s.applyExitGuardrail(exit, 2, deviceIDs, snapshot)
+		require.NoError(t, err)
+		assert.Equal(t, deviceIDs[:3], result.DeviceIDs)
+	})
+
+	t.Run("refuses when the first device already breaches", func(t *testing.T) {
+		busy := *snapshot
+		busy.CpuRequest = 65
+		result, err := s.applyExitGuardrail(exit, 1, deviceIDs, &busy)
+		require.NoError(t, err)
+		assert.Empty(t, result.DeviceIDs)
+		assert.Contains(t, result.Refused, "拒绝出池")
+	})
+
+	t.Run("selection under the ceiling is kept", func(t *testing.T) {
+		result, err := s.applyExitGuardrail(exit, 1, deviceIDs[:2], snapshot)
+		require.NoError(t, err)
+		assert.False(t, result.Trimmed)
+		assert.Equal(t, deviceIDs[:2], result.DeviceIDs)
+	})
+
+	t.Run("pool entry and missing snapshot are not checked", func(t *testing.T) {
+		entry := &portal.ElasticScalingStrategy{ThresholdTriggerAction: TriggerActionPoolEntry}
+		result, err := s.applyExitGuardrail(entry, 1, deviceIDs, snapshot)
+		require.NoError(t, err)
+		assert.Equal(t, deviceIDs, result.DeviceIDs)
+
+		result, err = s.applyExitGuardrail(exit, 1, deviceIDs, nil)
+		require.NoError(t, err)
+		assert.Equal(t, deviceIDs, result.DeviceIDs)
+	})
+}
+
+func TestGuardrailTrimmedOrderIsFlagged(t *testing.T) {
+	s, db := newTestService(t)
+	strategy := &portal.ElasticScalingStrategy{Name: "exit", ThresholdTriggerAction: TriggerActionPoolExit, Status: StrategyStatusEnabled}
+	require.NoError(t, db.Create(strategy).Error)
+	require.NoError(t, db.Create(&portal.Device{BaseModel: portal.BaseModel{ID: 1}, CICode: "device-1"}).Error)
+
+	evalCtx := &evaluationContext{
+		Trace:         newDeviceMatchingTrace(strategy, 1, "total", 0, 0),
+		GuardrailNote: "受出池护栏限制，设备数由 3 台调整为 1 台",
+	}
+	require.NoError(t, s.generateElasticScalingOrder(evalCtx, strategy, 1, "total", []int{1}, "", "", 0, 0, nil))
+
+	var detail portal.ElasticScalingOrderDetail
+	require.NoError(t, db.First(&detail).Error)
+	assert.True(t, detail.GuardrailTrimmed)
+
+	order, err := s.GetOrder(detail.OrderID)
+	require.NoError(t, err)
+	assert.True(t, order.GuardrailTrimmed)
+	assert.Contains(t, order.Description, "出池护栏")
+	assert.Contains(t, order.MatchingTrace.Notes, evalCtx.GuardrailNote)
+}
+
+func TestValidateExitCeilingLevel(t *testing.T) {
+	s, _ := newTestService(t)
+	cpuThreshold, cpuType := 30.0, ThresholdTypeAllocated
+	dto := &StrategyDTO{Name: "exit", ThresholdTriggerAction: TriggerActionPoolExit, CPUThresholdValue: &cpuThreshold, CPUThresholdType: &cpuType, Status: StrategyStatusEnabled, ClusterIDs: []int{1}, ExitCeilingLevel: "emergency"}
+	err := s.validateStrategyDTO(dto)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "出池护栏上限")
+}
@@ -0,0 +1,330 @@
+package es
+
+import (
+	"fmt"
+	"navy-ng/models/portal"
+	"sort"
+	"strings"
+
+	. "navy-ng/server/portal/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// faultDomainConstraints 设备选择的故障域（机房/机柜）反亲和约束，由匹配策略配置
+type faultDomainConstraints struct {
+	MaxPerCabinet      int  // 单次选择每个机柜最多设备数，为0时不限制
+	MaxPerRoom         int  // 单次选择每个机房最多设备数，为0时不限制
+	BalanceAcrossRooms bool // 优先从已选设备最少的机房选择
+	MinPerCabinet      int  // 出池时每个机柜至少保留的集群设备数，为0时不限制
+}
+
+// newFaultDomainConstraints 从匹配策略读取故障域约束，policy 为空时不设约束
+func newFaultDomainConstraints(policy *ResourcePoolDeviceMatchingPolicy) faultDomainConstraints {
+	if policy == nil {
+		return faultDomainConstraints{}
+	}
+	return faultDomainConstraints{
+		MaxPerCabinet:      policy.MaxDevicesPerCabinet,
+		MaxPerRoom:         policy.MaxDevicesPerRoom,
+		BalanceAcrossRooms: policy.BalanceAcrossRooms,
+		MinPerCabinet:      policy.MinDevicesPerCabinet,
+	}
+}
+
+// faultDomainUsage 多个匹配策略组合时已采用设备在各机柜、机房的分布。
+// 各策略的故障域上限基于合并后的选择计算，避免多个策略的选择拼接后超过上限
+type faultDomainUsage struct {
+	deviceIDs  map[int]bool
+	perCabinet map[string]int
+	perRoom    map[string]int
+}
+
+// newFaultDomainUsage 创建空的故障域分布
+func newFaultDomainUsage() *faultDomainUsage {
+	return &faultDomainUsage{
+		deviceIDs:  make(map[int]bool),
+		perCabinet: make(map[string]int),
+		perRoom:    make(map[string]int),
+	}
+}
+
+// has 判断设备是否已被前序策略采用，u 为空时返回false
+func (u *faultDomainUsage) has(deviceID int) bool {
+	return u != nil && u.deviceIDs[deviceID]
+}
+
+// record 记录采用的设备，同一设备被多个策略选中时只统计一次，u 为空时忽略
+func (u *faultDomainUsage) record(candidates []DeviceResponse, deviceIDs []int) {
+	if u == nil {
+		return
+	}
+	byID := make(map[int]DeviceResponse, len(candidates))
+	for _, device := range candidates {
+		byID[device.ID] = device
+	}
+	for _, id := range deviceIDs {
+		device, ok := byID[id]
+		if !ok || u.deviceIDs[id] {
+			continue
+		}
+		u.deviceIDs[id] = true
+		if cabinet := cabinetKey(device.IDC, device.Room, device.Cabinet, device.CabinetNO); cabinet != "" {
+			u.perCabinet[cabinet]++
+		}
+		if room := roomKey(device.IDC, device.Room); room != "" {
+			u.perRoom[room]++
+		}
+	}
+}
+
+// enabled 判断是否配置了任一约束
+func (c faultDomainConstraints) enabled(action string) bool {
+	return c.MaxPerCabinet > 0 || c.MaxPerRoom > 0 || c.BalanceAcrossRooms ||
+		(action == TriggerActionPoolExit && c.MinPerCabinet > 0)
+}
+
+// roomKey 返回设备所在机房的标识（IDC + 机房），未知时为空
+func roomKey(idc, room string) string {
+	if room == "" {
+		return ""
+	}
+	return idc + "/" + room
+}
+
+// cabinetKey 返回设备所在机柜的标识（机房 + 机柜，机柜为空时使用机柜编号），未知时为空
+func cabinetKey(idc, room, cabinet, cabinetNO string) string {
+	if cabinet == "" {
+		cabinet = cabinetNO
+	}
+	if cabinet == "" {
+		return ""
+	}
+	return strings.Join([]string{idc, room, cabinet}, "/")
+}
+
+// getClusterCabinetDeviceCounts 统计集群当前在各机柜中的设备数，用于出池时保留机柜最少设备
+func (s *ElasticScalingService) getClusterCabinetDeviceCounts(clusterID int) (map[string]int, error) {
+	var devices []portal.Device
+	if err := s.db.Select("idc", "room", "cabinet", "cabinet_no").
+		Where("cluster_id = ?", clusterID).Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, device := range devices {
+		if key := cabinetKey(device.IDC, device.Room, device.Cabinet, device.CabinetNO); key != "" {
+			counts[key]++
+		}
+	}
+	return counts, nil
+}
+
+// applyFaultDomainConstraints 按故障域约束调整选择结果。
+// 以选择算法选出的设备为优先，其余候选设备按容量降序作为补充，逐台选择满足约束的设备直至满足需求：
+// 每个机柜/机房的设备数不超过上限；出池时每个机柜保留的集群设备数不少于下限；
+// 开启机房均衡时优先从已选设备最少的机房选择。未知机房/机柜的设备不受机房/机柜约束。
+// usage 不为空时计数包含前序匹配策略已采用的设备，保证多个策略合并后的选择仍满足上限。
+func (s *ElasticScalingService) applyFaultDomainConstraints(
+	selection deviceSelection,
+	candidates []DeviceResponse,
+	clusterID int,
+	cpuDemand, memDemand float64,
+	action string,
+	constraints faultDomainConstraints,
+	usage *faultDomainUsage,
+) deviceSelection {
+	if !constraints.enabled(action) || len(candidates) == 0 {
+		return selection
+	}
+
+	var cabinetRemaining map[string]int
+	if action == TriggerActionPoolExit && constraints.MinPerCabinet > 0 {
+		counts, err := s.getClusterCabinetDeviceCounts(clusterID)
+		if err != nil {
+			s.logger.Error("Failed to count cluster devices per cabinet, skipping exit cabinet minimum",
+				zap.Error(err), zap.Int("clusterID", clusterID))
+		} else {
+			cabinetRemaining = counts
+		}
+	}
+
+	demand := newSelectionDemand(cpuDemand, memDemand, action)
+	// 没有资源增量时按原选择的设备数选择
+	targetCount := 0
+	if demand.cpu == 0 && demand.mem == 0 {
+		targetCount = len(selection.DeviceIDs)
+	}
+
+	// 候选顺序：算法选出的设备在前，其余按归一化容量降序（按设备数选择时保持候选顺序）
+	byID := make(map[int]DeviceResponse, len(candidates))
+	for _, device := range candidates {
+		byID[device.ID] = device
+	}
+	ranked := make([]DeviceResponse, 0, len(candidates))
+	preferred := make(map[int]bool, len(selection.DeviceIDs))
+	for _, id := range selection.DeviceIDs {
+		if device, ok := byID[id]; ok && !preferred[id] {
+			preferred[id] = true
+			ranked = append(ranked, device)
+		}
+	}
+	var rest []DeviceResponse
+	for _, device := range candidates {
+		if !preferred[device.ID] {
+			rest = append(rest, device)
+		}
+	}
+	if targetCount == 0 {
+		sortByNormalizedCapacity(rest, demand)
+	}
+	ranked = append(ranked, rest...)
+
+	// 计数从前序策略已采用的设备开始，上限作用于合并后的选择
+	used := make([]bool, len(ranked))
+	perCabinet := make(map[string]int)
+	perRoom := make(map[string]int)
+	if usage != nil {
+		for key, count := range usage.perCabinet {
+			perCabinet[key] = count
+		}
+		for key, count := range usage.perRoom {
+			perRoom[key] = count
+		}
+	}
+	var selectedDeviceIDs []int
+	var cpuFulfilled, memFulfilled float64
+
+	// violation 返回选择该设备会违反的故障域约束，满足约束时为空
+	violation := func(device DeviceResponse) string {
+		if usage.has(device.ID) {
+			return "" // 已被前序策略采用的设备不再占用名额
+		}
+		cabinet := cabinetKey(device.IDC, device.Room, device.Cabinet, device.CabinetNO)
+		room := roomKey(device.IDC, device.Room)
+		if constraints.MaxPerCabinet > 0 && cabinet != "" && perCabinet[cabinet] >= constraints.MaxPerCabinet {
+			return fmt.Sprintf("机柜 %s 已选择 %d 台，达到每机柜上限 %d 台", cabinet, perCabinet[cabinet], constraints.MaxPerCabinet)
+		}
+		if constraints.MaxPerRoom > 0 && room != "" && perRoom[room] >= constraints.MaxPerRoom {
+			return fmt.Sprintf("机房 %s 已选择 %d 台，达到每机房上限 %d 台", room, perRoom[room], constraints.MaxPerRoom)
+		}
+		if cabinetRemaining != nil && cabinet != "" && cabinetRemaining[cabinet]-perCabinet[cabinet]-1 < constraints.MinPerCabinet {
+			return fmt.Sprintf("机柜 %s 出池后剩余设备将少于 %d 台", cabinet, constraints.MinPerCabinet)
+		}
+		return ""
+	}
+
+	allowed := func(device DeviceResponse) bool {
+		if violation(device) != "" {
+			return false
+		}
+		if action == TriggerActionPoolExit && !demand.fits(cpuFulfilled, memFulfilled, device.CPU, device.Memory) {
+			return false
+		}
+		if targetCount == 0 {
+			// 只选择能缩小缺口的设备
+			return (device.CPU > 0 && cpuFulfilled < demand.cpu) || (device.Memory > 0 && memFulfilled < demand.mem)
+		}
+		return true
+	}
+
+	done := func() bool {
+		if targetCount > 0 {
+			return len(selectedDeviceIDs) >= targetCount
+		}
+		return demand.met(cpuFulfilled, memFulfilled)
+	}
+
+	for !done() {
+		best := -1
+		for i, device := range ranked {
+			if used[i] || !allowed(device) {
+				continue
+			}
+			if best < 0 {
+				best = i
+				if !constraints.BalanceAcrossRooms {
+					break
+				}
+				continue
+			}
+			// 机房均衡：选择已选设备最少的机房，相同时保持原有优先顺序
+			if perRoom[roomKey(device.IDC, device.Room)] < perRoom[roomKey(ranked[best].IDC, ranked[best].Room)] {
+				best = i
+			}
+		}
+		if best < 0 {
+			break // 没有满足约束的候选设备
+		}
+
+		device := ranked[best]
+		used[best] = true
+		selectedDeviceIDs = append(selectedDeviceIDs, device.ID)
+		cpuFulfilled += device.CPU
+		memFulfilled += device.Memory
+		if usage.has(device.ID) {
+			continue
+		}
+		if cabinet := cabinetKey(device.IDC, device.Room, device.Cabinet, device.CabinetNO); cabinet != "" {
+			perCabinet[cabinet]++
+		}
+		if room := roomKey(device.IDC, device.Room); room != "" {
+			perRoom[room]++
+		}
+	}
+
+	adjusted := !sameDeviceIDs(selection.DeviceIDs, selectedDeviceIDs)
+	if adjusted {
+		s.logger.Info("Adjusted device selection for fault-domain constraints",
+			zap.String("action", action),
+			zap.Ints("originalDeviceIDs", selection.DeviceIDs),
+			zap.Ints("selectedDeviceIDs", selectedDeviceIDs),
+			zap.Any("constraints", constraints))
+	}
+
+	// 记录因约束未被选择的设备
+	violations := make(map[int]string)
+	for i, device := range ranked {
+		if !used[i] {
+			if reason := violation(device); reason != "" {
+				violations[device.ID] = reason
+			}
+		}
+	}
+
+	selection.DeviceIDs = selectedDeviceIDs
+	selection.FaultDomainAdjusted = adjusted
+	selection.ConstraintViolations = violations
+	if targetCount > 0 {
+		selection.Score = float64(len(selectedDeviceIDs))
+	} else {
+		selection.Score = demand.score(len(selectedDeviceIDs), cpuFulfilled, memFulfilled)
+	}
+	return selection
+}
+
+// sortByNormalizedCapacity 按相对于需求的归一化容量降序排列，容量相同时保持原有顺序
+func sortByNormalizedCapacity(devices []DeviceResponse, demand selectionDemand) {
+	sort.SliceStable(devices, func(i, j int) bool {
+		ci, mi := demand.normalized(devices[i].CPU, devices[i].Memory)
+		cj, mj := demand.normalized(devices[j].CPU, devices[j].Memory)
+		return ci+mi > cj+mj
+	})
+}
+
+// sameDeviceIDs 判断两个设备列表是否包含相同的设备（忽略顺序）
+func sameDeviceIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[int]int, len(a))
+	for _, id := range a {
+		seen[id]++
+	}
+	for _, id := range b {
+		if seen[id] == 0 {
+			return false
+		}
+		seen[id]--
+	}
+	return true
+}
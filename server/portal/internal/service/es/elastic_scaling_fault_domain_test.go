@@ -0,0 +1,145 @@
+package es
+
+import (
+	"fmt"
+	"testing"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFilterAndSelectDevicesWithFaultDomain(t *testing.T) {
+	s := &ElasticScalingService{logger: zap.NewNop()}
+	entry := &portal.ElasticScalingStrategy{ThresholdTriggerAction: TriggerActionPoolEntry}
+
+	t.Run("no constraints keeps algorithm selection", func(t *testing.T) {
+		candidates := []service.DeviceResponse{
+			{ID: 1, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "A"},
+			{ID: 2, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "A"},
+			{ID: 3, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "B"},
+		}
+		selection := s.filterAndSelectDevicesWithPolicy(candidates, entry, 1, 20, 20, &service.ResourcePoolDeviceMatchingPolicy{}, nil, nil)
+		assert.Equal(t, []int{1, 2}, selection.DeviceIDs)
+		assert.False(t, selection.FaultDomainAdjusted)
+	})
+
+	t.Run("limits devices per cabinet", func(t *testing.T) {
+		candidates := []service.DeviceResponse{
+			{ID: 1, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "A"},
+			{ID: 2, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "A"},
+			{ID: 3, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "B"},
+			{ID: 4, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", CabinetNO: "C"},
+		}
+		policy := &service.ResourcePoolDeviceMatchingPolicy{MaxDevicesPerCabinet: 1}
+		selection := s.filterAndSelectDevicesWithPolicy(candidates, entry, 1, 30, 30, policy, nil, nil)
+		assert.Equal(t, []int{1, 3, 4}, selection.DeviceIDs)
+		assert.True(t, selection.FaultDomainAdjusted)
+	})
+
+	t.Run("limits devices per room and stops when no candidate is allowed", func(t *testing.T) {
+		candidates := []service.DeviceResponse{
+			{ID: 1, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "A"},
+			{ID: 2, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "B"},
+			{ID: 3, CPU: 10, Memory: 10, IDC: "idc1", Room: "R2", Cabinet: "A"},
+		}
+		policy := &service.ResourcePoolDeviceMatchingPolicy{MaxDevicesPerRoom: 1}
+		selection := s.filterAndSelectDevicesWithPolicy(candidates, entry, 1, 30, 30, policy, nil, nil)
+		assert.Equal(t, []int{1, 3}, selection.DeviceIDs)
+	})
+
+	t.Run("balances across rooms", func(t *testing.T) {
+		candidates := []service.DeviceResponse{
+			{ID: 1, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "A"},
+			{ID: 2, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "B"},
+			{ID: 3, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "C"},
+			{ID: 4, CPU: 10, Memory: 10, IDC: "idc1", Room: "R2", Cabinet: "A"},
+			{ID: 5, CPU: 10, Memory: 10, IDC: "idc1", Room: "R2", Cabinet: "B"},
+		}
+		policy := &service.ResourcePoolDeviceMatchingPolicy{BalanceAcrossRooms: true}
+		selection := s.filterAndSelectDevicesWithPolicy(candidates, entry, 1, 40, 40, policy, nil, nil)
+		assert.Equal(t, []int{1, 4, 2, 5}, selection.DeviceIDs)
+	})
+
+	t.Run("device count mode keeps the number of devices", func(t *testing.T) {
+		candidates := []service.DeviceResponse{
+			{ID: 1, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "A"},
+			{ID: 2, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "A"},
+			{ID: 3, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "B"},
+		}
+		policy := &service.ResourcePoolDeviceMatchingPolicy{MaxDevicesPerCabinet: 1}
+		selection := s.filterAndSelectDevicesWithPolicy(candidates, entry, 1, 0, 0, policy, nil, nil)
+		assert.Equal(t, selectionAlgorithmDeviceCount, selection.Algorithm)
+		assert.Equal(t, []int{1}, selection.DeviceIDs)
+		assert.False(t, selection.FaultDomainAdjusted)
+	})
+}
+
+func TestFaultDomainExitKeepsMinimumPerCabinet(t *testing.T) {
+	s, db := newTestService(t)
+	exit := &portal.ElasticScalingStrategy{ThresholdTriggerAction: TriggerActionPoolExit}
+
+	// 集群在机柜A有2台设备，机柜B有3台设备
+	devices := []portal.Device{
+		{BaseModel: portal.BaseModel{ID: 1}, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "A", ClusterID: 1},
+		{BaseModel: portal.BaseModel{ID: 2}, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "A", ClusterID: 1},
+		{BaseModel: portal.BaseModel{ID: 3}, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "B", ClusterID: 1},
+		{BaseModel: portal.BaseModel{ID: 4}, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "B", ClusterID: 1},
+		{BaseModel: portal.BaseModel{ID: 5}, CPU: 10, Memory: 10, IDC: "idc1", Room: "R1", Cabinet: "B", ClusterID: 1},
+	}
+	require.NoError(t, db.Create(&devices).Error)
+
+	var candidates []service.DeviceResponse
+	for _, device := range devices {
+		candidates = append(candidates, service.DeviceResponse{
+			ID: device.ID, CPU: device.CPU, Memory: device.Memory,
+			IDC: device.IDC, Room: device.Room, Cabinet: device.Cabinet, ClusterID: device.ClusterID,
+		})
+	}
+
+	t.Run("without minimum selects until demand is met", func(t *testing.T) {
+		selection := s.filterAndSelectDevicesWithPolicy(candidates, exit, 1, -30, -30, &service.ResourcePoolDeviceMatchingPolicy{}, nil, nil)
+		assert.Len(t, selection.DeviceIDs, 3)
+	})
+
+	t.Run("keeps minimum devices in each cabinet", func(t *testing.T) {
+		policy := &service.ResourcePoolDeviceMatchingPolicy{MinDevicesPerCabinet: 2}
+		selection := s.filterAndSelectDevicesWithPolicy(candidates, exit, 1, -30, -30, policy, nil, nil)
+		assert.Len(t, selection.DeviceIDs, 1)
+		assert.Subset(t, []int{3, 4, 5}, selection.DeviceIDs)
+		assert.True(t, selection.FaultDomainAdjusted)
+	})
+}
+
+func TestFaultDomainCapsHoldAcrossPolicies(t *testing.T) {
+	s, db := newMatchingTestService(t)
+	// 设备1、2位于同一机柜，设备3位于另一机柜
+	for i, cabinet := range []string{"A", "A", "B"} {
+		require.NoError(t, db.Create(&portal.Device{
+			BaseModel: portal.BaseModel{ID: i + 1},
+			CICode:    fmt.Sprintf("device-%d", i+1),
+			IDC:       "idc-a", Room: "R1", Cabinet: cabinet, CPU: 10, Memory: 10,
+		}).Error)
+	}
+	require.NoError(t, db.Create(&portal.QueryTemplate{BaseModel: portal.BaseModel{ID: 1}, Name: "device-1",
+		Groups: `[{"id":"g1","operator":"and","blocks":[{"id":"b1","type":"device","key":"ciCode","conditionType":"equal","value":"device-1","operator":"and"}]}]`}).Error)
+	require.NoError(t, db.Create(&portal.QueryTemplate{BaseModel: portal.BaseModel{ID: 2}, Name: "device-2-3",
+		Groups: `[{"id":"g1","operator":"and","blocks":[{"id":"b1","type":"device","key":"ciCode","conditionType":"in","value":["device-2","device-3"],"operator":"and"}]}]`}).Error)
+	require.NoError(t, db.Create(&portal.ResourcePoolDeviceMatchingPolicy{Name: "first", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 1, Status: "enabled", Priority: 1, MaxDevicesPerCabinet: 1}).Error)
+	require.NoError(t, db.Create(&portal.ResourcePoolDeviceMatchingPolicy{Name: "second", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 2, Status: "enabled", Priority: 2, MaxDevicesPerCabinet: 1}).Error)
+
+	// 无资源增量时按设备数选择：第二个策略单独选择时会选中与设备1同机柜的设备2
+	strategy := &portal.ElasticScalingStrategy{BaseModel: portal.BaseModel{ID: 1}, ThresholdTriggerAction: TriggerActionPoolEntry}
+	result, err := s.collectMatchedDevices(&evaluationContext{}, strategy, 1, "total", "", "", 0, 0)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []int{1, 3}, result.SelectedDeviceIDs)
+	second := result.Trace.Policies[1]
+	assert.Equal(t, []int{3}, second.SelectedDeviceIDs)
+	require.Len(t, second.RejectedDevices, 1)
+	assert.Equal(t, 2, second.RejectedDevices[0].DeviceID)
+	assert.Equal(t, MatchingRejectConstraintViolated, second.RejectedDevices[0].Reason)
+}
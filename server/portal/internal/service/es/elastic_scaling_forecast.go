@@ -0,0 +1,289 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"navy-ng/models/portal"
+	"strings"
+	"time"
+)
+
+const (
+	// 预测模式回看的历史天数
+	forecastLookbackDays = 30
+
+	// 进行预测所需的最少每日快照数量
+	minForecastPoints = 7
+
+	// Holt 线性趋势模型的平滑系数
+	holtAlpha = 0.5 // 水平
+	holtBeta  = 0.3 // 趋势
+
+	// 预测窗口的最大天数
+	maxForecastHorizonDays = 90
+
+	// 预测结果展示用日期格式
+	forecastDateFormat = time.DateOnly
+)
+
+// metricForecast 单个指标（CPU或内存）的预测结果
+type metricForecast struct {
+	ThresholdType string     `json:"thresholdType"`          // usage 或 allocated
+	Threshold     float64    `json:"threshold"`              // 阈值（百分比）
+	Values        []float64  `json:"values"`                 // 输入序列（每日最新快照的指标值）
+	Current       float64    `json:"current"`                // 最新快照的指标值
+	Level         float64    `json:"level"`                  // 模型拟合的当前水平
+	TrendPerDay   float64    `json:"trendPerDay"`            // 模型拟合的每日变化量
+	Projected     float64    `json:"projected"`              // 预测窗口末的指标值
+	CrossingDate  *time.Time `json:"crossingDate,omitempty"` // 预计越过阈值的日期，不会越过时为空
+}
+
+// forecastResult 预测模式下单次评估的预测结果，序列化后记录到策略执行历史
+type forecastResult struct {
+	Method         string          `json:"method"`                 // linear 或 holt
+	HorizonDays    int             `json:"horizonDays"`            // 预测窗口（天）
+	ConditionLogic string          `json:"conditionLogic"`         // CPU与内存的组合逻辑
+	StartDate      string          `json:"startDate"`              // 输入序列的开始日期
+	EndDate        string          `json:"endDate"`                // 输入序列的结束日期
+	Points         int             `json:"points"`                 // 输入序列的数据点数量
+	CPU            *metricForecast `json:"cpu,omitempty"`          // CPU预测
+	Memory         *metricForecast `json:"memory,omitempty"`       // 内存预测
+	CrossingDate   *time.Time      `json:"crossingDate,omitempty"` // 按组合逻辑得出的预计越过阈值日期
+	Triggered      bool            `json:"triggered"`              // 越过日期是否落在预测窗口内
+}
+
+// isForecastStrategy 判断策略是否使用预测模式触发
+func isForecastStrategy(strategy *portal.ElasticScalingStrategy) bool {
+	return strategy.TriggerMode == TriggerModeForecast
+}
+
+// forecastSnapshots 基于按时间升序的每日快照预测指标趋势，判断是否会在预测窗口内越过阈值。
+// 数据点不足时返回nil。
+func (s *ElasticScalingService) forecastSnapshots(
+	dailySnapshots []portal.ResourceSnapshot,
+	strategy *portal.ElasticScalingStrategy,
+	evalTime time.Time,
+) *forecastResult {
+	if len(dailySnapshots) < minForecastPoints {
+		return nil
+	}
+
+	method := strategy.ForecastMethod
+	if method == "" {
+		method = ForecastMethodLinear
+	}
+
+	first := time.Time(dailySnapshots[0].CreatedAt)
+	last := time.Time(dailySnapshots[len(dailySnapshots)-1].CreatedAt)
+	result := &forecastResult{
+		Method:         method,
+		HorizonDays:    strategy.ForecastHorizonDays,
+		ConditionLogic: strategy.ConditionLogic,
+		StartDate:      first.Format(forecastDateFormat),
+		EndDate:        last.Format(forecastDateFormat),
+		Points:         len(dailySnapshots),
+	}
+
+	// 以天为单位的时间轴，允许快照日期不连续
+	days := make([]float64, len(dailySnapshots))
+	for i, snapshot := range dailySnapshots {
+		days[i] = time.Time(snapshot.CreatedAt).Sub(first).Hours() / 24
+	}
+
+	if strategy.CPUThresholdValue > 0 {
+		values := make([]float64, len(dailySnapshots))
+		for i, snapshot := range dailySnapshots {
+			values[i] = cpuMetricValue(snapshot, strategy.CPUThresholdType)
+		}
+		result.CPU = forecastMetric(method, days, values, strategy.CPUThresholdType, strategy.CPUThresholdValue, last, evalTime, strategy.ForecastHorizonDays)
+	}
+	if strategy.MemoryThresholdValue > 0 {
+		values := make([]float64, len(dailySnapshots))
+		for i, snapshot := range dailySnapshots {
+			values[i] = memMetricValue(snapshot, strategy.MemoryThresholdType)
+		}
+		result.Memory = forecastMetric(method, days, values, strategy.MemoryThresholdType, strategy.MemoryThresholdValue, last, evalTime, strategy.ForecastHorizonDays)
+	}
+
+	result.CrossingDate = combineCrossingDates(result.CPU, result.Memory, strategy.ConditionLogic)
+	if result.CrossingDate != nil {
+		horizonEnd := evalTime.AddDate(0, 0, strategy.ForecastHorizonDays)
+		result.Triggered = !result.CrossingDate.After(horizonEnd)
+	}
+	return result
+}
+
+// forecastMetric 拟合单个指标序列，返回当前水平、趋势以及预计越过阈值的日期
+func forecastMetric(method string, days, values []float64, thresholdType string, threshold float64, last, evalTime time.Time, horizonDays int) *metricForecast {
+	var level, trend float64
+	if method == ForecastMethodHolt {
+		level, trend = holtLinearTrend(values)
+	} else {
+		slope, intercept := linearRegression(days, values)
+		level = intercept + slope*days[len(days)-1]
+		trend = slope
+	}
+
+	forecast := &metricForecast{
+		ThresholdType: thresholdType,
+		Threshold:     threshold,
+		Values:        values,
+		Current:       values[len(values)-1],
+		Level:         level,
+		TrendPerDay:   trend,
+	}
+
+	// 预测值以最新快照时间为起点外推
+	daysToHorizonEnd := evalTime.AddDate(0, 0, horizonDays).Sub(last).Hours() / 24
+	forecast.Projected = level + trend*daysToHorizonEnd
+
+	switch {
+	case level > threshold:
+		// 当前水平已超过阈值，立即越线
+		crossing := last
+		forecast.CrossingDate = &crossing
+	case trend > 0:
+		crossing := last.Add(time.Duration((threshold - level) / trend * float64(24*time.Hour)))
+		forecast.CrossingDate = &crossing
+	}
+	return forecast
+}
+
+// combineCrossingDates 按组合逻辑合并CPU与内存的越线日期：AND 取较晚者，OR 取较早者
+func combineCrossingDates(cpu, mem *metricForecast, logic string) *time.Time {
+	if cpu == nil && mem == nil {
+		return nil
+	}
+	if cpu == nil {
+		return mem.CrossingDate
+	}
+	if mem == nil {
+		return cpu.CrossingDate
+	}
+
+	if logic == ConditionLogicAnd {
+		if cpu.CrossingDate == nil || mem.CrossingDate == nil {
+			return nil
+		}
+		if cpu.CrossingDate.After(*mem.CrossingDate) {
+			return cpu.CrossingDate
+		}
+		return mem.CrossingDate
+	}
+
+	if cpu.CrossingDate == nil {
+		return mem.CrossingDate
+	}
+	if mem.CrossingDate == nil || cpu.CrossingDate.Before(*mem.CrossingDate) {
+		return cpu.CrossingDate
+	}
+	return mem.CrossingDate
+}
+
+// linearRegression 最小二乘法拟合 y = slope*x + intercept
+func linearRegression(xs, ys []float64) (slope, intercept float64) {
+	n := float64(len(xs))
+	var sumX, sumY, sumXY, sumXX float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+		sumXY += xs[i] * ys[i]
+		sumXX += xs[i] * xs[i]
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, sumY / n
+	}
+	slope = (n*sumXY - sumX*sumY) / denominator
+	intercept = (sumY - slope*sumX) / n
+	return slope, intercept
+}
+
+// holtLinearTrend Holt 线性趋势模型（无季节项的 Holt-Winters），返回最终的水平和每步趋势。
+// 序列按每日一个数据点处理。
+func holtLinearTrend(values []float64) (level, trend float64) {
+	level = values[0]
+	trend = values[1] - values[0]
+	for _, value := range values[1:] {
+		prevLevel := level
+		level = holtAlpha*value + (1-holtAlpha)*(level+trend)
+		trend = holtBeta*(level-prevLevel) + (1-holtBeta)*trend
+	}
+	return level, trend
+}
+
+// projectSnapshot 根据预测窗口末的指标值构造快照，用于按目标值计算资源增量
+func (f *forecastResult) projectSnapshot(snapshot portal.ResourceSnapshot) portal.ResourceSnapshot {
+	projected := snapshot
+	if f.CPU != nil {
+		value := math.Max(f.CPU.Projected, f.CPU.Current)
+		if f.CPU.ThresholdType == ThresholdTypeUsage {
+			projected.MaxCpuUsageRatio = value
+		} else {
+			projected.CpuRequest = value / 100 * snapshot.CpuCapacity
+		}
+	}
+	if f.Memory != nil {
+		value := math.Max(f.Memory.Projected, f.Memory.Current)
+		if f.Memory.ThresholdType == ThresholdTypeUsage {
+			projected.MaxMemoryUsageRatio = value
+		} else {
+			projected.MemRequest = value / 100 * snapshot.MemoryCapacity
+		}
+	}
+	return projected
+}
+
+// projectedCrossingTime 返回用于持久化的预计越线时间
+func (f *forecastResult) projectedCrossingTime() *portal.NavyTime {
+	if f == nil || f.CrossingDate == nil {
+		return nil
+	}
+	crossing := portal.NavyTime(*f.CrossingDate)
+	return &crossing
+}
+
+// inputJSON 序列化预测输入和结果，序列化失败时返回空字符串
+func (f *forecastResult) inputJSON() string {
+	if f == nil {
+		return ""
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// summary 返回预测结果的中文摘要，用作触发值描述
+func (f *forecastResult) summary() string {
+	var parts []string
+	for _, item := range []struct {
+		name     string
+		forecast *metricForecast
+	}{{"CPU", f.CPU}, {"内存", f.Memory}} {
+		if item.forecast == nil {
+			continue
+		}
+		part := fmt.Sprintf("%s%s 当前 %.2f%%，趋势 %+.2f%%/天，%d 天后预计 %.2f%%",
+			item.name, metricName(item.forecast.ThresholdType), item.forecast.Current,
+			item.forecast.TrendPerDay, f.HorizonDays, item.forecast.Projected)
+		parts = append(parts, part)
+	}
+
+	crossing := "预测窗口内不会越过阈值"
+	if f.CrossingDate != nil {
+		crossing = fmt.Sprintf("预计 %s 越过阈值", f.CrossingDate.Format(forecastDateFormat))
+	}
+	return fmt.Sprintf("%s；%s", strings.Join(parts, "；"), crossing)
+}
+
+// forecastMethodName 返回预测方法的中文名称
+func forecastMethodName(method string) string {
+	if method == ForecastMethodHolt {
+		return "Holt 线性趋势"
+	}
+	return "线性回归"
+}
@@ -0,0 +1,138 @@
+package es
+
+import (
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+
+	"github.com/jinzhu/now"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// dailyAllocationSnapshots 生成从 start 开始每天一个的快照，CPU分配率依次取 ratios
+func dailyAllocationSnapshots(clusterID int, start time.Time, ratios ...float64) []portal.ResourceSnapshot {
+	snapshots := make([]portal.ResourceSnapshot, len(ratios))
+	for i, ratio := range ratios {
+		snapshots[i] = portal.ResourceSnapshot{
+			BaseModel:   portal.BaseModel{CreatedAt: portal.NavyTime(start.AddDate(0, 0, i))},
+			ClusterID:   uint(clusterID),
+			CpuRequest:  ratio,
+			CpuCapacity: 100,
+		}
+	}
+	return snapshots
+}
+
+func newForecastStrategy(method string, horizonDays int) *portal.ElasticScalingStrategy {
+	return &portal.ElasticScalingStrategy{
+		Name:                   "forecast",
+		CPUThresholdType:       ThresholdTypeAllocated,
+		CPUThresholdValue:      85,
+		CPUTargetValue:         70,
+		ThresholdTriggerAction: TriggerActionPoolEntry,
+		TriggerMode:            TriggerModeForecast,
+		ForecastMethod:         method,
+		ForecastHorizonDays:    horizonDays,
+		Status:                 StrategyStatusEnabled,
+	}
+}
+
+func TestForecastSnapshots(t *testing.T) {
+	s := &ElasticScalingService{logger: zap.NewNop()}
+	start := time.Date(2026, 10, 1, 10, 0, 0, 0, time.Local)
+	// 每天增长2%，最后一天为78%
+	snapshots := dailyAllocationSnapshots(1, start, 60, 62, 64, 66, 68, 70, 72, 74, 76, 78)
+	evalTime := time.Time(snapshots[len(snapshots)-1].CreatedAt)
+
+	t.Run("linear regression projects crossing date within horizon", func(t *testing.T) {
+		result := s.forecastSnapshots(snapshots, newForecastStrategy(ForecastMethodLinear, 14), evalTime)
+		require.NotNil(t, result)
+		require.NotNil(t, result.CPU)
+		assert.InDelta(t, 2, result.CPU.TrendPerDay, 0.001)
+		assert.InDelta(t, 78+2*14, result.CPU.Projected, 0.001)
+		require.NotNil(t, result.CrossingDate)
+		// (85-78)/2 = 3.5 天后越线
+		assert.Equal(t, evalTime.Add(84*time.Hour), *result.CrossingDate)
+		assert.True(t, result.Triggered)
+	})
+
+	t.Run("does not trigger when crossing is beyond horizon", func(t *testing.T) {
+		result := s.forecastSnapshots(snapshots, newForecastStrategy(ForecastMethodLinear, 3), evalTime)
+		require.NotNil(t, result)
+		assert.NotNil(t, result.CrossingDate)
+		assert.False(t, result.Triggered)
+	})
+
+	t.Run("holt follows the trend", func(t *testing.T) {
+		result := s.forecastSnapshots(snapshots, newForecastStrategy(ForecastMethodHolt, 14), evalTime)
+		require.NotNil(t, result)
+		assert.InDelta(t, 2, result.CPU.TrendPerDay, 0.01)
+		assert.True(t, result.Triggered)
+	})
+
+	t.Run("flat series never crosses", func(t *testing.T) {
+		flat := dailyAllocationSnapshots(1, start, 50, 50, 50, 50, 50, 50, 50, 50)
+		result := s.forecastSnapshots(flat, newForecastStrategy(ForecastMethodLinear, 14), evalTime)
+		require.NotNil(t, result)
+		assert.Nil(t, result.CrossingDate)
+		assert.False(t, result.Triggered)
+	})
+
+	t.Run("requires enough history", func(t *testing.T) {
+		assert.Nil(t, s.forecastSnapshots(snapshots[:minForecastPoints-1], newForecastStrategy(ForecastMethodLinear, 14), evalTime))
+	})
+
+	t.Run("delta is sized on the projected value", func(t *testing.T) {
+		result := s.forecastSnapshots(snapshots, newForecastStrategy(ForecastMethodLinear, 14), evalTime)
+		projected := result.projectSnapshot(snapshots[len(snapshots)-1])
+		// 预测值106%，按目标70%计算：106/0.7 - 100
+		cpuDelta, _ := s.calculateResourceDelta(projected, newForecastStrategy(ForecastMethodLinear, 14))
+		assert.InDelta(t, 106/0.7-100, cpuDelta, 0.01)
+	})
+}
+
+func TestEvaluateAssociationRecordsForecast(t *testing.T) {
+	s, db := newTestService(t)
+
+	strategy := newForecastStrategy(ForecastMethodLinear, 14)
+	require.NoError(t, db.Create(strategy).Error)
+	start := now.BeginningOfDay().AddDate(0, 0, -9).Add(10 * time.Hour)
+	snapshots := dailyAllocationSnapshots(1, start, 60, 62, 64, 66, 68, 70, 72, 74, 76, 78)
+	require.NoError(t, db.Create(&snapshots).Error)
+
+	s.evaluateAssociation(&evaluationContext{}, strategy, 1)
+
+	// 未配置设备匹配策略，执行历史记录匹配失败，但应带上预测信息
+	var history portal.StrategyExecutionHistory
+	require.NoError(t, db.First(&history).Error)
+	assert.Equal(t, StrategyExecutionResultFailureInvalidTemplateID, history.Result)
+	assert.Contains(t, history.ForecastInput, `"method":"linear"`)
+	require.NotNil(t, history.ProjectedCrossingDate)
+	assert.Contains(t, history.TriggeredValue, "预计")
+	assert.Contains(t, history.ThresholdValue, "within 14 days (forecast)")
+}
+
+func TestValidateStrategyDTOForecast(t *testing.T) {
+	s := &ElasticScalingService{logger: zap.NewNop()}
+	threshold, allocated := 80.0, ThresholdTypeAllocated
+
+	newDTO := func(action string, horizon int) *StrategyDTO {
+		return &StrategyDTO{
+			Name:                   "forecast",
+			Status:                 StrategyStatusEnabled,
+			ClusterIDs:             []int{1},
+			ThresholdTriggerAction: action,
+			CPUThresholdValue:      &threshold,
+			CPUThresholdType:       &allocated,
+			TriggerMode:            TriggerModeForecast,
+			ForecastHorizonDays:    horizon,
+		}
+	}
+
+	assert.NoError(t, s.validateStrategyDTO(newDTO(TriggerActionPoolEntry, 14)))
+	assert.Error(t, s.validateStrategyDTO(newDTO(TriggerActionPoolExit, 14)))
+	assert.Error(t, s.validateStrategyDTO(newDTO(TriggerActionPoolEntry, 0)))
+}
@@ -0,0 +1,32 @@
+package es
+
+import (
+	"context"
+	"fmt"
+	"navy-ng/models/portal"
+	. "navy-ng/server/portal/internal/service"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// checkFreezeCalendar 检查集群在指定时间是否处于变更冻结期，返回是否冻结及中文原因。
+// 查询冻结日历失败时不阻断评估，仅记录错误日志。
+func (s *ElasticScalingService) checkFreezeCalendar(clusterID int, at time.Time) (bool, string) {
+	windows, err := NewFreezeCalendarService(s.db).ActiveFreezeWindows(context.Background(), &clusterID, at)
+	if err != nil {
+		s.logger.Error("Failed to check freeze calendar", zap.Error(err), zap.Int("clusterID", clusterID))
+		return false, ""
+	}
+	if len(windows) == 0 {
+		return false, ""
+	}
+
+	// 获取集群名称用于中文描述
+	var cluster portal.K8sCluster
+	clusterName := "未知集群"
+	if err := s.db.Select("clustername").First(&cluster, clusterID).Error; err == nil {
+		clusterName = cluster.ClusterName
+	}
+	return true, fmt.Sprintf("集群 %s 处于变更冻结期（%s），跳过本次评估", clusterName, FreezeWindowNames(windows))
+}
@@ -0,0 +1,127 @@
+package es
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreezeCalendarRules(t *testing.T) {
+	_, db := newTestService(t)
+	calendar := service.NewFreezeCalendarService(db)
+	ctx := context.Background()
+
+	clusterID := 1
+	otherCluster := 2
+	start := time.Date(2024, 10, 1, 0, 0, 0, 0, time.Local)
+	end := start.Add(7 * 24 * time.Hour)
+
+	_, err := calendar.CreateFreezeWindow(ctx, &service.FreezeWindowRequest{
+		Name: "国庆", RuleType: portal.FreezeRuleTypeRange, StartTime: &start, EndTime: &end,
+	}, "tester")
+	require.NoError(t, err)
+	_, err = calendar.CreateFreezeWindow(ctx, &service.FreezeWindowRequest{
+		Name: "周末", ClusterID: &clusterID, RuleType: portal.FreezeRuleTypeWeekly, Weekdays: []int{6, 0},
+	}, "tester")
+	require.NoError(t, err)
+	_, err = calendar.CreateFreezeWindow(ctx, &service.FreezeWindowRequest{
+		Name: "双十一", ClusterID: &clusterID, RuleType: portal.FreezeRuleTypeDates, Dates: []string{"11-11", "2024-12-31"},
+	}, "tester")
+	require.NoError(t, err)
+	_, err = calendar.CreateFreezeWindow(ctx, &service.FreezeWindowRequest{
+		Name: "停用", RuleType: portal.FreezeRuleTypeWeekly, Weekdays: []int{1, 2, 3, 4, 5}, Status: service.FreezeWindowStatusDisabled,
+	}, "tester")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		clusterID *int
+		at        time.Time
+		expected  []string
+	}{
+		{"global range applies to every cluster", &otherCluster, time.Date(2024, 10, 3, 12, 0, 0, 0, time.Local), []string{"国庆"}},
+		{"range end is exclusive", &otherCluster, end, nil},
+		{"weekly rule on saturday", &clusterID, time.Date(2024, 11, 16, 9, 0, 0, 0, time.Local), []string{"周末"}},
+		{"weekly rule does not leak to other clusters", &otherCluster, time.Date(2024, 11, 16, 9, 0, 0, 0, time.Local), nil},
+		{"annual date matches every year", &clusterID, time.Date(2025, 11, 11, 9, 0, 0, 0, time.Local), []string{"双十一"}},
+		{"one-off date", &clusterID, time.Date(2024, 12, 31, 9, 0, 0, 0, time.Local), []string{"双十一"}},
+		{"overlapping windows", &clusterID, time.Date(2024, 10, 5, 9, 0, 0, 0, time.Local), []string{"国庆", "周末"}},
+		{"global only without cluster", nil, time.Date(2024, 11, 16, 9, 0, 0, 0, time.Local), nil},
+		{"weekday is not frozen", &clusterID, time.Date(2024, 11, 13, 9, 0, 0, 0, time.Local), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := calendar.CheckFreezeStatus(ctx, tt.clusterID, tt.at)
+			require.NoError(t, err)
+			var names []string
+			for _, w := range status.Windows {
+				names = append(names, w.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+			assert.Equal(t, len(tt.expected) > 0, status.Frozen)
+		})
+	}
+}
+
+func TestFreezeCalendarValidation(t *testing.T) {
+	_, db := newTestService(t)
+	calendar := service.NewFreezeCalendarService(db)
+	start := time.Now()
+	end := start.Add(-time.Hour)
+
+	invalid := []service.FreezeWindowRequest{
+		{Name: "bad type", RuleType: "monthly"},
+		{Name: "no end", RuleType: portal.FreezeRuleTypeRange, StartTime: &start},
+		{Name: "end before start", RuleType: portal.FreezeRuleTypeRange, StartTime: &start, EndTime: &end},
+		{Name: "bad weekday", RuleType: portal.FreezeRuleTypeWeekly, Weekdays: []int{7}},
+		{Name: "bad date", RuleType: portal.FreezeRuleTypeDates, Dates: []string{"2024/10/01"}},
+		{Name: "bad status", RuleType: portal.FreezeRuleTypeWeekly, Weekdays: []int{0}, Status: "paused"},
+	}
+	for _, req := range invalid {
+		_, err := calendar.CreateFreezeWindow(context.Background(), &req, "tester")
+		assert.True(t, service.IsBadRequest(err), req.Name)
+	}
+}
+
+func TestEvaluateResourcePoolSkipsDuringFreeze(t *testing.T) {
+	s, db := newTestService(t)
+
+	strategy := &portal.ElasticScalingStrategy{
+		Name:                   "entry",
+		ThresholdTriggerAction: TriggerActionPoolEntry,
+		CPUThresholdValue:      80,
+		CPUThresholdType:       ThresholdTypeAllocated,
+		DurationMinutes:        1,
+		DurationUnit:           DurationUnitDay,
+		CooldownMinutes:        60,
+		ResourceTypes:          "total",
+		Status:                 StrategyStatusEnabled,
+	}
+	require.NoError(t, db.Create(strategy).Error)
+
+	clusterID := 1
+	_, err := service.NewFreezeCalendarService(db).CreateFreezeWindow(context.Background(), &service.FreezeWindowRequest{
+		Name: "全周冻结", ClusterID: &clusterID, RuleType: portal.FreezeRuleTypeWeekly, Weekdays: []int{0, 1, 2, 3, 4, 5, 6},
+	}, "tester")
+	require.NoError(t, err)
+
+	var records []portal.StrategyExecutionHistory
+	evalCtx := &evaluationContext{ExecutionRecords: &records}
+	s.evaluateResourcePool(evalCtx, strategy, clusterID, "total")
+
+	require.Len(t, records, 1)
+	assert.Equal(t, StrategyExecutionResultSkippedFreeze, records[0].Result)
+	assert.Contains(t, records[0].Reason, "全周冻结")
+
+	// 其他集群不受集群级冻结窗口影响
+	records = nil
+	s.evaluateResourcePool(evalCtx, strategy, 2, "total")
+	require.Len(t, records, 1)
+	assert.Equal(t, StrategyExecutionResultFailureNoSnapshots, records[0].Result)
+}
@@ -0,0 +1,233 @@
+package es
+
+import (
+	"errors"
+	"fmt"
+	"navy-ng/models/portal"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// oppositeOrder 防抖窗口内同一集群+资源池的反向订单
+type oppositeOrder struct {
+	OrderID    int             `gorm:"column:order_id"`
+	StrategyID *int            `gorm:"column:strategy_id"`
+	CreatedAt  portal.NavyTime `gorm:"column:created_at"`
+}
+
+// getHysteresisWindow 返回策略的反向订单防抖窗口，为0时表示未启用反向订单防抖
+func getHysteresisWindow(strategy *portal.ElasticScalingStrategy) time.Duration {
+	if strategy.HysteresisMinutes <= 0 {
+		return 0
+	}
+	return time.Duration(strategy.HysteresisMinutes) * time.Minute
+}
+
+// oppositeAction 返回与给定动作方向相反的动作
+func oppositeAction(action string) string {
+	if action == TriggerActionPoolEntry {
+		return TriggerActionPoolExit
+	}
+	return TriggerActionPoolEntry
+}
+
+// findRecentOppositeOrder 查询评估时间之前防抖窗口内同一集群+资源池由任意策略生成的非取消状态反向订单，
+// 未找到或策略未启用反向订单防抖时返回nil。
+func (s *ElasticScalingService) findRecentOppositeOrder(strategy *portal.ElasticScalingStrategy, clusterID int, resourceType string, evalTime time.Time) (*oppositeOrder, error) {
+	window := getHysteresisWindow(strategy)
+	if window <= 0 {
+		return nil, nil
+	}
+
+	var order oppositeOrder
+	err := s.db.Table("ng_orders o").
+		Select("o.id AS order_id, esd.strategy_id, o.created_at").
+		Joins("JOIN ng_elastic_scaling_order_details esd ON o.id = esd.order_id").
+		Where("esd.cluster_id = ? AND esd.resource_pool_type = ? AND esd.action_type = ? AND o.status != ? AND o.created_at >= ? AND o.created_at <= ?",
+			clusterID, resourceType, oppositeAction(strategy.ThresholdTriggerAction), portal.OrderStatusCancelled,
+			evalTime.Add(-window), evalTime).
+		Order("o.created_at DESC").
+		Take(&order).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query opposite order for cluster %d resource %s: %w", clusterID, resourceType, err)
+	}
+	return &order, nil
+}
+
+// checkOppositeOrderHysteresis 检查反向订单防抖窗口，命中时记录防抖拦截的执行历史并返回true。
+func (s *ElasticScalingService) checkOppositeOrderHysteresis(
+	evalCtx *evaluationContext,
+	strategy *portal.ElasticScalingStrategy,
+	clusterID int,
+	resourceType string,
+	triggeredValueStr string,
+	thresholdValueStr string,
+	evalTime time.Time,
+) (bool, error) {
+	order, err := s.findRecentOppositeOrder(strategy, clusterID, resourceType, evalTime)
+	if err != nil || order == nil {
+		return false, err
+	}
+
+	// 获取集群名称用于中文描述
+	var cluster portal.K8sCluster
+	clusterName := "未知集群"
+	if err := s.db.Select("clustername").First(&cluster, clusterID).Error; err == nil {
+		clusterName = cluster.ClusterName
+	}
+
+	reason := s.hysteresisReason(strategy, order, clusterName, resourceType)
+	s.logger.Info(reason,
+		zap.Int("strategyID", strategy.ID),
+		zap.Int("clusterID", clusterID),
+		zap.String("resourceType", resourceType),
+		zap.Int("oppositeOrderID", order.OrderID))
+
+	currentTime := portal.NavyTime(evalTime)
+	s.recordStrategyExecution(evalCtx, strategy.ID, clusterID, resourceType, StrategyExecutionResultBlockedAntiFlapping, nil, reason, triggeredValueStr, thresholdValueStr, &currentTime)
+	return true, nil
+}
+
+// hysteresisReason 返回反向订单防抖拦截的中文说明
+func (s *ElasticScalingService) hysteresisReason(strategy *portal.ElasticScalingStrategy, order *oppositeOrder, clusterName, resourceType string) string {
+	return fmt.Sprintf("集群 %s（%s类型）在 %s 已生成%s订单 %d，%d 分钟防抖窗口内不生成%s订单",
+		clusterName, resourceType, time.Time(order.CreatedAt).Format(time.DateTime),
+		s.getActionName(oppositeAction(strategy.ThresholdTriggerAction)), order.OrderID,
+		strategy.HysteresisMinutes, s.getActionName(strategy.ThresholdTriggerAction))
+}
+
+// getEntryStrategiesForPool 获取关联该集群且包含该资源池的所有启用入池策略
+func (s *ElasticScalingService) getEntryStrategiesForPool(clusterID int, resourceType string) ([]portal.ElasticScalingStrategy, error) {
+	var strategies []portal.ElasticScalingStrategy
+	err := s.db.Table("ng_elastic_scaling_strategy s").
+		Select("s.*").
+		Joins("JOIN ng_strategy_cluster_association sca ON s.id = sca.strategy_id").
+		Where("sca.cluster_id = ? AND s.threshold_trigger_action = ? AND s.status = ?",
+			clusterID, TriggerActionPoolEntry, StrategyStatusEnabled).
+		Find(&strategies).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []portal.ElasticScalingStrategy
+	for _, strategy := range strategies {
+		for _, rt := range parseResourceTypes(strategy.ResourceTypes) {
+			if rt == resourceType {
+				matched = append(matched, strategy)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+// projectSnapshotCapacity 假设负载（实际使用量或Request）不变，返回容量变化后的预测快照。
+// 容量变化量为正表示入池，为负表示出池。
+func projectSnapshotCapacity(snapshot portal.ResourceSnapshot, cpuCapacityChange, memCapacityChange float64) portal.ResourceSnapshot {
+	projected := snapshot
+	projected.CpuCapacity = snapshot.CpuCapacity + cpuCapacityChange
+	projected.MemoryCapacity = snapshot.MemoryCapacity + memCapacityChange
+	projected.MaxCpuUsageRatio = safePercentage(snapshot.MaxCpuUsageRatio/100*snapshot.CpuCapacity, projected.CpuCapacity)
+	projected.MaxMemoryUsageRatio = safePercentage(snapshot.MaxMemoryUsageRatio/100*snapshot.MemoryCapacity, projected.MemoryCapacity)
+	return projected
+}
+
+// entryThresholdViolations 返回预测快照达到或超过入池策略阈值的指标描述，未超过时返回空
+func entryThresholdViolations(projected portal.ResourceSnapshot, entry *portal.ElasticScalingStrategy) []string {
+	var violations []string
+	if entry.CPUThresholdValue > 0 {
+		if value := cpuMetricValue(projected, entry.CPUThresholdType); value >= entry.CPUThresholdValue {
+			violations = append(violations, fmt.Sprintf("CPU%s %.2f%% ≥ %.2f%%", metricName(entry.CPUThresholdType), value, entry.CPUThresholdValue))
+		}
+	}
+	if entry.MemoryThresholdValue > 0 {
+		if value := memMetricValue(projected, entry.MemoryThresholdType); value >= entry.MemoryThresholdValue {
+			violations = append(violations, fmt.Sprintf("内存%s %.2f%% ≥ %.2f%%", metricName(entry.MemoryThresholdType), value, entry.MemoryThresholdValue))
+		}
+	}
+	return violations
+}
+
+// checkExitAgainstEntryThresholds 出池前检查：预测出池后的指标必须低于该资源池所有启用入池策略的阈值，
+// 否则出池后会立即触发入池。命中时记录防抖拦截的执行历史（附带本次设备匹配过程）并返回true。
+func (s *ElasticScalingService) checkExitAgainstEntryThresholds(
+	evalCtx *evaluationContext,
+	strategy *portal.ElasticScalingStrategy,
+	clusterID int,
+	resourceType string,
+	selectedDeviceIDs []int,
+	triggeredValueStr string,
+	thresholdValueStr string,
+	latestSnapshot *portal.ResourceSnapshot,
+) (bool, error) {
+	reason, err := s.exitEntryConflict(strategy, clusterID, resourceType, selectedDeviceIDs, latestSnapshot)
+	if err != nil || reason == "" {
+		return false, err
+	}
+
+	s.logger.Info(reason,
+		zap.Int("strategyID", strategy.ID),
+		zap.Int("clusterID", clusterID),
+		zap.String("resourceType", resourceType))
+
+	s.recordBlockedExecution(evalCtx, strategy, clusterID, resourceType, StrategyExecutionResultBlockedAntiFlapping, reason, triggeredValueStr, thresholdValueStr)
+	return true, nil
+}
+
+// exitEntryConflict 预测出池后的指标，返回会达到入池策略阈值时的拦截原因，不冲突时返回空
+func (s *ElasticScalingService) exitEntryConflict(
+	strategy *portal.ElasticScalingStrategy,
+	clusterID int,
+	resourceType string,
+	selectedDeviceIDs []int,
+	latestSnapshot *portal.ResourceSnapshot,
+) (string, error) {
+	if strategy.ThresholdTriggerAction != TriggerActionPoolExit || len(selectedDeviceIDs) == 0 || latestSnapshot == nil {
+		return "", nil
+	}
+
+	entryStrategies, err := s.getEntryStrategiesForPool(clusterID, resourceType)
+	if err != nil {
+		return "", fmt.Errorf("failed to get entry strategies for cluster %d resource %s: %w", clusterID, resourceType, err)
+	}
+	if len(entryStrategies) == 0 {
+		return "", nil
+	}
+
+	var devices []portal.Device
+	if err := s.db.Where("id IN ?", selectedDeviceIDs).Find(&devices).Error; err != nil {
+		return "", fmt.Errorf("failed to fetch selected devices: %w", err)
+	}
+	var totalCPU, totalMemory float64
+	for _, d := range devices {
+		totalCPU += d.CPU
+		totalMemory += d.Memory
+	}
+	projected := projectSnapshotCapacity(*latestSnapshot, -totalCPU, -totalMemory)
+
+	var blocked []string
+	for i := range entryStrategies {
+		if violations := entryThresholdViolations(projected, &entryStrategies[i]); len(violations) > 0 {
+			blocked = append(blocked, fmt.Sprintf("入池策略 %s（%s）", entryStrategies[i].Name, strings.Join(violations, "，")))
+		}
+	}
+	if len(blocked) == 0 {
+		return "", nil
+	}
+
+	// 获取集群名称用于中文描述
+	var cluster portal.K8sCluster
+	clusterName := "未知集群"
+	if err := s.db.Select("clustername").First(&cluster, clusterID).Error; err == nil {
+		clusterName = cluster.ClusterName
+	}
+
+	return fmt.Sprintf("集群 %s（%s类型）预计出池 %d 台设备后将触发入池阈值，不生成出池订单：%s",
+		clusterName, resourceType, len(selectedDeviceIDs), strings.Join(blocked, "；")), nil
+}
@@ -0,0 +1,165 @@
+package es
+
+import (
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// createScalingOrder 创建一个指定动作和创建时间的弹性伸缩订单
+func createScalingOrder(t *testing.T, db *gorm.DB, number, action string, status portal.OrderStatus, createdAt time.Time) {
+	t.Helper()
+	order := portal.Order{
+		BaseModel:   portal.BaseModel{CreatedAt: portal.NavyTime(createdAt)},
+		OrderNumber: number,
+		Type:        portal.OrderTypeElasticScaling,
+		Status:      status,
+	}
+	require.NoError(t, db.Create(&order).Error)
+	require.NoError(t, db.Create(&portal.ElasticScalingOrderDetail{
+		OrderID:          order.ID,
+		ClusterID:        1,
+		ActionType:       action,
+		ResourcePoolType: "total",
+	}).Error)
+}
+
+func TestCheckOppositeOrderHysteresis(t *testing.T) {
+	s, db := newTestService(t)
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 1}, ClusterName: "cluster-a"}).Error)
+
+	evalTime := time.Now()
+	createScalingOrder(t, db, "ES-EXIT", TriggerActionPoolExit, portal.OrderStatusPending, evalTime.Add(-time.Hour))
+
+	entry := &portal.ElasticScalingStrategy{ThresholdTriggerAction: TriggerActionPoolEntry, HysteresisMinutes: 120}
+	exit := &portal.ElasticScalingStrategy{ThresholdTriggerAction: TriggerActionPoolExit, HysteresisMinutes: 120}
+
+	t.Run("blocks entry within window after exit order", func(t *testing.T) {
+		blocked, err := s.checkOppositeOrderHysteresis(&evaluationContext{}, entry, 1, "total", "", "", evalTime)
+		require.NoError(t, err)
+		assert.True(t, blocked)
+
+		var history portal.StrategyExecutionHistory
+		require.NoError(t, db.Last(&history).Error)
+		assert.Equal(t, StrategyExecutionResultBlockedAntiFlapping, history.Result)
+		assert.Contains(t, history.Reason, "防抖窗口")
+	})
+
+	t.Run("same direction is not blocked", func(t *testing.T) {
+		blocked, err := s.checkOppositeOrderHysteresis(&evaluationContext{}, exit, 1, "total", "", "", evalTime)
+		require.NoError(t, err)
+		assert.False(t, blocked)
+	})
+
+	t.Run("outside window is not blocked", func(t *testing.T) {
+		shortWindow := *entry
+		shortWindow.HysteresisMinutes = 30
+		blocked, err := s.checkOppositeOrderHysteresis(&evaluationContext{}, &shortWindow, 1, "total", "", "", evalTime)
+		require.NoError(t, err)
+		assert.False(t, blocked)
+	})
+
+	t.Run("zero window disables the check", func(t *testing.T) {
+		disabled := *entry
+		disabled.HysteresisMinutes = 0
+		blocked, err := s.checkOppositeOrderHysteresis(&evaluationContext{}, &disabled, 1, "total", "", "", evalTime)
+		require.NoError(t, err)
+		assert.False(t, blocked)
+	})
+
+	t.Run("orders after the evaluation time are ignored", func(t *testing.T) {
+		blocked, err := s.checkOppositeOrderHysteresis(&evaluationContext{}, entry, 1, "total", "", "", evalTime.Add(-2*time.Hour))
+		require.NoError(t, err)
+		assert.False(t, blocked)
+	})
+
+	t.Run("other resource pool is not blocked", func(t *testing.T) {
+		blocked, err := s.checkOppositeOrderHysteresis(&evaluationContext{}, entry, 1, "compute", "", "", evalTime)
+		require.NoError(t, err)
+		assert.False(t, blocked)
+	})
+
+	t.Run("cancelled orders are ignored", func(t *testing.T) {
+		require.NoError(t, db.Model(&portal.Order{}).Where("order_number = ?", "ES-EXIT").
+			Update("status", portal.OrderStatusCancelled).Error)
+		blocked, err := s.checkOppositeOrderHysteresis(&evaluationContext{}, entry, 1, "total", "", "", evalTime)
+		require.NoError(t, err)
+		assert.False(t, blocked)
+	})
+}
+
+func TestCheckExitAgainstEntryThresholds(t *testing.T) {
+	s, db := newTestService(t)
+
+	entry := &portal.ElasticScalingStrategy{
+		Name:                   "entry",
+		ThresholdTriggerAction: TriggerActionPoolEntry,
+		CPUThresholdValue:      80,
+		CPUThresholdType:       ThresholdTypeAllocated,
+		Status:                 StrategyStatusEnabled,
+		ResourceTypes:          "total",
+	}
+	require.NoError(t, db.Create(entry).Error)
+	require.NoError(t, db.Create(&portal.StrategyClusterAssociation{StrategyID: entry.ID, ClusterID: 1}).Error)
+
+	large := portal.Device{CICode: "large", CPU: 30}
+	small := portal.Device{CICode: "small", CPU: 10}
+	require.NoError(t, db.Create(&large).Error)
+	require.NoError(t, db.Create(&small).Error)
+
+	exit := &portal.ElasticScalingStrategy{ThresholdTriggerAction: TriggerActionPoolExit}
+	snapshot := &portal.ResourceSnapshot{CpuRequest: 60, CpuCapacity: 100}
+
+	t.Run("blocks exit that would breach entry threshold", func(t *testing.T) {
+		// 出池后 60/70 = 85.7% ≥ 80%
+		evalCtx := &evaluationContext{Trace: newDeviceMatchingTrace(exit, 1, "total", 0, 0)}
+		evalCtx.Trace.setOrderDevices([]int{large.ID})
+		blocked, err := s.checkExitAgainstEntryThresholds(evalCtx, exit, 1, "total", []int{large.ID}, "", "", snapshot)
+		require.NoError(t, err)
+		assert.True(t, blocked)
+
+		var history portal.StrategyExecutionHistory
+		require.NoError(t, db.Last(&history).Error)
+		assert.Equal(t, StrategyExecutionResultBlockedAntiFlapping, history.Result)
+		assert.Contains(t, history.Reason, "入池策略 entry")
+
+		trace := s.historyMatchingTrace(history)
+		require.NotNil(t, trace)
+		assert.Equal(t, []int{large.ID}, trace.OrderDeviceIDs)
+	})
+
+	t.Run("allows exit that stays below entry threshold", func(t *testing.T) {
+		// 出池后 60/90 = 66.7% < 80%
+		blocked, err := s.checkExitAgainstEntryThresholds(&evaluationContext{}, exit, 1, "total", []int{small.ID}, "", "", snapshot)
+		require.NoError(t, err)
+		assert.False(t, blocked)
+	})
+
+	t.Run("ignores entry strategies of other pools", func(t *testing.T) {
+		blocked, err := s.checkExitAgainstEntryThresholds(&evaluationContext{}, exit, 1, "compute", []int{large.ID}, "", "", snapshot)
+		require.NoError(t, err)
+		assert.False(t, blocked)
+	})
+}
+
+func TestProjectSnapshotCapacity(t *testing.T) {
+	snapshot := portal.ResourceSnapshot{
+		CpuRequest:          50,
+		CpuCapacity:         100,
+		MaxCpuUsageRatio:    40,
+		MemRequest:          100,
+		MemoryCapacity:      200,
+		MaxMemoryUsageRatio: 30,
+	}
+
+	projected := projectSnapshotCapacity(snapshot, -20, -50)
+	assert.InDelta(t, 62.5, cpuMetricValue(projected, ThresholdTypeAllocated), 0.001)
+	assert.InDelta(t, 50, cpuMetricValue(projected, ThresholdTypeUsage), 0.001)
+	assert.InDelta(t, 66.667, memMetricValue(projected, ThresholdTypeAllocated), 0.001)
+	assert.InDelta(t, 40, memMetricValue(projected, ThresholdTypeUsage), 0.001)
+}
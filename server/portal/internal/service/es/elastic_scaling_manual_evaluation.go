@@ -0,0 +1,108 @@
+package es
+
+import (
+	"errors"
+	"fmt"
+	"navy-ng/models/portal"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ErrStrategyEvaluationLocked 策略正在被其他实例评估（未获取到分布式锁）
+var ErrStrategyEvaluationLocked = errors.New("策略正在评估中，请稍后重试")
+
+// EvaluateStrategyNow 手动同步评估单个策略，可限定到单个集群和资源类型。
+// 与定时任务使用同一把 Redis 锁；Force 为 true 时忽略冷却期。
+// 评估过程与定时任务一致（满足条件时会创建订单），返回本次写入的各集群+资源池执行历史。
+func (s *ElasticScalingService) EvaluateStrategyNow(strategyID int, req StrategyEvaluateRequestDTO) (*StrategyEvaluateResultDTO, error) {
+	var strategy portal.ElasticScalingStrategy
+	if err := s.db.First(&strategy, strategyID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("策略不存在: %d", strategyID)
+		}
+		return nil, err
+	}
+	if strategy.Status != StrategyStatusEnabled {
+		return nil, errors.New("策略未启用，请使用模拟功能查看效果")
+	}
+
+	associations, err := s.getStrategyClusterAssociations(strategy.ID)
+	if err != nil {
+		return nil, fmt.Errorf(errFailedToGetAssociations, strategy.ID, err)
+	}
+	if req.ClusterID != nil {
+		var matched []portal.StrategyClusterAssociation
+		for _, assoc := range associations {
+			if assoc.ClusterID == *req.ClusterID {
+				matched = append(matched, assoc)
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("集群 %d 未关联到策略", *req.ClusterID)
+		}
+		associations = matched
+	}
+
+	lockKey := fmt.Sprintf(lockKeyFormat, strategy.ID)
+	lockValue := fmt.Sprintf(lockValueFormat, strategy.ID, time.Now().UnixNano())
+	locked, err := s.redisHandler.AcquireLock(lockKey, lockValue, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf(errFailedToCheckLock, strategy.ID, err)
+	}
+	if !locked {
+		return nil, ErrStrategyEvaluationLocked
+	}
+	defer s.redisHandler.Delete(lockKey)
+
+	s.logger.Info("Manually evaluating strategy",
+		zap.Int("strategyID", strategy.ID),
+		zap.Any("clusterID", req.ClusterID),
+		zap.String("resourceType", req.ResourceType),
+		zap.Bool("force", req.Force))
+
+	// 本次评估写入的执行历史通过评估上下文收集，强制评估选项同样只作用于本次评估
+	var records []portal.StrategyExecutionHistory
+	evalCtx := &evaluationContext{ForceEvaluation: req.Force, ExecutionRecords: &records, StatusSource: portal.OrderStatusSourceAPI}
+
+	evaluated := 0
+	for _, assoc := range associations {
+		effective := s.effectiveStrategyForCluster(&strategy, assoc.ClusterID)
+		for _, resourceType := range parseResourceTypes(effective.ResourceTypes) {
+			if req.ResourceType != "" && resourceType != req.ResourceType {
+				continue
+			}
+			s.evaluateResourcePool(evalCtx, effective, assoc.ClusterID, resourceType)
+			evaluated++
+		}
+	}
+	if evaluated == 0 {
+		return nil, fmt.Errorf("策略未配置资源类型: %s", req.ResourceType)
+	}
+
+	result := &StrategyEvaluateResultDTO{
+		StrategyID:   strategy.ID,
+		StrategyName: strategy.Name,
+		Force:        req.Force,
+		Results:      make([]StrategyExecutionHistoryDTO, len(records)),
+	}
+	for i, h := range records {
+		result.Results[i] = StrategyExecutionHistoryDTO{
+			ID:             h.ID,
+			ClusterID:      h.ClusterID,
+			ResourceType:   h.ResourceType,
+			ExecutionTime:  time.Time(h.ExecutionTime),
+			TriggeredValue: h.TriggeredValue,
+			ThresholdValue: h.ThresholdValue,
+			Result:         h.Result,
+			OrderID:        h.OrderID,
+			Reason:         h.Reason,
+
+			ForecastInput:         h.ForecastInput,
+			ProjectedCrossingDate: navyTimePtrToTimePtr(h.ProjectedCrossingDate),
+			MatchingTrace:         s.historyMatchingTrace(h),
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,96 @@
+package es
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLockRedis 仅模拟分布式锁行为的 RedisHandlerInterface 实现
+type fakeLockRedis struct {
+	held map[string]bool
+}
+
+func (r *fakeLockRedis) AcquireLock(key, value string, expiry time.Duration) (bool, error) {
+	if r.held[key] {
+		return false, nil
+	}
+	r.held[key] = true
+	return true, nil
+}
+
+func (r *fakeLockRedis) Delete(key string)                                             { delete(r.held, key) }
+func (r *fakeLockRedis) Expire(expiration time.Duration)                               {}
+func (r *fakeLockRedis) Get(key string) string                                         { return "" }
+func (r *fakeLockRedis) SetWithExpireTime(key, value string, expiration time.Duration) {}
+func (r *fakeLockRedis) ScanKeys(pattern string) ([]string, error)                     { return nil, nil }
+
+func TestEvaluateStrategyNow(t *testing.T) {
+	s, db := newTestService(t)
+	redis := &fakeLockRedis{held: map[string]bool{}}
+	s.redisHandler = redis
+
+	strategy := &portal.ElasticScalingStrategy{
+		Name:                   "entry",
+		ThresholdTriggerAction: TriggerActionPoolEntry,
+		CPUThresholdValue:      80,
+		CPUThresholdType:       ThresholdTypeAllocated,
+		DurationMinutes:        1,
+		DurationUnit:           DurationUnitDay,
+		CooldownMinutes:        60,
+		ResourceTypes:          "compute,total",
+		Status:                 StrategyStatusEnabled,
+	}
+	require.NoError(t, db.Create(strategy).Error)
+	require.NoError(t, db.Create(&portal.StrategyClusterAssociation{StrategyID: strategy.ID, ClusterID: 1}).Error)
+	require.NoError(t, db.Create(&portal.StrategyClusterAssociation{StrategyID: strategy.ID, ClusterID: 2}).Error)
+
+	// 集群1的 total 资源池在冷却期内
+	createScalingOrder(t, db, "ES-ENTRY", TriggerActionPoolEntry, portal.OrderStatusPending, time.Now().Add(-10*time.Minute))
+	require.NoError(t, db.Model(&portal.ElasticScalingOrderDetail{}).Where("cluster_id = ?", 1).
+		Update("strategy_id", strategy.ID).Error)
+
+	clusterID := 1
+	t.Run("limits evaluation to one cluster and resource type", func(t *testing.T) {
+		result, err := s.EvaluateStrategyNow(strategy.ID, StrategyEvaluateRequestDTO{ClusterID: &clusterID, ResourceType: "total"})
+		require.NoError(t, err)
+		require.Len(t, result.Results, 1)
+		assert.Equal(t, 1, result.Results[0].ClusterID)
+		assert.Equal(t, "total", result.Results[0].ResourceType)
+		assert.Equal(t, StrategyExecutionResultSkippedCooldown, result.Results[0].Result)
+		assert.NotZero(t, result.Results[0].ID)
+	})
+
+	t.Run("force bypasses cooldown", func(t *testing.T) {
+		result, err := s.EvaluateStrategyNow(strategy.ID, StrategyEvaluateRequestDTO{ClusterID: &clusterID, ResourceType: "total", Force: true})
+		require.NoError(t, err)
+		require.Len(t, result.Results, 1)
+		assert.Equal(t, StrategyExecutionResultFailureNoSnapshots, result.Results[0].Result)
+		assert.NotEmpty(t, result.Results[0].Reason)
+	})
+
+	t.Run("evaluates all associated pools by default", func(t *testing.T) {
+		result, err := s.EvaluateStrategyNow(strategy.ID, StrategyEvaluateRequestDTO{})
+		require.NoError(t, err)
+		assert.Len(t, result.Results, 4)
+	})
+
+	t.Run("respects the redis lock", func(t *testing.T) {
+		redis.held[fmt.Sprintf(lockKeyFormat, strategy.ID)] = true
+		defer delete(redis.held, fmt.Sprintf(lockKeyFormat, strategy.ID))
+
+		_, err := s.EvaluateStrategyNow(strategy.ID, StrategyEvaluateRequestDTO{})
+		assert.ErrorIs(t, err, ErrStrategyEvaluationLocked)
+	})
+
+	t.Run("rejects clusters not associated with the strategy", func(t *testing.T) {
+		other := 3
+		_, err := s.EvaluateStrategyNow(strategy.ID, StrategyEvaluateRequestDTO{ClusterID: &other})
+		assert.Error(t, err)
+	})
+}
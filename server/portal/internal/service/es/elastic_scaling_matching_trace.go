@@ -0,0 +1,239 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"navy-ng/models/portal"
+	"time"
+
+	. "navy-ng/server/portal/internal/service"
+)
+
+// 候选设备未进入订单的原因
+const (
+	MatchingRejectReserved           = "reserved"            // 已被其他进行中的订单预留
+	MatchingRejectClusterMismatch    = "cluster_mismatch"    // 出池时设备不属于目标集群
+	MatchingRejectNotSelected        = "not_selected"        // 未被选择算法选中
+	MatchingRejectAssigned           = "assigned"            // 入池时设备已属于其他集群，排在未分配设备之后未被选中
+	MatchingRejectConstraintViolated = "constraint_violated" // 违反故障域约束
+	MatchingRejectClaimed            = "claimed"             // 已由优先级更高的匹配策略选中
+	MatchingRejectIncompatible       = "incompatible"        // 不满足资源池硬件兼容性规则
+	MatchingRejectDoNotEvict         = "do_not_evict"        // 出池时节点带有禁止驱逐的标签或污点
+)
+
+// maxTracedRejectedDevices 每个匹配策略最多记录的未选中设备数，避免匹配过程过大
+const maxTracedRejectedDevices = 500
+
+// DeviceMatchingTrace 设备匹配过程，随订单保存，用于解释订单为什么包含（或不包含）某些设备
+type DeviceMatchingTrace struct {
+	StrategyID     int                    `json:"strategyId"`
+	ClusterID      int                    `json:"clusterId"`
+	ResourceType   string                 `json:"resourceType"`
+	ActionType     string                 `json:"actionType"`
+	CPUDelta       float64                `json:"cpuDelta"`
+	MemDelta       float64                `json:"memDelta"`
+	MatchedAt      time.Time              `json:"matchedAt"`
+	CombineMode    string                 `json:"combineMode"`              // 多个匹配策略的组合模式
+	CandidateCount int                    `json:"candidateCount"`           // 各匹配策略查询到的不同设备总数
+	Policies       []*PolicyMatchingTrace `json:"policies"`                 // 按优先级排序
+	DevicePolicies map[int]int            `json:"devicePolicies,omitempty"` // 设备ID -> 选中该设备的匹配策略ID
+	OrderDeviceIDs []int                  `json:"orderDeviceIds"`           // 最终写入订单的设备
+	Notes          []string               `json:"notes,omitempty"`          // 无候选设备、预算裁剪等说明
+}
+
+// PolicyMatchingTrace 单个匹配策略的匹配过程
+type PolicyMatchingTrace struct {
+	PolicyID           int                   `json:"policyId"`
+	PolicyName         string                `json:"policyName"`
+	Priority           int                   `json:"priority"`
+	QueryTemplateID    int                   `json:"queryTemplateId"`
+	CPUDelta           float64               `json:"cpuDelta"` // 该策略需要满足的增量，fill_remaining 模式下为剩余增量
+	MemDelta           float64               `json:"memDelta"`
+	Adopted            bool                  `json:"adopted"`        // 选择结果是否被订单采用
+	Note               string                `json:"note,omitempty"` // 未执行或未采用的原因
+	FilterGroups       []FilterGroup         `json:"filterGroups"`   // 完整的查询条件，包括注入的集群和机房条件
+	Error              string                `json:"error,omitempty"`
+	QueriedCount       int                   `json:"queriedCount"`   // 查询到的设备数
+	CandidateCount     int                   `json:"candidateCount"` // 排除已预留和已被前序策略选中设备后的候选设备数
+	SelectionAlgorithm string                `json:"selectionAlgorithm,omitempty"`
+	SelectionScore     float64               `json:"selectionScore"`
+	SelectedDeviceIDs  []int                 `json:"selectedDeviceIds"`
+	RejectedCount      int                   `json:"rejectedCount"`
+	RejectedDevices    []RejectedDeviceTrace `json:"rejectedDevices"`      // 最多记录 maxTracedRejectedDevices 台
+	DrainCosts         map[int]float64       `json:"drainCosts,omitempty"` // 出池时选中设备的排空代价
+}
+
+// RejectedDeviceTrace 未选中的候选设备及原因
+type RejectedDeviceTrace struct {
+	DeviceID int    `json:"deviceId"`
+	CICode   string `json:"ciCode,omitempty"`
+	Reason   string `json:"reason"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// newDeviceMatchingTrace 创建设备匹配过程
+func newDeviceMatchingTrace(strategy *portal.ElasticScalingStrategy, clusterID int, resourceType string, cpuDelta, memDelta float64) *DeviceMatchingTrace {
+	return &DeviceMatchingTrace{
+		StrategyID:   strategy.ID,
+		ClusterID:    clusterID,
+		ResourceType: resourceType,
+		ActionType:   strategy.ThresholdTriggerAction,
+		CPUDelta:     cpuDelta,
+		MemDelta:     memDelta,
+		MatchedAt:    time.Now(),
+	}
+}
+
+// addPolicy 记录开始处理一个匹配策略
+func (t *DeviceMatchingTrace) addPolicy(policy ResourcePoolDeviceMatchingPolicy) *PolicyMatchingTrace {
+	policyTrace := &PolicyMatchingTrace{
+		PolicyID:        policy.ID,
+		PolicyName:      policy.Name,
+		Priority:        policy.Priority,
+		QueryTemplateID: policy.QueryTemplateID,
+	}
+	t.Policies = append(t.Policies, policyTrace)
+	return policyTrace
+}
+
+// addNote 追加说明，t 为空时忽略（手动创建的订单没有匹配过程）
+func (t *DeviceMatchingTrace) addNote(note string) {
+	if t != nil {
+		t.Notes = append(t.Notes, note)
+	}
+}
+
+// setOrderDevices 记录最终写入订单的设备
+func (t *DeviceMatchingTrace) setOrderDevices(deviceIDs []int) {
+	if t != nil {
+		t.OrderDeviceIDs = append([]int{}, deviceIDs...)
+	}
+}
+
+// excludedDevices 进入选择算法前被排除的候选设备及原因（设备ID -> 未选中原因）
+type excludedDevices map[int]RejectedDeviceTrace
+
+// addReserved 记录已被进行中订单预留的设备（设备ID -> 订单ID）
+func (e excludedDevices) addReserved(reserved map[int]int) {
+	for deviceID, orderID := range reserved {
+		e[deviceID] = RejectedDeviceTrace{DeviceID: deviceID, Reason: MatchingRejectReserved, Detail: fmt.Sprintf("已被订单 %d 预留", orderID)}
+	}
+}
+
+// addIncompatible 记录不满足资源池兼容性规则的设备（设备ID -> 原因）
+func (e excludedDevices) addIncompatible(incompatible map[int]string) {
+	for deviceID, reason := range incompatible {
+		e[deviceID] = RejectedDeviceTrace{DeviceID: deviceID, Reason: MatchingRejectIncompatible, Detail: reason}
+	}
+}
+
+// addDoNotEvict 记录出池时带有禁止驱逐标签或污点的设备（设备ID -> 原因）
+func (e excludedDevices) addDoNotEvict(forbidden map[int]string) {
+	for deviceID, reason := range forbidden {
+		e[deviceID] = RejectedDeviceTrace{DeviceID: deviceID, Reason: MatchingRejectDoNotEvict, Detail: reason}
+	}
+}
+
+// addClaimed 记录已由前序匹配策略选中的设备（设备ID -> 策略ID）
+func (e excludedDevices) addClaimed(claimed map[int]int) {
+	for deviceID, policyID := range claimed {
+		e[deviceID] = RejectedDeviceTrace{DeviceID: deviceID, Reason: MatchingRejectClaimed, Detail: fmt.Sprintf("已由匹配策略 %d 选中", policyID)}
+	}
+}
+
+// recordQueried 记录一批查询到的设备，excluded 为进入选择算法前已被排除的设备
+func (p *PolicyMatchingTrace) recordQueried(queried []DeviceResponse, excluded excludedDevices) {
+	p.QueriedCount += len(queried)
+	p.CandidateCount += len(queried) - len(excluded)
+	for _, device := range queried {
+		if excludedDevice, ok := excluded[device.ID]; ok {
+			p.recordRejected(device, excludedDevice.Reason, excludedDevice.Detail)
+		}
+	}
+}
+
+// recordSelection 记录选择结果以及每台未选中候选设备的原因
+func (p *PolicyMatchingTrace) recordSelection(candidates []DeviceResponse, selection deviceSelection, action string, clusterID int) {
+	p.SelectionAlgorithm = selection.Algorithm
+	p.SelectionScore = selection.Score
+	p.SelectedDeviceIDs = append([]int{}, selection.DeviceIDs...)
+
+	selected := make(map[int]bool, len(selection.DeviceIDs))
+	for _, id := range selection.DeviceIDs {
+		selected[id] = true
+	}
+	for _, device := range candidates {
+		if selected[device.ID] {
+			continue
+		}
+		if action == TriggerActionPoolExit && device.ClusterID != clusterID {
+			p.recordRejected(device, MatchingRejectClusterMismatch, fmt.Sprintf("设备属于集群 %d", device.ClusterID))
+		} else if violation, ok := selection.ConstraintViolations[device.ID]; ok {
+			p.recordRejected(device, MatchingRejectConstraintViolated, violation)
+		} else if action == TriggerActionPoolEntry && device.ClusterID != 0 && device.Cluster != "" {
+			p.recordRejected(device, MatchingRejectAssigned, fmt.Sprintf("设备已属于集群 %s，入池时优先选择未分配的设备", device.Cluster))
+		} else {
+			p.recordRejected(device, MatchingRejectNotSelected, fmt.Sprintf("未被 %s 算法选中", selection.Algorithm))
+		}
+	}
+}
+
+// recordRejected 记录一台未选中的设备，超过 maxTracedRejectedDevices 台时只计数
+func (p *PolicyMatchingTrace) recordRejected(device DeviceResponse, reason, detail string) {
+	p.RejectedCount++
+	if len(p.RejectedDevices) < maxTracedRejectedDevices {
+		p.RejectedDevices = append(p.RejectedDevices, RejectedDeviceTrace{DeviceID: device.ID, CICode: device.CICode, Reason: reason, Detail: detail})
+	}
+}
+
+// recordDrainCosts 记录选中设备的排空代价，没有节点数据的设备不记录
+func (p *PolicyMatchingTrace) recordDrainCosts(deviceIDs []int, costs drainCosts) {
+	for _, id := range deviceIDs {
+		if cost, ok := costs[id]; ok {
+			if p.DrainCosts == nil {
+				p.DrainCosts = make(map[int]float64)
+			}
+			p.DrainCosts[id] = cost
+		}
+	}
+}
+
+// candidateCount 返回查询到的候选设备总数，t 为空时返回0
+func (t *DeviceMatchingTrace) candidateCount() int {
+	if t == nil {
+		return 0
+	}
+	return t.CandidateCount
+}
+
+// policyForDevice 返回选中设备的匹配策略ID，t 为空或未记录时返回0
+func (t *DeviceMatchingTrace) policyForDevice(deviceID int) int {
+	if t == nil {
+		return 0
+	}
+	return t.DevicePolicies[deviceID]
+}
+
+// marshalMatchingTrace 序列化匹配过程，t 为空时返回空字符串
+func marshalMatchingTrace(t *DeviceMatchingTrace) (string, error) {
+	if t == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// unmarshalMatchingTrace 解析订单保存的匹配过程，未保存时返回 nil
+func unmarshalMatchingTrace(data string) (*DeviceMatchingTrace, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var trace DeviceMatchingTrace
+	if err := json.Unmarshal([]byte(data), &trace); err != nil {
+		return nil, err
+	}
+	return &trace, nil
+}
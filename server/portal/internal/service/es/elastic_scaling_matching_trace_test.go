@@ -0,0 +1,98 @@
+package es
+
+import (
+	"testing"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyMatchingTraceRecordSelection(t *testing.T) {
+	queried := []service.DeviceResponse{
+		{ID: 1, CICode: "D1", ClusterID: 1},
+		{ID: 2, CICode: "D2", ClusterID: 1},
+		{ID: 3, CICode: "D3", ClusterID: 2},
+		{ID: 4, CICode: "D4", ClusterID: 1},
+		{ID: 5, CICode: "D5", ClusterID: 1},
+	}
+	selection := deviceSelection{
+		Algorithm:            SelectionAlgorithmGreedy,
+		DeviceIDs:            []int{1},
+		ConstraintViolations: map[int]string{4: "机柜已满"},
+	}
+
+	excluded := make(excludedDevices)
+	excluded.addReserved(map[int]int{2: 9})
+	trace := &PolicyMatchingTrace{}
+	trace.recordQueried(queried, excluded)
+	trace.recordSelection(append(queried[:1:1], queried[2:]...), selection, TriggerActionPoolExit, 1)
+
+	assert.Equal(t, 5, trace.QueriedCount)
+	assert.Equal(t, 4, trace.CandidateCount)
+	assert.Equal(t, []int{1}, trace.SelectedDeviceIDs)
+	assert.Equal(t, 4, trace.RejectedCount)
+
+	reasons := make(map[int]string)
+	for _, rejected := range trace.RejectedDevices {
+		reasons[rejected.DeviceID] = rejected.Reason
+	}
+	assert.Equal(t, map[int]string{
+		2: MatchingRejectReserved,
+		3: MatchingRejectClusterMismatch,
+		4: MatchingRejectConstraintViolated,
+		5: MatchingRejectNotSelected,
+	}, reasons)
+}
+
+func TestPolicyMatchingTraceLabelsAssignedEntryCandidates(t *testing.T) {
+	queried := []service.DeviceResponse{
+		{ID: 1, CICode: "D1"},
+		{ID: 2, CICode: "D2"},
+		{ID: 3, CICode: "D3", ClusterID: 7, Cluster: "cluster-7"},
+	}
+	trace := &PolicyMatchingTrace{}
+	trace.recordQueried(queried, make(excludedDevices))
+	trace.recordSelection(queried, deviceSelection{Algorithm: SelectionAlgorithmGreedy, DeviceIDs: []int{1}}, TriggerActionPoolEntry, 1)
+
+	require.Len(t, trace.RejectedDevices, 2)
+	assert.Equal(t, MatchingRejectNotSelected, trace.RejectedDevices[0].Reason)
+	assert.Equal(t, MatchingRejectAssigned, trace.RejectedDevices[1].Reason)
+	assert.Contains(t, trace.RejectedDevices[1].Detail, "cluster-7")
+}
+
+func TestMatchingTraceStoredWithOrder(t *testing.T) {
+	s, db := newTestService(t)
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 1}, ClusterName: "cluster-a"}).Error)
+	require.NoError(t, db.Create(&portal.QueryTemplate{BaseModel: portal.BaseModel{ID: 1}, Name: "all", Groups: "[]"}).Error)
+	require.NoError(t, db.Create(&portal.ResourcePoolDeviceMatchingPolicy{
+		Name:             "entry",
+		ResourcePoolType: "total",
+		ActionType:       TriggerActionPoolEntry,
+		QueryTemplateID:  1,
+		Status:           "enabled",
+	}).Error)
+
+	strategy := &portal.ElasticScalingStrategy{
+		BaseModel:              portal.BaseModel{ID: 1},
+		Name:                   "entry",
+		ThresholdTriggerAction: TriggerActionPoolEntry,
+	}
+	require.NoError(t, s.matchDevicesForStrategy(&evaluationContext{}, strategy, 1, "total", "90", "80", 10, 10, nil))
+
+	var order portal.Order
+	require.NoError(t, db.First(&order).Error)
+	detail, err := s.GetOrder(order.ID)
+	require.NoError(t, err)
+	require.NotNil(t, detail.MatchingTrace)
+
+	trace := detail.MatchingTrace
+	assert.Equal(t, 1, trace.StrategyID)
+	assert.Equal(t, 1, trace.ClusterID)
+	require.Len(t, trace.Policies, 1)
+	assert.Equal(t, "entry", trace.Policies[0].PolicyName)
+	assert.Empty(t, trace.OrderDeviceIDs)
+	assert.NotEmpty(t, trace.Notes)
+}
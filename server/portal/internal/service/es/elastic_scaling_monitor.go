@@ -29,6 +29,7 @@ type ElasticScalingMonitor struct {
 // MonitorConfig 监控配置
 type MonitorConfig struct {
 	MonitorCron        string        // 监控任务的 Cron 表达式
+	SlidingWindowCron  string        // 分钟/小时级策略评估的 Cron 表达式，为空时不单独调度
 	EvaluationInterval time.Duration // 策略评估间隔
 	LockTimeout        time.Duration // Redis锁超时时间
 	LockRetryInterval  time.Duration // Redis锁重试间隔
@@ -39,6 +40,7 @@ type MonitorConfig struct {
 func DefaultMonitorConfig() MonitorConfig {
 	return MonitorConfig{
 		MonitorCron:        "0 10,14,18 * * *", // 每天10点、14点、18点运行
+		SlidingWindowCron:  "*/10 * * * *",     // 每10分钟评估一次分钟/小时级策略
 		EvaluationInterval: 10 * time.Minute,   // 每10分钟评估一次策略
 		LockTimeout:        30 * time.Second,   // 锁超时时间30秒
 		LockRetryInterval:  1 * time.Second,    // 锁重试间隔1秒
@@ -109,6 +111,17 @@ func (m *ElasticScalingMonitor) startStrategyEvaluator() {
 		m.logger.Error("Failed to add cron job", zap.Error(err))
 	}
 
+	if m.config.SlidingWindowCron != "" {
+		_, err = m.cron.AddFunc(m.config.SlidingWindowCron, func() {
+			if err := m.evaluateSlidingWindowStrategiesWithLock(); err != nil {
+				m.logger.Error("Sliding window strategy evaluation failed", zap.Error(err))
+			}
+		})
+		if err != nil {
+			m.logger.Error("Failed to add sliding window cron job", zap.Error(err))
+		}
+	}
+
 	<-m.stopChan
 }
 
@@ -144,3 +157,26 @@ func (m *ElasticScalingMonitor) evaluateStrategiesWithLock() error {
 	m.logger.Info("Strategy evaluation completed")
 	return nil
 }
+
+// evaluateSlidingWindowStrategiesWithLock 使用Redis分布式锁评估分钟/小时级策略
+func (m *ElasticScalingMonitor) evaluateSlidingWindowStrategiesWithLock() error {
+	lockKey := "elastic_scaling:sliding_window_evaluation_lock"
+	lockValue := fmt.Sprintf("monitor:%d", time.Now().UnixNano())
+
+	success, err := m.redisHandler.AcquireLock(lockKey, lockValue, m.config.LockTimeout)
+	if err != nil {
+		return fmt.Errorf("获取分布式锁失败: %w", err)
+	}
+
+	if !success {
+		m.logger.Info("Could not acquire sliding window evaluation lock, another instance might be executing", zap.String("lockKey", lockKey))
+		return nil
+	}
+
+	defer m.redisHandler.Delete(lockKey)
+
+	if err := m.scalingService.EvaluateSlidingWindowStrategies(); err != nil {
+		return fmt.Errorf("滑动窗口策略评估失败: %w", err)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"navy-ng/models/portal"
 	. "navy-ng/server/portal/internal/service"
+	"navy-ng/server/portal/internal/service/order"
 	"strings"
 	"time"
 
@@ -60,13 +61,28 @@ const (
 	actionNamePoolExit   = "退池"
 )
 
-// CreateOrder 创建弹性伸缩订单
+// CreateOrder 创建弹性伸缩订单（接口调用）
 func (s *ElasticScalingService) CreateOrder(dto OrderDTO) (int, error) {
+	return s.CreateOrderFrom(portal.OrderStatusSourceAPI, dto)
+}
+
+// CreateOrderFrom 创建弹性伸缩订单，source 为写入订单创建记录的来源
+func (s *ElasticScalingService) CreateOrderFrom(source string, dto OrderDTO) (int, error) {
+	return s.createOrder(order.WithStatusSource(context.Background(), source), dto)
+}
+
+// createOrder 创建弹性伸缩订单，订单创建记录的来源取自 ctx
+func (s *ElasticScalingService) createOrder(ctx context.Context, dto OrderDTO) (int, error) {
 	// 使用事务确保数据一致性
+	matchingTrace, err := marshalMatchingTrace(dto.MatchingTrace)
+	if err != nil {
+		return 0, fmt.Errorf("序列化设备匹配过程失败: %w", err)
+	}
+
 	var orderID int
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 创建基础订单
-		order := &portal.Order{
+		baseOrder := &portal.Order{
 			OrderNumber: s.generateOrderNumber(),
 			Name:        dto.Name,
 			Description: dto.Description,
@@ -75,14 +91,17 @@ func (s *ElasticScalingService) CreateOrder(dto OrderDTO) (int, error) {
 			CreatedBy:   dto.CreatedBy,
 		}
 
-		if err := tx.Create(order).Error; err != nil {
+		if err := tx.Create(baseOrder).Error; err != nil {
+			return err
+		}
+		orderID = baseOrder.ID
+		if err := order.RecordOrderCreated(ctx, tx, baseOrder); err != nil {
 			return err
 		}
-		orderID = order.ID
 
 		// 创建弹性伸缩订单详情
 		detail := &portal.ElasticScalingOrderDetail{
-			OrderID:                order.ID,
+			OrderID:                baseOrder.ID,
 			ClusterID:              dto.ClusterID,
 			StrategyID:             dto.StrategyID,
 			ActionType:             dto.ActionType,
@@ -90,6 +109,9 @@ func (s *ElasticScalingService) CreateOrder(dto OrderDTO) (int, error) {
 			DeviceCount:            dto.DeviceCount,
 			StrategyTriggeredValue: dto.StrategyTriggeredValue,
 			StrategyThresholdValue: dto.StrategyThresholdValue,
+			MatchingTrace:          matchingTrace,
+			CandidateCount:         dto.MatchingTrace.candidateCount(),
+			GuardrailTrimmed:       dto.GuardrailTrimmed,
 		}
 
 		// 维护相关字段现在由MaintenanceOrderDetail处理
@@ -103,9 +125,10 @@ func (s *ElasticScalingService) CreateOrder(dto OrderDTO) (int, error) {
 		if len(dto.Devices) > 0 {
 			for _, deviceID := range dto.Devices {
 				orderDevice := portal.OrderDevice{
-					OrderID:  order.ID,
-					DeviceID: deviceID,
-					Status:   StatusPending,
+					OrderID:          baseOrder.ID,
+					DeviceID:         deviceID,
+					Status:           StatusPending,
+					MatchingPolicyID: dto.MatchingTrace.policyForDevice(deviceID),
 				}
 				if err := tx.Create(&orderDevice).Error; err != nil {
 					return err
@@ -113,7 +136,8 @@ func (s *ElasticScalingService) CreateOrder(dto OrderDTO) (int, error) {
 			}
 		}
 
-		return nil
+		// 预留设备，设备已被其他进行中的订单占用时回滚整个订单
+		return ReserveDevices(tx, baseOrder.ID, dto.Devices)
 	})
 
 	if err != nil {
@@ -160,147 +184,217 @@ func (s *ElasticScalingService) GetOrder(id int) (*OrderDetailDTO, error) {
 		return nil, fmt.Errorf(errOrderTypeMismatch, id)
 	}
 
-	detail := order.ElasticScalingDetail
+	details, err := s.buildOrderDetails([]portal.Order{order})
+	if err != nil {
+		return nil, err
+	}
+	return details[0], nil
+}
 
-	// 获取集群名称
-	var cluster portal.K8sCluster
-	clusterName := unknownCluster
-	if err := s.db.Select(fieldClusterName).First(&cluster, detail.ClusterID).Error; err == nil {
-		clusterName = cluster.ClusterName
+// orderNames 订单列表引用的集群名称和策略名称
+type orderNames struct {
+	clusters   map[int]string
+	strategies map[int]string
+}
+
+// loadOrderNames 批量查询订单关联的集群名称和策略名称
+func (s *ElasticScalingService) loadOrderNames(orders []portal.Order) (*orderNames, error) {
+	var clusterIDs, strategyIDs []int
+	for _, order := range orders {
+		clusterIDs = append(clusterIDs, order.ElasticScalingDetail.ClusterID)
+		if order.ElasticScalingDetail.StrategyID != nil {
+			strategyIDs = append(strategyIDs, *order.ElasticScalingDetail.StrategyID)
+		}
 	}
 
-	// 获取策略名称（如果有关联策略）
-	strategyName := ""
-	if detail.StrategyID != nil {
-		var strategy portal.ElasticScalingStrategy
-		if err := s.db.Select(fieldName).First(&strategy, *detail.StrategyID).Error; err == nil {
-			strategyName = strategy.Name
+	names := &orderNames{clusters: make(map[int]string), strategies: make(map[int]string)}
+	if len(clusterIDs) > 0 {
+		var clusters []portal.K8sCluster
+		if err := s.db.Select("id", fieldClusterName).Where("id IN ?", clusterIDs).Find(&clusters).Error; err != nil {
+			return nil, err
 		}
+		for _, cluster := range clusters {
+			names.clusters[cluster.ID] = cluster.ClusterName
+		}
+	}
+	if len(strategyIDs) > 0 {
+		var strategies []portal.ElasticScalingStrategy
+		if err := s.db.Select("id", fieldName).Where("id IN ?", strategyIDs).Find(&strategies).Error; err != nil {
+			return nil, err
+		}
+		for _, strategy := range strategies {
+			names.strategies[strategy.ID] = strategy.Name
+		}
+	}
+	return names, nil
+}
+
+// clusterName 获取集群名称，集群不存在时返回未知集群
+func (n *orderNames) clusterName(clusterID int) string {
+	if name, ok := n.clusters[clusterID]; ok {
+		return name
+	}
+	return unknownCluster
+}
+
+// strategyName 获取策略名称，未关联策略时返回空
+func (n *orderNames) strategyName(strategyID *int) string {
+	if strategyID == nil {
+		return ""
+	}
+	return n.strategies[*strategyID]
+}
+
+// buildOrderDetails 批量组装订单详情，集群、策略、订单设备、设备和匹配策略各查询一次。
+// orders 须预加载弹性伸缩订单详情
+func (s *ElasticScalingService) buildOrderDetails(orders []portal.Order) ([]*OrderDetailDTO, error) {
+	if len(orders) == 0 {
+		return []*OrderDetailDTO{}, nil
+	}
+
+	names, err := s.loadOrderNames(orders)
+	if err != nil {
+		return nil, err
 	}
 
 	// 获取关联设备
+	orderIDs := make([]int, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.ID
+	}
 	var orderDevices []portal.OrderDevice
-	if err := s.db.Where("order_id = ?", order.ID).Find(&orderDevices).Error; err != nil {
+	if err := s.db.Where("order_id IN ?", orderIDs).Order("device_id").Find(&orderDevices).Error; err != nil {
 		return nil, err
 	}
 
-	// 准备设备ID列表
-	deviceIDs := make([]int, len(orderDevices))
-	for i, od := range orderDevices {
-		deviceIDs[i] = od.DeviceID
+	orderDevicesByOrder := make(map[int][]portal.OrderDevice)
+	deviceIDs := make([]int, 0, len(orderDevices))
+	var policyIDs []int
+	for _, od := range orderDevices {
+		orderDevicesByOrder[od.OrderID] = append(orderDevicesByOrder[od.OrderID], od)
+		deviceIDs = append(deviceIDs, od.DeviceID)
+		if od.MatchingPolicyID > 0 {
+			policyIDs = append(policyIDs, od.MatchingPolicyID)
+		}
 	}
 
 	// 获取设备详情
-	var devices []portal.Device
+	devices := make(map[int]portal.Device)
 	if len(deviceIDs) > 0 {
-		if err := s.db.Where("id IN ?", deviceIDs).Find(&devices).Error; err != nil {
+		var found []portal.Device
+		if err := s.db.Where("id IN ?", deviceIDs).Find(&found).Error; err != nil {
 			return nil, err
 		}
-	}
-
-	// 获取特定设备详情（如果是维护订单）
-	var deviceInfo *DeviceDTO
-	// 对于维护订单，我们从OrderDevice关联表中获取第一个设备作为主要设备
-	if detail.ActionType == actionTypeMaintenanceRequest || detail.ActionType == actionTypeMaintenanceUncordon {
-		if len(orderDevices) > 0 && len(devices) > 0 {
-			// 使用第一个关联设备
-			for _, device := range devices {
-				deviceInfo = &DeviceDTO{
-					ID:           device.ID,
-					CICode:       device.CICode,
-					IP:           device.IP,
-					ArchType:     device.ArchType,
-					CPU:          device.CPU,
-					Memory:       device.Memory,
-					Status:       device.Status,
-					Role:         device.Role,
-					Cluster:      device.Cluster,
-					ClusterID:    device.ClusterID,
-					IsSpecial:    device.IsSpecial,
-					FeatureCount: device.FeatureCount,
-				}
-				break // 只取第一个设备
-			}
+		for _, device := range found {
+			devices[device.ID] = device
 		}
 	}
 
-	// 构建DTO
-	dto := &OrderDetailDTO{
-		OrderDTO: OrderDTO{
-			ID:               order.ID,
-			OrderNumber:      order.OrderNumber,
-			Name:             order.Name,        // 订单名称
-			Description:      order.Description, // 订单描述
-			ClusterID:        detail.ClusterID,
-			ClusterName:      clusterName,
-			StrategyID:       detail.StrategyID,
-			StrategyName:     strategyName,
-			ActionType:       detail.ActionType,
-			ResourcePoolType: detail.ResourcePoolType,
-			Status:           string(order.Status),
-			DeviceCount:      detail.DeviceCount,
-			// DeviceID字段已移除，通过OrderDevice关联表获取设备信息
-			DeviceInfo:           deviceInfo,
-			Executor:             order.Executor,
-			CreatedBy:            order.CreatedBy,
-			CreatedAt:            time.Time(order.CreatedAt),
-			FailureReason:        order.FailureReason,
-			MaintenanceStartTime: nil,
-			MaintenanceEndTime:   nil,
-			ExternalTicketID:     "",
-		},
-		Devices: make([]DeviceDTO, len(devices)),
+	// 查询选中设备的匹配策略名称
+	policyNames := make(map[int]string)
+	if len(policyIDs) > 0 {
+		var policies []portal.ResourcePoolDeviceMatchingPolicy
+		if err := s.db.Select("id", "name").Where("id IN ?", policyIDs).Find(&policies).Error; err != nil {
+			return nil, err
+		}
+		for _, policy := range policies {
+			policyNames[policy.ID] = policy.Name
+		}
 	}
 
-	// Maintenance time fields are now handled by MaintenanceOrderDetail
-	// These fields are no longer part of ElasticScalingOrderDetail
+	result := make([]*OrderDetailDTO, len(orders))
+	for i, order := range orders {
+		detail := order.ElasticScalingDetail
 
-	// Fix execution and completion time in GetOrder
-	if order.ExecutionTime != nil {
-		execTime := time.Time(*order.ExecutionTime)
-		dto.ExecutionTime = &execTime
-	}
+		// 转换设备列表，附带设备在订单中的状态和选中设备的匹配策略
+		deviceDTOs := make([]DeviceDTO, 0, len(orderDevicesByOrder[order.ID]))
+		for _, od := range orderDevicesByOrder[order.ID] {
+			device, ok := devices[od.DeviceID]
+			if !ok {
+				continue
+			}
+			deviceDTO := DeviceDTO{
+				ID:           device.ID,
+				CICode:       device.CICode,
+				IP:           device.IP,
+				ArchType:     device.ArchType,
+				CPU:          device.CPU,
+				Memory:       device.Memory,
+				Status:       device.Status,
+				Role:         device.Role,
+				Cluster:      device.Cluster,
+				ClusterID:    device.ClusterID,
+				IsSpecial:    device.IsSpecial,
+				FeatureCount: device.FeatureCount,
+				OrderStatus:  od.Status,
+			}
+			if od.MatchingPolicyID > 0 {
+				deviceDTO.MatchingPolicyID = od.MatchingPolicyID
+				deviceDTO.MatchingPolicyName = policyNames[od.MatchingPolicyID]
+			}
+			deviceDTOs = append(deviceDTOs, deviceDTO)
+		}
 
-	if order.CompletionTime != nil {
-		complTime := time.Time(*order.CompletionTime)
-		dto.CompletionTime = &complTime
-	}
+		// 对于维护订单，使用第一个关联设备作为主要设备
+		var deviceInfo *DeviceDTO
+		if (detail.ActionType == actionTypeMaintenanceRequest || detail.ActionType == actionTypeMaintenanceUncordon) && len(deviceDTOs) > 0 {
+			info := deviceDTOs[0]
+			info.OrderStatus = ""
+			info.MatchingPolicyID = 0
+			info.MatchingPolicyName = ""
+			deviceInfo = &info
+		}
 
-	// 转换设备列表
-	deviceStatusMap := make(map[int]string)
-	for _, od := range orderDevices {
-		deviceStatusMap[od.DeviceID] = od.Status
-	}
+		dto := &OrderDetailDTO{
+			OrderDTO: OrderDTO{
+				ID:               order.ID,
+				OrderNumber:      order.OrderNumber,
+				Name:             order.Name,        // 订单名称
+				Description:      order.Description, // 订单描述
+				ClusterID:        detail.ClusterID,
+				ClusterName:      names.clusterName(detail.ClusterID),
+				StrategyID:       detail.StrategyID,
+				StrategyName:     names.strategyName(detail.StrategyID),
+				ActionType:       detail.ActionType,
+				ResourcePoolType: detail.ResourcePoolType,
+				Status:           string(order.Status),
+				DeviceCount:      detail.DeviceCount,
+				CandidateCount:   detail.CandidateCount,
+				GuardrailTrimmed: detail.GuardrailTrimmed,
+				Version:          order.Version,
+				// DeviceID字段已移除，通过OrderDevice关联表获取设备信息
+				DeviceInfo:    deviceInfo,
+				Executor:      order.Executor,
+				CreatedBy:     order.CreatedBy,
+				CreatedAt:     time.Time(order.CreatedAt),
+				FailureReason: order.FailureReason,
+			},
+			Devices: deviceDTOs,
+		}
 
-	for i, device := range devices {
-		deviceDTO := DeviceDTO{
-			ID:           device.ID,
-			CICode:       device.CICode,
-			IP:           device.IP,
-			ArchType:     device.ArchType,
-			CPU:          device.CPU,
-			Memory:       device.Memory,
-			Status:       device.Status,
-			Role:         device.Role,
-			Cluster:      device.Cluster,
-			ClusterID:    device.ClusterID,
-			IsSpecial:    device.IsSpecial,
-			FeatureCount: device.FeatureCount,
+		// 解析设备匹配过程，解析失败不影响订单详情
+		if trace, err := unmarshalMatchingTrace(detail.MatchingTrace); err != nil {
+			s.logger.Warn("Failed to unmarshal matching trace", zap.Int("orderID", order.ID), zap.Error(err))
+		} else {
+			dto.MatchingTrace = trace
 		}
 
-		// 添加设备在订单中的状态
-		if status, ok := deviceStatusMap[device.ID]; ok {
-			deviceDTO.OrderStatus = status
+		if order.ExecutionTime != nil {
+			execTime := time.Time(*order.ExecutionTime)
+			dto.ExecutionTime = &execTime
+		}
+		if order.CompletionTime != nil {
+			complTime := time.Time(*order.CompletionTime)
+			dto.CompletionTime = &complTime
 		}
 
-		dto.Devices[i] = deviceDTO
+		result[i] = dto
 	}
-
-	return dto, nil
+	return result, nil
 }
 
-// ListOrders 获取订单列表
-func (s *ElasticScalingService) ListOrders(clusterID int, strategyID int, actionType string, status string, name string, page, pageSize int) ([]OrderListItemDTO, int, error) {
+// listOrderPage 按过滤条件分页查询弹性伸缩订单（预加载订单详情），返回当前页订单和满足条件的订单总数
+func (s *ElasticScalingService) listOrderPage(clusterID int, strategyID int, actionType string, status string, name string, page, pageSize int) ([]portal.Order, int, error) {
 	var total int64
 
 	// 构建查询，联合查询基础订单表和详情表
@@ -324,81 +418,58 @@ func (s *ElasticScalingService) ListOrders(clusterID int, strategyID int, action
 	if name != "" {
 		query = query.Where(queryOrderName, "%"+name+"%")
 	}
+	query = query.Session(&gorm.Session{})
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// 分页查询，获取完整的订单信息
-	var orders []portal.Order
-	orderQuery := s.db.Preload(preloadElasticScalingDetail).
-		Where("type = ?", portal.OrderTypeElasticScaling)
-
-	// 添加订单名称过滤条件
-	if name != "" {
-		orderQuery = orderQuery.Where("name LIKE ?", "%"+name+"%")
+	// 在过滤后的结果上分页，再加载当前页的完整订单信息
+	var ids []int
+	if err := query.Order("o.created_at DESC, o.id DESC").
+		Offset((page-1)*pageSize).
+		Limit(pageSize).
+		Pluck("o.id", &ids).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(ids) == 0 {
+		return []portal.Order{}, int(total), nil
 	}
 
-	if err := orderQuery.Order(OrderByCreatedAtDesc).
-		Offset((page - 1) * pageSize).
-		Limit(pageSize).
+	var orders []portal.Order
+	if err := s.db.Preload(preloadElasticScalingDetail).
+		Where("id IN ?", ids).
+		Order("created_at DESC, id DESC").
 		Find(&orders).Error; err != nil {
 		return nil, 0, err
 	}
+	return orders, int(total), nil
+}
+
+// ListOrders 获取订单列表，返回当前页订单和满足条件的订单总数
+func (s *ElasticScalingService) ListOrders(clusterID int, strategyID int, actionType string, status string, name string, page, pageSize int) ([]OrderListItemDTO, int, error) {
+	orders, total, err := s.listOrderPage(clusterID, strategyID, actionType, status, name, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	names, err := s.loadOrderNames(orders)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	// 准备结果
 	result := make([]OrderListItemDTO, 0, len(orders))
 	for _, order := range orders {
-		// 检查是否有详情信息
-		if order.ElasticScalingDetail == nil {
-			continue
-		}
-
 		detail := order.ElasticScalingDetail
-
-		// 应用过滤条件（因为预加载可能包含不符合条件的记录）
-		if clusterID > 0 && detail.ClusterID != clusterID {
-			continue
-		}
-		if strategyID > 0 && (detail.StrategyID == nil || *detail.StrategyID != strategyID) {
-			continue
-		}
-		if actionType != "" && detail.ActionType != actionType {
-			continue
-		}
-		if status != "" && string(order.Status) != status {
-			continue
-		}
-		if name != "" && !strings.Contains(strings.ToLower(order.Name), strings.ToLower(name)) {
-			continue
-		}
-
-		// 获取集群名称
-		var cluster portal.K8sCluster
-		clusterName := unknownCluster
-		if err := s.db.Select(fieldClusterName).First(&cluster, detail.ClusterID).Error; err == nil {
-			clusterName = cluster.ClusterName
-		}
-
-		// 获取策略名称（如果有关联策略）
-		var strategyName string
-		if detail.StrategyID != nil {
-			var strategy portal.ElasticScalingStrategy
-			if err := s.db.Select(fieldName).First(&strategy, *detail.StrategyID).Error; err == nil {
-				strategyName = strategy.Name
-			}
-		}
-
 		result = append(result, OrderListItemDTO{
 			ID:               order.ID,
 			OrderNumber:      order.OrderNumber,
 			Name:             order.Name,        // 订单名称
 			Description:      order.Description, // 订单描述
 			ClusterID:        detail.ClusterID,
-			ClusterName:      clusterName,
+			ClusterName:      names.clusterName(detail.ClusterID),
 			StrategyID:       detail.StrategyID,
-			StrategyName:     strategyName,
+			StrategyName:     names.strategyName(detail.StrategyID),
 			ActionType:       detail.ActionType,
 			ResourcePoolType: detail.ResourcePoolType,
 			Status:           string(order.Status),
@@ -408,13 +479,27 @@ func (s *ElasticScalingService) ListOrders(clusterID int, strategyID int, action
 		})
 	}
 
-	return result, int(len(result)), nil
+	return result, total, nil
 }
 
-// UpdateOrderStatus 更新订单状态
+// UpdateOrderStatus 更新订单状态（接口调用）
 func (s *ElasticScalingService) UpdateOrderStatus(id int, status string, executor string, reason string) error {
-	// 使用通用订单服务更新状态
-	ctx := context.Background()
+	return s.UpdateOrderStatusFrom(portal.OrderStatusSourceAPI, id, status, executor, reason)
+}
+
+// UpdateOrderStatusIfMatch 更新订单状态（接口调用），订单当前版本与客户端持有的版本（If-Match）不一致时返回前置条件失败错误
+func (s *ElasticScalingService) UpdateOrderStatusIfMatch(id int, version int, status string, executor string, reason string) error {
+	ctx := order.WithStatusSource(context.Background(), portal.OrderStatusSourceAPI)
+	return s.updateOrderStatus(order.WithExpectedVersion(ctx, version), id, status, executor, reason)
+}
+
+// UpdateOrderStatusFrom 更新订单状态，source 为写入状态变更记录的变更来源
+func (s *ElasticScalingService) UpdateOrderStatusFrom(source string, id int, status string, executor string, reason string) error {
+	return s.updateOrderStatus(order.WithStatusSource(context.Background(), source), id, status, executor, reason)
+}
+
+// updateOrderStatus 校验弹性伸缩订单后使用通用订单服务更新状态，并记录策略执行历史
+func (s *ElasticScalingService) updateOrderStatus(ctx context.Context, id int, status string, executor string, reason string) error {
 	orderStatus := portal.OrderStatus(status)
 
 	// 验证状态
@@ -494,6 +579,7 @@ func (s *ElasticScalingService) UpdateOrderStatus(id int, status string, executo
 		// 注意：recordStrategyExecution 内部的 ExecutionTime 将被我们这里提供的 executionTimeForHistory 覆盖
 		// triggeredValue 和 thresholdValue 将从 detail 对象中获取
 		errRecord := s.recordStrategyExecution(
+			&evaluationContext{}, // 订单状态变更不属于某次策略评估，使用默认上下文
 			*detail.StrategyID,
 			detail.ClusterID,        // clusterID 参数
 			detail.ResourcePoolType, // resourceType 参数
@@ -514,8 +600,13 @@ func (s *ElasticScalingService) UpdateOrderStatus(id int, status string, executo
 	return nil
 }
 
-// UpdateOrderDeviceStatus 更新订单中单个设备的状态
-func (s *ElasticScalingService) UpdateOrderDeviceStatus(orderID int, deviceID int, status string) error {
+// UpdateOrderDeviceStatus 更新订单中单个设备的状态（接口调用）
+func (s *ElasticScalingService) UpdateOrderDeviceStatus(orderID int, deviceID int, status string, executor string) error {
+	return s.UpdateOrderDeviceStatusFrom(portal.OrderStatusSourceAPI, orderID, deviceID, status, executor)
+}
+
+// UpdateOrderDeviceStatusFrom 更新订单中设备的状态，并在同一事务中写入设备状态变更记录；设备状态被并发修改时返回版本冲突错误
+func (s *ElasticScalingService) UpdateOrderDeviceStatusFrom(source string, orderID int, deviceID int, status string, executor string) error {
 	// 验证状态
 	validStatuses := map[string]bool{
 		StatusPending:   true,
@@ -529,19 +620,43 @@ func (s *ElasticScalingService) UpdateOrderDeviceStatus(orderID int, deviceID in
 		return fmt.Errorf(errInvalidDeviceStatus, status)
 	}
 
-	// 查找订单设备关联记录
-	var orderDevice portal.OrderDevice
-	err := s.db.Where("order_id = ? AND device_id = ?", orderID, deviceID).First(&orderDevice).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf(errDeviceNotInOrder)
+	// 以读取到的版本号为条件更新状态，期间设备状态被其他操作修改时返回版本冲突错误
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var orderDevice portal.OrderDevice
+		if err := tx.Where("order_id = ? AND device_id = ?", orderID, deviceID).First(&orderDevice).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf(errDeviceNotInOrder)
+			}
+			return err
+		}
+
+		result := tx.Model(&portal.OrderDevice{}).
+			Where("order_id = ? AND device_id = ? AND version = ?", orderID, deviceID, orderDevice.Version).
+			Updates(map[string]interface{}{
+				"status":  status,
+				"version": gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return NewConflictError(fmt.Sprintf("订单 %d 中设备 %d 的状态已被其他操作修改", orderID, deviceID))
 		}
-		return err
-	}
 
-	// 更新状态
-	orderDevice.Status = status
-	return s.db.Save(&orderDevice).Error
+		return tx.Create(&portal.OrderStatusHistory{
+			OrderID:    orderID,
+			DeviceID:   &deviceID,
+			FromStatus: orderDevice.Status,
+			ToStatus:   status,
+			Executor:   executor,
+			Source:     source,
+		}).Error
+	})
+}
+
+// GetOrderTimeline 获取订单时间线，包含订单及设备的状态变更和关联的策略执行记录
+func (s *ElasticScalingService) GetOrderTimeline(id int) ([]order.OrderTimelineEntryDTO, error) {
+	return s.orderService.GetOrderTimeline(context.Background(), id)
 }
 
 // GetOrderDevices 获取订单中的所有设备
@@ -567,8 +682,26 @@ func (s *ElasticScalingService) GetOrderDevices(orderID int) ([]DeviceDTO, error
 
 	// 转换设备列表
 	deviceStatusMap := make(map[int]string)
+	devicePolicyMap := make(map[int]int)
+	var policyIDs []int
 	for _, od := range orderDevices {
 		deviceStatusMap[od.DeviceID] = od.Status
+		if od.MatchingPolicyID > 0 {
+			devicePolicyMap[od.DeviceID] = od.MatchingPolicyID
+			policyIDs = append(policyIDs, od.MatchingPolicyID)
+		}
+	}
+
+	// 查询选中设备的匹配策略名称
+	policyNames := make(map[int]string)
+	if len(policyIDs) > 0 {
+		var policies []portal.ResourcePoolDeviceMatchingPolicy
+		if err := s.db.Select("id", "name").Where("id IN ?", policyIDs).Find(&policies).Error; err != nil {
+			return nil, err
+		}
+		for _, policy := range policies {
+			policyNames[policy.ID] = policy.Name
+		}
 	}
 
 	deviceDTOs := make([]DeviceDTO, len(devices))
@@ -590,6 +723,10 @@ func (s *ElasticScalingService) GetOrderDevices(orderID int) ([]DeviceDTO, error
 		if status, ok := deviceStatusMap[device.ID]; ok {
 			deviceDTO.OrderStatus = status
 		}
+		if policyID, ok := devicePolicyMap[device.ID]; ok {
+			deviceDTO.MatchingPolicyID = policyID
+			deviceDTO.MatchingPolicyName = policyNames[policyID]
+		}
 		deviceDTOs[i] = deviceDTO
 	}
 
@@ -690,6 +827,15 @@ func (s *ElasticScalingService) buildEmailHTML(subject, actionName, clusterName
 		actionIcon = "⚡"
 	}
 
+	// 阈值提醒文案，按策略配置的指标类型描述（如"CPU 使用率 > 80.00%"）
+	thresholdNotice := "集群资源分配率已超过阈值"
+	if dto.StrategyThresholdValue != "" {
+		thresholdNotice = fmt.Sprintf("集群资源已满足触发条件（%s）", dto.StrategyThresholdValue)
+		if dto.StrategyTriggeredValue != "" {
+			thresholdNotice += fmt.Sprintf("，当前值：%s", dto.StrategyTriggeredValue)
+		}
+	}
+
 	// 如果无设备，使用警告色
 	if len(devices) == 0 {
 		headerColor = "linear-gradient(135deg, #ff7a45 0%, #d4380d 100%)"
@@ -947,7 +1093,7 @@ func (s *ElasticScalingService) buildEmailHTML(subject, actionName, clusterName
             <div style="border-left: 4px solid #ff4d4f; background-color: #fff2f0; padding: 16px; margin-bottom: 24px;">
                 <h4 style="color: #cf1322; margin: 0 0 8px 0; font-size: 14px;">⚠️ 重要提醒</h4>
                 <p style="color: #a8071a; font-size: 13px; line-height: 1.6; margin: 0;">
-                    集群资源分配率已超过阈值，建议<strong>尽快完成入池操作</strong>以确保集群稳定运行。
+                    ` + thresholdNotice + `，建议<strong>尽快完成入池操作</strong>以确保集群稳定运行。
                     请按照处理指引的步骤进行操作，如遇问题及时联系技术支持团队。
                 </p>
             </div>`)
@@ -995,7 +1141,7 @@ func (s *ElasticScalingService) buildEmailHTML(subject, actionName, clusterName
             <div style="border-left: 4px solid #ff4d4f; background-color: #fff2f0; padding: 16px; margin-bottom: 24px;">
                 <h4 style="color: #cf1322; margin: 0 0 8px 0; font-size: 14px;">⚠️ 重要提醒</h4>
                 <p style="color: #a8071a; font-size: 13px; line-height: 1.6; margin: 0;">
-                    集群资源分配率已连续超过阈值，建议<strong>尽快协调设备资源</strong>以避免性能问题。
+                    ` + thresholdNotice + `，建议<strong>尽快协调设备资源</strong>以避免性能问题。
                     如短期内无法获得设备，请考虑其他优化措施或临时扩容方案。
                 </p>
             </div>`)
@@ -1040,6 +1186,7 @@ func (s *ElasticScalingService) generateOrderName(strategy *portal.ElasticScalin
 
 // generateOrderDescription generates a detailed description for the order in HTML format.
 func (s *ElasticScalingService) generateOrderDescription(
+	evalCtx *evaluationContext,
 	strategy *portal.ElasticScalingStrategy,
 	clusterID int,
 	resourceType string,
@@ -1063,11 +1210,19 @@ func (s *ElasticScalingService) generateOrderDescription(
 	htmlBuilder.WriteString(fmt.Sprintf("<p>策略 <strong>%s</strong> 为集群 <strong>%s</strong>（%s类型）触发%s操作。</p>",
 		strategy.Name, clusterName, resourceType, actionName))
 
+	// 预测触发时说明预测依据
+	if evalCtx.Forecast != nil {
+		htmlBuilder.WriteString(s.buildForecastDescription(evalCtx.Forecast))
+	}
+
 	if len(selectedDeviceIDs) == 0 {
 		htmlBuilder.WriteString("<p><strong>注意：</strong>未匹配到合适设备，请关注。</p>")
 		return htmlBuilder.String()
 	}
 
+	// 记录设备选择算法及目标得分
+	htmlBuilder.WriteString(buildSelectionDescription(evalCtx.Selections))
+
 	// 如果没有快照信息，无法计算预测值，返回基础描述
 	if latestSnapshot == nil {
 		htmlBuilder.WriteString(fmt.Sprintf("<p>匹配到 %d 台设备。</p>", len(selectedDeviceIDs)))
@@ -1088,10 +1243,12 @@ func (s *ElasticScalingService) generateOrderDescription(
 		totalMemory += d.Memory
 	}
 
-	// 计算新的分配率
-	currentCPUAllocation := safePercentage(latestSnapshot.CpuRequest, latestSnapshot.CpuCapacity)
-	currentMemAllocation := safePercentage(latestSnapshot.MemRequest, latestSnapshot.MemoryCapacity)
-	newCPUAllocationRate, newMemAllocationRate := s.calculateProjectedAllocation(latestSnapshot, totalCPU, totalMemory, strategy.ThresholdTriggerAction)
+	// 按策略配置的阈值类型（使用率/分配率）计算当前值和预测值
+	cpuName := metricName(strategy.CPUThresholdType)
+	memName := metricName(strategy.MemoryThresholdType)
+	currentCPUValue := cpuMetricValue(*latestSnapshot, strategy.CPUThresholdType)
+	currentMemValue := memMetricValue(*latestSnapshot, strategy.MemoryThresholdType)
+	newCPUValue, newMemValue := s.calculateProjectedAllocation(latestSnapshot, strategy, totalCPU, totalMemory)
 
 	var changeVerb string
 	if strategy.ThresholdTriggerAction == TriggerActionPoolEntry {
@@ -1105,37 +1262,75 @@ func (s *ElasticScalingService) generateOrderDescription(
 	htmlBuilder.WriteString(fmt.Sprintf("<p>匹配到 %d 台设备（总CPU: %.1f核, 总内存: %.1f GB）。</p>",
 		len(selectedDeviceIDs), totalCPU, totalMemory/1024))
 
-	htmlBuilder.WriteString("<h5>预计操作后资源分配率变化</h5>")
+	htmlBuilder.WriteString("<h5>预计操作后资源指标变化</h5>")
 	htmlBuilder.WriteString("<ul>")
-	htmlBuilder.WriteString(fmt.Sprintf("<li>CPU分配率将由 <strong>%.2f%%</strong> %s至 <strong>%.2f%%</strong></li>",
-		currentCPUAllocation, changeVerb, newCPUAllocationRate))
-	htmlBuilder.WriteString(fmt.Sprintf("<li>内存分配率将由 <strong>%.2f%%</strong> %s至 <strong>%.2f%%</strong></li>",
-		currentMemAllocation, changeVerb, newMemAllocationRate))
+	htmlBuilder.WriteString(fmt.Sprintf("<li>CPU%s将由 <strong>%.2f%%</strong> %s至 <strong>%.2f%%</strong>%s</li>",
+		cpuName, currentCPUValue, changeVerb, newCPUValue, formatTargetSuffix(strategy.CPUThresholdValue, strategy.CPUTargetValue)))
+	htmlBuilder.WriteString(fmt.Sprintf("<li>内存%s将由 <strong>%.2f%%</strong> %s至 <strong>%.2f%%</strong>%s</li>",
+		memName, currentMemValue, changeVerb, newMemValue, formatTargetSuffix(strategy.MemoryThresholdValue, strategy.MemoryTargetValue)))
 	htmlBuilder.WriteString("</ul>")
 
 	return htmlBuilder.String()
 }
 
-// calculateProjectedAllocation calculates the projected resource allocation rates after the scaling action.
-func (s *ElasticScalingService) calculateProjectedAllocation(snapshot *portal.ResourceSnapshot, deviceTotalCPU, deviceTotalMemory float64, action string) (cpuRate float64, memRate float64) {
-	currentCPURequest := snapshot.CpuRequest
-	currentMemRequest := snapshot.MemRequest
-	currentCPUCapacity := snapshot.CpuCapacity
-	currentMemCapacity := snapshot.MemoryCapacity
+// buildForecastDescription 构建预测触发说明的HTML片段
+func (s *ElasticScalingService) buildForecastDescription(forecast *forecastResult) string {
+	var builder strings.Builder
+	builder.WriteString("<h5>预测触发说明</h5>")
+	builder.WriteString(fmt.Sprintf("<p>本次操作由预测模式提前触发：基于 %s 至 %s 共 %d 个每日快照，使用<strong>%s</strong>模型预测未来 %d 天的资源趋势。</p>",
+		forecast.StartDate, forecast.EndDate, forecast.Points, forecastMethodName(forecast.Method), forecast.HorizonDays))
+
+	builder.WriteString("<ul>")
+	for _, item := range []struct {
+		name     string
+		forecast *metricForecast
+	}{{"CPU", forecast.CPU}, {"内存", forecast.Memory}} {
+		if item.forecast == nil {
+			continue
+		}
+		crossing := "预测窗口内不会越过阈值"
+		if item.forecast.CrossingDate != nil {
+			crossing = fmt.Sprintf("预计 <strong>%s</strong> 越过阈值", item.forecast.CrossingDate.Format(forecastDateFormat))
+		}
+		builder.WriteString(fmt.Sprintf("<li>%s%s当前 %.2f%%，趋势 %+.2f%%/天，%d 天后预计达到 <strong>%.2f%%</strong>（阈值 %.2f%%），%s</li>",
+			item.name, metricName(item.forecast.ThresholdType), item.forecast.Current, item.forecast.TrendPerDay,
+			forecast.HorizonDays, item.forecast.Projected, item.forecast.Threshold, crossing))
+	}
+	builder.WriteString("</ul>")
+
+	if forecast.CrossingDate != nil {
+		condition := ""
+		if forecast.CPU != nil && forecast.Memory != nil {
+			condition = fmt.Sprintf("综合 %s 条件，", forecast.ConditionLogic)
+		}
+		builder.WriteString(fmt.Sprintf("<p>%s预计 <strong>%s</strong> 触及阈值，资源需求按预测窗口末的预测值计算。</p>",
+			condition, forecast.CrossingDate.Format(forecastDateFormat)))
+	}
+	return builder.String()
+}
 
-	var newCPUCapacity, newMemCapacity float64
+// formatTargetSuffix 返回描述中的目标值提示，未配置阈值时返回空
+func formatTargetSuffix(threshold, target float64) string {
+	if threshold <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("（目标值 %.2f%%）", effectiveTargetValue(target, threshold))
+}
 
-	if action == TriggerActionPoolEntry {
-		newCPUCapacity = currentCPUCapacity + deviceTotalCPU
-		newMemCapacity = currentMemCapacity + deviceTotalMemory
-	} else { // TriggerActionPoolExit
-		newCPUCapacity = currentCPUCapacity - deviceTotalCPU
-		newMemCapacity = currentMemCapacity - deviceTotalMemory
+// calculateProjectedAllocation calculates the projected CPU/memory metrics after the scaling action.
+// The metric for each resource follows the strategy's threshold type (usage or allocated),
+// assuming the current load stays constant while the capacity changes.
+// Since device selection is sized by the target-based delta, the projection should land near the target value.
+func (s *ElasticScalingService) calculateProjectedAllocation(snapshot *portal.ResourceSnapshot, strategy *portal.ElasticScalingStrategy, deviceTotalCPU, deviceTotalMemory float64) (cpuRate float64, memRate float64) {
+	cpuChange, memChange := deviceTotalCPU, deviceTotalMemory
+	if strategy.ThresholdTriggerAction != TriggerActionPoolEntry { // TriggerActionPoolExit
+		cpuChange, memChange = -deviceTotalCPU, -deviceTotalMemory
 	}
 
-	// 使用 safePercentage 函数安全地计算百分比
-	cpuRate = safePercentage(currentCPURequest, newCPUCapacity)
-	memRate = safePercentage(currentMemRequest, newMemCapacity)
+	// 负载（实际使用量或Request）不变，按调整后的容量重新计算指标
+	projected := projectSnapshotCapacity(*snapshot, cpuChange, memChange)
+	cpuRate = cpuMetricValue(projected, strategy.CPUThresholdType)
+	memRate = memMetricValue(projected, strategy.MemoryThresholdType)
 
 	return cpuRate, memRate
 }
@@ -0,0 +1,126 @@
+package es
+
+import (
+	"context"
+	"testing"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+	"navy-ng/server/portal/internal/service/order"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderTimelineRecordsSources(t *testing.T) {
+	s, db := newTestService(t)
+	s.orderService = order.NewOrderService(db)
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 1}, ClusterName: "cluster-a"}).Error)
+	require.NoError(t, db.Create(&portal.Device{BaseModel: portal.BaseModel{ID: 1}, CICode: "node-1"}).Error)
+
+	orderID, err := s.CreateOrder(OrderDTO{Name: "退池订单", ClusterID: 1, ActionType: TriggerActionPoolExit, ResourcePoolType: "total", DeviceCount: 1, Devices: []int{1}, CreatedBy: "tester"})
+	require.NoError(t, err)
+
+	require.NoError(t, s.UpdateOrderStatus(orderID, string(portal.OrderStatusProcessing), "alice", ""))
+	require.NoError(t, s.UpdateOrderDeviceStatusFrom(portal.OrderStatusSourceEvent, orderID, 1, StatusSuccess, "system"))
+	require.NoError(t, s.UpdateOrderStatusFrom(portal.OrderStatusSourceEvent, orderID, string(portal.OrderStatusCompleted), "system", "所有设备操作已完成"))
+
+	timeline, err := s.GetOrderTimeline(orderID)
+	require.NoError(t, err)
+	require.Len(t, timeline, 4)
+
+	assert.Equal(t, order.TimelineKindCreated, timeline[0].Kind)
+	assert.Equal(t, portal.OrderStatusSourceAPI, timeline[0].Source)
+	assert.Equal(t, string(portal.OrderStatusPending), timeline[0].ToStatus)
+	assert.Equal(t, portal.OrderStatusSourceAPI, timeline[1].Source)
+	assert.Equal(t, "alice", timeline[1].Executor)
+
+	assert.Equal(t, order.TimelineKindDeviceStatus, timeline[2].Kind)
+	assert.Equal(t, "node-1", timeline[2].DeviceCICode)
+	assert.Equal(t, StatusSuccess, timeline[2].ToStatus)
+	assert.Equal(t, portal.OrderStatusSourceEvent, timeline[2].Source)
+
+	assert.Equal(t, string(portal.OrderStatusCompleted), timeline[3].ToStatus)
+	assert.Equal(t, portal.OrderStatusSourceEvent, timeline[3].Source)
+}
+
+func TestOrderTimelineCreationSources(t *testing.T) {
+	s, db := newTestService(t)
+	s.orderService = order.NewOrderService(db)
+	s.redisHandler = &fakeLockRedis{held: map[string]bool{}}
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 1}, ClusterName: "cluster-a"}).Error)
+
+	createdSource := func(orderID int) string {
+		timeline, err := s.GetOrderTimeline(orderID)
+		require.NoError(t, err)
+		require.NotEmpty(t, timeline)
+		require.Equal(t, order.TimelineKindCreated, timeline[0].Kind)
+		return timeline[0].Source
+	}
+
+	t.Run("orders created by the scheduled evaluation come from the monitor", func(t *testing.T) {
+		strategy := &portal.ElasticScalingStrategy{Name: "entry", ThresholdTriggerAction: TriggerActionPoolEntry, CPUThresholdValue: 80,
+			CPUThresholdType: ThresholdTypeAllocated, DurationMinutes: 1, DurationUnit: DurationUnitDay, ResourceTypes: "total", Status: StrategyStatusEnabled}
+		require.NoError(t, db.Create(strategy).Error)
+		require.NoError(t, db.Create(&portal.StrategyClusterAssociation{StrategyID: strategy.ID, ClusterID: 1}).Error)
+		require.NoError(t, db.Create(&portal.ResourceSnapshot{ClusterID: 1, ResourceType: "total", CpuRequest: 90, CpuCapacity: 100}).Error)
+		require.NoError(t, db.Create(&portal.QueryTemplate{BaseModel: portal.BaseModel{ID: 1}, Name: "all", Groups: "[]"}).Error)
+		require.NoError(t, db.Create(&portal.ResourcePoolDeviceMatchingPolicy{Name: "entry", ResourcePoolType: "total",
+			ActionType: TriggerActionPoolEntry, QueryTemplateID: 1, Status: "enabled"}).Error)
+
+		require.NoError(t, s.EvaluateStrategies())
+
+		var detail portal.ElasticScalingOrderDetail
+		require.NoError(t, db.Where("strategy_id = ?", strategy.ID).First(&detail).Error)
+		assert.Equal(t, portal.OrderStatusSourceMonitor, createdSource(detail.OrderID))
+	})
+
+	t.Run("unified order API marks the API source unless the caller set one", func(t *testing.T) {
+		unified := NewUnifiedOrderService(s)
+		dto := OrderDTO{Name: "入池订单", ClusterID: 1, ActionType: TriggerActionPoolEntry, ResourcePoolType: "total", CreatedBy: "tester"}
+
+		created, err := unified.CreateOrder(context.Background(), dto)
+		require.NoError(t, err)
+		assert.Equal(t, portal.OrderStatusSourceAPI, createdSource(created.ID))
+
+		created, err = unified.CreateOrder(order.WithStatusSource(context.Background(), portal.OrderStatusSourceEvent), dto)
+		require.NoError(t, err)
+		assert.Equal(t, portal.OrderStatusSourceEvent, createdSource(created.ID))
+
+		require.NoError(t, unified.ProcessOrder(context.Background(), created.ID, "alice"))
+		timeline, err := s.GetOrderTimeline(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, portal.OrderStatusSourceAPI, timeline[len(timeline)-1].Source)
+	})
+}
+
+func TestOrderVersionConcurrency(t *testing.T) {
+	s, db := newTestService(t)
+	s.orderService = order.NewOrderService(db)
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 1}, ClusterName: "cluster-a"}).Error)
+	require.NoError(t, db.Create(&portal.Device{BaseModel: portal.BaseModel{ID: 1}, CICode: "node-1"}).Error)
+
+	orderID, err := s.CreateOrder(OrderDTO{Name: "入池订单", ClusterID: 1, ActionType: TriggerActionPoolEntry, ResourcePoolType: "total", DeviceCount: 1, Devices: []int{1}, CreatedBy: "tester"})
+	require.NoError(t, err)
+
+	detail, err := s.GetOrder(orderID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, detail.Version)
+
+	// 携带过期版本号的更新被拒绝，订单状态保持不变
+	require.NoError(t, s.UpdateOrderStatusIfMatch(orderID, detail.Version, string(portal.OrderStatusProcessing), "alice", ""))
+	err = s.UpdateOrderStatusIfMatch(orderID, detail.Version, string(portal.OrderStatusCancelled), "bob", "")
+	assert.True(t, service.IsPreconditionFailed(err))
+
+	detail, err = s.GetOrder(orderID)
+	require.NoError(t, err)
+	assert.Equal(t, string(portal.OrderStatusProcessing), detail.Status)
+	assert.Equal(t, 1, detail.Version)
+
+	// 订单设备状态更新同样递增设备关联的版本号
+	require.NoError(t, s.UpdateOrderDeviceStatus(orderID, 1, StatusSuccess, "system"))
+	var orderDevice portal.OrderDevice
+	require.NoError(t, db.Where("order_id = ? AND device_id = ?", orderID, 1).First(&orderDevice).Error)
+	assert.Equal(t, StatusSuccess, orderDevice.Status)
+	assert.Equal(t, 1, orderDevice.Version)
+}
@@ -0,0 +1,117 @@
+package es
+
+import (
+	"math"
+
+	. "navy-ng/server/portal/internal/service"
+)
+
+// 同一资源池类型和动作类型多个匹配策略的组合模式，由优先级最高的策略的 CombineMode 决定
+const (
+	PolicyCombineModeUnion         = "union"          // 每个策略独立满足全部增量，合并所有策略选中的设备（默认）
+	PolicyCombineModeFirstWins     = "first_wins"     // 按优先级执行，采用第一个满足增量的策略的选择结果
+	PolicyCombineModeFillRemaining = "fill_remaining" // 按优先级执行，后续策略只补足前序策略未满足的增量
+)
+
+// normalizePolicyCombineMode 返回有效的组合模式，未配置或未知时为 union
+func normalizePolicyCombineMode(mode string) string {
+	switch mode {
+	case PolicyCombineModeFirstWins, PolicyCombineModeFillRemaining:
+		return mode
+	default:
+		return PolicyCombineModeUnion
+	}
+}
+
+// policyCombineMode 返回一组匹配策略的组合模式，policies 已按优先级排序
+func policyCombineMode(policies []ResourcePoolDeviceMatchingPolicy) string {
+	if len(policies) == 0 {
+		return PolicyCombineModeUnion
+	}
+	return normalizePolicyCombineMode(policies[0].CombineMode)
+}
+
+// policyOutcome 单个匹配策略的执行结果
+type policyOutcome struct {
+	policyID   int
+	selection  deviceSelection
+	trace      *PolicyMatchingTrace
+	candidates []DeviceResponse // 该策略的候选设备，用于统计采用设备的故障域分布
+}
+
+// adopt 采用匹配策略的选择结果，并记录每台设备由哪个策略选中（重复的设备归属于先采用的策略）
+func (r *deviceMatchResult) adopt(outcome policyOutcome) {
+	r.SelectedDeviceIDs = append(r.SelectedDeviceIDs, outcome.selection.DeviceIDs...)
+	r.Selections = append(r.Selections, outcome.selection)
+	for _, deviceID := range outcome.selection.DeviceIDs {
+		if _, ok := r.DevicePolicies[deviceID]; !ok {
+			r.DevicePolicies[deviceID] = outcome.policyID
+		}
+	}
+	r.faultDomainUsage.record(outcome.candidates, outcome.selection.DeviceIDs)
+	outcome.trace.Adopted = true
+	outcome.trace.Note = ""
+}
+
+// bestPartialOutcome 返回得分最优的非空选择结果，得分相同时优先级高的策略优先，没有时返回 nil
+func bestPartialOutcome(outcomes []policyOutcome) *policyOutcome {
+	var best *policyOutcome
+	for i := range outcomes {
+		if len(outcomes[i].selection.DeviceIDs) == 0 {
+			continue
+		}
+		if best == nil || outcomes[i].selection.Score < best.selection.Score {
+			best = &outcomes[i]
+		}
+	}
+	return best
+}
+
+// remainingDelta 返回扣除已选容量后的剩余增量，符号与原增量一致（入池为正，出池为负）
+func remainingDelta(delta, fulfilled float64) float64 {
+	switch {
+	case delta > 0:
+		return math.Max(delta-fulfilled, 0)
+	case delta < 0:
+		return math.Min(delta+fulfilled, 0)
+	default:
+		return 0
+	}
+}
+
+// selectionCapacity 返回选中设备的CPU和内存总量
+func selectionCapacity(candidates []DeviceResponse, deviceIDs []int) (cpu, mem float64) {
+	selected := make(map[int]bool, len(deviceIDs))
+	for _, id := range deviceIDs {
+		selected[id] = true
+	}
+	for _, device := range candidates {
+		if selected[device.ID] {
+			cpu += device.CPU
+			mem += device.Memory
+		}
+	}
+	return cpu, mem
+}
+
+// selectionSatisfied 判断已选容量是否满足增量；没有资源增量（按设备数选择）时选中设备即满足
+func selectionSatisfied(cpuDelta, memDelta float64, action string, cpu, mem float64, count int) bool {
+	if cpuDelta == 0 && memDelta == 0 {
+		return count > 0
+	}
+	return count > 0 && newSelectionDemand(cpuDelta, memDelta, action).met(cpu, mem)
+}
+
+// excludeClaimedDevices 排除已由前序匹配策略选中的设备，返回剩余候选设备及被排除设备所属的策略
+func excludeClaimedDevices(devices []DeviceResponse, devicePolicies map[int]int) ([]DeviceResponse, map[int]int) {
+	claimed := make(map[int]int)
+	available := make([]DeviceResponse, 0, len(devices))
+	for _, device := range devices {
+		if policyID, ok := devicePolicies[device.ID]; ok {
+			claimed[device.ID] = policyID
+			continue
+		}
+		available = append(available, device)
+	}
+	return available, claimed
+}
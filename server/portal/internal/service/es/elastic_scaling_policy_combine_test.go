@@ -0,0 +1,210 @@
+package es
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newMatchingTestService 创建可执行设备查询的测试服务（补充设备查询关联的节点、标签和污点表）
+func newMatchingTestService(t *testing.T) (*ElasticScalingService, *gorm.DB) {
+	t.Helper()
+	s, db := newTestService(t)
+	require.NoError(t, db.AutoMigrate(
+		&portal.K8sNode{},
+		&portal.K8sNodeLabel{},
+		&portal.K8sNodeTaint{},
+		&portal.LabelManagement{},
+		&portal.TaintManagement{},
+		&portal.DeviceApp{},
+	))
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 1}, ClusterName: "cluster-a"}).Error)
+	return s, db
+}
+
+// createIDCTemplate 创建按 IDC 筛选设备的查询模板
+func createIDCTemplate(t *testing.T, db *gorm.DB, id int, idc string) {
+	t.Helper()
+	groups := fmt.Sprintf(`[{"id":"g1","operator":"and","blocks":[{"id":"b1","type":"device","key":"idc","conditionType":"equal","value":%q,"operator":"and"}]}]`, idc)
+	require.NoError(t, db.Create(&portal.QueryTemplate{BaseModel: portal.BaseModel{ID: id}, Name: idc, Groups: groups}).Error)
+}
+
+func TestCollectMatchedDevicesCombineModes(t *testing.T) {
+	s, db := newMatchingTestService(t)
+	for i, idc := range []string{"idc-a", "idc-a", "idc-b", "idc-b", "idc-b"} {
+		require.NoError(t, db.Create(&portal.Device{
+			BaseModel: portal.BaseModel{ID: i + 1},
+			CICode:    fmt.Sprintf("device-%d", i+1),
+			IDC:       idc,
+			CPU:       10,
+			Memory:    10,
+		}).Error)
+	}
+	createIDCTemplate(t, db, 1, "idc-a")
+	createIDCTemplate(t, db, 2, "idc-b")
+
+	// 先创建的策略优先级较低，验证按优先级而不是ID执行
+	fallback := portal.ResourcePoolDeviceMatchingPolicy{Name: "idc-b", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 2, Status: "enabled", Priority: 2}
+	primary := portal.ResourcePoolDeviceMatchingPolicy{Name: "idc-a", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 1, Status: "enabled", Priority: 1}
+	require.NoError(t, db.Create(&fallback).Error)
+	require.NoError(t, db.Create(&primary).Error)
+
+	strategy := &portal.ElasticScalingStrategy{BaseModel: portal.BaseModel{ID: 1}, ThresholdTriggerAction: TriggerActionPoolEntry}
+	collect := func(t *testing.T, mode string, delta float64) *deviceMatchResult {
+		t.Helper()
+		require.NoError(t, db.Model(&portal.ResourcePoolDeviceMatchingPolicy{}).Where("1 = 1").Update("combine_mode", mode).Error)
+		result, err := s.collectMatchedDevices(&evaluationContext{}, strategy, 1, "total", "", "", delta, delta)
+		require.NoError(t, err)
+		assert.Equal(t, normalizePolicyCombineMode(mode), result.Trace.CombineMode)
+		require.Len(t, result.Trace.Policies, 2)
+		assert.Equal(t, primary.ID, result.Trace.Policies[0].PolicyID)
+		return result
+	}
+
+	t.Run("union merges every policy", func(t *testing.T) {
+		result := collect(t, "", 20)
+		assert.ElementsMatch(t, []int{1, 2, 3, 4}, result.SelectedDeviceIDs)
+		assert.Equal(t, map[int]int{1: primary.ID, 2: primary.ID, 3: fallback.ID, 4: fallback.ID}, result.DevicePolicies)
+	})
+
+	t.Run("first wins stops at the first satisfying policy", func(t *testing.T) {
+		result := collect(t, PolicyCombineModeFirstWins, 20)
+		assert.ElementsMatch(t, []int{1, 2}, result.SelectedDeviceIDs)
+		assert.True(t, result.Trace.Policies[0].Adopted)
+		assert.False(t, result.Trace.Policies[1].Adopted)
+		assert.NotEmpty(t, result.Trace.Policies[1].Note)
+	})
+
+	t.Run("first wins falls through to a policy that satisfies the delta", func(t *testing.T) {
+		result := collect(t, PolicyCombineModeFirstWins, 30)
+		assert.ElementsMatch(t, []int{3, 4, 5}, result.SelectedDeviceIDs)
+		assert.False(t, result.Trace.Policies[0].Adopted)
+		assert.True(t, result.Trace.Policies[1].Adopted)
+		require.Len(t, result.Selections, 1)
+		assert.Equal(t, "idc-b", result.Selections[0].PolicyName)
+	})
+
+	t.Run("first wins uses the best partial selection when nothing satisfies", func(t *testing.T) {
+		result := collect(t, PolicyCombineModeFirstWins, 50)
+		assert.ElementsMatch(t, []int{3, 4, 5}, result.SelectedDeviceIDs)
+		assert.True(t, result.Trace.Policies[1].Adopted)
+	})
+
+	t.Run("fill remaining only requests the remaining delta", func(t *testing.T) {
+		result := collect(t, PolicyCombineModeFillRemaining, 30)
+		require.Len(t, result.SelectedDeviceIDs, 3)
+		assert.Equal(t, primary.ID, result.DevicePolicies[1])
+		assert.Equal(t, primary.ID, result.DevicePolicies[2])
+		assert.Equal(t, 10.0, result.Trace.Policies[1].CPUDelta)
+		assert.Len(t, result.Trace.Policies[1].SelectedDeviceIDs, 1)
+	})
+
+	t.Run("fill remaining skips policies once the delta is met", func(t *testing.T) {
+		result := collect(t, PolicyCombineModeFillRemaining, 20)
+		assert.ElementsMatch(t, []int{1, 2}, result.SelectedDeviceIDs)
+		assert.Zero(t, result.Trace.Policies[1].QueriedCount)
+		assert.NotEmpty(t, result.Trace.Policies[1].Note)
+	})
+}
+
+func TestFillRemainingExcludesClaimedDevices(t *testing.T) {
+	s, db := newMatchingTestService(t)
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, db.Create(&portal.Device{BaseModel: portal.BaseModel{ID: i}, CICode: fmt.Sprintf("device-%d", i), IDC: "idc-a", Room: "R1", Cabinet: "A", CPU: 10, Memory: 10}).Error)
+	}
+	createIDCTemplate(t, db, 1, "idc-a")
+	// 所有设备位于同一机柜，第一个策略只能选择1台，其余增量由第二个策略补足
+	require.NoError(t, db.Create(&portal.ResourcePoolDeviceMatchingPolicy{Name: "first", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 1, Status: "enabled", MaxDevicesPerCabinet: 1, CombineMode: PolicyCombineModeFillRemaining}).Error)
+	require.NoError(t, db.Create(&portal.ResourcePoolDeviceMatchingPolicy{Name: "second", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 1, Status: "enabled", Priority: 1}).Error)
+
+	strategy := &portal.ElasticScalingStrategy{BaseModel: portal.BaseModel{ID: 1}, ThresholdTriggerAction: TriggerActionPoolEntry}
+	result, err := s.collectMatchedDevices(&evaluationContext{}, strategy, 1, "total", "", "", 20, 20)
+	require.NoError(t, err)
+
+	assert.Len(t, result.SelectedDeviceIDs, 2)
+	assert.Len(t, s.deduplicateDeviceIDs(result.SelectedDeviceIDs), 2)
+	second := result.Trace.Policies[1]
+	assert.Equal(t, 2, second.CandidateCount)
+	var claimed int
+	for _, rejected := range second.RejectedDevices {
+		if rejected.Reason == MatchingRejectClaimed {
+			claimed++
+		}
+	}
+	assert.Equal(t, 1, claimed)
+}
+
+func TestOrderDevicesRecordMatchingPolicy(t *testing.T) {
+	s, db := newTestService(t)
+	policy := portal.ResourcePoolDeviceMatchingPolicy{Name: "idc-a", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 1, Status: "enabled"}
+	require.NoError(t, db.Create(&policy).Error)
+	require.NoError(t, db.Create(&portal.Device{BaseModel: portal.BaseModel{ID: 1}, CICode: "device-1"}).Error)
+	require.NoError(t, db.Create(&portal.Device{BaseModel: portal.BaseModel{ID: 2}, CICode: "device-2"}).Error)
+
+	orderID, err := s.CreateOrder(OrderDTO{
+		ClusterID:     1,
+		ActionType:    TriggerActionPoolEntry,
+		Devices:       []int{1, 2},
+		MatchingTrace: &DeviceMatchingTrace{DevicePolicies: map[int]int{1: policy.ID}},
+	})
+	require.NoError(t, err)
+
+	devices, err := s.GetOrderDevices(orderID)
+	require.NoError(t, err)
+	byID := make(map[int]DeviceDTO)
+	for _, device := range devices {
+		byID[device.ID] = device
+	}
+	assert.Equal(t, policy.ID, byID[1].MatchingPolicyID)
+	assert.Equal(t, "idc-a", byID[1].MatchingPolicyName)
+	assert.Zero(t, byID[2].MatchingPolicyID)
+}
+
+func TestPolicyPriorityAndCombineMode(t *testing.T) {
+	_, db := newTestService(t)
+	require.NoError(t, db.Create(&portal.QueryTemplate{BaseModel: portal.BaseModel{ID: 1}, Name: "all", Groups: "[]"}).Error)
+	policyService := NewResourcePoolDeviceMatchingPolicyService(db, nil)
+	ctx := context.Background()
+
+	low := &service.ResourcePoolDeviceMatchingPolicy{Name: "low", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 1, Status: "enabled", Priority: 5}
+	high := &service.ResourcePoolDeviceMatchingPolicy{Name: "high", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 1, Status: "enabled", Priority: 1, CombineMode: PolicyCombineModeFillRemaining}
+	other := &service.ResourcePoolDeviceMatchingPolicy{Name: "exit", ResourcePoolType: "total", ActionType: TriggerActionPoolExit, QueryTemplateID: 1, Status: "enabled"}
+	require.NoError(t, policyService.CreateResourcePoolDeviceMatchingPolicy(ctx, low))
+	require.NoError(t, policyService.CreateResourcePoolDeviceMatchingPolicy(ctx, other))
+	require.NoError(t, policyService.CreateResourcePoolDeviceMatchingPolicy(ctx, high))
+
+	policies, err := policyService.GetResourcePoolDeviceMatchingPoliciesByType(ctx, "total", TriggerActionPoolEntry)
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	assert.Equal(t, "high", policies[0].Name)
+	assert.Equal(t, "low", policies[1].Name)
+	assert.Equal(t, PolicyCombineModeFillRemaining, policyCombineMode(policies))
+
+	// 保存低优先级策略不会改写其他策略，组合模式仍由优先级最高的策略决定
+	low.CombineMode = PolicyCombineModeFirstWins
+	require.NoError(t, policyService.UpdateResourcePoolDeviceMatchingPolicy(ctx, low))
+	policies, err = policyService.GetResourcePoolDeviceMatchingPoliciesByType(ctx, "total", TriggerActionPoolEntry)
+	require.NoError(t, err)
+	assert.Equal(t, PolicyCombineModeFillRemaining, policies[0].CombineMode)
+	assert.Equal(t, PolicyCombineModeFirstWins, policies[1].CombineMode)
+	assert.Equal(t, PolicyCombineModeFillRemaining, policyCombineMode(policies))
+
+	exitPolicies, err := policyService.GetResourcePoolDeviceMatchingPoliciesByType(ctx, "total", TriggerActionPoolExit)
+	require.NoError(t, err)
+	assert.Equal(t, PolicyCombineModeUnion, policyCombineMode(exitPolicies))
+}
+
+func TestRemainingDelta(t *testing.T) {
+	assert.Equal(t, 5.0, remainingDelta(15, 10))
+	assert.Equal(t, 0.0, remainingDelta(10, 15))
+	assert.Equal(t, -5.0, remainingDelta(-15, 10))
+	assert.Equal(t, 0.0, remainingDelta(-10, 15))
+	assert.Equal(t, 0.0, remainingDelta(0, 10))
+}
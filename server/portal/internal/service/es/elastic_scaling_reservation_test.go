@@ -0,0 +1,79 @@
+package es
+
+import (
+	"testing"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+	"navy-ng/server/portal/internal/service/order"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceReservation(t *testing.T) {
+	s, db := newTestService(t)
+	s.orderService = order.NewOrderService(db)
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 1}, ClusterName: "cluster-a"}).Error)
+
+	newOrder := func(deviceIDs ...int) OrderDTO {
+		return OrderDTO{
+			Name:             "入池订单",
+			ClusterID:        1,
+			ActionType:       TriggerActionPoolEntry,
+			ResourcePoolType: "total",
+			DeviceCount:      len(deviceIDs),
+			Devices:          deviceIDs,
+			CreatedBy:        "tester",
+		}
+	}
+	reservedBy := func(deviceIDs ...int) map[int]int {
+		reserved, err := service.ReservedDeviceIDs(db, deviceIDs)
+		require.NoError(t, err)
+		return reserved
+	}
+
+	firstOrderID, err := s.CreateOrder(newOrder(1, 2))
+	require.NoError(t, err)
+	assert.Equal(t, map[int]int{1: firstOrderID, 2: firstOrderID}, reservedBy(1, 2, 3))
+
+	t.Run("rejects order with devices reserved by an active order", func(t *testing.T) {
+		_, err := s.CreateOrder(newOrder(2, 3))
+		require.Error(t, err)
+		assert.True(t, service.IsConflict(err))
+
+		var orderCount int64
+		db.Model(&portal.Order{}).Count(&orderCount)
+		assert.Equal(t, int64(1), orderCount)
+		assert.NotContains(t, reservedBy(3), 3)
+	})
+
+	t.Run("excludes reserved devices from candidates", func(t *testing.T) {
+		candidates := []service.DeviceResponse{{ID: 1}, {ID: 2}, {ID: 3}}
+		available, reserved, err := service.ExcludeReservedDevices(db, candidates)
+		require.NoError(t, err)
+		assert.Equal(t, []service.DeviceResponse{{ID: 3}}, available)
+		assert.Len(t, reserved, 2)
+	})
+
+	t.Run("releases reservations when order is cancelled", func(t *testing.T) {
+		require.NoError(t, s.UpdateOrderStatus(firstOrderID, string(portal.OrderStatusCancelled), "tester", "取消"))
+		assert.Empty(t, reservedBy(1, 2))
+
+		secondOrderID, err := s.CreateOrder(newOrder(2, 3))
+		require.NoError(t, err)
+		assert.Equal(t, map[int]int{2: secondOrderID, 3: secondOrderID}, reservedBy(2, 3))
+	})
+
+	t.Run("reservations of finished orders do not block new orders", func(t *testing.T) {
+		require.NoError(t, db.Create(&portal.Order{OrderNumber: "ES-DONE", Type: portal.OrderTypeElasticScaling, Status: portal.OrderStatusCompleted}).Error)
+		var done portal.Order
+		require.NoError(t, db.Where("order_number = ?", "ES-DONE").First(&done).Error)
+		require.NoError(t, db.Create(&portal.DeviceReservation{DeviceID: 4, OrderID: done.ID}).Error)
+		assert.Empty(t, reservedBy(4))
+
+		orderID, err := s.CreateOrder(newOrder(4))
+		require.NoError(t, err)
+		assert.Equal(t, map[int]int{4: orderID}, reservedBy(4))
+	})
+}
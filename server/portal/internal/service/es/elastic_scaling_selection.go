@@ -0,0 +1,271 @@
+package es
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	. "navy-ng/server/portal/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// 设备选择算法，由 ResourcePoolDeviceMatchingPolicy.SelectionAlgorithm 指定
+const (
+	SelectionAlgorithmGreedy   = "greedy"    // 按CPU排序贪婪选择（默认）
+	SelectionAlgorithmBalanced = "balanced"  // 每次选择对CPU和内存剩余缺口贡献最大的设备
+	SelectionAlgorithmMinWaste = "min_waste" // 分支限界搜索，最小化设备数和超额量
+
+	// selectionAlgorithmDeviceCount 没有资源增量时按平均规格估算设备数，不使用选择算法
+	selectionAlgorithmDeviceCount = "device_count"
+)
+
+const (
+	// selectionShortfallWeight 目标函数中未满足需求的权重，保证满足需求优先于减少设备数
+	selectionShortfallWeight = 10.0
+	// minWasteSearchNodeLimit 分支限界搜索的节点上限，超出后返回当前最优解
+	minWasteSearchNodeLimit = 200000
+)
+
+// deviceSelector 设备选择算法：从候选设备中选择满足资源增量的设备，出池时 costs 为候选设备的排空代价
+type deviceSelector func(s *ElasticScalingService, devices []DeviceResponse, cpuDemand, memDemand float64, action string, costs drainCosts) []int
+
+// deviceSelectors 已注册的设备选择算法
+var deviceSelectors = map[string]deviceSelector{
+	SelectionAlgorithmGreedy:   (*ElasticScalingService).greedySelectDevices,
+	SelectionAlgorithmBalanced: (*ElasticScalingService).balancedSelectDevices,
+	SelectionAlgorithmMinWaste: (*ElasticScalingService).minWasteSelectDevices,
+}
+
+// deviceSelection 单个匹配策略的设备选择结果
+type deviceSelection struct {
+	PolicyName string
+	Algorithm  string
+	DeviceIDs  []int
+	Score      float64 // 目标函数得分，越低越好
+
+	FaultDomainAdjusted  bool           // 是否因故障域约束调整了算法的选择结果
+	ConstraintViolations map[int]string // 因故障域约束未选择的设备及原因
+}
+
+// normalizeSelectionAlgorithm 返回有效的选择算法名称，未配置或未知时使用贪婪算法
+func normalizeSelectionAlgorithm(algorithm string) string {
+	if _, ok := deviceSelectors[algorithm]; ok {
+		return algorithm
+	}
+	return SelectionAlgorithmGreedy
+}
+
+// selectionDemand 资源需求的绝对值：入池为需要增加的容量，出池为需要移除的容量
+type selectionDemand struct {
+	cpu, mem float64
+}
+
+func newSelectionDemand(cpuDemand, memDemand float64, action string) selectionDemand {
+	if action == TriggerActionPoolExit {
+		cpuDemand, memDemand = -cpuDemand, -memDemand
+	}
+	return selectionDemand{cpu: math.Max(cpuDemand, 0), mem: math.Max(memDemand, 0)}
+}
+
+// normalized 返回设备在各维度上相对于需求的比例，需求为0的维度记为0
+func (d selectionDemand) normalized(cpu, mem float64) (float64, float64) {
+	var nc, nm float64
+	if d.cpu > 0 {
+		nc = cpu / d.cpu
+	}
+	if d.mem > 0 {
+		nm = mem / d.mem
+	}
+	return nc, nm
+}
+
+// met 判断已选容量是否满足需求
+func (d selectionDemand) met(cpu, mem float64) bool {
+	return cpu >= d.cpu && mem >= d.mem
+}
+
+// fits 判断出池时再移除该设备是否会超过需求（只约束需求大于0的维度）
+func (d selectionDemand) fits(cpu, mem, deviceCPU, deviceMem float64) bool {
+	return (d.cpu <= 0 || cpu+deviceCPU <= d.cpu) && (d.mem <= 0 || mem+deviceMem <= d.mem)
+}
+
+// score 计算选择结果的目标函数：未满足比例 * 权重 + 设备数 + 超额比例
+func (d selectionDemand) score(count int, cpu, mem float64) float64 {
+	nc, nm := d.normalized(cpu, mem)
+	var shortfall, overshoot float64
+	if d.cpu > 0 {
+		shortfall += math.Max(1-nc, 0)
+		overshoot += math.Max(nc-1, 0)
+	}
+	if d.mem > 0 {
+		shortfall += math.Max(1-nm, 0)
+		overshoot += math.Max(nm-1, 0)
+	}
+	return selectionShortfallWeight*shortfall + float64(count) + overshoot
+}
+
+// selectDevicesWithAlgorithm 使用指定算法选择设备并计算目标函数得分
+func (s *ElasticScalingService) selectDevicesWithAlgorithm(algorithm string, devices []DeviceResponse, cpuDemand, memDemand float64, action string, costs drainCosts) deviceSelection {
+	algorithm = normalizeSelectionAlgorithm(algorithm)
+	selected := deviceSelectors[algorithm](s, devices, cpuDemand, memDemand, action, costs)
+	return deviceSelection{
+		Algorithm: algorithm,
+		DeviceIDs: selected,
+		Score:     scoreDeviceSelection(devices, selected, cpuDemand, memDemand, action),
+	}
+}
+
+// scoreDeviceSelection 计算已选设备的目标函数得分
+func scoreDeviceSelection(devices []DeviceResponse, selected []int, cpuDemand, memDemand float64, action string) float64 {
+	chosen := make(map[int]bool, len(selected))
+	for _, id := range selected {
+		chosen[id] = true
+	}
+	var cpu, mem float64
+	for _, device := range devices {
+		if chosen[device.ID] {
+			cpu += device.CPU
+			mem += device.Memory
+		}
+	}
+	return newSelectionDemand(cpuDemand, memDemand, action).score(len(selected), cpu, mem)
+}
+
+// balancedSelectDevices 均衡选择：每次选择对CPU和内存剩余缺口（按需求归一化）贡献之和最大的设备，
+// 贡献相同时选择超额更少的设备，超额也相同时选择排空代价更低的设备。出池时只考虑移除后不超过需求的设备。
+func (s *ElasticScalingService) balancedSelectDevices(devices []DeviceResponse, cpuDemand, memDemand float64, action string, costs drainCosts) []int {
+	demand := newSelectionDemand(cpuDemand, memDemand, action)
+	used := make([]bool, len(devices))
+	var selectedDeviceIDs []int
+	var cpuFulfilled, memFulfilled float64
+
+	for !demand.met(cpuFulfilled, memFulfilled) {
+		best, bestGain, bestWaste := -1, 0.0, 0.0
+		for i, device := range devices {
+			if used[i] {
+				continue
+			}
+			if action == TriggerActionPoolExit && !demand.fits(cpuFulfilled, memFulfilled, device.CPU, device.Memory) {
+				continue
+			}
+			remainingCPU, remainingMem := demand.normalized(math.Max(demand.cpu-cpuFulfilled, 0), math.Max(demand.mem-memFulfilled, 0))
+			nc, nm := demand.normalized(device.CPU, device.Memory)
+			gain := math.Min(nc, remainingCPU) + math.Min(nm, remainingMem)
+			waste := math.Max(nc-remainingCPU, 0) + math.Max(nm-remainingMem, 0)
+			if gain > bestGain || (best >= 0 && gain == bestGain && (waste < bestWaste || (waste == bestWaste && cheaperToDrain(costs, device.ID, devices[best].ID)))) {
+				best, bestGain, bestWaste = i, gain, waste
+			}
+		}
+		if best < 0 {
+			break // 没有设备能继续缩小缺口
+		}
+		used[best] = true
+		selectedDeviceIDs = append(selectedDeviceIDs, devices[best].ID)
+		cpuFulfilled += devices[best].CPU
+		memFulfilled += devices[best].Memory
+	}
+
+	s.logger.Info("Balanced device selection completed",
+		zap.String("action", action),
+		zap.Float64("cpuDemand", cpuDemand),
+		zap.Float64("memDemand", memDemand),
+		zap.Float64("cpuFulfilled", cpuFulfilled),
+		zap.Float64("memFulfilled", memFulfilled),
+		zap.Ints("selectedDeviceIDs", selectedDeviceIDs))
+
+	return selectedDeviceIDs
+}
+
+// minWasteSelectDevices 最小浪费选择：以均衡选择的结果为初始解，通过分支限界搜索
+// 目标函数（未满足比例 * 权重 + 设备数 + 超额比例）最小的设备组合。
+// 出池时不允许超过需求，得分相同时保留初始解（均衡选择已按排空代价择优）。搜索节点数超过上限时返回当前最优解。
+func (s *ElasticScalingService) minWasteSelectDevices(devices []DeviceResponse, cpuDemand, memDemand float64, action string, costs drainCosts) []int {
+	demand := newSelectionDemand(cpuDemand, memDemand, action)
+
+	// 按归一化容量降序排列，相同规格的设备相邻，便于剪除重复分支
+	sorted := make([]DeviceResponse, len(devices))
+	copy(sorted, devices)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ci, mi := demand.normalized(sorted[i].CPU, sorted[i].Memory)
+		cj, mj := demand.normalized(sorted[j].CPU, sorted[j].Memory)
+		return ci+mi > cj+mj
+	})
+
+	// 后缀容量和，用于估算下界
+	suffixCPU := make([]float64, len(sorted)+1)
+	suffixMem := make([]float64, len(sorted)+1)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		suffixCPU[i] = suffixCPU[i+1] + sorted[i].CPU
+		suffixMem[i] = suffixMem[i+1] + sorted[i].Memory
+	}
+
+	best := s.balancedSelectDevices(devices, cpuDemand, memDemand, action, costs)
+	bestScore := scoreDeviceSelection(devices, best, cpuDemand, memDemand, action)
+	var current []int
+	nodes := 0
+
+	var search func(index int, cpu, mem float64)
+	search = func(index int, cpu, mem float64) {
+		nodes++
+		if nodes > minWasteSearchNodeLimit {
+			return
+		}
+		if score := demand.score(len(current), cpu, mem); score < bestScore {
+			bestScore = score
+			best = append([]int(nil), current...)
+		}
+		if demand.met(cpu, mem) || index >= len(sorted) {
+			return // 入池已满足时继续添加只会增加设备数和超额
+		}
+		// 下界：剩余设备全部加入（出池时受需求上限约束）后的未满足比例 + 至少再选一台
+		optimistic := demand.score(0, math.Min(cpu+suffixCPU[index], math.Max(demand.cpu, cpu)), math.Min(mem+suffixMem[index], math.Max(demand.mem, mem)))
+		if optimistic+float64(len(current)+1) >= bestScore {
+			return
+		}
+
+		var lastCPU, lastMem float64
+		for i := index; i < len(sorted); i++ {
+			device := sorted[i]
+			if i > index && device.CPU == lastCPU && device.Memory == lastMem {
+				continue // 相同规格的设备在同一层只尝试一次
+			}
+			lastCPU, lastMem = device.CPU, device.Memory
+			if action == TriggerActionPoolExit && !demand.fits(cpu, mem, device.CPU, device.Memory) {
+				continue
+			}
+			current = append(current, device.ID)
+			search(i+1, cpu+device.CPU, mem+device.Memory)
+			current = current[:len(current)-1]
+		}
+	}
+	search(0, 0, 0)
+
+	s.logger.Info("Min-waste device selection completed",
+		zap.String("action", action),
+		zap.Float64("cpuDemand", cpuDemand),
+		zap.Float64("memDemand", memDemand),
+		zap.Float64("score", bestScore),
+		zap.Int("searchedNodes", nodes),
+		zap.Ints("selectedDeviceIDs", best))
+
+	return best
+}
+
+// buildSelectionDescription 生成订单描述中的设备选择算法说明
+func buildSelectionDescription(selections []deviceSelection) string {
+	if len(selections) == 0 {
+		return ""
+	}
+	var items []string
+	for _, selection := range selections {
+		item := fmt.Sprintf("%s：算法 %s，选择 %d 台，目标得分 %.2f",
+			selection.PolicyName, selection.Algorithm, len(selection.DeviceIDs), selection.Score)
+		if selection.FaultDomainAdjusted {
+			item += "（已按机房/机柜分散约束调整）"
+		}
+		items = append(items, "<li>"+item+"</li>")
+	}
+	return "<p><strong>设备选择：</strong>（目标得分 = 未满足比例×10 + 设备数 + 超额比例，越低越好）</p><ul>" + strings.Join(items, "") + "</ul>"
+}
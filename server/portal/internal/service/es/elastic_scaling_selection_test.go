@@ -0,0 +1,84 @@
+package es
+
+import (
+	"testing"
+
+	"navy-ng/server/portal/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSelectDevicesWithAlgorithm(t *testing.T) {
+	s := &ElasticScalingService{logger: zap.NewNop()}
+
+	t.Run("balanced prefers memory-heavy device for memory demand", func(t *testing.T) {
+		devices := []service.DeviceResponse{
+			{ID: 1, CPU: 64, Memory: 128},
+			{ID: 2, CPU: 32, Memory: 512},
+		}
+		greedy := s.selectDevicesWithAlgorithm(SelectionAlgorithmGreedy, devices, 30, 500, TriggerActionPoolEntry, nil)
+		balanced := s.selectDevicesWithAlgorithm(SelectionAlgorithmBalanced, devices, 30, 500, TriggerActionPoolEntry, nil)
+
+		assert.ElementsMatch(t, []int{1, 2}, greedy.DeviceIDs)
+		assert.Equal(t, []int{2}, balanced.DeviceIDs)
+		assert.Less(t, balanced.Score, greedy.Score)
+	})
+
+	t.Run("min waste avoids overshoot", func(t *testing.T) {
+		devices := []service.DeviceResponse{
+			{ID: 1, CPU: 60, Memory: 60},
+			{ID: 2, CPU: 50, Memory: 50},
+			{ID: 3, CPU: 50, Memory: 50},
+		}
+		balanced := s.selectDevicesWithAlgorithm(SelectionAlgorithmBalanced, devices, 100, 100, TriggerActionPoolEntry, nil)
+		minWaste := s.selectDevicesWithAlgorithm(SelectionAlgorithmMinWaste, devices, 100, 100, TriggerActionPoolEntry, nil)
+
+		assert.ElementsMatch(t, []int{1, 2}, balanced.DeviceIDs)
+		assert.InDelta(t, 2.2, balanced.Score, 1e-9)
+		assert.ElementsMatch(t, []int{2, 3}, minWaste.DeviceIDs)
+		assert.InDelta(t, 2.0, minWaste.Score, 1e-9)
+	})
+
+	t.Run("min waste fills exit demand without exceeding it", func(t *testing.T) {
+		devices := []service.DeviceResponse{
+			{ID: 1, CPU: 60, Memory: 60},
+			{ID: 2, CPU: 50, Memory: 50},
+			{ID: 3, CPU: 50, Memory: 50},
+		}
+		balanced := s.selectDevicesWithAlgorithm(SelectionAlgorithmBalanced, devices, -100, -100, TriggerActionPoolExit, nil)
+		minWaste := s.selectDevicesWithAlgorithm(SelectionAlgorithmMinWaste, devices, -100, -100, TriggerActionPoolExit, nil)
+
+		assert.Equal(t, []int{1}, balanced.DeviceIDs)
+		assert.ElementsMatch(t, []int{2, 3}, minWaste.DeviceIDs)
+		assert.Less(t, minWaste.Score, balanced.Score)
+	})
+
+	t.Run("only constrains dimensions with demand", func(t *testing.T) {
+		devices := []service.DeviceResponse{
+			{ID: 1, CPU: 16, Memory: 1024},
+			{ID: 2, CPU: 40, Memory: 64},
+		}
+		selection := s.selectDevicesWithAlgorithm(SelectionAlgorithmMinWaste, devices, 40, 0, TriggerActionPoolEntry, nil)
+		assert.Equal(t, []int{2}, selection.DeviceIDs)
+		assert.InDelta(t, 1.0, selection.Score, 1e-9)
+	})
+
+	t.Run("unknown algorithm falls back to greedy", func(t *testing.T) {
+		devices := []service.DeviceResponse{{ID: 1, CPU: 10, Memory: 10}}
+		selection := s.selectDevicesWithAlgorithm("", devices, 5, 5, TriggerActionPoolEntry, nil)
+		assert.Equal(t, SelectionAlgorithmGreedy, selection.Algorithm)
+		assert.Equal(t, []int{1}, selection.DeviceIDs)
+	})
+}
+
+func TestBuildSelectionDescription(t *testing.T) {
+	assert.Empty(t, buildSelectionDescription(nil))
+
+	description := buildSelectionDescription([]deviceSelection{
+		{PolicyName: "计算池入池", Algorithm: SelectionAlgorithmMinWaste, DeviceIDs: []int{1, 2}, Score: 2},
+	})
+	assert.Contains(t, description, "计算池入池")
+	assert.Contains(t, description, "min_waste")
+	assert.Contains(t, description, "2.00")
+}
@@ -22,7 +22,11 @@ const (
 	StrategyExecutionResultBreachedPendingDeviceMatch = "breached_pending_device_match" // From previous step
 	StrategyExecutionResultFailureNoSnapshots         = "failure_no_snapshots_for_duration"
 	StrategyExecutionResultFailureThresholdNotMet     = "failure_threshold_not_met"
-	StrategyExecutionResultSkippedCooldown            = "skipped_cooldown" // 冷却期内跳过评估
+	StrategyExecutionResultSkippedCooldown            = "skipped_cooldown"       // 冷却期内跳过评估
+	StrategyExecutionResultBlockedAntiFlapping        = "blocked_anti_flapping"  // 防抖拦截：反向订单窗口内或出池后会触发入池
+	StrategyExecutionResultSkippedFreeze              = "skipped_freeze"         // 变更冻结期内跳过评估
+	StrategyExecutionResultBudgetExceeded             = "budget_exceeded"        // 自动伸缩预算已用尽，未创建订单
+	StrategyExecutionResultBlockedExitGuardrail       = "blocked_exit_guardrail" // 出池护栏拦截：出池1台设备即超过资源池告警上限
 	StrategyExecutionResultFailureInvalidTemplateID   = "failure_invalid_query_template_id"
 	StrategyExecutionResultFailureTemplateNotFound    = "failure_query_template_not_found"
 	StrategyExecutionResultFailureTemplateUnmarshal   = "failure_query_template_unmarshal_error"
@@ -49,7 +53,7 @@ type ElasticScalingService struct {
 	cache                       DeviceCacheInterface  // Changed to DeviceCacheInterface
 	orderService                order.OrderService    // 通用订单服务
 	eventManager                *events.EventManager  // 事件管理器
-	matchDevicesForStrategyFunc func(strategy *portal.ElasticScalingStrategy, clusterID int, resourceType, triggeredValue, thresholdValue string, cpuDelta, memDelta float64, latestSnapshot *portal.ResourceSnapshot) error
+	matchDevicesForStrategyFunc func(evalCtx *evaluationContext, strategy *portal.ElasticScalingStrategy, clusterID int, resourceType, triggeredValue, thresholdValue string, cpuDelta, memDelta float64, latestSnapshot *portal.ResourceSnapshot) error
 }
 
 // GetStrategyExecutionHistoryWithPagination 获取策略执行历史（分页）
@@ -140,12 +144,26 @@ func (s *ElasticScalingService) GetStrategyExecutionHistoryWithPagination(strate
 			OrderID:        history.OrderID,
 			HasOrder:       history.OrderID != nil,
 			Reason:         history.Reason,
+
+			ForecastInput:         history.ForecastInput,
+			ProjectedCrossingDate: navyTimePtrToTimePtr(history.ProjectedCrossingDate),
+			MatchingTrace:         s.historyMatchingTrace(history),
 		}
 	}
 
 	return result, total, nil
 }
 
+// historyMatchingTrace 解析执行历史保存的设备匹配过程，解析失败时记录日志并忽略
+func (s *ElasticScalingService) historyMatchingTrace(history portal.StrategyExecutionHistory) *DeviceMatchingTrace {
+	trace, err := unmarshalMatchingTrace(history.MatchingTrace)
+	if err != nil {
+		s.logger.Warn("Failed to unmarshal device matching trace of strategy execution history", zap.Error(err), zap.Int("historyID", history.ID))
+		return nil
+	}
+	return trace
+}
+
 // NewElasticScalingService 创建弹性伸缩服务实例
 // 接受数据库连接、RedisHandlerInterface 实例、logger、cache 和 eventManager 作为参数
 func NewElasticScalingService(db *gorm.DB, redisHandler RedisHandlerInterface, logger *zap.Logger, cache DeviceCacheInterface, eventManager *events.EventManager) *ElasticScalingService {
@@ -158,8 +176,6 @@ func NewElasticScalingService(db *gorm.DB, redisHandler RedisHandlerInterface, l
 		orderService: orderService, // 初始化通用订单服务
 		eventManager: eventManager, // 初始化事件管理器
 	}
-	s.matchDevicesForStrategyFunc = s.matchDevicesForStrategy
-
 	// 注册订单事件处理器
 	if eventManager != nil {
 		s.RegisterEventHandlers(eventManager)
@@ -169,8 +185,16 @@ func NewElasticScalingService(db *gorm.DB, redisHandler RedisHandlerInterface, l
 	return s
 }
 
+// matchDevices 执行设备匹配和订单创建，测试中可通过 SetMatchDevicesForStrategyFunc 替换。
+func (s *ElasticScalingService) matchDevices(evalCtx *evaluationContext, strategy *portal.ElasticScalingStrategy, clusterID int, resourceType, triggeredValue, thresholdValue string, cpuDelta, memDelta float64, latestSnapshot *portal.ResourceSnapshot) error {
+	if s.matchDevicesForStrategyFunc != nil {
+		return s.matchDevicesForStrategyFunc(evalCtx, strategy, clusterID, resourceType, triggeredValue, thresholdValue, cpuDelta, memDelta, latestSnapshot)
+	}
+	return s.matchDevicesForStrategy(evalCtx, strategy, clusterID, resourceType, triggeredValue, thresholdValue, cpuDelta, memDelta, latestSnapshot)
+}
+
 // SetMatchDevicesForStrategyFunc is a test helper to mock the device matching function.
-func (s *ElasticScalingService) SetMatchDevicesForStrategyFunc(f func(strategy *portal.ElasticScalingStrategy, clusterID int, resourceType, triggeredValue, thresholdValue string, cpuDelta, memDelta float64, latestSnapshot *portal.ResourceSnapshot) error) {
+func (s *ElasticScalingService) SetMatchDevicesForStrategyFunc(f func(evalCtx *evaluationContext, strategy *portal.ElasticScalingStrategy, clusterID int, resourceType, triggeredValue, thresholdValue string, cpuDelta, memDelta float64, latestSnapshot *portal.ResourceSnapshot) error) {
 	s.matchDevicesForStrategyFunc = f
 }
 
@@ -0,0 +1,416 @@
+package es
+
+import (
+	"errors"
+	"fmt"
+	"navy-ng/models/portal"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/now"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// 单次模拟允许回放的最大天数
+	maxSimulationDays = 90
+
+	// 内联策略未命名时使用的默认名称
+	defaultSimulationStrategyName = "模拟策略"
+)
+
+// SimulateStrategy 在历史快照上回放策略（按天模式逐日、分钟/小时模式逐个快照），返回每一个会生成订单
+// （或因冻结期、冷却期、防抖、出池护栏、预算拦截）的时间点。
+// 模拟过程复用真实的评估、增量计算、设备匹配和拦截检查，但不会写入 ng_orders 和 ng_strategy_execution_history。
+// 注意：设备匹配基于当前的设备库存，而非历史时刻的库存。
+func (s *ElasticScalingService) SimulateStrategy(req StrategySimulationRequestDTO) (*StrategySimulationResultDTO, error) {
+	startDate, endDate, err := parseSimulationDateRange(req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, clusterIDs, err := s.resolveSimulationStrategy(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(clusterIDs) == 0 {
+		return nil, errors.New("至少需要指定一个集群")
+	}
+
+	// 模拟模式的评估上下文保证设备匹配流程中的任何记录都不会落库
+	evalCtx := &evaluationContext{DryRun: true}
+
+	clusterNames := s.getClusterNameMap(clusterIDs)
+	result := &StrategySimulationResultDTO{
+		StrategyID:   strategy.ID,
+		StrategyName: strategy.Name,
+		StartDate:    startDate.Format(dateFormat),
+		EndDate:      endDate.Format(dateFormat),
+		Points:       []StrategySimulationPointDTO{},
+	}
+
+	for _, clusterID := range clusterIDs {
+		// 已保存的策略按集群合并覆盖配置
+		clusterStrategy := s.effectiveStrategyForCluster(strategy, clusterID)
+		for _, resourceType := range parseResourceTypes(clusterStrategy.ResourceTypes) {
+			points, err := s.simulateClusterResourcePool(evalCtx, clusterStrategy, clusterID, clusterNames[clusterID], resourceType, startDate, endDate)
+			if err != nil {
+				return nil, err
+			}
+			result.Points = append(result.Points, points...)
+		}
+	}
+
+	sort.SliceStable(result.Points, func(i, j int) bool {
+		return result.Points[i].Date < result.Points[j].Date
+	})
+
+	for _, point := range result.Points {
+		switch point.Result {
+		case StrategyExecutionResultOrderCreated, StrategyExecutionResultOrderCreatedNoDevices:
+			result.OrderCount++
+		case StrategyExecutionResultSkippedCooldown:
+			result.CooldownSkipCount++
+		case StrategyExecutionResultSkippedFreeze, StrategyExecutionResultBlockedAntiFlapping,
+			StrategyExecutionResultBlockedExitGuardrail, StrategyExecutionResultBudgetExceeded:
+			result.BlockedCount++
+		}
+	}
+
+	s.logger.Info("Strategy simulation completed",
+		zap.Int("strategyID", strategy.ID),
+		zap.String("startDate", result.StartDate),
+		zap.String("endDate", result.EndDate),
+		zap.Int("orderCount", result.OrderCount),
+		zap.Int("cooldownSkipCount", result.CooldownSkipCount),
+		zap.Int("blockedCount", result.BlockedCount))
+
+	return result, nil
+}
+
+// simulateClusterResourcePool 回放单个集群+资源池在日期区间内的评估结果，按真实评估的顺序执行拦截检查：
+// 冻结期、冷却期、反向订单防抖、出池护栏、出池后触发入池阈值、自动伸缩预算。
+// 冷却期仅根据本次模拟中产生的订单计算；冻结期、反向订单防抖和预算用量按评估时间点查询已存在的数据，
+// 预算用量不包含本次模拟中产生的订单。
+func (s *ElasticScalingService) simulateClusterResourcePool(
+	evalCtx *evaluationContext,
+	strategy *portal.ElasticScalingStrategy,
+	clusterID int,
+	clusterName string,
+	resourceType string,
+	startDate, endDate time.Time,
+) ([]StrategySimulationPointDTO, error) {
+	snapshots, err := s.getSnapshotsBetween(clusterID, resourceType,
+		evaluationLookbackStart(strategy, startDate), now.With(endDate).EndOfDay())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshots for cluster %d resource %s: %w", clusterID, resourceType, err)
+	}
+
+	var points []StrategySimulationPointDTO
+	var lastOrderTime *time.Time
+
+	for _, evalTime := range simulationEvaluationTimes(strategy, snapshots, startDate, endDate) {
+		evaluation := s.evaluateSnapshotsAt(strategy, snapshots, evalTime)
+		if !evaluation.Breached {
+			continue
+		}
+
+		pointDate := evalTime.Format(dateFormat)
+		if isSlidingWindowStrategy(strategy) {
+			pointDate = evalTime.Format(time.DateTime)
+		}
+		triggeredValue, thresholdValue := evaluation.TriggeredValue, evaluation.ThresholdValue
+		latestSnapshot := *evaluation.latestSnapshot()
+		evaluationTime := time.Time(latestSnapshot.CreatedAt)
+		point := StrategySimulationPointDTO{
+			Date:               pointDate,
+			ClusterID:          clusterID,
+			ClusterName:        clusterName,
+			ResourceType:       resourceType,
+			ConsecutiveDays:    evaluation.ConsecutiveDays,
+			SustainedMinutes:   evaluation.SustainedMinutes,
+			ProjectedCrossing:  formatForecastCrossing(evaluation.Forecast),
+			TriggeredValue:     triggeredValue,
+			ThresholdValue:     thresholdValue,
+			CandidateDeviceIDs: []int{},
+			SelectedDevices:    []DeviceDTO{},
+		}
+
+		if frozen, reason := s.checkFreezeCalendar(clusterID, evaluationTime); frozen {
+			point.Result = StrategyExecutionResultSkippedFreeze
+			point.Reason = reason
+			points = append(points, point)
+			continue
+		}
+
+		if lastOrderTime != nil && strategy.CooldownMinutes > 0 &&
+			evaluationTime.Before(lastOrderTime.Add(time.Duration(strategy.CooldownMinutes)*time.Minute)) {
+			point.Result = StrategyExecutionResultSkippedCooldown
+			point.Reason = fmt.Sprintf("集群 %s（%s类型）处于冷却期内（上次模拟订单时间 %s），跳过本次评估",
+				clusterName, resourceType, lastOrderTime.Format(time.DateTime))
+			points = append(points, point)
+			continue
+		}
+
+		// 与真实评估一致：防抖窗口内已有反向订单时不生成订单
+		opposite, err := s.findRecentOppositeOrder(strategy, clusterID, resourceType, evaluationTime)
+		if err != nil {
+			return nil, err
+		}
+		if opposite != nil {
+			point.Result = StrategyExecutionResultBlockedAntiFlapping
+			point.Reason = s.hysteresisReason(strategy, opposite, clusterName, resourceType)
+			points = append(points, point)
+			continue
+		}
+
+		point.CPUDelta, point.MemDelta = s.calculateResourceDelta(evaluation.deltaSnapshot(), strategy)
+
+		matchResult, err := s.collectMatchedDevices(evalCtx.derive(), strategy, clusterID, resourceType, triggeredValue, thresholdValue, point.CPUDelta, point.MemDelta)
+		if err != nil {
+			point.Result = StrategyExecutionResultFailureInvalidTemplateID
+			point.Reason = fmt.Sprintf("获取设备匹配策略失败: %s", err.Error())
+			points = append(points, point)
+			continue
+		}
+
+		selectedDeviceIDs := s.deduplicateDeviceIDs(matchResult.SelectedDeviceIDs)
+		point.CandidateCount = matchResult.CandidateCount
+		if matchResult.CandidateDeviceIDs != nil {
+			point.CandidateDeviceIDs = matchResult.CandidateDeviceIDs
+		}
+
+		// 与真实评估一致：出池护栏、出池后触发入池阈值和预算检查都可能拦截或裁剪订单
+		selectedDeviceIDs, notes, blocked, err := s.simulateOrderGates(strategy, clusterID, resourceType, selectedDeviceIDs, &latestSnapshot, evaluationTime)
+		if err != nil {
+			return nil, err
+		}
+		if len(selectedDeviceIDs) > 0 {
+			point.SelectedDevices = s.loadDeviceDTOs(selectedDeviceIDs)
+		}
+		if blocked != nil {
+			point.Result, point.Reason = blocked.result, blocked.reason
+			points = append(points, point)
+			continue
+		}
+
+		actionName := s.getActionName(strategy.ThresholdTriggerAction)
+		if len(selectedDeviceIDs) == 0 {
+			point.Result = StrategyExecutionResultOrderCreatedNoDevices
+			point.Reason = fmt.Sprintf("将为集群 %s（%s类型）创建%s提醒订单，但无可用设备匹配（候选设备 %d 台）",
+				clusterName, resourceType, actionName, matchResult.CandidateCount)
+		} else {
+			point.Result = StrategyExecutionResultOrderCreated
+			point.Reason = fmt.Sprintf("将为集群 %s（%s类型）创建%s订单，涉及设备 %d 台",
+				clusterName, resourceType, actionName, len(selectedDeviceIDs))
+		}
+		for _, note := range notes {
+			point.Reason += "；" + note
+		}
+		lastOrderTime = &evaluationTime
+		points = append(points, point)
+	}
+
+	return points, nil
+}
+
+// simulationBlock 模拟中拦截订单的检查结果
+type simulationBlock struct {
+	result string // 对应的策略执行结果
+	reason string
+}
+
+// simulateOrderGates 对模拟选中的设备执行创建订单前的检查（出池护栏、出池后触发入池阈值、自动伸缩预算），
+// 返回裁剪后的设备、裁剪说明，以及拦截时的结果。与真实评估不同，这里不记录执行历史、不发送预算告警
+func (s *ElasticScalingService) simulateOrderGates(
+	strategy *portal.ElasticScalingStrategy,
+	clusterID int,
+	resourceType string,
+	selectedDeviceIDs []int,
+	latestSnapshot *portal.ResourceSnapshot,
+	evalTime time.Time,
+) ([]int, []string, *simulationBlock, error) {
+	var notes []string
+
+	if len(selectedDeviceIDs) > 0 {
+		guardrail, err := s.applyExitGuardrail(strategy, clusterID, selectedDeviceIDs, latestSnapshot)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if guardrail.Refused != "" {
+			return selectedDeviceIDs, nil, &simulationBlock{result: StrategyExecutionResultBlockedExitGuardrail, reason: guardrail.Refused}, nil
+		}
+		if guardrail.Trimmed {
+			selectedDeviceIDs = guardrail.DeviceIDs
+			notes = append(notes, guardrail.Note)
+		}
+
+		reason, err := s.exitEntryConflict(strategy, clusterID, resourceType, selectedDeviceIDs, latestSnapshot)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if reason != "" {
+			return selectedDeviceIDs, nil, &simulationBlock{result: StrategyExecutionResultBlockedAntiFlapping, reason: reason}, nil
+		}
+	}
+
+	requestedCount := len(selectedDeviceIDs)
+	capped, budgetNote, budgetExceeded, err := s.applyScalingBudgets(strategy.ThresholdTriggerAction, clusterID, resourceType, selectedDeviceIDs, evalTime)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if budgetExceeded != "" {
+		reason := fmt.Sprintf("需要%s %d 台设备，自动伸缩预算已用尽，不会创建订单：%s",
+			s.getActionName(strategy.ThresholdTriggerAction), requestedCount, budgetExceeded)
+		return selectedDeviceIDs, nil, &simulationBlock{result: StrategyExecutionResultBudgetExceeded, reason: reason}, nil
+	}
+	if budgetNote != "" {
+		notes = append(notes, budgetNote)
+	}
+	return capped, notes, nil, nil
+}
+
+// formatForecastCrossing 返回预测结果中的预计越线日期，无预测结果时返回空
+func formatForecastCrossing(forecast *forecastResult) string {
+	if forecast == nil || forecast.CrossingDate == nil {
+		return ""
+	}
+	return forecast.CrossingDate.Format(forecastDateFormat)
+}
+
+// simulationEvaluationTimes 返回回放时的评估时间点：
+// 按天模式为区间内每个有快照的自然日（取当天最新快照的时间），分钟/小时模式为区间内每个快照的采集时间。
+func simulationEvaluationTimes(strategy *portal.ElasticScalingStrategy, snapshots []portal.ResourceSnapshot, startDate, endDate time.Time) []time.Time {
+	rangeStart := now.With(startDate).BeginningOfDay()
+	rangeEnd := now.With(endDate).EndOfDay()
+
+	var inRange []portal.ResourceSnapshot
+	for _, snapshot := range snapshots {
+		createdAt := time.Time(snapshot.CreatedAt)
+		if !createdAt.Before(rangeStart) && !createdAt.After(rangeEnd) {
+			inRange = append(inRange, snapshot)
+		}
+	}
+	// 按天模式下，当天没有快照时跳过，避免重复评估前一天的数据
+	if !isSlidingWindowStrategy(strategy) {
+		inRange = latestSnapshotPerDay(inRange)
+	}
+
+	times := make([]time.Time, len(inRange))
+	for i, snapshot := range inRange {
+		times[i] = time.Time(snapshot.CreatedAt)
+	}
+	return times
+}
+
+// resolveSimulationStrategy 解析模拟所用的策略和集群列表。
+func (s *ElasticScalingService) resolveSimulationStrategy(req StrategySimulationRequestDTO) (*portal.ElasticScalingStrategy, []int, error) {
+	if req.StrategyID != nil {
+		var strategy portal.ElasticScalingStrategy
+		if err := s.db.First(&strategy, *req.StrategyID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, fmt.Errorf("策略不存在: %d", *req.StrategyID)
+			}
+			return nil, nil, err
+		}
+
+		clusterIDs := req.ClusterIDs
+		if len(clusterIDs) == 0 {
+			associations, err := s.getStrategyClusterAssociations(strategy.ID)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, assoc := range associations {
+				clusterIDs = append(clusterIDs, assoc.ClusterID)
+			}
+		}
+		return &strategy, clusterIDs, nil
+	}
+
+	if req.Strategy == nil {
+		return nil, nil, errors.New("必须指定策略ID或内联策略配置")
+	}
+
+	dto := *req.Strategy
+	if len(req.ClusterIDs) > 0 {
+		dto.ClusterIDs = req.ClusterIDs
+	}
+	if dto.Name == "" {
+		dto.Name = defaultSimulationStrategyName
+	}
+	if dto.Status == "" {
+		dto.Status = StrategyStatusEnabled
+	}
+	if err := s.validateStrategyDTO(&dto); err != nil {
+		return nil, nil, err
+	}
+
+	strategy := newStrategyModelFromDTO(&dto)
+	return &strategy, dto.ClusterIDs, nil
+}
+
+// parseSimulationDateRange 解析并校验模拟的日期区间。
+func parseSimulationDateRange(start, end string) (time.Time, time.Time, error) {
+	startDate, err := time.ParseInLocation(dateFormat, start, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("无效的开始日期: %s", start)
+	}
+	endDate, err := time.ParseInLocation(dateFormat, end, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("无效的结束日期: %s", end)
+	}
+	if endDate.Before(startDate) {
+		return time.Time{}, time.Time{}, errors.New("结束日期不能早于开始日期")
+	}
+	if endDate.Sub(startDate) >= maxSimulationDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("模拟区间不能超过 %d 天", maxSimulationDays)
+	}
+	return startDate, endDate, nil
+}
+
+// getClusterNameMap 批量获取集群名称
+func (s *ElasticScalingService) getClusterNameMap(clusterIDs []int) map[int]string {
+	names := make(map[int]string, len(clusterIDs))
+	for _, id := range clusterIDs {
+		names[id] = unknownCluster
+	}
+
+	var clusters []portal.K8sCluster
+	if err := s.db.Where("id IN ?", clusterIDs).Find(&clusters).Error; err != nil {
+		s.logger.Warn("Failed to get cluster names", zap.Error(err))
+		return names
+	}
+	for _, cluster := range clusters {
+		names[cluster.ID] = cluster.ClusterName
+	}
+	return names
+}
+
+// loadDeviceDTOs 根据设备ID批量获取设备DTO
+func (s *ElasticScalingService) loadDeviceDTOs(deviceIDs []int) []DeviceDTO {
+	var devices []portal.Device
+	if err := s.db.Where("id IN ?", deviceIDs).Find(&devices).Error; err != nil {
+		s.logger.Error("Failed to load devices", zap.Error(err))
+		return []DeviceDTO{}
+	}
+
+	result := make([]DeviceDTO, len(devices))
+	for i, device := range devices {
+		result[i] = DeviceDTO{
+			ID:           device.ID,
+			CICode:       device.CICode,
+			IP:           device.IP,
+			ArchType:     device.ArchType,
+			CPU:          device.CPU,
+			Memory:       device.Memory,
+			Status:       device.Status,
+			Role:         device.Role,
+			Cluster:      device.Cluster,
+			ClusterID:    device.ClusterID,
+			IsSpecial:    device.IsSpecial,
+			FeatureCount: device.FeatureCount,
+		}
+	}
+	return result
+}
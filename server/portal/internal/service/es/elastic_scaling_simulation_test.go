@@ -0,0 +1,280 @@
+package es
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+
+	"github.com/jinzhu/now"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTestService 创建基于临时 SQLite 数据库的弹性伸缩服务
+func newTestService(t *testing.T) (*ElasticScalingService, *gorm.DB) {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), fmt.Sprintf("es_test_%d.db", time.Now().UnixNano()))
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.AutoMigrate(
+		&portal.ElasticScalingStrategy{},
+		&portal.StrategyClusterAssociation{},
+		&portal.FreezeWindow{},
+		&portal.ElasticScalingBudget{},
+		&portal.DeviceReservation{},
+		&portal.ClusterLocation{},
+		&portal.ResourcePoolCompatibilityRule{},
+		&portal.DrainProtectionRule{},
+		&portal.OrderStatusHistory{},
+		&portal.ResourceSnapshot{},
+		&portal.StrategyExecutionHistory{},
+		&portal.K8sCluster{},
+		&portal.Device{},
+		&portal.QueryTemplate{},
+		&portal.Order{},
+		&portal.OrderDevice{},
+		&portal.ElasticScalingOrderDetail{},
+		&portal.MaintenanceOrderDetail{},
+		&portal.ResourcePoolDeviceMatchingPolicy{},
+		&portal.NotificationLog{},
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return &ElasticScalingService{db: db, logger: zap.NewNop()}, db
+}
+
+func TestSimulateStrategy(t *testing.T) {
+	s, db := newTestService(t)
+
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 1}, ClusterName: "cluster-sim"}).Error)
+
+	// 连续5天分配率90%
+	start := now.BeginningOfDay().AddDate(0, 0, -4)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, db.Create(&portal.ResourceSnapshot{
+			BaseModel:   portal.BaseModel{CreatedAt: portal.NavyTime(start.AddDate(0, 0, i).Add(10 * time.Hour))},
+			ClusterID:   1,
+			CpuRequest:  90,
+			CpuCapacity: 100,
+		}).Error)
+	}
+
+	cpuThreshold := 80.0
+	cpuType := ThresholdTypeAllocated
+	req := StrategySimulationRequestDTO{
+		Strategy: &StrategyDTO{
+			ThresholdTriggerAction: TriggerActionPoolEntry,
+			CPUThresholdValue:      &cpuThreshold,
+			CPUThresholdType:       &cpuType,
+			DurationMinutes:        2,
+			CooldownMinutes:        2 * 24 * 60,
+		},
+		ClusterIDs: []int{1},
+		StartDate:  start.Format(time.DateOnly),
+		EndDate:    start.AddDate(0, 0, 4).Format(time.DateOnly),
+	}
+
+	t.Run("replays breaches without writing orders or history", func(t *testing.T) {
+		result, err := s.SimulateStrategy(req)
+		require.NoError(t, err)
+
+		// 第2天起连续满足条件；无设备匹配策略，因此每个触发点都记录为匹配失败且不进入冷却
+		require.Len(t, result.Points, 4)
+		for _, point := range result.Points {
+			assert.Equal(t, "cluster-sim", point.ClusterName)
+			assert.Equal(t, StrategyExecutionResultFailureInvalidTemplateID, point.Result)
+			assert.Greater(t, point.CPUDelta, 0.0)
+		}
+		assert.Equal(t, 0, result.OrderCount)
+
+		var orderCount, historyCount int64
+		db.Model(&portal.Order{}).Count(&orderCount)
+		db.Model(&portal.StrategyExecutionHistory{}).Count(&historyCount)
+		assert.Zero(t, orderCount)
+		assert.Zero(t, historyCount)
+	})
+
+	t.Run("applies cooldown between simulated orders", func(t *testing.T) {
+		require.NoError(t, db.Create(&portal.QueryTemplate{BaseModel: portal.BaseModel{ID: 1}, Name: "all", Groups: "[]"}).Error)
+		require.NoError(t, db.Create(&portal.ResourcePoolDeviceMatchingPolicy{
+			Name:             "entry",
+			ResourcePoolType: "total",
+			ActionType:       TriggerActionPoolEntry,
+			QueryTemplateID:  1,
+			Status:           "enabled",
+		}).Error)
+
+		result, err := s.SimulateStrategy(req)
+		require.NoError(t, err)
+
+		var results []string
+		for _, point := range result.Points {
+			results = append(results, point.Result)
+		}
+		assert.Equal(t, []string{
+			StrategyExecutionResultOrderCreatedNoDevices,
+			StrategyExecutionResultSkippedCooldown,
+			StrategyExecutionResultOrderCreatedNoDevices,
+			StrategyExecutionResultSkippedCooldown,
+		}, results)
+		assert.Equal(t, 2, result.OrderCount)
+		assert.Equal(t, 2, result.CooldownSkipCount)
+
+		var orderCount int64
+		db.Model(&portal.Order{}).Count(&orderCount)
+		assert.Zero(t, orderCount)
+	})
+
+	t.Run("applies opposite order hysteresis like the real evaluation", func(t *testing.T) {
+		// 第4天9点生成了出池订单：之前的评估不受影响，2天的防抖窗口拦截第4、5天的评估
+		exitAt := start.AddDate(0, 0, 3).Add(9 * time.Hour)
+		exitOrder := portal.Order{BaseModel: portal.BaseModel{CreatedAt: portal.NavyTime(exitAt)}, OrderNumber: "ES-SIM-EXIT", Type: portal.OrderTypeElasticScaling, Status: portal.OrderStatusCompleted}
+		require.NoError(t, db.Create(&exitOrder).Error)
+		require.NoError(t, db.Create(&portal.ElasticScalingOrderDetail{OrderID: exitOrder.ID, ClusterID: 1, ActionType: TriggerActionPoolExit, ResourcePoolType: "total"}).Error)
+		t.Cleanup(func() {
+			db.Where("order_id = ?", exitOrder.ID).Delete(&portal.ElasticScalingOrderDetail{})
+			db.Delete(&exitOrder)
+		})
+
+		withHysteresis := req
+		strategyDTO := *req.Strategy
+		strategyDTO.CooldownMinutes = 0
+		strategyDTO.HysteresisMinutes = 2 * 24 * 60
+		withHysteresis.Strategy = &strategyDTO
+
+		result, err := s.SimulateStrategy(withHysteresis)
+		require.NoError(t, err)
+
+		var results []string
+		for _, point := range result.Points {
+			results = append(results, point.Result)
+		}
+		assert.Equal(t, []string{
+			StrategyExecutionResultOrderCreatedNoDevices,
+			StrategyExecutionResultOrderCreatedNoDevices,
+			StrategyExecutionResultBlockedAntiFlapping,
+			StrategyExecutionResultBlockedAntiFlapping,
+		}, results)
+		assert.Contains(t, result.Points[2].Reason, "防抖窗口")
+
+		strategyDTO.HysteresisMinutes = 0
+		result, err = s.SimulateStrategy(withHysteresis)
+		require.NoError(t, err)
+		assert.Equal(t, 4, result.OrderCount, "防抖窗口为0时不拦截")
+	})
+
+	t.Run("applies freeze windows and budgets like the real evaluation", func(t *testing.T) {
+		// 第3天处于冻结期；第4天9点已自动创建1个入池订单，每日1单的预算拦截第4天的评估
+		clusterID := 1
+		freeze := portal.FreezeWindow{Name: "发布冻结", ClusterID: &clusterID, RuleType: portal.FreezeRuleTypeDates,
+			Dates: start.AddDate(0, 0, 2).Format(time.DateOnly), Status: StrategyStatusEnabled}
+		require.NoError(t, db.Create(&freeze).Error)
+		budget := portal.ElasticScalingBudget{Name: "每日入池", ActionType: TriggerActionPoolEntry,
+			Period: portal.ScalingBudgetPeriodDaily, MaxOrders: 1, Status: StrategyStatusEnabled}
+		require.NoError(t, db.Create(&budget).Error)
+		createAutoScalingOrder(t, db, "ES-SIM-BUDGET", TriggerActionPoolEntry, portal.OrderStatusCompleted, start.AddDate(0, 0, 3).Add(9*time.Hour), 1)
+		t.Cleanup(func() {
+			db.Delete(&freeze)
+			db.Delete(&budget)
+			db.Where("order_number = ?", "ES-SIM-BUDGET").Delete(&portal.Order{})
+		})
+
+		noCooldown := req
+		strategyDTO := *req.Strategy
+		strategyDTO.CooldownMinutes = 0
+		noCooldown.Strategy = &strategyDTO
+
+		result, err := s.SimulateStrategy(noCooldown)
+		require.NoError(t, err)
+
+		var results []string
+		for _, point := range result.Points {
+			results = append(results, point.Result)
+		}
+		assert.Equal(t, []string{
+			StrategyExecutionResultOrderCreatedNoDevices,
+			StrategyExecutionResultSkippedFreeze,
+			StrategyExecutionResultBudgetExceeded,
+			StrategyExecutionResultOrderCreatedNoDevices,
+		}, results)
+		assert.Contains(t, result.Points[1].Reason, "发布冻结")
+		assert.Contains(t, result.Points[2].Reason, "预算已用尽")
+		assert.Equal(t, 2, result.OrderCount)
+		assert.Equal(t, 2, result.BlockedCount)
+
+		var notificationCount int64
+		db.Model(&portal.NotificationLog{}).Count(&notificationCount)
+		assert.Zero(t, notificationCount, "模拟不发送预算告警")
+	})
+
+	t.Run("rejects invalid date range", func(t *testing.T) {
+		invalid := req
+		invalid.StartDate, invalid.EndDate = invalid.EndDate, invalid.StartDate
+		_, err := s.SimulateStrategy(invalid)
+		assert.Error(t, err)
+	})
+}
+
+func TestSimulateOrderGates(t *testing.T) {
+	s, db := newTestService(t)
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 1}, ClusterName: "cluster-a"}).Error)
+	var deviceIDs []int
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, db.Create(&portal.Device{BaseModel: portal.BaseModel{ID: i}, CICode: fmt.Sprintf("device-%d", i), CPU: 10, Memory: 10}).Error)
+		deviceIDs = append(deviceIDs, i)
+	}
+	entry := &portal.ElasticScalingStrategy{Name: "entry", ThresholdTriggerAction: TriggerActionPoolEntry, CPUThresholdValue: 80,
+		CPUThresholdType: ThresholdTypeAllocated, ResourceTypes: "total", Status: StrategyStatusEnabled}
+	require.NoError(t, db.Create(entry).Error)
+	require.NoError(t, db.Create(&portal.StrategyClusterAssociation{StrategyID: entry.ID, ClusterID: 1}).Error)
+
+	exit := &portal.ElasticScalingStrategy{ThresholdTriggerAction: TriggerActionPoolExit, CPUThresholdType: ThresholdTypeAllocated, MemoryThresholdType: ThresholdTypeAllocated}
+	// 小集群生产环境警告上限 70%：出池 5 台被护栏裁剪为 2 台，出池后 CPU 分配率 62.5%
+	snapshot := &portal.ResourceSnapshot{CpuRequest: 50, CpuCapacity: 100, MemRequest: 10, MemoryCapacity: 100, BMCount: 10}
+	evalTime := time.Now()
+
+	t.Run("trims by the exit guardrail", func(t *testing.T) {
+		selected, notes, blocked, err := s.simulateOrderGates(exit, 1, "total", deviceIDs, snapshot, evalTime)
+		require.NoError(t, err)
+		assert.Nil(t, blocked)
+		assert.Equal(t, deviceIDs[:2], selected)
+		require.Len(t, notes, 1)
+		assert.Contains(t, notes[0], "由 5 台调整为 2 台")
+	})
+
+	t.Run("blocks when the guardrail refuses", func(t *testing.T) {
+		busy := *snapshot
+		busy.CpuRequest = 65
+		_, _, blocked, err := s.simulateOrderGates(exit, 1, "total", deviceIDs, &busy, evalTime)
+		require.NoError(t, err)
+		require.NotNil(t, blocked)
+		assert.Equal(t, StrategyExecutionResultBlockedExitGuardrail, blocked.result)
+	})
+
+	t.Run("blocks when the exit would trigger an entry strategy", func(t *testing.T) {
+		require.NoError(t, db.Model(entry).Update("cpu_threshold_value", 60).Error)
+		_, _, blocked, err := s.simulateOrderGates(exit, 1, "total", deviceIDs, snapshot, evalTime)
+		require.NoError(t, err)
+		require.NotNil(t, blocked)
+		assert.Equal(t, StrategyExecutionResultBlockedAntiFlapping, blocked.result)
+		assert.Contains(t, blocked.reason, "入池策略 entry")
+
+		var historyCount int64
+		db.Model(&portal.StrategyExecutionHistory{}).Count(&historyCount)
+		assert.Zero(t, historyCount)
+	})
+}
@@ -17,40 +17,7 @@ func (s *ElasticScalingService) CreateStrategy(dto StrategyDTO) (int, error) {
 	}
 
 	// 构建策略模型
-	strategy := portal.ElasticScalingStrategy{
-		Name:                   dto.Name,
-		Description:            dto.Description,
-		ThresholdTriggerAction: dto.ThresholdTriggerAction,
-
-		ResourceTypes:   dto.ResourceTypes,
-		Status:          dto.Status,
-		CreatedBy:       dto.CreatedBy,
-		DurationMinutes: dto.DurationMinutes,
-		CooldownMinutes: dto.CooldownMinutes,
-	}
-
-	// 设置可选字段
-	if dto.CPUThresholdValue != nil {
-		strategy.CPUThresholdValue = *dto.CPUThresholdValue
-		strategy.CPUThresholdType = *dto.CPUThresholdType
-
-		if dto.CPUTargetValue != nil {
-			strategy.CPUTargetValue = *dto.CPUTargetValue
-		}
-	}
-
-	if dto.MemoryThresholdValue != nil {
-		strategy.MemoryThresholdValue = *dto.MemoryThresholdValue
-		strategy.MemoryThresholdType = *dto.MemoryThresholdType
-
-		if dto.MemoryTargetValue != nil {
-			strategy.MemoryTargetValue = *dto.MemoryTargetValue
-		}
-	}
-
-	if dto.CPUThresholdValue != nil && dto.MemoryThresholdValue != nil {
-		strategy.ConditionLogic = dto.ConditionLogic
-	}
+	strategy := newStrategyModelFromDTO(&dto)
 
 	// 使用事务确保策略与集群关联的原子性
 	err := s.db.Transaction(func(tx *gorm.DB) error {
@@ -59,12 +26,8 @@ func (s *ElasticScalingService) CreateStrategy(dto StrategyDTO) (int, error) {
 			return err
 		}
 
-		// 创建集群关联关系
-		for _, clusterID := range dto.ClusterIDs {
-			association := portal.StrategyClusterAssociation{
-				StrategyID: strategy.ID,
-				ClusterID:  clusterID,
-			}
+		// 创建集群关联关系（包含集群级覆盖配置）
+		for _, association := range newStrategyClusterAssociations(strategy.ID, &dto) {
 			if err := tx.Create(&association).Error; err != nil {
 				return err
 			}
@@ -104,7 +67,13 @@ func (s *ElasticScalingService) UpdateStrategy(id int64, dto StrategyDTO) error
 	strategy.ResourceTypes = dto.ResourceTypes
 	strategy.Status = dto.Status
 	strategy.DurationMinutes = dto.DurationMinutes
+	strategy.DurationUnit = dto.DurationUnit
 	strategy.CooldownMinutes = dto.CooldownMinutes
+	strategy.HysteresisMinutes = dto.HysteresisMinutes
+	strategy.ExitCeilingLevel = dto.ExitCeilingLevel
+	strategy.TriggerMode = dto.TriggerMode
+	strategy.ForecastMethod = dto.ForecastMethod
+	strategy.ForecastHorizonDays = dto.ForecastHorizonDays
 
 	// 设置可选字段
 	if dto.CPUThresholdValue != nil {
@@ -155,12 +124,8 @@ func (s *ElasticScalingService) UpdateStrategy(id int64, dto StrategyDTO) error
 			return err
 		}
 
-		// 创建新的关联
-		for _, clusterID := range dto.ClusterIDs {
-			association := portal.StrategyClusterAssociation{
-				StrategyID: strategy.ID,
-				ClusterID:  clusterID,
-			}
+		// 创建新的关联（包含集群级覆盖配置）
+		for _, association := range newStrategyClusterAssociations(strategy.ID, &dto) {
 			if err := tx.Create(&association).Error; err != nil {
 				return err
 			}
@@ -223,12 +188,21 @@ func (s *ElasticScalingService) GetStrategy(id int64) (*StrategyDetailDTO, error
 			CreatedAt:       time.Time(strategy.CreatedAt),
 			UpdatedAt:       time.Time(strategy.UpdatedAt),
 			DurationMinutes: strategy.DurationMinutes,
+			DurationUnit:    strategy.DurationUnit,
 			CooldownMinutes: strategy.CooldownMinutes,
 			ClusterIDs:      clusterIDs,
+
+			HysteresisMinutes:   strategy.HysteresisMinutes,
+			ExitCeilingLevel:    strategy.ExitCeilingLevel,
+			TriggerMode:         strategy.TriggerMode,
+			ForecastMethod:      strategy.ForecastMethod,
+			ForecastHorizonDays: strategy.ForecastHorizonDays,
 		},
+		ClusterConfigs:   s.buildClusterConfigs(&strategy, associations),
 		ExecutionHistory: make([]StrategyExecutionHistoryDTO, len(histories)),
 		RelatedOrders:    make([]OrderListItemDTO, len(orders)),
 	}
+	dto.ClusterOverrides = clusterOverrideDTOs(associations)
 
 	// 设置可选阈值字段
 	if strategy.CPUThresholdValue > 0 {
@@ -261,7 +235,10 @@ func (s *ElasticScalingService) GetStrategy(id int64) (*StrategyDetailDTO, error
 
 	// 添加持续时间和冷却时间
 	dto.DurationMinutes = strategy.DurationMinutes
+	dto.DurationUnit = strategy.DurationUnit
 	dto.CooldownMinutes = strategy.CooldownMinutes
+	dto.HysteresisMinutes = strategy.HysteresisMinutes
+	dto.ExitCeilingLevel = strategy.ExitCeilingLevel
 
 	// 添加资源类型
 	dto.ResourceTypes = strategy.ResourceTypes
@@ -278,6 +255,9 @@ func (s *ElasticScalingService) GetStrategy(id int64) (*StrategyDetailDTO, error
 			Result:         h.Result,
 			OrderID:        h.OrderID,
 			Reason:         h.Reason,
+
+			ForecastInput:         h.ForecastInput,
+			ProjectedCrossingDate: navyTimePtrToTimePtr(h.ProjectedCrossingDate),
 		}
 	}
 
@@ -370,15 +350,19 @@ func (s *ElasticScalingService) ListStrategies(name string, status string, actio
 			Description:            strategy.Description,
 			ThresholdTriggerAction: strategy.ThresholdTriggerAction,
 
-			Status:            strategy.Status,
-			CreatedAt:         time.Time(strategy.CreatedAt),
-			UpdatedAt:         time.Time(strategy.UpdatedAt),
-			Clusters:          clusterNames,
-			CPUTargetValue:    &strategy.CPUTargetValue,
-			MemoryTargetValue: &strategy.MemoryTargetValue,
-			DurationMinutes:   strategy.DurationMinutes,
-			CooldownMinutes:   strategy.CooldownMinutes,
-			ResourceTypes:     strategy.ResourceTypes,
+			Status:              strategy.Status,
+			CreatedAt:           time.Time(strategy.CreatedAt),
+			UpdatedAt:           time.Time(strategy.UpdatedAt),
+			Clusters:            clusterNames,
+			CPUTargetValue:      &strategy.CPUTargetValue,
+			MemoryTargetValue:   &strategy.MemoryTargetValue,
+			DurationMinutes:     strategy.DurationMinutes,
+			DurationUnit:        strategy.DurationUnit,
+			CooldownMinutes:     strategy.CooldownMinutes,
+			HysteresisMinutes:   strategy.HysteresisMinutes,
+			TriggerMode:         strategy.TriggerMode,
+			ForecastHorizonDays: strategy.ForecastHorizonDays,
+			ResourceTypes:       strategy.ResourceTypes,
 		}
 
 		// 设置可选阈值字段
@@ -453,6 +437,53 @@ func (s *ElasticScalingService) DeleteStrategy(id int64) error {
 	})
 }
 
+// newStrategyModelFromDTO 根据DTO构建策略模型（不包含集群关联）
+func newStrategyModelFromDTO(dto *StrategyDTO) portal.ElasticScalingStrategy {
+	strategy := portal.ElasticScalingStrategy{
+		Name:                   dto.Name,
+		Description:            dto.Description,
+		ThresholdTriggerAction: dto.ThresholdTriggerAction,
+
+		ResourceTypes:   dto.ResourceTypes,
+		Status:          dto.Status,
+		CreatedBy:       dto.CreatedBy,
+		DurationMinutes: dto.DurationMinutes,
+		DurationUnit:    dto.DurationUnit,
+		CooldownMinutes: dto.CooldownMinutes,
+
+		HysteresisMinutes:   dto.HysteresisMinutes,
+		ExitCeilingLevel:    dto.ExitCeilingLevel,
+		TriggerMode:         dto.TriggerMode,
+		ForecastMethod:      dto.ForecastMethod,
+		ForecastHorizonDays: dto.ForecastHorizonDays,
+	}
+
+	// 设置可选字段
+	if dto.CPUThresholdValue != nil {
+		strategy.CPUThresholdValue = *dto.CPUThresholdValue
+		strategy.CPUThresholdType = *dto.CPUThresholdType
+
+		if dto.CPUTargetValue != nil {
+			strategy.CPUTargetValue = *dto.CPUTargetValue
+		}
+	}
+
+	if dto.MemoryThresholdValue != nil {
+		strategy.MemoryThresholdValue = *dto.MemoryThresholdValue
+		strategy.MemoryThresholdType = *dto.MemoryThresholdType
+
+		if dto.MemoryTargetValue != nil {
+			strategy.MemoryTargetValue = *dto.MemoryTargetValue
+		}
+	}
+
+	if dto.CPUThresholdValue != nil && dto.MemoryThresholdValue != nil {
+		strategy.ConditionLogic = dto.ConditionLogic
+	}
+
+	return strategy
+}
+
 // validateStrategyDTO 验证策略DTO
 func (s *ElasticScalingService) validateStrategyDTO(dto *StrategyDTO) error {
 	if dto.Name == "" {
@@ -471,7 +502,7 @@ func (s *ElasticScalingService) validateStrategyDTO(dto *StrategyDTO) error {
 		if *dto.CPUThresholdValue <= 0 || *dto.CPUThresholdValue > 100 {
 			return errors.New("CPU阈值必须在0-100之间")
 		}
-		if *dto.CPUThresholdType != ThresholdTypeUsage && *dto.CPUThresholdType != ThresholdTypeAllocated {
+		if dto.CPUThresholdType == nil || (*dto.CPUThresholdType != ThresholdTypeUsage && *dto.CPUThresholdType != ThresholdTypeAllocated) {
 			return errors.New("无效的CPU阈值类型")
 		}
 
@@ -494,7 +525,7 @@ func (s *ElasticScalingService) validateStrategyDTO(dto *StrategyDTO) error {
 		if *dto.MemoryThresholdValue <= 0 || *dto.MemoryThresholdValue > 100 {
 			return errors.New("内存阈值必须在0-100之间")
 		}
-		if *dto.MemoryThresholdType != ThresholdTypeUsage && *dto.MemoryThresholdType != ThresholdTypeAllocated {
+		if dto.MemoryThresholdType == nil || (*dto.MemoryThresholdType != ThresholdTypeUsage && *dto.MemoryThresholdType != ThresholdTypeAllocated) {
 			return errors.New("无效的内存阈值类型")
 		}
 
@@ -513,6 +544,14 @@ func (s *ElasticScalingService) validateStrategyDTO(dto *StrategyDTO) error {
 		}
 	}
 
+	// 目标值依赖对应的阈值，单独设置目标值没有意义
+	if dto.CPUThresholdValue == nil && dto.CPUTargetValue != nil {
+		return errors.New("设置CPU目标值时必须同时设置CPU阈值")
+	}
+	if dto.MemoryThresholdValue == nil && dto.MemoryTargetValue != nil {
+		return errors.New("设置内存目标值时必须同时设置内存阈值")
+	}
+
 	if dto.CPUThresholdValue != nil && dto.MemoryThresholdValue != nil {
 		if dto.ConditionLogic != ConditionLogicAnd && dto.ConditionLogic != ConditionLogicOr {
 			return errors.New("无效的条件逻辑，必须为AND或OR")
@@ -533,9 +572,45 @@ func (s *ElasticScalingService) validateStrategyDTO(dto *StrategyDTO) error {
 		return errors.New("持续时间不能为负数")
 	}
 
+	switch dto.DurationUnit {
+	case "", DurationUnitDay:
+	case DurationUnitHour, DurationUnitMinute:
+		// 滑动窗口模式下，持续时间为0没有意义
+		if dto.DurationMinutes == 0 {
+			return errors.New("按分钟或小时评估时持续时间必须大于0")
+		}
+	default:
+		return errors.New("无效的持续时间单位，必须为day、hour或minute")
+	}
+
 	if dto.CooldownMinutes < 0 {
 		return errors.New("冷却时间不能为负数")
 	}
 
-	return nil
+	if dto.HysteresisMinutes < 0 {
+		return errors.New("防抖窗口不能为负数")
+	}
+
+	if dto.ExitCeilingLevel != "" && dto.ExitCeilingLevel != ExitCeilingLevelWarning && dto.ExitCeilingLevel != ExitCeilingLevelCritical {
+		return errors.New("出池护栏上限必须为 warning 或 critical")
+	}
+
+	switch dto.TriggerMode {
+	case "", TriggerModeThreshold:
+	case TriggerModeForecast:
+		// 预测模式仅用于提前扩容
+		if dto.ThresholdTriggerAction != TriggerActionPoolEntry {
+			return errors.New("预测模式仅支持入池动作")
+		}
+		if dto.ForecastHorizonDays <= 0 || dto.ForecastHorizonDays > maxForecastHorizonDays {
+			return fmt.Errorf("预测窗口必须在1-%d天之间", maxForecastHorizonDays)
+		}
+		if dto.ForecastMethod != "" && dto.ForecastMethod != ForecastMethodLinear && dto.ForecastMethod != ForecastMethodHolt {
+			return errors.New("无效的预测方法，必须为linear或holt")
+		}
+	default:
+		return errors.New("无效的触发模式，必须为threshold或forecast")
+	}
+
+	return s.validateClusterOverrides(dto)
 }
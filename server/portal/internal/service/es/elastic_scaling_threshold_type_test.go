@@ -0,0 +1,124 @@
+package es
+
+import (
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestEvaluateSnapshotsThresholdType(t *testing.T) {
+	s := &ElasticScalingService{logger: zap.NewNop()}
+
+	// 分配率 90%，使用率 40%
+	snapshots := []portal.ResourceSnapshot{
+		{BaseModel: portal.BaseModel{CreatedAt: portal.NavyTime(time.Now().AddDate(0, 0, -2))}, CpuRequest: 90, CpuCapacity: 100, MaxCpuUsageRatio: 40},
+		{BaseModel: portal.BaseModel{CreatedAt: portal.NavyTime(time.Now().AddDate(0, 0, -1))}, CpuRequest: 90, CpuCapacity: 100, MaxCpuUsageRatio: 40},
+	}
+
+	t.Run("allocated threshold uses request over capacity", func(t *testing.T) {
+		strategy := &portal.ElasticScalingStrategy{
+			CPUThresholdType:       ThresholdTypeAllocated,
+			CPUThresholdValue:      80,
+			ThresholdTriggerAction: TriggerActionPoolEntry,
+			DurationMinutes:        2,
+		}
+		breached, _, triggered, threshold := s.EvaluateSnapshots(snapshots, strategy)
+		assert.True(t, breached)
+		assert.Contains(t, triggered, "分配率")
+		assert.Contains(t, threshold, "分配率")
+	})
+
+	t.Run("usage threshold uses max usage ratio", func(t *testing.T) {
+		strategy := &portal.ElasticScalingStrategy{
+			CPUThresholdType:       ThresholdTypeUsage,
+			CPUThresholdValue:      80,
+			ThresholdTriggerAction: TriggerActionPoolEntry,
+			DurationMinutes:        2,
+		}
+		breached, _, _, _ := s.EvaluateSnapshots(snapshots, strategy)
+		assert.False(t, breached)
+
+		strategy.CPUThresholdValue = 30
+		breached, _, triggered, threshold := s.EvaluateSnapshots(snapshots, strategy)
+		assert.True(t, breached)
+		assert.Contains(t, triggered, "使用率")
+		assert.Contains(t, threshold, "使用率")
+	})
+
+	t.Run("delta is sized on the configured metric", func(t *testing.T) {
+		strategy := &portal.ElasticScalingStrategy{
+			CPUThresholdType:       ThresholdTypeUsage,
+			CPUThresholdValue:      20,
+			ThresholdTriggerAction: TriggerActionPoolEntry,
+		}
+		// 使用率 40% -> 20%，容量需翻倍
+		cpuDelta, _ := s.calculateResourceDelta(snapshots[1], strategy)
+		assert.InDelta(t, 100, cpuDelta, 0.01)
+	})
+}
+
+func TestCalculateResourceDeltaTargetValue(t *testing.T) {
+	s := &ElasticScalingService{logger: zap.NewNop()}
+	snapshot := portal.ResourceSnapshot{CpuRequest: 90, CpuCapacity: 100}
+
+	t.Run("entry sizes toward target instead of threshold", func(t *testing.T) {
+		strategy := &portal.ElasticScalingStrategy{
+			CPUThresholdType:       ThresholdTypeAllocated,
+			CPUThresholdValue:      80,
+			CPUTargetValue:         60,
+			ThresholdTriggerAction: TriggerActionPoolEntry,
+		}
+		// 90 / 0.6 = 150，需新增 50 核
+		cpuDelta, memDelta := s.calculateResourceDelta(snapshot, strategy)
+		assert.InDelta(t, 50, cpuDelta, 0.01)
+		assert.Zero(t, memDelta)
+	})
+
+	t.Run("falls back to threshold when target is not set", func(t *testing.T) {
+		strategy := &portal.ElasticScalingStrategy{
+			CPUThresholdType:       ThresholdTypeAllocated,
+			CPUThresholdValue:      75,
+			ThresholdTriggerAction: TriggerActionPoolEntry,
+		}
+		cpuDelta, _ := s.calculateResourceDelta(snapshot, strategy)
+		assert.InDelta(t, 20, cpuDelta, 0.01)
+	})
+
+	t.Run("exit selection does not overshoot the target", func(t *testing.T) {
+		devices := []service.DeviceResponse{{ID: 1, CPU: 16}, {ID: 2, CPU: 32}, {ID: 3, CPU: 64}}
+		selected := s.greedySelectDevices(devices, -50, 0, TriggerActionPoolExit, nil)
+		assert.Equal(t, []int{1, 2}, selected)
+	})
+}
+
+func TestValidateStrategyDTOTargetValue(t *testing.T) {
+	s := &ElasticScalingService{logger: zap.NewNop()}
+	threshold, allocated := 80.0, ThresholdTypeAllocated
+
+	newDTO := func(action string, target float64) *StrategyDTO {
+		return &StrategyDTO{
+			Name:                   "target",
+			Status:                 StrategyStatusEnabled,
+			ClusterIDs:             []int{1},
+			ThresholdTriggerAction: action,
+			CPUThresholdValue:      &threshold,
+			CPUThresholdType:       &allocated,
+			CPUTargetValue:         &target,
+		}
+	}
+
+	assert.NoError(t, s.validateStrategyDTO(newDTO(TriggerActionPoolEntry, 60)))
+	assert.Error(t, s.validateStrategyDTO(newDTO(TriggerActionPoolEntry, 90)))
+	assert.NoError(t, s.validateStrategyDTO(newDTO(TriggerActionPoolExit, 90)))
+	assert.Error(t, s.validateStrategyDTO(newDTO(TriggerActionPoolExit, 60)))
+
+	memTarget := 50.0
+	dto := newDTO(TriggerActionPoolEntry, 60)
+	dto.MemoryTargetValue = &memTarget
+	assert.Error(t, s.validateStrategyDTO(dto))
+}
@@ -0,0 +1,128 @@
+package es
+
+import (
+	"context"
+
+	"navy-ng/models/portal"
+	. "navy-ng/server/portal/internal/service"
+	"navy-ng/server/portal/internal/service/order"
+)
+
+// UnifiedOrderDetailDTO 统一订单接口返回的弹性伸缩订单，序列化结果与弹性伸缩订单详情一致
+type UnifiedOrderDetailDTO struct {
+	*OrderDetailDTO
+	base *portal.Order
+}
+
+// GetBaseOrder 实现了 order.RichOrder 接口
+func (dto *UnifiedOrderDetailDTO) GetBaseOrder() *portal.Order {
+	return dto.base
+}
+
+// ElasticScalingOrderQueryDTO 定义了通过统一订单接口查询弹性伸缩订单时支持的参数
+type ElasticScalingOrderQueryDTO struct {
+	Page       int    `form:"page"`
+	PageSize   int    `form:"pageSize"`
+	ClusterID  int    `form:"clusterId"`
+	StrategyID int    `form:"strategyId"`
+	ActionType string `form:"actionType"`
+	Status     string `form:"status"`
+	Name       string `form:"name"`
+}
+
+// unifiedOrderService 将弹性伸缩服务适配为 order.UnifiedOrderService
+type unifiedOrderService struct {
+	s *ElasticScalingService
+}
+
+// NewUnifiedOrderService 创建弹性伸缩订单的统一订单服务，供统一订单接口按订单类型分发
+func NewUnifiedOrderService(s *ElasticScalingService) order.UnifiedOrderService[*UnifiedOrderDetailDTO, OrderDTO] {
+	return &unifiedOrderService{s: s}
+}
+
+// CreateOrder 创建弹性伸缩订单，设备已被其他进行中的订单预留时返回冲突错误
+func (u *unifiedOrderService) CreateOrder(ctx context.Context, createDTO OrderDTO) (*UnifiedOrderDetailDTO, error) {
+	orderID, err := u.s.createOrder(apiSource(ctx), createDTO)
+	if err != nil {
+		return nil, err
+	}
+	return u.GetOrder(ctx, orderID)
+}
+
+// GetOrder 获取弹性伸缩订单详情
+func (u *unifiedOrderService) GetOrder(ctx context.Context, id int) (*UnifiedOrderDetailDTO, error) {
+	db := u.s.db.WithContext(ctx)
+	if err := order.CheckOrderType(ctx, db, id, portal.OrderTypeElasticScaling); err != nil {
+		return nil, err
+	}
+
+	var base portal.Order
+	if err := db.First(&base, id).Error; err != nil {
+		return nil, HandleDBError(err, "订单", id)
+	}
+
+	detail, err := u.s.GetOrder(id)
+	if err != nil {
+		return nil, err
+	}
+	return &UnifiedOrderDetailDTO{OrderDetailDTO: detail, base: &base}, nil
+}
+
+// ListOrders 获取弹性伸缩订单列表，按过滤条件分页后批量组装订单详情，返回满足条件的订单总数
+func (u *unifiedOrderService) ListOrders(ctx context.Context, query any) ([]*UnifiedOrderDetailDTO, int, error) {
+	var params ElasticScalingOrderQueryDTO
+	if err := order.BindListQuery(query, &params); err != nil {
+		return nil, 0, err
+	}
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+	if params.PageSize <= 0 {
+		params.PageSize = 10
+	}
+
+	orders, total, err := u.s.listOrderPage(params.ClusterID, params.StrategyID, params.ActionType, params.Status, params.Name, params.Page, params.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	details, err := u.s.buildOrderDetails(orders)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]*UnifiedOrderDetailDTO, len(orders))
+	for i := range orders {
+		result[i] = &UnifiedOrderDetailDTO{OrderDetailDTO: details[i], base: &orders[i]}
+	}
+	return result, total, nil
+}
+
+// UpdateOrderStatus 更新弹性伸缩订单状态，并记录策略执行历史
+func (u *unifiedOrderService) UpdateOrderStatus(ctx context.Context, id int, status string, executor string, reason string) error {
+	return u.s.updateOrderStatus(apiSource(ctx), id, status, executor, reason)
+}
+
+// ProcessOrder 将订单状态更新为处理中
+func (u *unifiedOrderService) ProcessOrder(ctx context.Context, id int, executor string) error {
+	return u.s.updateOrderStatus(apiSource(ctx), id, string(portal.OrderStatusProcessing), executor, "")
+}
+
+// CompleteOrder 将订单状态更新为已完成
+func (u *unifiedOrderService) CompleteOrder(ctx context.Context, id int, executor string) error {
+	return u.s.updateOrderStatus(apiSource(ctx), id, string(portal.OrderStatusCompleted), executor, "")
+}
+
+// FailOrder 将订单状态更新为失败
+func (u *unifiedOrderService) FailOrder(ctx context.Context, id int, executor string, reason string) error {
+	return u.s.updateOrderStatus(apiSource(ctx), id, string(portal.OrderStatusFailed), executor, reason)
+}
+
+// CancelOrder 将订单状态更新为已取消
+func (u *unifiedOrderService) CancelOrder(ctx context.Context, id int, executor string) error {
+	return u.s.updateOrderStatus(apiSource(ctx), id, string(portal.OrderStatusCancelled), executor, "")
+}
+
+// apiSource 统一订单接口的调用未标记状态变更来源时视为接口调用
+func apiSource(ctx context.Context) context.Context {
+	return order.WithDefaultStatusSource(ctx, portal.OrderStatusSourceAPI)
+}
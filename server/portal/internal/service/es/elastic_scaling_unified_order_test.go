@@ -0,0 +1,90 @@
+package es
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+	"navy-ng/server/portal/internal/service/order"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedOrderService(t *testing.T) {
+	s, db := newTestService(t)
+	s.orderService = order.NewOrderService(db)
+	require.NoError(t, db.Create(&portal.K8sCluster{BaseModel: portal.BaseModel{ID: 1}, ClusterName: "cluster-a"}).Error)
+	require.NoError(t, db.Create(&portal.Device{BaseModel: portal.BaseModel{ID: 1}, CICode: "node-1"}).Error)
+
+	u := NewUnifiedOrderService(s)
+	ctx := context.Background()
+
+	created, err := u.CreateOrder(ctx, OrderDTO{Name: "入池订单", ClusterID: 1, ActionType: TriggerActionPoolEntry, ResourcePoolType: "total", DeviceCount: 1, Devices: []int{1}, CreatedBy: "tester"})
+	require.NoError(t, err)
+	require.NotNil(t, created.GetBaseOrder())
+	assert.Equal(t, portal.OrderTypeElasticScaling, created.GetBaseOrder().Type)
+	assert.Equal(t, "cluster-a", created.ClusterName)
+	require.Len(t, created.Devices, 1)
+
+	// 设备已被进行中的订单预留时返回冲突错误
+	_, err = u.CreateOrder(ctx, OrderDTO{Name: "重复订单", ClusterID: 1, ActionType: TriggerActionPoolEntry, ResourcePoolType: "total", DeviceCount: 1, Devices: []int{1}, CreatedBy: "tester"})
+	assert.True(t, service.IsConflict(err))
+
+	orders, total, err := u.ListOrders(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, orders, 1)
+	assert.Equal(t, created.ID, orders[0].GetBaseOrder().ID)
+
+	id := created.GetBaseOrder().ID
+	require.NoError(t, u.ProcessOrder(ctx, id, "tester"))
+	require.NoError(t, u.CompleteOrder(ctx, id, "tester"))
+	detail, err := u.GetOrder(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, portal.OrderStatusCompleted, detail.GetBaseOrder().Status)
+
+	// 其他类型的订单对弹性伸缩服务不可见
+	general := &portal.Order{OrderNumber: "ORD-GENERAL", Type: portal.OrderTypeGeneral, Status: portal.OrderStatusPending}
+	require.NoError(t, db.Create(general).Error)
+	_, err = u.GetOrder(ctx, general.ID)
+	assert.True(t, service.IsNotFound(err))
+
+	t.Run("list paginates filtered orders and reports the full count", func(t *testing.T) {
+		base := time.Now()
+		for i := 0; i < 3; i++ {
+			createScalingOrder(t, db, fmt.Sprintf("ES-EXIT-%d", i), TriggerActionPoolExit, portal.OrderStatusPending, base.Add(time.Duration(i)*time.Minute))
+		}
+
+		list := func(rawQuery string) ([]*UnifiedOrderDetailDTO, int) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/orders/elastic_scaling?"+rawQuery, nil)
+			orders, total, err := u.ListOrders(ctx, c)
+			require.NoError(t, err)
+			return orders, total
+		}
+
+		orders, total := list("actionType=pool_exit&pageSize=2")
+		assert.Equal(t, 3, total)
+		require.Len(t, orders, 2)
+		assert.Equal(t, "ES-EXIT-2", orders[0].OrderNumber)
+		assert.Equal(t, "ES-EXIT-1", orders[1].OrderNumber)
+		assert.Equal(t, "cluster-a", orders[0].ClusterName)
+
+		orders, total = list("actionType=pool_exit&pageSize=2&page=2")
+		assert.Equal(t, 3, total)
+		require.Len(t, orders, 1)
+		assert.Equal(t, "ES-EXIT-0", orders[0].OrderNumber)
+
+		orders, total = list("actionType=pool_entry")
+		assert.Equal(t, 1, total)
+		require.Len(t, orders, 1)
+		require.Len(t, orders[0].Devices, 1)
+		assert.Equal(t, "node-1", orders[0].Devices[0].CICode)
+	})
+}
@@ -0,0 +1,249 @@
+package es
+
+import (
+	"fmt"
+	"navy-ng/models/portal"
+	. "navy-ng/server/portal/internal/service"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/now"
+)
+
+const (
+	// 滑动窗口模式下相邻快照允许的最大间隔，超过该间隔视为数据缺失，持续计时中断
+	snapshotGapTolerance = 30 * time.Minute
+
+	// 按天模式额外回看的天数，确保有足够的历史数据进行判断
+	dailyLookbackPaddingDays = 3
+)
+
+// snapshotEvaluation 单个集群+资源池在某一评估时间点的快照评估结果
+type snapshotEvaluation struct {
+	Snapshots        []portal.ResourceSnapshot // 参与评估的快照（按天模式为每日最新快照），按时间升序
+	Breached         bool
+	ConsecutiveDays  int             // 按天模式：连续满足条件的天数
+	SustainedMinutes int             // 滑动窗口模式：持续满足条件的分钟数
+	Forecast         *forecastResult // 预测模式：预测结果，数据不足时为nil
+	TriggeredValue   string
+	ThresholdValue   string
+}
+
+// latestSnapshot 返回参与评估的最新快照，没有快照时返回nil
+func (e *snapshotEvaluation) latestSnapshot() *portal.ResourceSnapshot {
+	if len(e.Snapshots) == 0 {
+		return nil
+	}
+	return &e.Snapshots[len(e.Snapshots)-1]
+}
+
+// progressDescription 返回当前持续情况的中文描述，用于执行历史
+func (e *snapshotEvaluation) progressDescription(strategy *portal.ElasticScalingStrategy) string {
+	if isForecastStrategy(strategy) {
+		if e.Forecast == nil {
+			return fmt.Sprintf("历史快照不足 %d 天，无法进行预测", minForecastPoints)
+		}
+		if e.Forecast.CrossingDate == nil {
+			return fmt.Sprintf("预测 %d 天内不会越过阈值", strategy.ForecastHorizonDays)
+		}
+		return fmt.Sprintf("预计 %s 越过阈值，超出 %d 天预测窗口",
+			e.Forecast.CrossingDate.Format(forecastDateFormat), strategy.ForecastHorizonDays)
+	}
+	if isSlidingWindowStrategy(strategy) {
+		return fmt.Sprintf("当前持续 %d 分钟（需要 %d 分钟）",
+			e.SustainedMinutes, int(getEvaluationWindow(strategy)/time.Minute))
+	}
+	return fmt.Sprintf("当前连续天数 %d 天（需要 %d 天）", e.ConsecutiveDays, getRequiredConsecutiveDays(strategy))
+}
+
+// deltaSnapshot 返回计算资源增量所依据的快照：预测模式使用预测窗口末的预测值，其余模式使用最新快照
+func (e *snapshotEvaluation) deltaSnapshot() portal.ResourceSnapshot {
+	latest := *e.latestSnapshot()
+	if e.Forecast != nil {
+		return e.Forecast.projectSnapshot(latest)
+	}
+	return latest
+}
+
+// isSlidingWindowStrategy 判断策略是否按分钟/小时的滑动窗口评估
+func isSlidingWindowStrategy(strategy *portal.ElasticScalingStrategy) bool {
+	return strategy.DurationUnit == DurationUnitMinute || strategy.DurationUnit == DurationUnitHour
+}
+
+// getEvaluationWindow 返回滑动窗口模式下需要持续满足条件的时长
+func getEvaluationWindow(strategy *portal.ElasticScalingStrategy) time.Duration {
+	switch strategy.DurationUnit {
+	case DurationUnitHour:
+		return time.Duration(strategy.DurationMinutes) * time.Hour
+	case DurationUnitMinute:
+		return time.Duration(strategy.DurationMinutes) * time.Minute
+	default:
+		return time.Duration(getRequiredConsecutiveDays(strategy)) * 24 * time.Hour
+	}
+}
+
+// formatStrategyDuration 返回策略持续时间的中文描述，如"3 天"、"90 分钟"
+func formatStrategyDuration(strategy *portal.ElasticScalingStrategy) string {
+	switch strategy.DurationUnit {
+	case DurationUnitHour:
+		return fmt.Sprintf("%d 小时", strategy.DurationMinutes)
+	case DurationUnitMinute:
+		return fmt.Sprintf("%d 分钟", strategy.DurationMinutes)
+	default:
+		return fmt.Sprintf("%d 天", getRequiredConsecutiveDays(strategy))
+	}
+}
+
+// formatStrategyDurationEnglish 返回阈值描述中使用的持续时间，如"3 days"、"90 minutes"
+func formatStrategyDurationEnglish(strategy *portal.ElasticScalingStrategy) string {
+	switch strategy.DurationUnit {
+	case DurationUnitHour:
+		return fmt.Sprintf("%d hours", strategy.DurationMinutes)
+	case DurationUnitMinute:
+		return fmt.Sprintf("%d minutes", strategy.DurationMinutes)
+	default:
+		return fmt.Sprintf("%d days", getRequiredConsecutiveDays(strategy))
+	}
+}
+
+// evaluationLookbackStart 返回在 evalTime 评估时需要回看的最早快照时间
+func evaluationLookbackStart(strategy *portal.ElasticScalingStrategy, evalTime time.Time) time.Time {
+	if isForecastStrategy(strategy) {
+		return now.With(evalTime).BeginningOfDay().AddDate(0, 0, -forecastLookbackDays+1)
+	}
+	if isSlidingWindowStrategy(strategy) {
+		return evalTime.Add(-getEvaluationWindow(strategy) - snapshotGapTolerance)
+	}
+	daysToCheck := getRequiredConsecutiveDays(strategy) + dailyLookbackPaddingDays
+	return now.With(evalTime).BeginningOfDay().AddDate(0, 0, -daysToCheck+1)
+}
+
+// getSnapshotsBetween 获取指定时间区间内的原始资源快照，按时间升序返回。
+func (s *ElasticScalingService) getSnapshotsBetween(clusterID int, resourceType string, start, end time.Time) ([]portal.ResourceSnapshot, error) {
+	query := s.db.Where("cluster_id = ? AND created_at between ? and ?", clusterID, start, end)
+
+	if resourceType != resourceTypeTotal {
+		query = query.Where(queryResourceTypeAndPool, resourceType, resourceType)
+	}
+
+	var snapshots []portal.ResourceSnapshot
+	if err := query.Order(OrderByCreatedAtDesc).Find(&snapshots).Error; err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return time.Time(snapshots[i].CreatedAt).Before(time.Time(snapshots[j].CreatedAt))
+	})
+	return snapshots, nil
+}
+
+// latestSnapshotPerDay 按天分组，每天只保留最新的一个快照，按时间升序返回。
+func latestSnapshotPerDay(snapshots []portal.ResourceSnapshot) []portal.ResourceSnapshot {
+	dailySnapshotMap := make(map[string]portal.ResourceSnapshot)
+	for _, snapshot := range snapshots {
+		day := time.Time(snapshot.CreatedAt).Format(dateFormat)
+		if existing, exists := dailySnapshotMap[day]; !exists || time.Time(snapshot.CreatedAt).After(time.Time(existing.CreatedAt)) {
+			dailySnapshotMap[day] = snapshot
+		}
+	}
+
+	orderedDailySnapshots := make([]portal.ResourceSnapshot, 0, len(dailySnapshotMap))
+	for _, snapshot := range dailySnapshotMap {
+		orderedDailySnapshots = append(orderedDailySnapshots, snapshot)
+	}
+
+	// 按创建时间升序排序
+	sort.Slice(orderedDailySnapshots, func(i, j int) bool {
+		return time.Time(orderedDailySnapshots[i].CreatedAt).Before(time.Time(orderedDailySnapshots[j].CreatedAt))
+	})
+
+	return orderedDailySnapshots
+}
+
+// evaluateSnapshotsAt 在指定评估时间点，基于按时间升序的原始快照评估策略。
+// 按天模式每天只取最新快照并要求连续N天满足条件；分钟/小时模式基于原始快照的滑动窗口评估；
+// 预测模式基于每日快照拟合趋势，判断是否会在预测窗口内越过阈值。
+func (s *ElasticScalingService) evaluateSnapshotsAt(
+	strategy *portal.ElasticScalingStrategy,
+	rawSnapshots []portal.ResourceSnapshot,
+	evalTime time.Time,
+) *snapshotEvaluation {
+	start := evaluationLookbackStart(strategy, evalTime)
+	var window []portal.ResourceSnapshot
+	for _, snapshot := range rawSnapshots {
+		createdAt := time.Time(snapshot.CreatedAt)
+		if !createdAt.Before(start) && !createdAt.After(evalTime) {
+			window = append(window, snapshot)
+		}
+	}
+
+	result := &snapshotEvaluation{}
+	if isForecastStrategy(strategy) {
+		result.Snapshots = latestSnapshotPerDay(window)
+		result.ThresholdValue = s.buildThresholdString(strategy)
+		result.Forecast = s.forecastSnapshots(result.Snapshots, strategy, evalTime)
+		if result.Forecast != nil {
+			result.Breached = result.Forecast.Triggered
+			result.TriggeredValue = result.Forecast.summary()
+		}
+		return result
+	}
+	if isSlidingWindowStrategy(strategy) {
+		result.Snapshots = window
+		result.Breached, result.SustainedMinutes, result.TriggeredValue, result.ThresholdValue =
+			s.EvaluateSnapshotWindow(window, strategy, evalTime)
+		return result
+	}
+
+	result.Snapshots = latestSnapshotPerDay(window)
+	result.Breached, result.ConsecutiveDays, result.TriggeredValue, result.ThresholdValue =
+		s.EvaluateSnapshots(result.Snapshots, strategy)
+	return result
+}
+
+// EvaluateSnapshotWindow 是滑动窗口模式的核心评估逻辑，无副作用，易于测试。
+// 从最新快照向前回溯连续满足条件的快照，相邻快照间隔不超过 snapshotGapTolerance 时视为持续满足，
+// 持续时长达到策略窗口且最新快照未过期时触发。
+func (s *ElasticScalingService) EvaluateSnapshotWindow(
+	snapshots []portal.ResourceSnapshot,
+	strategy *portal.ElasticScalingStrategy,
+	evalTime time.Time,
+) (breached bool, sustainedMinutes int, triggeredValueStr string, thresholdValueStr string) {
+	thresholdValueStr = s.buildThresholdString(strategy)
+	if len(snapshots) == 0 {
+		return false, 0, "", thresholdValueStr
+	}
+
+	var streakStart, streakEnd time.Time
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		singleBreached, singleTriggeredValue, _ := s.checkSingleSnapshotBreach(snapshots[i], strategy)
+		if i == len(snapshots)-1 {
+			triggeredValueStr = singleTriggeredValue // 始终展示最新快照的监控值
+		}
+		if !singleBreached {
+			break
+		}
+
+		createdAt := time.Time(snapshots[i].CreatedAt)
+		if streakEnd.IsZero() {
+			streakEnd = createdAt
+		} else if streakStart.Sub(createdAt) > snapshotGapTolerance {
+			break // 数据缺失时间过长，持续计时中断
+		}
+		streakStart = createdAt
+	}
+
+	if streakEnd.IsZero() {
+		return false, 0, triggeredValueStr, thresholdValueStr
+	}
+
+	sustained := streakEnd.Sub(streakStart)
+	sustainedMinutes = int(sustained / time.Minute)
+
+	// 最新的满足条件快照已过期，说明当前状态未知，不触发
+	if evalTime.Sub(streakEnd) > snapshotGapTolerance {
+		return false, sustainedMinutes, triggeredValueStr, thresholdValueStr
+	}
+
+	return sustained >= getEvaluationWindow(strategy), sustainedMinutes, triggeredValueStr, thresholdValueStr
+}
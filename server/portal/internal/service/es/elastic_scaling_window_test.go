@@ -0,0 +1,90 @@
+package es
+
+import (
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// windowSnapshots 生成从 start 开始每隔 interval 一个的快照，分配率依次取 ratios
+func windowSnapshots(start time.Time, interval time.Duration, ratios ...float64) []portal.ResourceSnapshot {
+	snapshots := make([]portal.ResourceSnapshot, len(ratios))
+	for i, ratio := range ratios {
+		snapshots[i] = portal.ResourceSnapshot{
+			BaseModel:   portal.BaseModel{CreatedAt: portal.NavyTime(start.Add(time.Duration(i) * interval))},
+			CpuRequest:  ratio,
+			CpuCapacity: 100,
+		}
+	}
+	return snapshots
+}
+
+func TestEvaluateSnapshotWindow(t *testing.T) {
+	s := &ElasticScalingService{logger: zap.NewNop()}
+	strategy := &portal.ElasticScalingStrategy{
+		CPUThresholdType:       ThresholdTypeAllocated,
+		CPUThresholdValue:      85,
+		ThresholdTriggerAction: TriggerActionPoolEntry,
+		DurationMinutes:        90,
+		DurationUnit:           DurationUnitMinute,
+	}
+	start := time.Date(2026, 10, 16, 10, 0, 0, 0, time.Local)
+
+	t.Run("triggers when breach is sustained for the window", func(t *testing.T) {
+		// 10:00 - 11:30 每10分钟一个快照，全部超过阈值
+		snapshots := windowSnapshots(start, 10*time.Minute, 90, 90, 90, 90, 90, 90, 90, 90, 90, 90)
+		breached, sustained, triggered, threshold := s.EvaluateSnapshotWindow(snapshots, strategy, start.Add(95*time.Minute))
+		assert.True(t, breached)
+		assert.Equal(t, 90, sustained)
+		assert.Contains(t, triggered, "90.00%")
+		assert.Contains(t, threshold, "for 90 minutes")
+	})
+
+	t.Run("does not trigger before the window elapses", func(t *testing.T) {
+		snapshots := windowSnapshots(start, 10*time.Minute, 70, 90, 90, 90, 90, 90, 90, 90, 90, 90)
+		breached, sustained, _, _ := s.EvaluateSnapshotWindow(snapshots, strategy, start.Add(95*time.Minute))
+		assert.False(t, breached)
+		assert.Equal(t, 80, sustained)
+	})
+
+	t.Run("tolerates short gaps but breaks on long ones", func(t *testing.T) {
+		// 相邻快照间隔30分钟（未超过容忍间隔）仍视为持续满足
+		snapshots := windowSnapshots(start, 10*time.Minute, 90, 90, 90, 90, 90, 90, 90, 90, 90, 90)
+		withGap := append(append([]portal.ResourceSnapshot{}, snapshots[:4]...), snapshots[6:]...)
+		breached, _, _, _ := s.EvaluateSnapshotWindow(withGap, strategy, start.Add(95*time.Minute))
+		assert.True(t, breached)
+
+		// 缺失超过容忍间隔则重新计时
+		withLongGap := append(append([]portal.ResourceSnapshot{}, snapshots[:2]...), snapshots[6:]...)
+		breached, sustained, _, _ := s.EvaluateSnapshotWindow(withLongGap, strategy, start.Add(95*time.Minute))
+		assert.False(t, breached)
+		assert.Equal(t, 30, sustained)
+	})
+
+	t.Run("does not trigger on stale data", func(t *testing.T) {
+		snapshots := windowSnapshots(start, 10*time.Minute, 90, 90, 90, 90, 90, 90, 90, 90, 90, 90)
+		breached, _, _, _ := s.EvaluateSnapshotWindow(snapshots, strategy, start.Add(3*time.Hour))
+		assert.False(t, breached)
+	})
+
+	t.Run("hour unit scales the window", func(t *testing.T) {
+		hourly := *strategy
+		hourly.DurationMinutes = 2
+		hourly.DurationUnit = DurationUnitHour
+		snapshots := windowSnapshots(start, 10*time.Minute, 90, 90, 90, 90, 90, 90, 90, 90, 90, 90)
+		breached, _, _, _ := s.EvaluateSnapshotWindow(snapshots, &hourly, start.Add(95*time.Minute))
+		assert.False(t, breached)
+	})
+}
+
+func TestGetRequiredConsecutiveDaysDurationUnit(t *testing.T) {
+	// 显式按天时不再按数值大小推断单位
+	assert.Equal(t, 120, getRequiredConsecutiveDays(&portal.ElasticScalingStrategy{DurationMinutes: 120, DurationUnit: DurationUnitDay}))
+	// 未指定单位的历史策略保持兼容
+	assert.Equal(t, 3, getRequiredConsecutiveDays(&portal.ElasticScalingStrategy{DurationMinutes: 3}))
+	assert.Equal(t, 2, getRequiredConsecutiveDays(&portal.ElasticScalingStrategy{DurationMinutes: 2 * 24 * 60}))
+}
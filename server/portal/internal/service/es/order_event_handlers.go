@@ -2,9 +2,12 @@ package es
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"navy-ng/models/portal"
+	. "navy-ng/server/portal/internal/service"
 	"navy-ng/server/portal/internal/service/events"
+	"navy-ng/server/portal/internal/service/order"
 	"time"
 
 	"go.uber.org/zap"
@@ -79,7 +82,7 @@ func (h *OrderEventHandler) HandleDeviceOperationCompletedGeneric(ctx context.Co
 		zap.String("result", deviceData.Result))
 
 	// 更新订单中设备的状态
-	err := h.orderService.UpdateOrderDeviceStatus(deviceData.OrderID, deviceData.DeviceID, StatusSuccess)
+	err := h.updateOrderDeviceStatus(deviceData.OrderID, deviceData.DeviceID, StatusSuccess)
 	if err != nil {
 		h.logger.Error("Failed to update order device status",
 			zap.Int("orderID", deviceData.OrderID),
@@ -100,12 +103,7 @@ func (h *OrderEventHandler) HandleDeviceOperationCompletedGeneric(ctx context.Co
 	// 如果所有设备都已完成，更新订单状态为已完成
 	if allCompleted {
 		reason := fmt.Sprintf("所有设备操作已完成 - %s", deviceData.Action)
-		err = h.orderService.UpdateOrderStatus(
-			deviceData.OrderID,
-			string(portal.OrderStatusCompleted),
-			"system",
-			reason,
-		)
+		err = h.updateOrderStatus(deviceData.OrderID, string(portal.OrderStatusCompleted), reason)
 		if err != nil {
 			h.logger.Error("Failed to update order status to completed",
 				zap.Int("orderID", deviceData.OrderID),
@@ -131,7 +129,7 @@ func (h *OrderEventHandler) HandleDeviceOperationFailedGeneric(ctx context.Conte
 		zap.String("error", deviceData.ErrorMsg))
 
 	// 更新订单中设备的状态为失败
-	err := h.orderService.UpdateOrderDeviceStatus(deviceData.OrderID, deviceData.DeviceID, "failed")
+	err := h.updateOrderDeviceStatus(deviceData.OrderID, deviceData.DeviceID, "failed")
 	if err != nil {
 		h.logger.Error("Failed to update order device status to failed",
 			zap.Int("orderID", deviceData.OrderID),
@@ -151,12 +149,7 @@ func (h *OrderEventHandler) HandleDeviceOperationFailedGeneric(ctx context.Conte
 
 	if shouldFailOrder {
 		reason := fmt.Sprintf("设备操作失败 - 设备ID: %d, 错误: %s", deviceData.DeviceID, deviceData.ErrorMsg)
-		err = h.orderService.UpdateOrderStatus(
-			deviceData.OrderID,
-			string(portal.OrderStatusFailed),
-			"system",
-			reason,
-		)
+		err = h.updateOrderStatus(deviceData.OrderID, string(portal.OrderStatusFailed), reason)
 		if err != nil {
 			h.logger.Error("Failed to update order status to failed",
 				zap.Int("orderID", deviceData.OrderID),
@@ -206,7 +199,7 @@ func (h *OrderEventHandler) HandleDeviceReturningGeneric(ctx context.Context, ev
 		zap.Int("deviceID", deviceData.DeviceID))
 
 	// 更新设备状态为归还中
-	err := h.orderService.UpdateOrderDeviceStatus(deviceData.OrderID, deviceData.DeviceID, "returning")
+	err := h.updateOrderDeviceStatus(deviceData.OrderID, deviceData.DeviceID, "returning")
 	if err != nil {
 		h.logger.Error("Failed to update device status to returning",
 			zap.Int("orderID", deviceData.OrderID),
@@ -226,12 +219,7 @@ func (h *OrderEventHandler) HandleDeviceReturningGeneric(ctx context.Context, ev
 
 	if allReturning {
 		// 更新订单状态为归还中
-		err = h.orderService.UpdateOrderStatus(
-			deviceData.OrderID,
-			"returning",
-			"system",
-			"所有设备开始归还流程",
-		)
+		err = h.updateOrderStatus(deviceData.OrderID, "returning", "所有设备开始归还流程")
 		if err != nil {
 			h.logger.Error("Failed to update order status to returning",
 				zap.Int("orderID", deviceData.OrderID),
@@ -295,13 +283,8 @@ func (h *OrderEventHandler) HandleMaintenanceCompleted(ctx context.Context, even
 	// 根据维护类型决定订单状态更新逻辑
 	switch maintenanceEvent.MaintenanceType {
 	case "cordon":
-		// Cordon完成，更新订单状态为处理中
-		err := h.orderService.UpdateOrderStatus(
-			maintenanceEvent.OrderID,
-			string(portal.OrderStatusProcessing),
-			"system",
-			"设备已成功cordon，开始执行后续操作",
-		)
+		// Cordon完成，维护订单进入维护中，弹性伸缩维护请求订单进入处理中
+		err := h.updateMaintenanceOrderStatus(ctx, maintenanceEvent.OrderID, portal.OrderStatus(portal.MaintenanceStatusInProgress), portal.OrderStatusProcessing, "设备已成功cordon，开始执行后续操作")
 		if err != nil {
 			return err
 		}
@@ -314,12 +297,7 @@ func (h *OrderEventHandler) HandleMaintenanceCompleted(ctx context.Context, even
 
 	case "uncordon":
 		// Uncordon完成，标记维护订单完成
-		err := h.orderService.UpdateOrderStatus(
-			maintenanceEvent.OrderID,
-			string(portal.OrderStatusCompleted),
-			"system",
-			"设备维护完成，已成功uncordon",
-		)
+		err := h.updateMaintenanceOrderStatus(ctx, maintenanceEvent.OrderID, portal.OrderStatusCompleted, portal.OrderStatusCompleted, "设备维护完成，已成功uncordon")
 		if err != nil {
 			return err
 		}
@@ -349,12 +327,7 @@ func (h *OrderEventHandler) HandleMaintenanceFailed(ctx context.Context, event e
 	reason := fmt.Sprintf("维护操作失败 - 类型: %s, 设备ID: %d, 错误: %s",
 		maintenanceEvent.MaintenanceType, maintenanceEvent.DeviceID, maintenanceEvent.Error)
 
-	err := h.orderService.UpdateOrderStatus(
-		maintenanceEvent.OrderID,
-		string(portal.OrderStatusFailed),
-		"system",
-		reason,
-	)
+	err := h.updateMaintenanceOrderStatus(ctx, maintenanceEvent.OrderID, portal.OrderStatusFailed, portal.OrderStatusFailed, reason)
 	if err != nil {
 		h.logger.Error("Failed to update order status to failed after maintenance failure",
 			zap.Int("orderID", maintenanceEvent.OrderID),
@@ -375,7 +348,7 @@ func (h *OrderEventHandler) cancelAllDeviceOperations(orderID int, reason string
 	for _, device := range devices {
 		// 如果设备操作还在进行中，则取消
 		if device.OrderStatus == "executing" || device.OrderStatus == "pending" {
-			err = h.orderService.UpdateOrderDeviceStatus(orderID, device.ID, "cancelled")
+			err = h.updateOrderDeviceStatus(orderID, device.ID, "cancelled")
 			if err != nil {
 				h.logger.Error("Failed to cancel device operation",
 					zap.Int("orderID", orderID),
@@ -397,12 +370,7 @@ func (h *OrderEventHandler) cancelAllDeviceOperations(orderID int, reason string
 // startRollbackProcess 启动回滚流程
 func (h *OrderEventHandler) startRollbackProcess(orderID int, rollbackActionType, reason string) error {
 	// 更新订单状态为回滚中
-	err := h.orderService.UpdateOrderStatus(
-		orderID,
-		string(portal.OrderStatusReturning),
-		"system",
-		fmt.Sprintf("开始回滚操作: %s, 原因: %s", rollbackActionType, reason),
-	)
+	err := h.updateOrderStatus(orderID, string(portal.OrderStatusReturning), fmt.Sprintf("开始回滚操作: %s, 原因: %s", rollbackActionType, reason))
 	if err != nil {
 		return err
 	}
@@ -428,7 +396,7 @@ func (h *OrderEventHandler) startRollbackProcess(orderID int, rollbackActionType
 			}
 
 			// 更新设备状态为回滚中
-			err = h.orderService.UpdateOrderDeviceStatus(orderID, device.ID, "rollback_executing")
+			err = h.updateOrderDeviceStatus(orderID, device.ID, "rollback_executing")
 			if err != nil {
 				h.logger.Error("Failed to update device status to rollback_executing",
 					zap.Int("orderID", orderID),
@@ -566,3 +534,53 @@ func (s *ElasticScalingService) PublishScalingEvent(
 
 	return eventManager.Publish(events.PublishRequest{Event: event, Ctx: ctx})
 }
+
+// updateOrderStatus 事件处理器更新订单状态：版本冲突时重新读取订单并重试；
+// 并发事件已将订单更新为目标状态时视为成功，避免重复完成或失败
+func (h *OrderEventHandler) updateOrderStatus(orderID int, status string, reason string) error {
+	err := order.RetryOnConflict(func() error {
+		return h.orderService.UpdateOrderStatusFrom(portal.OrderStatusSourceEvent, orderID, status, "system", reason)
+	})
+
+	var transitionErr *order.IllegalTransitionError
+	if errors.As(err, &transitionErr) && string(transitionErr.From) == status {
+		h.logger.Info("Order already in target status, skip update",
+			zap.Int("orderID", orderID),
+			zap.String("status", status))
+		return nil
+	}
+	return err
+}
+
+// updateMaintenanceOrderStatus 推进维护事件关联的订单：维护订单按维护订单状态机更新为 maintenanceStatus，
+// 弹性伸缩维护请求订单经弹性伸缩服务更新为 scalingStatus。订单已处于目标状态时跳过
+func (h *OrderEventHandler) updateMaintenanceOrderStatus(ctx context.Context, orderID int, maintenanceStatus, scalingStatus portal.OrderStatus, reason string) error {
+	var target portal.Order
+	if err := h.orderService.db.WithContext(ctx).Select("id", "type").First(&target, orderID).Error; err != nil {
+		return HandleDBError(err, "订单", orderID)
+	}
+	if target.Type != portal.OrderTypeMaintenance {
+		return h.updateOrderStatus(orderID, string(scalingStatus), reason)
+	}
+
+	ctx = order.WithStatusSource(ctx, portal.OrderStatusSourceEvent)
+	err := order.RetryOnConflict(func() error {
+		return h.orderService.orderService.UpdateOrderStatus(ctx, orderID, maintenanceStatus, "system", reason)
+	})
+
+	var transitionErr *order.IllegalTransitionError
+	if errors.As(err, &transitionErr) && transitionErr.From == maintenanceStatus {
+		h.logger.Info("Order already in target status, skip update",
+			zap.Int("orderID", orderID),
+			zap.String("status", string(maintenanceStatus)))
+		return nil
+	}
+	return err
+}
+
+// updateOrderDeviceStatus 事件处理器更新订单中设备的状态，版本冲突时重新读取并重试
+func (h *OrderEventHandler) updateOrderDeviceStatus(orderID int, deviceID int, status string) error {
+	return order.RetryOnConflict(func() error {
+		return h.orderService.UpdateOrderDeviceStatusFrom(portal.OrderStatusSourceEvent, orderID, deviceID, status, "system")
+	})
+}
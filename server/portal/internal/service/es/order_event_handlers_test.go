@@ -0,0 +1,75 @@
+package es
+
+import (
+	"context"
+	"testing"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service/events"
+	"navy-ng/server/portal/internal/service/order"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandleMaintenanceEventSequence(t *testing.T) {
+	s, db := newTestService(t)
+	s.orderService = order.NewOrderService(db)
+	handler := NewOrderEventHandler(s, zap.NewNop())
+	ctx := context.Background()
+
+	complete := func(orderID int, maintenanceType string) {
+		t.Helper()
+		require.NoError(t, handler.HandleMaintenanceCompleted(ctx, events.NewMaintenanceEvent(events.MaintenanceRequest{
+			EventType:       events.EventTypeMaintenanceCompleted,
+			OrderID:         orderID,
+			DeviceID:        1,
+			MaintenanceType: maintenanceType,
+			Status:          "completed",
+		})))
+	}
+	status := func(orderID int) portal.OrderStatus {
+		t.Helper()
+		var o portal.Order
+		require.NoError(t, db.First(&o, orderID).Error)
+		return o.Status
+	}
+
+	t.Run("maintenance order follows cordon, drain and uncordon", func(t *testing.T) {
+		maintenance := portal.Order{OrderNumber: "MAINT-1", Type: portal.OrderTypeMaintenance, Status: portal.OrderStatus(portal.MaintenanceStatusScheduled)}
+		require.NoError(t, db.Create(&maintenance).Error)
+		require.NoError(t, db.Create(&portal.MaintenanceOrderDetail{OrderID: maintenance.ID, ClusterID: 1, ExternalTicketID: "T-1", MaintenanceType: string(portal.MaintenanceTypeCordon)}).Error)
+
+		complete(maintenance.ID, "cordon")
+		assert.Equal(t, portal.OrderStatus(portal.MaintenanceStatusInProgress), status(maintenance.ID))
+
+		// 重复投递的 cordon 事件不影响订单
+		complete(maintenance.ID, "cordon")
+		complete(maintenance.ID, "drain")
+		assert.Equal(t, portal.OrderStatus(portal.MaintenanceStatusInProgress), status(maintenance.ID))
+
+		complete(maintenance.ID, "uncordon")
+		assert.Equal(t, portal.OrderStatusCompleted, status(maintenance.ID))
+
+		var history []portal.OrderStatusHistory
+		require.NoError(t, db.Where("order_id = ?", maintenance.ID).Order("id").Find(&history).Error)
+		require.Len(t, history, 2)
+		assert.Equal(t, string(portal.MaintenanceStatusInProgress), history[0].ToStatus)
+		assert.Equal(t, string(portal.OrderStatusCompleted), history[1].ToStatus)
+		for _, entry := range history {
+			assert.Equal(t, portal.OrderStatusSourceEvent, entry.Source)
+		}
+	})
+
+	t.Run("elastic scaling maintenance request goes through processing", func(t *testing.T) {
+		request := portal.Order{OrderNumber: "ES-MAINT-1", Type: portal.OrderTypeElasticScaling, Status: portal.OrderStatusPending}
+		require.NoError(t, db.Create(&request).Error)
+		require.NoError(t, db.Create(&portal.ElasticScalingOrderDetail{OrderID: request.ID, ClusterID: 1, ActionType: actionTypeMaintenanceRequest}).Error)
+
+		complete(request.ID, "cordon")
+		assert.Equal(t, portal.OrderStatusProcessing, status(request.ID))
+		complete(request.ID, "uncordon")
+		assert.Equal(t, portal.OrderStatusCompleted, status(request.ID))
+	})
+}
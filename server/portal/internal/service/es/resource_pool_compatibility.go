@@ -0,0 +1,463 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"navy-ng/models/portal"
+	"strconv"
+	"strings"
+	"time"
+
+	. "navy-ng/server/portal/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// compatibilityTemplateKeys 保存匹配策略时需要与兼容性规则比对的查询模板字段
+var compatibilityTemplateKeys = map[string]string{
+	"archType": "CPU架构",
+	"model":    "型号",
+	"osIssue":  "操作系统版本",
+}
+
+// compatibilityChecker 按资源池兼容性规则判断设备能否入池
+type compatibilityChecker struct {
+	rule       portal.ResourcePoolCompatibilityRule
+	archTypes  []string
+	models     []string
+	osVersions []string
+}
+
+// newCompatibilityChecker 根据兼容性规则创建检查器
+func newCompatibilityChecker(rule portal.ResourcePoolCompatibilityRule) *compatibilityChecker {
+	return &compatibilityChecker{
+		rule:       rule,
+		archTypes:  splitRuleList(rule.ArchTypes),
+		models:     splitRuleList(rule.Models),
+		osVersions: splitRuleList(rule.OSVersions),
+	}
+}
+
+// archAllowed 判断CPU架构是否在允许列表中，未限制时返回 true
+func (c *compatibilityChecker) archAllowed(arch string) bool {
+	if len(c.archTypes) == 0 {
+		return true
+	}
+	for _, allowed := range c.archTypes {
+		if strings.EqualFold(strings.TrimSpace(arch), allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// modelAllowed 判断型号是否匹配允许的型号前缀，未限制时返回 true
+func (c *compatibilityChecker) modelAllowed(model string) bool {
+	return c.models == nil || hasAnyPrefixFold(model, c.models)
+}
+
+// osVersionAllowed 判断操作系统版本是否匹配允许的版本前缀，未限制时返回 true
+func (c *compatibilityChecker) osVersionAllowed(osIssue string) bool {
+	return c.osVersions == nil || hasAnyPrefixFold(osIssue, c.osVersions)
+}
+
+// needsGPUInfo 判断规则是否需要查询节点GPU信息
+func (c *compatibilityChecker) needsGPUInfo() bool {
+	return c.rule.GPURequirement == portal.GPURequirementRequired || c.rule.GPURequirement == portal.GPURequirementForbidden
+}
+
+// check 返回设备不满足规则的原因，满足时返回空字符串。
+// gpu 为设备对应 K8s 节点的GPU信息，known 为 false 表示未找到节点。设备表没有GPU信息，
+// 未入池的设备通常没有节点记录，因此无法确认GPU时不拦截入池，只按已知的节点GPU信息判断
+func (c *compatibilityChecker) check(device DeviceResponse, gpu string, known bool) string {
+	var reasons []string
+	if !c.archAllowed(device.ArchType) {
+		reasons = append(reasons, fmt.Sprintf("CPU架构 %q 不在允许列表 %s 中", device.ArchType, strings.Join(c.archTypes, ",")))
+	}
+	switch c.rule.GPURequirement {
+	case portal.GPURequirementRequired:
+		if known && !hasGPU(gpu) {
+			reasons = append(reasons, "设备不包含GPU")
+		}
+	case portal.GPURequirementForbidden:
+		if known && hasGPU(gpu) {
+			reasons = append(reasons, fmt.Sprintf("设备包含GPU（%s）", gpu))
+		}
+	}
+	if c.rule.MinDiskCount > 0 && device.DiskCount < c.rule.MinDiskCount {
+		reasons = append(reasons, fmt.Sprintf("磁盘数 %d 小于 %d", device.DiskCount, c.rule.MinDiskCount))
+	}
+	if c.rule.MinNetworkSpeed > 0 {
+		speed, ok := parseNetworkSpeed(device.NetworkSpeed)
+		if !ok {
+			reasons = append(reasons, fmt.Sprintf("无法识别网卡速率 %q", device.NetworkSpeed))
+		} else if speed < c.rule.MinNetworkSpeed {
+			reasons = append(reasons, fmt.Sprintf("网卡速率 %dMb/s 小于 %dMb/s", speed, c.rule.MinNetworkSpeed))
+		}
+	}
+	if !c.modelAllowed(device.Model) {
+		reasons = append(reasons, fmt.Sprintf("型号 %q 不在允许列表 %s 中", device.Model, strings.Join(c.models, ",")))
+	}
+	if !c.osVersionAllowed(device.OSIssue) {
+		reasons = append(reasons, fmt.Sprintf("操作系统版本 %q 不在允许列表 %s 中", device.OSIssue, strings.Join(c.osVersions, ",")))
+	}
+	return strings.Join(reasons, "；")
+}
+
+// templateValueAllowed 判断查询模板中某个字段的取值是否可能满足规则
+func (c *compatibilityChecker) templateValueAllowed(key, value string) bool {
+	switch key {
+	case "archType":
+		return c.archAllowed(value)
+	case "model":
+		return c.modelAllowed(value)
+	case "osIssue":
+		return c.osVersionAllowed(value)
+	default:
+		return true
+	}
+}
+
+// hasGPU 判断节点GPU信息是否表示包含GPU
+func hasGPU(gpu string) bool {
+	gpu = strings.TrimSpace(strings.ToLower(gpu))
+	return gpu != "" && gpu != "0" && gpu != "false" && gpu != "none"
+}
+
+// parseNetworkSpeed 将网卡速率解析为 Mb/s，支持 "10000"、"10000Mb/s"、"25G"、"25Gb/s" 等格式
+func parseNetworkSpeed(value string) (int, bool) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	end := 0
+	for end < len(value) && (value[end] >= '0' && value[end] <= '9' || value[end] == '.') {
+		end++
+	}
+	if end == 0 {
+		return 0, false
+	}
+	speed, err := strconv.ParseFloat(value[:end], 64)
+	if err != nil {
+		return 0, false
+	}
+	if strings.HasPrefix(strings.TrimSpace(value[end:]), "g") {
+		speed *= 1000
+	}
+	return int(speed), true
+}
+
+// hasAnyPrefixFold 判断值是否以任一前缀开头（不区分大小写）
+func hasAnyPrefixFold(value string, prefixes []string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitRuleList 拆分逗号分隔的规则列表，去除空白项，为空时返回 nil
+func splitRuleList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// loadCompatibilityChecker 获取资源池的兼容性检查器，未配置规则时返回 nil
+func loadCompatibilityChecker(db *gorm.DB, resourcePoolType string) (*compatibilityChecker, error) {
+	var rule portal.ResourcePoolCompatibilityRule
+	if err := db.Where("resource_pool_type = ?", resourcePoolType).First(&rule).Error; err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get compatibility rule of resource pool %s: %w", resourcePoolType, err)
+	}
+	return newCompatibilityChecker(rule), nil
+}
+
+// excludeIncompatibleDevices 排除不满足兼容性规则的设备，返回剩余候选设备及被排除设备的原因
+func excludeIncompatibleDevices(db *gorm.DB, checker *compatibilityChecker, devices []DeviceResponse) ([]DeviceResponse, map[int]string, error) {
+	incompatible := make(map[int]string)
+	if checker == nil || len(devices) == 0 {
+		return devices, incompatible, nil
+	}
+
+	var gpus map[string]string
+	if checker.needsGPUInfo() {
+		var err error
+		if gpus, err = loadNodeGPUs(db, devices); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	available := make([]DeviceResponse, 0, len(devices))
+	for _, device := range devices {
+		gpu, known := gpus[strings.ToLower(device.CICode)]
+		if reason := checker.check(device, gpu, known); reason != "" {
+			incompatible[device.ID] = reason
+			continue
+		}
+		available = append(available, device)
+	}
+	return available, incompatible, nil
+}
+
+// loadNodeGPUs 按设备编码批量获取对应 K8s 节点的GPU信息，key 为小写的节点名称
+func loadNodeGPUs(db *gorm.DB, devices []DeviceResponse) (map[string]string, error) {
+	names := make([]string, 0, len(devices))
+	for _, device := range devices {
+		names = append(names, strings.ToLower(device.CICode))
+	}
+	var nodes []portal.K8sNode
+	if err := db.Select("nodename", "gpu").Where("LOWER(nodename) IN ?", names).Find(&nodes).Error; err != nil {
+		return nil, fmt.Errorf("failed to get node gpu info: %w", err)
+	}
+	gpus := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		gpus[strings.ToLower(node.NodeName)] = node.GPU
+	}
+	return gpus, nil
+}
+
+// checkTemplateCompatibility 检查查询模板中的架构、型号和操作系统版本条件是否与兼容性规则冲突。
+// 条件的所有取值都不满足规则时，该条件筛选出的设备均无法入池；过滤组和条件按 OR 拆分为多个分支，
+// 只有每个分支都包含冲突条件时模板才筛选不出可入池的设备，视为冲突
+func checkTemplateCompatibility(db *gorm.DB, checker *compatibilityChecker, templateID int) error {
+	var template portal.QueryTemplate
+	if err := db.First(&template, templateID).Error; err != nil {
+		return HandleDBError(err, "查询模板", templateID)
+	}
+	var groups []FilterGroup
+	if err := json.Unmarshal([]byte(template.Groups), &groups); err != nil {
+		return NewBadRequestError(fmt.Sprintf("查询模板 %d 的过滤组解析失败: %v", templateID, err))
+	}
+
+	// 过滤组之间按前一个组的操作符连接，AND 连接的组中任一组冲突即整个分支冲突
+	var conflicts []string
+	branchConflict, started := "", false
+	for i, group := range groups {
+		if len(group.Blocks) == 0 {
+			continue
+		}
+		if started && groups[i-1].Operator == LogicalOperatorOr {
+			if branchConflict == "" {
+				return nil
+			}
+			conflicts = append(conflicts, branchConflict)
+			branchConflict = ""
+		}
+		started = true
+		if branchConflict == "" {
+			branchConflict = groupCompatibilityConflict(checker, group)
+		}
+	}
+	if branchConflict == "" {
+		return nil
+	}
+	conflicts = append(conflicts, branchConflict)
+	return NewBadRequestError(fmt.Sprintf("查询模板「%s」的%s与资源池 %s 的兼容性规则冲突",
+		template.Name, strings.Join(conflicts, "、"), checker.rule.ResourcePoolType))
+}
+
+// groupCompatibilityConflict 返回过滤组中导致冲突的条件描述，组内按 OR 连接的任一分支没有冲突条件时返回空字符串
+func groupCompatibilityConflict(checker *compatibilityChecker, group FilterGroup) string {
+	var conflicts []string
+	branchConflict := ""
+	for j, block := range group.Blocks {
+		if j > 0 && group.Blocks[j-1].Operator == LogicalOperatorOr {
+			if branchConflict == "" {
+				return ""
+			}
+			conflicts = append(conflicts, branchConflict)
+			branchConflict = ""
+		}
+		if branchConflict == "" {
+			branchConflict = blockCompatibilityConflict(checker, block)
+		}
+	}
+	if branchConflict == "" {
+		return ""
+	}
+	return strings.Join(append(conflicts, branchConflict), "、")
+}
+
+// blockCompatibilityConflict 返回与兼容性规则冲突的条件描述，条件的任一取值满足规则或不需要比对时返回空字符串
+func blockCompatibilityConflict(checker *compatibilityChecker, block FilterBlock) string {
+	label, ok := compatibilityTemplateKeys[block.Key]
+	if !ok || block.Type != FilterTypeDevice {
+		return ""
+	}
+	if block.ConditionType != ConditionTypeEqual && block.ConditionType != ConditionTypeIn {
+		return ""
+	}
+	values := filterBlockValues(block.Value)
+	if len(values) == 0 {
+		return ""
+	}
+	for _, value := range values {
+		if checker.templateValueAllowed(block.Key, value) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s条件 %s", label, strings.Join(values, ","))
+}
+
+// filterBlockValues 将筛选块的值转换为字符串列表，兼容字符串（逗号分隔）和数组
+func filterBlockValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return splitRuleList(v)
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				values = append(values, strings.TrimSpace(s))
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// validatePolicyCompatibility 校验入池匹配策略的查询模板是否与资源池兼容性规则冲突，其他动作类型不校验
+func validatePolicyCompatibility(db *gorm.DB, resourcePoolType, actionType string, templateID int) error {
+	if actionType != TriggerActionPoolEntry {
+		return nil
+	}
+	checker, err := loadCompatibilityChecker(db, resourcePoolType)
+	if err != nil || checker == nil {
+		return err
+	}
+	return checkTemplateCompatibility(db, checker, templateID)
+}
+
+// ListCompatibilityRules 获取所有资源池兼容性规则
+func (s *ElasticScalingService) ListCompatibilityRules() ([]ResourcePoolCompatibilityRuleDTO, error) {
+	var rules []portal.ResourcePoolCompatibilityRule
+	if err := s.db.Order("resource_pool_type").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	result := make([]ResourcePoolCompatibilityRuleDTO, 0, len(rules))
+	for i := range rules {
+		result = append(result, toCompatibilityRuleDTO(&rules[i]))
+	}
+	return result, nil
+}
+
+// CreateCompatibilityRule 创建资源池兼容性规则
+func (s *ElasticScalingService) CreateCompatibilityRule(dto ResourcePoolCompatibilityRuleDTO) (int, error) {
+	rule := newCompatibilityRuleModel(&dto)
+	if err := s.validateCompatibilityRule(&rule, 0); err != nil {
+		return 0, err
+	}
+	rule.CreatedBy = dto.CreatedBy
+	if err := s.db.Create(&rule).Error; err != nil {
+		return 0, err
+	}
+	return rule.ID, nil
+}
+
+// UpdateCompatibilityRule 更新资源池兼容性规则
+func (s *ElasticScalingService) UpdateCompatibilityRule(id int, dto ResourcePoolCompatibilityRuleDTO) error {
+	var existing portal.ResourcePoolCompatibilityRule
+	if err := s.db.First(&existing, id).Error; err != nil {
+		return HandleDBError(err, "兼容性规则", id)
+	}
+
+	rule := newCompatibilityRuleModel(&dto)
+	if err := s.validateCompatibilityRule(&rule, id); err != nil {
+		return err
+	}
+	rule.BaseModel = existing.BaseModel
+	rule.CreatedBy = existing.CreatedBy
+	return s.db.Save(&rule).Error
+}
+
+// DeleteCompatibilityRule 删除资源池兼容性规则
+func (s *ElasticScalingService) DeleteCompatibilityRule(id int) error {
+	result := s.db.Delete(&portal.ResourcePoolCompatibilityRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return NewNotFoundError("兼容性规则", id)
+	}
+	return nil
+}
+
+// validateCompatibilityRule 校验兼容性规则：每个资源池只能有一条规则，
+// 且资源池已启用的入池匹配策略的查询模板不能与规则冲突
+func (s *ElasticScalingService) validateCompatibilityRule(rule *portal.ResourcePoolCompatibilityRule, id int) error {
+	if rule.ResourcePoolType == "" {
+		return NewBadRequestError("资源池类型不能为空")
+	}
+	if rule.GPURequirement != "" && rule.GPURequirement != portal.GPURequirementRequired && rule.GPURequirement != portal.GPURequirementForbidden {
+		return NewBadRequestError("GPU要求必须为 required 或 forbidden")
+	}
+	if rule.MinDiskCount < 0 || rule.MinNetworkSpeed < 0 {
+		return NewBadRequestError("最少磁盘数和最低网卡速率不能为负数")
+	}
+
+	var count int64
+	if err := s.db.Model(&portal.ResourcePoolCompatibilityRule{}).
+		Where("resource_pool_type = ? AND id <> ?", rule.ResourcePoolType, id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return NewBadRequestError(fmt.Sprintf("资源池 %s 已存在兼容性规则", rule.ResourcePoolType))
+	}
+
+	var policies []portal.ResourcePoolDeviceMatchingPolicy
+	if err := s.db.Where("resource_pool_type = ? AND action_type = ? AND status = ?", rule.ResourcePoolType, TriggerActionPoolEntry, StrategyStatusEnabled).
+		Order("id").Find(&policies).Error; err != nil {
+		return err
+	}
+	checker := newCompatibilityChecker(*rule)
+	for _, policy := range policies {
+		if err := checkTemplateCompatibility(s.db, checker, int(policy.QueryTemplateID)); err != nil {
+			if IsBadRequest(err) {
+				return NewBadRequestError(fmt.Sprintf("匹配策略「%s」: %v", policy.Name, err))
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// newCompatibilityRuleModel 根据DTO构建兼容性规则模型
+func newCompatibilityRuleModel(dto *ResourcePoolCompatibilityRuleDTO) portal.ResourcePoolCompatibilityRule {
+	return portal.ResourcePoolCompatibilityRule{
+		ResourcePoolType: strings.TrimSpace(dto.ResourcePoolType),
+		ArchTypes:        strings.Join(splitRuleList(strings.Join(dto.ArchTypes, ",")), ","),
+		GPURequirement:   dto.GPURequirement,
+		MinDiskCount:     dto.MinDiskCount,
+		MinNetworkSpeed:  dto.MinNetworkSpeed,
+		Models:           strings.Join(splitRuleList(strings.Join(dto.Models, ",")), ","),
+		OSVersions:       strings.Join(splitRuleList(strings.Join(dto.OSVersions, ",")), ","),
+		Description:      dto.Description,
+	}
+}
+
+// toCompatibilityRuleDTO 将兼容性规则转换为DTO
+func toCompatibilityRuleDTO(rule *portal.ResourcePoolCompatibilityRule) ResourcePoolCompatibilityRuleDTO {
+	return ResourcePoolCompatibilityRuleDTO{
+		ID:               rule.ID,
+		ResourcePoolType: rule.ResourcePoolType,
+		ArchTypes:        splitRuleList(rule.ArchTypes),
+		GPURequirement:   rule.GPURequirement,
+		MinDiskCount:     rule.MinDiskCount,
+		MinNetworkSpeed:  rule.MinNetworkSpeed,
+		Models:           splitRuleList(rule.Models),
+		OSVersions:       splitRuleList(rule.OSVersions),
+		Description:      rule.Description,
+		CreatedBy:        rule.CreatedBy,
+		CreatedAt:        time.Time(rule.CreatedAt),
+		UpdatedAt:        time.Time(rule.UpdatedAt),
+	}
+}
@@ -0,0 +1,197 @@
+package es
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectMatchedDevicesFiltersIncompatibleDevices(t *testing.T) {
+	s, db := newMatchingTestService(t)
+	devices := []portal.Device{
+		{ArchType: "x86", DiskCount: 4, NetworkSpeed: "25G", Model: "R740", OSIssue: "CentOS 7.9"},
+		{ArchType: "arm", DiskCount: 4, NetworkSpeed: "25G", Model: "R740", OSIssue: "CentOS 7.9"},
+		{ArchType: "x86", DiskCount: 1, NetworkSpeed: "25G", Model: "R740", OSIssue: "CentOS 7.9"},
+		{ArchType: "x86", DiskCount: 4, NetworkSpeed: "1000Mb/s", Model: "R740", OSIssue: "CentOS 7.9"},
+		{ArchType: "X86", DiskCount: 4, NetworkSpeed: "25000", Model: "r740xd", OSIssue: "CentOS 7.6"},
+		{ArchType: "x86", DiskCount: 4, NetworkSpeed: "25G", Model: "R740", OSIssue: "CentOS 7.9"},
+	}
+	for i := range devices {
+		devices[i].ID = i + 1
+		devices[i].CICode = fmt.Sprintf("device-%d", i+1)
+		devices[i].IDC = "idc-a"
+		devices[i].CPU = 10
+		devices[i].Memory = 10
+		require.NoError(t, db.Create(&devices[i]).Error)
+	}
+	// device-6 对应的节点包含GPU
+	require.NoError(t, db.Create(&portal.K8sNode{NodeName: "device-6", GPU: "2"}).Error)
+	require.NoError(t, db.Create(&portal.K8sNode{NodeName: "device-1", GPU: "0"}).Error)
+
+	createIDCTemplate(t, db, 1, "idc-a")
+	require.NoError(t, db.Create(&portal.ResourcePoolDeviceMatchingPolicy{Name: "idc-a", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 1, Status: "enabled"}).Error)
+	require.NoError(t, db.Create(&portal.ResourcePoolCompatibilityRule{
+		ResourcePoolType: "total",
+		ArchTypes:        "x86",
+		GPURequirement:   portal.GPURequirementForbidden,
+		MinDiskCount:     2,
+		MinNetworkSpeed:  10000,
+		Models:           "R740",
+		OSVersions:       "CentOS 7.9,CentOS 7.6",
+	}).Error)
+
+	strategy := &portal.ElasticScalingStrategy{BaseModel: portal.BaseModel{ID: 1}, ThresholdTriggerAction: TriggerActionPoolEntry}
+	result, err := s.collectMatchedDevices(&evaluationContext{}, strategy, 1, "total", "", "", 100, 100)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []int{1, 5}, result.SelectedDeviceIDs)
+	assert.Equal(t, 2, result.CandidateCount)
+
+	policyTrace := result.Trace.Policies[0]
+	assert.Equal(t, 6, policyTrace.QueriedCount)
+	assert.Equal(t, 2, policyTrace.CandidateCount)
+	incompatible := make(map[int]string)
+	for _, rejected := range policyTrace.RejectedDevices {
+		if rejected.Reason == MatchingRejectIncompatible {
+			incompatible[rejected.DeviceID] = rejected.Detail
+		}
+	}
+	assert.Len(t, incompatible, 4)
+	assert.Contains(t, incompatible[2], "CPU架构")
+	assert.Contains(t, incompatible[3], "磁盘数")
+	assert.Contains(t, incompatible[4], "网卡速率")
+	assert.Contains(t, incompatible[6], "GPU")
+}
+
+func TestCompatibilityRuleDoesNotApplyToPoolExit(t *testing.T) {
+	s, db := newMatchingTestService(t)
+	require.NoError(t, db.Create(&portal.Device{BaseModel: portal.BaseModel{ID: 1}, CICode: "device-1", IDC: "idc-a", ArchType: "arm", ClusterID: 1, CPU: 10, Memory: 10}).Error)
+	createIDCTemplate(t, db, 1, "idc-a")
+	require.NoError(t, db.Create(&portal.ResourcePoolDeviceMatchingPolicy{Name: "exit", ResourcePoolType: "total", ActionType: TriggerActionPoolExit, QueryTemplateID: 1, Status: "enabled"}).Error)
+	require.NoError(t, db.Create(&portal.ResourcePoolCompatibilityRule{ResourcePoolType: "total", ArchTypes: "x86"}).Error)
+
+	strategy := &portal.ElasticScalingStrategy{BaseModel: portal.BaseModel{ID: 1}, ThresholdTriggerAction: TriggerActionPoolExit}
+	result, err := s.collectMatchedDevices(&evaluationContext{}, strategy, 1, "total", "", "", 0, 0)
+	require.NoError(t, err)
+	for _, rejected := range result.Trace.Policies[0].RejectedDevices {
+		assert.NotEqual(t, MatchingRejectIncompatible, rejected.Reason)
+	}
+}
+
+func TestPolicyTemplateValidatedAgainstCompatibilityRule(t *testing.T) {
+	s, db := newTestService(t)
+	require.NoError(t, db.Create(&portal.QueryTemplate{BaseModel: portal.BaseModel{ID: 1}, Name: "arm", Groups: `[{"id":"g1","operator":"and","blocks":[{"id":"b1","type":"device","key":"archType","conditionType":"in","value":["arm","aarch64"],"operator":"and"}]}]`}).Error)
+	require.NoError(t, db.Create(&portal.QueryTemplate{BaseModel: portal.BaseModel{ID: 2}, Name: "mixed", Groups: `[{"id":"g1","operator":"and","blocks":[{"id":"b1","type":"device","key":"archType","conditionType":"in","value":"arm,x86","operator":"and"}]}]`}).Error)
+
+	_, err := s.CreateCompatibilityRule(ResourcePoolCompatibilityRuleDTO{ResourcePoolType: "total", ArchTypes: []string{"x86"}})
+	require.NoError(t, err)
+
+	policyService := NewResourcePoolDeviceMatchingPolicyService(db, nil)
+	ctx := context.Background()
+
+	conflicting := &service.ResourcePoolDeviceMatchingPolicy{Name: "arm", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 1, Status: "enabled"}
+	err = policyService.CreateResourcePoolDeviceMatchingPolicy(ctx, conflicting)
+	require.Error(t, err)
+	assert.True(t, service.IsBadRequest(err))
+
+	// 退池策略和部分取值满足规则的模板不受限制
+	require.NoError(t, policyService.CreateResourcePoolDeviceMatchingPolicy(ctx, &service.ResourcePoolDeviceMatchingPolicy{Name: "exit", ResourcePoolType: "total", ActionType: TriggerActionPoolExit, QueryTemplateID: 1, Status: "enabled"}))
+	mixed := &service.ResourcePoolDeviceMatchingPolicy{Name: "mixed", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 2, Status: "enabled"}
+	require.NoError(t, policyService.CreateResourcePoolDeviceMatchingPolicy(ctx, mixed))
+
+	mixed.QueryTemplateID = 1
+	err = policyService.UpdateResourcePoolDeviceMatchingPolicy(ctx, mixed)
+	assert.True(t, service.IsBadRequest(err))
+}
+
+func TestTemplateCompatibilityEvaluatesOrBranches(t *testing.T) {
+	_, db := newTestService(t)
+	checker := newCompatibilityChecker(portal.ResourcePoolCompatibilityRule{ResourcePoolType: "total", ArchTypes: "x86"})
+	templates := map[string]string{
+		// 两个组按 OR 连接，x86 组可以筛选出满足规则的设备
+		"groups-or": `[{"id":"g1","operator":"or","blocks":[{"id":"b1","type":"device","key":"archType","conditionType":"equal","value":"arm","operator":"and"}]},` +
+			`{"id":"g2","operator":"and","blocks":[{"id":"b2","type":"device","key":"archType","conditionType":"equal","value":"x86","operator":"and"}]}]`,
+		// 组内条件按 OR 连接
+		"blocks-or": `[{"id":"g1","operator":"and","blocks":[{"id":"b1","type":"device","key":"archType","conditionType":"equal","value":"arm","operator":"or"},` +
+			`{"id":"b2","type":"device","key":"idc","conditionType":"equal","value":"idc-a","operator":"and"}]}]`,
+		// 两个组按 AND 连接，任一组冲突即冲突
+		"groups-and": `[{"id":"g1","operator":"and","blocks":[{"id":"b1","type":"device","key":"idc","conditionType":"equal","value":"idc-a","operator":"and"}]},` +
+			`{"id":"g2","operator":"and","blocks":[{"id":"b2","type":"device","key":"archType","conditionType":"equal","value":"arm","operator":"and"}]}]`,
+		// 每个 OR 分支都冲突
+		"all-branches": `[{"id":"g1","operator":"or","blocks":[{"id":"b1","type":"device","key":"archType","conditionType":"equal","value":"arm","operator":"and"}]},` +
+			`{"id":"g2","operator":"and","blocks":[{"id":"b2","type":"device","key":"archType","conditionType":"in","value":["aarch64"],"operator":"and"}]}]`,
+	}
+	ids := make(map[string]int)
+	for name, groups := range templates {
+		template := portal.QueryTemplate{Name: name, Groups: groups}
+		require.NoError(t, db.Create(&template).Error)
+		ids[name] = template.ID
+	}
+
+	assert.NoError(t, checkTemplateCompatibility(db, checker, ids["groups-or"]))
+	assert.NoError(t, checkTemplateCompatibility(db, checker, ids["blocks-or"]))
+	assert.True(t, service.IsBadRequest(checkTemplateCompatibility(db, checker, ids["groups-and"])))
+
+	err := checkTemplateCompatibility(db, checker, ids["all-branches"])
+	require.True(t, service.IsBadRequest(err))
+	assert.Contains(t, err.Error(), "arm")
+	assert.Contains(t, err.Error(), "aarch64")
+}
+
+func TestGPURequirementWithUnknownNode(t *testing.T) {
+	checker := newCompatibilityChecker(portal.ResourcePoolCompatibilityRule{ResourcePoolType: "gpu", GPURequirement: portal.GPURequirementRequired})
+	device := service.DeviceResponse{ID: 1, CICode: "device-1"}
+
+	assert.Empty(t, checker.check(device, "", false), "未入池设备没有节点记录时不拦截")
+	assert.Empty(t, checker.check(device, "2", true))
+	assert.Contains(t, checker.check(device, "0", true), "不包含GPU")
+}
+
+func TestCompatibilityRuleValidation(t *testing.T) {
+	s, db := newTestService(t)
+	require.NoError(t, db.Create(&portal.QueryTemplate{BaseModel: portal.BaseModel{ID: 1}, Name: "arm", Groups: `[{"id":"g1","operator":"and","blocks":[{"id":"b1","type":"device","key":"archType","conditionType":"equal","value":"arm","operator":"and"}]}]`}).Error)
+	require.NoError(t, db.Create(&portal.ResourcePoolDeviceMatchingPolicy{Name: "arm", ResourcePoolType: "total", ActionType: TriggerActionPoolEntry, QueryTemplateID: 1, Status: "enabled"}).Error)
+
+	_, err := s.CreateCompatibilityRule(ResourcePoolCompatibilityRuleDTO{ResourcePoolType: "total", ArchTypes: []string{"x86"}})
+	assert.True(t, service.IsBadRequest(err), "existing entry policy conflicts with the rule")
+
+	_, err = s.CreateCompatibilityRule(ResourcePoolCompatibilityRuleDTO{ResourcePoolType: "total", GPURequirement: "maybe"})
+	assert.True(t, service.IsBadRequest(err))
+
+	id, err := s.CreateCompatibilityRule(ResourcePoolCompatibilityRuleDTO{ResourcePoolType: "total", ArchTypes: []string{" arm ", ""}, MinDiskCount: 2})
+	require.NoError(t, err)
+	_, err = s.CreateCompatibilityRule(ResourcePoolCompatibilityRuleDTO{ResourcePoolType: "total"})
+	assert.True(t, service.IsBadRequest(err), "only one rule per resource pool")
+
+	rules, err := s.ListCompatibilityRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, []string{"arm"}, rules[0].ArchTypes)
+
+	require.NoError(t, s.UpdateCompatibilityRule(id, ResourcePoolCompatibilityRuleDTO{ResourcePoolType: "total", ArchTypes: []string{"arm", "x86"}}))
+	require.NoError(t, s.DeleteCompatibilityRule(id))
+	assert.True(t, service.IsNotFound(s.DeleteCompatibilityRule(id)))
+}
+
+func TestParseNetworkSpeed(t *testing.T) {
+	cases := map[string]int{
+		"1000":      1000,
+		"10000Mb/s": 10000,
+		"25G":       25000,
+		"2.5Gb/s":   2500,
+		" 100 gbps": 100000,
+	}
+	for value, expected := range cases {
+		speed, ok := parseNetworkSpeed(value)
+		assert.True(t, ok, value)
+		assert.Equal(t, expected, speed, value)
+	}
+	_, ok := parseNetworkSpeed("unknown")
+	assert.False(t, ok)
+}
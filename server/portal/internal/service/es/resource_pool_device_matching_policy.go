@@ -56,7 +56,7 @@ func (s *ResourcePoolDeviceMatchingPolicyService) GetResourcePoolDeviceMatchingP
 		// 从数据库获取分页的策略，只选择必要的字段
 		var dbPolicies []portal.ResourcePoolDeviceMatchingPolicy
 		if err := tx.
-			Select("id, name, description, resource_pool_type, action_type, query_template_id, status, created_by, updated_by, created_at, updated_at").
+			Select("id, name, description, resource_pool_type, action_type, query_template_id, status, addition_conds, selection_algorithm, max_devices_per_cabinet, max_devices_per_room, balance_across_rooms, min_devices_per_cabinet, priority, combine_mode, created_by, updated_by, created_at, updated_at").
 			Order("id desc"). // 默认按ID降序排列
 			Offset(offset).
 			Limit(size).
@@ -108,18 +108,25 @@ func (s *ResourcePoolDeviceMatchingPolicyService) GetResourcePoolDeviceMatchingP
 
 			// 创建策略对象
 			policies[i] = ResourcePoolDeviceMatchingPolicy{
-				ID:               dbPolicy.ID,
-				Name:             dbPolicy.Name,
-				Description:      dbPolicy.Description,
-				ResourcePoolType: dbPolicy.ResourcePoolType,
-				ActionType:       dbPolicy.ActionType,
-				QueryTemplateID:  int(dbPolicy.QueryTemplateID),
-				Status:           dbPolicy.Status,
-				AdditionConds:    additionConds,
-				CreatedBy:        dbPolicy.CreatedBy,
-				UpdatedBy:        dbPolicy.UpdatedBy,
-				CreatedAt:        time.Time(dbPolicy.CreatedAt),
-				UpdatedAt:        time.Time(dbPolicy.UpdatedAt),
+				ID:                   dbPolicy.ID,
+				Name:                 dbPolicy.Name,
+				Description:          dbPolicy.Description,
+				ResourcePoolType:     dbPolicy.ResourcePoolType,
+				ActionType:           dbPolicy.ActionType,
+				QueryTemplateID:      int(dbPolicy.QueryTemplateID),
+				Status:               dbPolicy.Status,
+				AdditionConds:        additionConds,
+				SelectionAlgorithm:   dbPolicy.SelectionAlgorithm,
+				MaxDevicesPerCabinet: dbPolicy.MaxDevicesPerCabinet,
+				MaxDevicesPerRoom:    dbPolicy.MaxDevicesPerRoom,
+				BalanceAcrossRooms:   dbPolicy.BalanceAcrossRooms,
+				MinDevicesPerCabinet: dbPolicy.MinDevicesPerCabinet,
+				Priority:             dbPolicy.Priority,
+				CombineMode:          dbPolicy.CombineMode,
+				CreatedBy:            dbPolicy.CreatedBy,
+				UpdatedBy:            dbPolicy.UpdatedBy,
+				CreatedAt:            time.Time(dbPolicy.CreatedAt),
+				UpdatedAt:            time.Time(dbPolicy.UpdatedAt),
 			}
 
 			// 如果找到了关联的查询模板，解析其查询条件组并添加到策略中
@@ -203,19 +210,26 @@ func (s *ResourcePoolDeviceMatchingPolicyService) GetResourcePoolDeviceMatchingP
 
 	// 转换为服务层策略格式
 	policy := &ResourcePoolDeviceMatchingPolicy{
-		ID:               dbPolicy.ID,
-		Name:             dbPolicy.Name,
-		Description:      dbPolicy.Description,
-		ResourcePoolType: dbPolicy.ResourcePoolType,
-		ActionType:       dbPolicy.ActionType,
-		QueryTemplateID:  int(dbPolicy.QueryTemplateID),
-		QueryGroups:      queryGroups,
-		Status:           dbPolicy.Status,
-		AdditionConds:    additionConds,
-		CreatedBy:        dbPolicy.CreatedBy,
-		UpdatedBy:        dbPolicy.UpdatedBy,
-		CreatedAt:        time.Time(dbPolicy.CreatedAt),
-		UpdatedAt:        time.Time(dbPolicy.UpdatedAt),
+		ID:                   dbPolicy.ID,
+		Name:                 dbPolicy.Name,
+		Description:          dbPolicy.Description,
+		ResourcePoolType:     dbPolicy.ResourcePoolType,
+		ActionType:           dbPolicy.ActionType,
+		QueryTemplateID:      int(dbPolicy.QueryTemplateID),
+		QueryGroups:          queryGroups,
+		Status:               dbPolicy.Status,
+		AdditionConds:        additionConds,
+		SelectionAlgorithm:   dbPolicy.SelectionAlgorithm,
+		MaxDevicesPerCabinet: dbPolicy.MaxDevicesPerCabinet,
+		MaxDevicesPerRoom:    dbPolicy.MaxDevicesPerRoom,
+		BalanceAcrossRooms:   dbPolicy.BalanceAcrossRooms,
+		MinDevicesPerCabinet: dbPolicy.MinDevicesPerCabinet,
+		Priority:             dbPolicy.Priority,
+		CombineMode:          dbPolicy.CombineMode,
+		CreatedBy:            dbPolicy.CreatedBy,
+		UpdatedBy:            dbPolicy.UpdatedBy,
+		CreatedAt:            time.Time(dbPolicy.CreatedAt),
+		UpdatedAt:            time.Time(dbPolicy.UpdatedAt),
 		QueryTemplate: &QueryTemplate{
 			ID:          template.ID,
 			Name:        template.Name,
@@ -260,6 +274,11 @@ func (s *ResourcePoolDeviceMatchingPolicyService) CreateResourcePoolDeviceMatchi
 		return fmt.Errorf("query template not found: %d", policy.QueryTemplateID)
 	}
 
+	// 入池策略的查询模板不能与资源池兼容性规则冲突
+	if err := validatePolicyCompatibility(s.db.WithContext(ctx), policy.ResourcePoolType, policy.ActionType, policy.QueryTemplateID); err != nil {
+		return err
+	}
+
 	// 处理额外动态条件
 	var additionCondsJSON string
 	if len(policy.AdditionConds) > 0 {
@@ -272,15 +291,22 @@ func (s *ResourcePoolDeviceMatchingPolicyService) CreateResourcePoolDeviceMatchi
 
 	// 将策略数据转换为数据库模型
 	dbPolicy := &portal.ResourcePoolDeviceMatchingPolicy{
-		Name:             policy.Name,
-		Description:      policy.Description,
-		ResourcePoolType: policy.ResourcePoolType,
-		ActionType:       policy.ActionType,
-		QueryTemplateID:  uint(policy.QueryTemplateID),
-		Status:           policy.Status,
-		AdditionConds:    additionCondsJSON,
-		CreatedBy:        policy.CreatedBy,
-		UpdatedBy:        policy.UpdatedBy,
+		Name:                 policy.Name,
+		Description:          policy.Description,
+		ResourcePoolType:     policy.ResourcePoolType,
+		ActionType:           policy.ActionType,
+		QueryTemplateID:      uint(policy.QueryTemplateID),
+		Status:               policy.Status,
+		AdditionConds:        additionCondsJSON,
+		SelectionAlgorithm:   policy.SelectionAlgorithm,
+		MaxDevicesPerCabinet: policy.MaxDevicesPerCabinet,
+		MaxDevicesPerRoom:    policy.MaxDevicesPerRoom,
+		BalanceAcrossRooms:   policy.BalanceAcrossRooms,
+		MinDevicesPerCabinet: policy.MinDevicesPerCabinet,
+		Priority:             policy.Priority,
+		CombineMode:          policy.CombineMode,
+		CreatedBy:            policy.CreatedBy,
+		UpdatedBy:            policy.UpdatedBy,
 	}
 
 	// 创建新策略
@@ -350,6 +376,11 @@ func (s *ResourcePoolDeviceMatchingPolicyService) UpdateResourcePoolDeviceMatchi
 		return fmt.Errorf("query template not found: %d", policy.QueryTemplateID)
 	}
 
+	// 入池策略的查询模板不能与资源池兼容性规则冲突
+	if err := validatePolicyCompatibility(s.db.WithContext(ctx), policy.ResourcePoolType, policy.ActionType, policy.QueryTemplateID); err != nil {
+		return err
+	}
+
 	// 处理额外动态条件
 	var additionCondsJSON string
 	if len(policy.AdditionConds) > 0 {
@@ -364,14 +395,21 @@ func (s *ResourcePoolDeviceMatchingPolicyService) UpdateResourcePoolDeviceMatchi
 	result := s.db.WithContext(ctx).Model(&portal.ResourcePoolDeviceMatchingPolicy{}).
 		Where("id = ?", policy.ID).
 		Updates(map[string]interface{}{
-			"name":               policy.Name,
-			"description":        policy.Description,
-			"resource_pool_type": policy.ResourcePoolType,
-			"action_type":        policy.ActionType,
-			"query_template_id":  uint(policy.QueryTemplateID),
-			"status":             policy.Status,
-			"addition_conds":     additionCondsJSON,
-			"updated_by":         policy.UpdatedBy,
+			"name":                    policy.Name,
+			"description":             policy.Description,
+			"resource_pool_type":      policy.ResourcePoolType,
+			"action_type":             policy.ActionType,
+			"query_template_id":       uint(policy.QueryTemplateID),
+			"status":                  policy.Status,
+			"addition_conds":          additionCondsJSON,
+			"selection_algorithm":     policy.SelectionAlgorithm,
+			"max_devices_per_cabinet": policy.MaxDevicesPerCabinet,
+			"max_devices_per_room":    policy.MaxDevicesPerRoom,
+			"balance_across_rooms":    policy.BalanceAcrossRooms,
+			"min_devices_per_cabinet": policy.MinDevicesPerCabinet,
+			"priority":                policy.Priority,
+			"combine_mode":            policy.CombineMode,
+			"updated_by":              policy.UpdatedBy,
 		})
 
 	if result.Error != nil {
@@ -469,6 +507,7 @@ func (s *ResourcePoolDeviceMatchingPolicyService) GetResourcePoolDeviceMatchingP
 	var dbPolicies []portal.ResourcePoolDeviceMatchingPolicy
 	if err := s.db.WithContext(ctx).
 		Where("resource_pool_type = ? AND action_type = ? AND status = 'enabled'", resourcePoolType, actionType).
+		Order("priority, id"). // 按优先级执行，优先级相同时先创建的先执行
 		Find(&dbPolicies).Error; err != nil {
 		return nil, fmt.Errorf("failed to get policies by type: %w", err)
 	}
@@ -517,18 +556,25 @@ func (s *ResourcePoolDeviceMatchingPolicyService) GetResourcePoolDeviceMatchingP
 
 		// 创建策略对象
 		policies[i] = ResourcePoolDeviceMatchingPolicy{
-			ID:               dbPolicy.ID,
-			Name:             dbPolicy.Name,
-			Description:      dbPolicy.Description,
-			ResourcePoolType: dbPolicy.ResourcePoolType,
-			ActionType:       dbPolicy.ActionType,
-			QueryTemplateID:  int(dbPolicy.QueryTemplateID),
-			Status:           dbPolicy.Status,
-			AdditionConds:    additionConds,
-			CreatedBy:        dbPolicy.CreatedBy,
-			UpdatedBy:        dbPolicy.UpdatedBy,
-			CreatedAt:        time.Time(dbPolicy.CreatedAt),
-			UpdatedAt:        time.Time(dbPolicy.UpdatedAt),
+			ID:                   dbPolicy.ID,
+			Name:                 dbPolicy.Name,
+			Description:          dbPolicy.Description,
+			ResourcePoolType:     dbPolicy.ResourcePoolType,
+			ActionType:           dbPolicy.ActionType,
+			QueryTemplateID:      int(dbPolicy.QueryTemplateID),
+			Status:               dbPolicy.Status,
+			AdditionConds:        additionConds,
+			SelectionAlgorithm:   dbPolicy.SelectionAlgorithm,
+			MaxDevicesPerCabinet: dbPolicy.MaxDevicesPerCabinet,
+			MaxDevicesPerRoom:    dbPolicy.MaxDevicesPerRoom,
+			BalanceAcrossRooms:   dbPolicy.BalanceAcrossRooms,
+			MinDevicesPerCabinet: dbPolicy.MinDevicesPerCabinet,
+			Priority:             dbPolicy.Priority,
+			CombineMode:          dbPolicy.CombineMode,
+			CreatedBy:            dbPolicy.CreatedBy,
+			UpdatedBy:            dbPolicy.UpdatedBy,
+			CreatedAt:            time.Time(dbPolicy.CreatedAt),
+			UpdatedAt:            time.Time(dbPolicy.UpdatedAt),
 		}
 
 		// 如果找到了关联的查询模板，解析其查询条件组并添加到策略中
@@ -0,0 +1,26 @@
+package events
+
+import (
+	"context"
+)
+
+// OpsJobEventData 运维任务事件数据结构
+type OpsJobEventData struct {
+	JobID    int    `json:"job_id"`
+	Status   string `json:"status"`   // pending, running, completed, failed
+	Progress int    `json:"progress"` // 任务进度（0-100）
+	Message  string `json:"message,omitempty"`
+}
+
+// PublishOpsJobEvent 发布运维任务事件，em 为空时不发布
+func PublishOpsJobEvent(em *EventManager, ctx context.Context, eventType string, data OpsJobEventData) error {
+	if em == nil {
+		return nil
+	}
+	return PublishGeneric(em, GenericEventRequest[OpsJobEventData]{
+		EventType: eventType,
+		Data:      data,
+		Source:    "ops_job_service",
+		Context:   ctx,
+	})
+}
@@ -29,6 +29,12 @@ const (
 	EventTypeScalingCompleted = "scaling.completed"
 	EventTypeScalingCancelled = "scaling.cancelled"
 	EventTypeScalingReturning = "scaling.returning"
+
+	// 运维任务相关事件
+	EventTypeOpsJobStarted   = "ops_job.started"
+	EventTypeOpsJobProgress  = "ops_job.progress"
+	EventTypeOpsJobCompleted = "ops_job.completed"
+	EventTypeOpsJobFailed    = "ops_job.failed"
 )
 
 // BaseEvent 基础事件结构
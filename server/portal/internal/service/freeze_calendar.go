@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"navy-ng/models/portal"
+)
+
+// 冻结窗口状态
+const (
+	FreezeWindowStatusEnabled  = "enabled"
+	FreezeWindowStatusDisabled = "disabled"
+)
+
+// 每年重复日期的格式
+const freezeAnnualDateFormat = "01-02"
+
+// FreezeCalendarService 变更冻结日历服务
+// 冻结窗口分为全局窗口和集群窗口，规则支持一次性时间段、每周固定星期和指定日期
+type FreezeCalendarService struct {
+	db *gorm.DB
+}
+
+// NewFreezeCalendarService 创建变更冻结日历服务实例
+func NewFreezeCalendarService(db *gorm.DB) *FreezeCalendarService {
+	return &FreezeCalendarService{db: db}
+}
+
+// ListFreezeWindows 获取冻结窗口列表
+func (s *FreezeCalendarService) ListFreezeWindows(ctx context.Context, query *FreezeWindowQuery) (*FreezeWindowListResponse, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	db := s.db.WithContext(timeoutCtx).Model(&portal.FreezeWindow{})
+	if query.ClusterID != nil {
+		db = db.Where("cluster_id IS NULL OR cluster_id = ?", *query.ClusterID)
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, NewServerError("获取冻结窗口总数失败", err)
+	}
+
+	var windows []portal.FreezeWindow
+	offset := (query.Page - 1) * query.Size
+	if err := db.Offset(offset).Limit(query.Size).Order("created_at DESC").Find(&windows).Error; err != nil {
+		return nil, NewServerError("获取冻结窗口列表失败", err)
+	}
+
+	responses := make([]*FreezeWindowResponse, len(windows))
+	for i := range windows {
+		responses[i] = convertToFreezeWindowResponse(&windows[i])
+	}
+
+	return &FreezeWindowListResponse{
+		List:  responses,
+		Total: total,
+		Page:  query.Page,
+		Size:  query.Size,
+	}, nil
+}
+
+// GetFreezeWindow 根据ID获取冻结窗口
+func (s *FreezeCalendarService) GetFreezeWindow(ctx context.Context, id int) (*FreezeWindowResponse, error) {
+	var window portal.FreezeWindow
+	if err := s.db.WithContext(ctx).First(&window, id).Error; err != nil {
+		return nil, HandleDBError(err, "冻结窗口", id)
+	}
+	return convertToFreezeWindowResponse(&window), nil
+}
+
+// CreateFreezeWindow 创建冻结窗口
+func (s *FreezeCalendarService) CreateFreezeWindow(ctx context.Context, req *FreezeWindowRequest, username string) (*FreezeWindowResponse, error) {
+	if err := validateFreezeWindowRequest(req); err != nil {
+		return nil, err
+	}
+
+	window := req.toModel()
+	window.CreatedBy = username
+	if err := s.db.WithContext(ctx).Create(&window).Error; err != nil {
+		return nil, NewServerError("创建冻结窗口失败", err)
+	}
+	return convertToFreezeWindowResponse(&window), nil
+}
+
+// UpdateFreezeWindow 更新冻结窗口
+func (s *FreezeCalendarService) UpdateFreezeWindow(ctx context.Context, id int, req *FreezeWindowRequest) (*FreezeWindowResponse, error) {
+	if err := validateFreezeWindowRequest(req); err != nil {
+		return nil, err
+	}
+
+	var existing portal.FreezeWindow
+	if err := s.db.WithContext(ctx).First(&existing, id).Error; err != nil {
+		return nil, HandleDBError(err, "冻结窗口", id)
+	}
+
+	window := req.toModel()
+	window.BaseModel = existing.BaseModel
+	window.CreatedBy = existing.CreatedBy
+	if err := s.db.WithContext(ctx).Save(&window).Error; err != nil {
+		return nil, NewServerError("更新冻结窗口失败", err)
+	}
+	return convertToFreezeWindowResponse(&window), nil
+}
+
+// DeleteFreezeWindow 删除冻结窗口
+func (s *FreezeCalendarService) DeleteFreezeWindow(ctx context.Context, id int) error {
+	result := s.db.WithContext(ctx).Delete(&portal.FreezeWindow{}, id)
+	if result.Error != nil {
+		return NewServerError("删除冻结窗口失败", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return NewNotFoundError("冻结窗口", id)
+	}
+	return nil
+}
+
+// ActiveFreezeWindows 返回在指定时间生效的冻结窗口（全局窗口以及指定集群的窗口）。
+// clusterID 为空时只检查全局窗口。
+func (s *FreezeCalendarService) ActiveFreezeWindows(ctx context.Context, clusterID *int, at time.Time) ([]portal.FreezeWindow, error) {
+	db := s.db.WithContext(ctx).Where("status = ?", FreezeWindowStatusEnabled)
+	if clusterID != nil {
+		db = db.Where("cluster_id IS NULL OR cluster_id = ?", *clusterID)
+	} else {
+		db = db.Where("cluster_id IS NULL")
+	}
+
+	var windows []portal.FreezeWindow
+	if err := db.Order("id").Find(&windows).Error; err != nil {
+		return nil, NewServerError("获取冻结窗口失败", err)
+	}
+
+	var active []portal.FreezeWindow
+	for i := range windows {
+		if freezeWindowActiveAt(&windows[i], at) {
+			active = append(active, windows[i])
+		}
+	}
+	return active, nil
+}
+
+// CheckFreezeStatus 查询指定时间是否处于冻结期，供自动伸缩和维护订单确认使用
+func (s *FreezeCalendarService) CheckFreezeStatus(ctx context.Context, clusterID *int, at time.Time) (*FreezeStatusResponse, error) {
+	windows, err := s.ActiveFreezeWindows(ctx, clusterID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &FreezeStatusResponse{
+		Frozen:    len(windows) > 0,
+		ClusterID: clusterID,
+		CheckedAt: at,
+		Windows:   make([]*FreezeWindowResponse, len(windows)),
+	}
+	for i := range windows {
+		response.Windows[i] = convertToFreezeWindowResponse(&windows[i])
+	}
+	return response, nil
+}
+
+// FreezeWindowNames 返回冻结窗口名称列表，用于拼接提示信息
+func FreezeWindowNames(windows []portal.FreezeWindow) string {
+	names := make([]string, len(windows))
+	for i, window := range windows {
+		names[i] = window.Name
+	}
+	return strings.Join(names, "、")
+}
+
+// freezeWindowActiveAt 判断冻结窗口在指定时间是否生效
+func freezeWindowActiveAt(window *portal.FreezeWindow, at time.Time) bool {
+	switch window.RuleType {
+	case portal.FreezeRuleTypeRange:
+		if window.StartTime == nil || window.EndTime == nil {
+			return false
+		}
+		return !at.Before(time.Time(*window.StartTime)) && at.Before(time.Time(*window.EndTime))
+	case portal.FreezeRuleTypeWeekly:
+		for _, weekday := range parseWeekdays(window.Weekdays) {
+			if time.Weekday(weekday) == at.Weekday() {
+				return true
+			}
+		}
+	case portal.FreezeRuleTypeDates:
+		day, annual := at.Format(time.DateOnly), at.Format(freezeAnnualDateFormat)
+		for _, date := range splitFreezeList(window.Dates) {
+			if date == day || date == annual {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateFreezeWindowRequest 校验冻结窗口请求
+func validateFreezeWindowRequest(req *FreezeWindowRequest) error {
+	if req.Status != "" && req.Status != FreezeWindowStatusEnabled && req.Status != FreezeWindowStatusDisabled {
+		return NewBadRequestError("状态必须为 enabled 或 disabled")
+	}
+
+	switch req.RuleType {
+	case portal.FreezeRuleTypeRange:
+		if req.StartTime == nil || req.EndTime == nil {
+			return NewBadRequestError("一次性冻结窗口必须设置开始时间和结束时间")
+		}
+		if !req.EndTime.After(*req.StartTime) {
+			return NewBadRequestError("结束时间必须晚于开始时间")
+		}
+	case portal.FreezeRuleTypeWeekly:
+		if len(req.Weekdays) == 0 {
+			return NewBadRequestError("每周冻结窗口必须设置星期")
+		}
+		for _, weekday := range req.Weekdays {
+			if weekday < 0 || weekday > 6 {
+				return NewBadRequestError(fmt.Sprintf("无效的星期: %d，必须在0-6之间（0为周日）", weekday))
+			}
+		}
+	case portal.FreezeRuleTypeDates:
+		if len(req.Dates) == 0 {
+			return NewBadRequestError("指定日期冻结窗口必须设置日期")
+		}
+		for _, date := range req.Dates {
+			if _, err := time.Parse(time.DateOnly, date); err == nil {
+				continue
+			}
+			if _, err := time.Parse(freezeAnnualDateFormat, date); err != nil {
+				return NewBadRequestError(fmt.Sprintf("无效的日期: %s，格式必须为 YYYY-MM-DD 或 MM-DD", date))
+			}
+		}
+	default:
+		return NewBadRequestError("无效的规则类型，必须为 range、weekly 或 dates")
+	}
+	return nil
+}
+
+// toModel 将请求转换为数据模型，只保留规则类型对应的字段
+func (r *FreezeWindowRequest) toModel() portal.FreezeWindow {
+	window := portal.FreezeWindow{
+		Name:        r.Name,
+		Description: r.Description,
+		ClusterID:   r.ClusterID,
+		RuleType:    r.RuleType,
+		Status:      r.Status,
+	}
+	if window.Status == "" {
+		window.Status = FreezeWindowStatusEnabled
+	}
+
+	switch r.RuleType {
+	case portal.FreezeRuleTypeRange:
+		start, end := portal.NavyTime(*r.StartTime), portal.NavyTime(*r.EndTime)
+		window.StartTime, window.EndTime = &start, &end
+	case portal.FreezeRuleTypeWeekly:
+		weekdays := append([]int(nil), r.Weekdays...)
+		sort.Ints(weekdays)
+		parts := make([]string, len(weekdays))
+		for i, weekday := range weekdays {
+			parts[i] = strconv.Itoa(weekday)
+		}
+		window.Weekdays = strings.Join(parts, ",")
+	case portal.FreezeRuleTypeDates:
+		window.Dates = strings.Join(r.Dates, ",")
+	}
+	return window
+}
+
+// convertToFreezeWindowResponse 将数据模型转换为响应
+func convertToFreezeWindowResponse(window *portal.FreezeWindow) *FreezeWindowResponse {
+	response := &FreezeWindowResponse{
+		ID:          window.ID,
+		Name:        window.Name,
+		Description: window.Description,
+		ClusterID:   window.ClusterID,
+		RuleType:    window.RuleType,
+		Weekdays:    parseWeekdays(window.Weekdays),
+		Dates:       splitFreezeList(window.Dates),
+		Status:      window.Status,
+		CreatedBy:   window.CreatedBy,
+		CreatedAt:   time.Time(window.CreatedAt),
+		UpdatedAt:   time.Time(window.UpdatedAt),
+	}
+	if window.StartTime != nil {
+		start := time.Time(*window.StartTime)
+		response.StartTime = &start
+	}
+	if window.EndTime != nil {
+		end := time.Time(*window.EndTime)
+		response.EndTime = &end
+	}
+	return response
+}
+
+// parseWeekdays 解析逗号分隔的星期列表，忽略无效值
+func parseWeekdays(value string) []int {
+	var weekdays []int
+	for _, part := range splitFreezeList(value) {
+		if weekday, err := strconv.Atoi(part); err == nil {
+			weekdays = append(weekdays, weekday)
+		}
+	}
+	return weekdays
+}
+
+// splitFreezeList 拆分逗号分隔的列表，忽略空项
+func splitFreezeList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,57 @@
+package service
+
+import "time"
+
+// FreezeWindowQuery 冻结窗口查询参数
+type FreezeWindowQuery struct {
+	Page      int    `form:"page" json:"page" binding:"omitempty,min=1"`
+	Size      int    `form:"size" json:"size" binding:"omitempty,min=1,max=100"`
+	ClusterID *int   `form:"cluster_id" json:"clusterId"` // 为空时返回全部，指定时返回该集群及全局窗口
+	Status    string `form:"status" json:"status"`
+}
+
+// FreezeWindowRequest 创建/更新冻结窗口请求
+type FreezeWindowRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	Description string     `json:"description"`
+	ClusterID   *int       `json:"clusterId"` // 为空时全局生效
+	RuleType    string     `json:"ruleType" binding:"required"`
+	StartTime   *time.Time `json:"startTime"` // range：开始时间
+	EndTime     *time.Time `json:"endTime"`   // range：结束时间
+	Weekdays    []int      `json:"weekdays"`  // weekly：0-6，0为周日
+	Dates       []string   `json:"dates"`     // dates：YYYY-MM-DD 为单日，MM-DD 为每年重复
+	Status      string     `json:"status"`    // enabled 或 disabled，为空时为 enabled
+}
+
+// FreezeWindowResponse 冻结窗口响应
+type FreezeWindowResponse struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	ClusterID   *int       `json:"clusterId"`
+	RuleType    string     `json:"ruleType"`
+	StartTime   *time.Time `json:"startTime,omitempty"`
+	EndTime     *time.Time `json:"endTime,omitempty"`
+	Weekdays    []int      `json:"weekdays,omitempty"`
+	Dates       []string   `json:"dates,omitempty"`
+	Status      string     `json:"status"`
+	CreatedBy   string     `json:"createdBy"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// FreezeWindowListResponse 冻结窗口列表响应
+type FreezeWindowListResponse struct {
+	List  []*FreezeWindowResponse `json:"list"`
+	Total int64                   `json:"total"`
+	Page  int                     `json:"page"`
+	Size  int                     `json:"size"`
+}
+
+// FreezeStatusResponse 冻结状态查询响应
+type FreezeStatusResponse struct {
+	Frozen    bool                    `json:"frozen"`
+	ClusterID *int                    `json:"clusterId,omitempty"`
+	CheckedAt time.Time               `json:"checkedAt"`
+	Windows   []*FreezeWindowResponse `json:"windows"` // 当前生效的冻结窗口
+}
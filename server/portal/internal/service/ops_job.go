@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"math/rand"
 	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service/events"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -18,6 +20,8 @@ type OpsJobService struct {
 	db            *gorm.DB
 	activeJobs    map[int]*JobExecution
 	activeJobsMux sync.Mutex
+	logger        *zap.Logger
+	eventManager  *events.EventManager // 任务开始、运行进度、完成和失败时发布事件，为空时不发布
 }
 
 // ClientConnection represents a WebSocket client connection with its own mutex
@@ -34,13 +38,20 @@ type JobExecution struct {
 }
 
 // NewOpsJobService creates a new OpsJobService.
-func NewOpsJobService(db *gorm.DB) *OpsJobService {
+func NewOpsJobService(db *gorm.DB, logger *zap.Logger) *OpsJobService {
 	return &OpsJobService{
 		db:         db,
 		activeJobs: make(map[int]*JobExecution),
+		logger:     logger,
 	}
 }
 
+// WithEventManager sets the event manager used to publish job lifecycle events.
+func (s *OpsJobService) WithEventManager(em *events.EventManager) *OpsJobService {
+	s.eventManager = em
+	return s
+}
+
 // Constants for OpsJob service
 const (
 	// ErrOpsJobNotFoundMsg is the error message for record not found errors.
@@ -160,6 +171,7 @@ func (s *OpsJobService) StartJob(jobID int, conn *websocket.Conn) error {
 	job.Status = StatusRunning
 	job.LogContent += "Job execution started...\n"
 	s.db.Save(&job)
+	s.publishJobEvent(events.EventTypeOpsJobStarted, jobID, StatusRunning, job.Progress, "Job execution started")
 
 	// Start job execution in a goroutine
 	go s.executeJob(ctx, jobID)
@@ -268,6 +280,15 @@ func (s *OpsJobService) updateJobStatus(jobID int, status string, progress int,
 	}
 	s.db.Save(&job)
 
+	switch status {
+	case StatusRunning:
+		s.publishJobEvent(events.EventTypeOpsJobProgress, jobID, status, progress, logLine)
+	case StatusCompleted:
+		s.publishJobEvent(events.EventTypeOpsJobCompleted, jobID, status, progress, logLine)
+	case StatusFailed:
+		s.publishJobEvent(events.EventTypeOpsJobFailed, jobID, status, progress, logLine)
+	}
+
 	// Notify clients
 	update := OpsJobStatusUpdate{
 		ID:       jobID,
@@ -286,6 +307,22 @@ func (s *OpsJobService) updateJobStatus(jobID int, status string, progress int,
 	}
 }
 
+// publishJobEvent publishes a job lifecycle event so that linked orders can follow the job.
+func (s *OpsJobService) publishJobEvent(eventType string, jobID int, status string, progress int, message string) {
+	err := events.PublishOpsJobEvent(s.eventManager, context.Background(), eventType, events.OpsJobEventData{
+		JobID:    jobID,
+		Status:   status,
+		Progress: progress,
+		Message:  message,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to publish ops job event",
+			zap.String("eventType", eventType),
+			zap.Int("jobID", jobID),
+			zap.Error(err))
+	}
+}
+
 // cleanupJob removes the job from active jobs map
 func (s *OpsJobService) cleanupJob(jobID int) {
 	s.activeJobsMux.Lock()
@@ -0,0 +1,361 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	. "navy-ng/server/portal/internal/service"
+	"navy-ng/server/portal/internal/service/events"
+
+	"navy-ng/models/portal"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 部署订单由运维任务事件推进时使用的执行人
+const deploymentEventExecutor = "system"
+
+// DeploymentOrderDTO 是包含部署详情、目标节点和目标设备的部署订单
+type DeploymentOrderDTO struct {
+	*portal.Order
+	Details *portal.DeploymentOrderDetail `json:"details,omitempty"`
+	Nodes   []string                      `json:"nodes"`
+	Devices []portal.Device               `json:"devices,omitempty"`
+}
+
+// GetBaseOrder 实现了 RichOrder 接口
+func (dto *DeploymentOrderDTO) GetBaseOrder() *portal.Order {
+	return dto.Order
+}
+
+// DeploymentOrderCreateDTO 定义了创建部署订单时需要的数据，目标设备和目标节点至少指定一项
+type DeploymentOrderCreateDTO struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	OpsJobID    int      `json:"opsJobId" binding:"required"`  // 执行部署的运维任务ID
+	ClusterID   int      `json:"clusterId" binding:"required"` // 目标集群ID
+	Devices     []int    `json:"devices"`                      // 目标设备ID列表
+	Nodes       []string `json:"nodes"`                        // 目标节点名称列表
+	CreatedBy   string   `json:"createdBy" binding:"required"`
+}
+
+// DeploymentOrderQueryDTO 定义了查询部署订单时支持的参数
+type DeploymentOrderQueryDTO struct {
+	Page      int    `form:"page"`
+	PageSize  int    `form:"pageSize"`
+	Status    string `form:"status"`
+	ClusterID int    `form:"clusterId"`
+	OpsJobID  int    `form:"opsJobId"`
+}
+
+// DeploymentOrderService 部署订单服务，订单状态随关联运维任务的事件推进
+type DeploymentOrderService struct {
+	db          *gorm.DB
+	baseService OrderService
+	logger      *zap.Logger
+}
+
+// NewDeploymentOrderService 创建部署订单服务，eventManager 不为空时注册运维任务事件处理器
+func NewDeploymentOrderService(db *gorm.DB, logger *zap.Logger, eventManager *events.EventManager) *DeploymentOrderService {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	s := &DeploymentOrderService{
+		db:          db,
+		baseService: NewOrderService(db),
+		logger:      logger,
+	}
+	if eventManager != nil {
+		s.RegisterEventHandlers(eventManager)
+	}
+	return s
+}
+
+// CreateOrder 创建部署订单。运维任务创建订单前已开始或结束时，订单立即同步到对应状态
+func (s *DeploymentOrderService) CreateOrder(ctx context.Context, dto DeploymentOrderCreateDTO) (*DeploymentOrderDTO, error) {
+	if dto.Name == "" || dto.OpsJobID <= 0 || dto.ClusterID <= 0 {
+		return nil, NewBadRequestError("订单名称、运维任务和目标集群不能为空")
+	}
+	if len(dto.Devices) == 0 && len(dto.Nodes) == 0 {
+		return nil, NewBadRequestError("目标设备和目标节点至少指定一项")
+	}
+
+	var job portal.OpsJob
+	if err := s.db.WithContext(ctx).Select("id", "status").Where("id = ? AND deleted = ?", dto.OpsJobID, EmptyString).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, NewBadRequestError(fmt.Sprintf("运维任务 %d 不存在", dto.OpsJobID))
+		}
+		return nil, err
+	}
+
+	order := &portal.Order{
+		Name:        dto.Name,
+		Description: dto.Description,
+		Type:        portal.OrderTypeDeployment,
+		Status:      portal.OrderStatusPending,
+		CreatedBy:   dto.CreatedBy,
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := NewOrderService(tx).CreateOrder(ctx, order); err != nil {
+			return err
+		}
+		detail := &portal.DeploymentOrderDetail{
+			OrderID:   order.ID,
+			OpsJobID:  dto.OpsJobID,
+			ClusterID: dto.ClusterID,
+			Nodes:     strings.Join(dto.Nodes, ","),
+			JobStatus: job.Status,
+		}
+		if err := tx.Create(detail).Error; err != nil {
+			return err
+		}
+		for _, deviceID := range dto.Devices {
+			orderDevice := &portal.OrderDevice{OrderID: order.ID, DeviceID: deviceID, Status: string(portal.OrderStatusPending)}
+			if err := tx.Create(orderDevice).Error; err != nil {
+				return err
+			}
+		}
+		// 预留目标设备，设备已被其他进行中的订单预留时回滚整个订单
+		return ReserveDevices(tx, order.ID, dto.Devices)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建部署订单失败: %w", err)
+	}
+
+	// 订单已提交，同步任务状态失败不影响创建结果，后续的运维任务事件会继续推进订单
+	if err := s.followJob(WithStatusSource(ctx, portal.OrderStatusSourceEvent), order.ID, job.ID, job.Status, ""); err != nil {
+		s.logger.Error("Failed to sync new deployment order with its ops job",
+			zap.Int("orderID", order.ID),
+			zap.Int("jobID", job.ID),
+			zap.String("jobStatus", job.Status),
+			zap.Error(err))
+	}
+	return s.GetOrder(ctx, order.ID)
+}
+
+// GetOrder 获取部署订单详情，包括目标节点和目标设备
+func (s *DeploymentOrderService) GetOrder(ctx context.Context, id int) (*DeploymentOrderDTO, error) {
+	db := s.db.WithContext(ctx)
+	if err := CheckOrderType(ctx, db, id, portal.OrderTypeDeployment); err != nil {
+		return nil, err
+	}
+
+	var order portal.Order
+	if err := db.First(&order, id).Error; err != nil {
+		return nil, HandleDBError(err, "订单", id)
+	}
+
+	var detail portal.DeploymentOrderDetail
+	if err := db.Where(fieldOrderID, id).First(&detail).Error; err != nil {
+		return nil, HandleDBError(err, "部署订单详情", id)
+	}
+
+	var devices []portal.Device
+	if err := db.Where("id IN (?)", db.Model(&portal.OrderDevice{}).Select("device_id").Where(fieldOrderID, id)).
+		Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	return &DeploymentOrderDTO{Order: &order, Details: &detail, Nodes: splitNodes(detail.Nodes), Devices: devices}, nil
+}
+
+// ListOrders 获取部署订单列表，query 为 *gin.Context 时按查询参数过滤
+func (s *DeploymentOrderService) ListOrders(ctx context.Context, query any) ([]*DeploymentOrderDTO, int, error) {
+	params := DeploymentOrderQueryDTO{Page: 1, PageSize: 10}
+	if err := BindListQuery(query, &params); err != nil {
+		return nil, 0, err
+	}
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+	if params.PageSize <= 0 {
+		params.PageSize = 10
+	}
+
+	db := s.db.WithContext(ctx)
+	orderQuery := db.Model(&portal.Order{}).Where(fieldType, portal.OrderTypeDeployment)
+	if params.Status != "" {
+		orderQuery = orderQuery.Where(fieldStatusEq, params.Status)
+	}
+	if params.ClusterID > 0 || params.OpsJobID > 0 {
+		detailQuery := db.Model(&portal.DeploymentOrderDetail{}).Select("order_id")
+		if params.ClusterID > 0 {
+			detailQuery = detailQuery.Where("cluster_id = ?", params.ClusterID)
+		}
+		if params.OpsJobID > 0 {
+			detailQuery = detailQuery.Where("ops_job_id = ?", params.OpsJobID)
+		}
+		orderQuery = orderQuery.Where("id IN (?)", detailQuery)
+	}
+
+	var total int64
+	if err := orderQuery.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var orders []portal.Order
+	if err := orderQuery.Order("id DESC").
+		Offset((params.Page - 1) * params.PageSize).
+		Limit(params.PageSize).
+		Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(orders) == 0 {
+		return []*DeploymentOrderDTO{}, int(total), nil
+	}
+
+	orderIDs := make([]int, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.ID
+	}
+	var details []portal.DeploymentOrderDetail
+	if err := db.Where("order_id IN (?)", orderIDs).Find(&details).Error; err != nil {
+		return nil, 0, err
+	}
+	detailsMap := make(map[int]*portal.DeploymentOrderDetail, len(details))
+	for i := range details {
+		detailsMap[details[i].OrderID] = &details[i]
+	}
+
+	result := make([]*DeploymentOrderDTO, len(orders))
+	for i := range orders {
+		dto := &DeploymentOrderDTO{Order: &orders[i], Details: detailsMap[orders[i].ID]}
+		if dto.Details != nil {
+			dto.Nodes = splitNodes(dto.Details.Nodes)
+		}
+		result[i] = dto
+	}
+	return result, int(total), nil
+}
+
+// UpdateOrderStatus 更新部署订单状态
+func (s *DeploymentOrderService) UpdateOrderStatus(ctx context.Context, id int, status string, executor string, reason string) error {
+	return s.baseService.UpdateOrderStatus(ctx, id, portal.OrderStatus(status), executor, reason)
+}
+
+// ProcessOrder 将部署订单状态更新为处理中
+func (s *DeploymentOrderService) ProcessOrder(ctx context.Context, id int, executor string) error {
+	return s.baseService.ProcessOrder(ctx, id, executor)
+}
+
+// CompleteOrder 将部署订单状态更新为已完成
+func (s *DeploymentOrderService) CompleteOrder(ctx context.Context, id int, executor string) error {
+	return s.baseService.CompleteOrder(ctx, id, executor)
+}
+
+// FailOrder 将部署订单状态更新为失败
+func (s *DeploymentOrderService) FailOrder(ctx context.Context, id int, executor string, reason string) error {
+	return s.baseService.FailOrder(ctx, id, executor, reason)
+}
+
+// CancelOrder 将部署订单状态更新为已取消
+func (s *DeploymentOrderService) CancelOrder(ctx context.Context, id int, executor string) error {
+	return s.baseService.CancelOrder(ctx, id, executor)
+}
+
+// RegisterEventHandlers 注册运维任务事件处理器，运维任务开始、完成或失败时推进关联的部署订单，运行进度更新时记录任务进度
+func (s *DeploymentOrderService) RegisterEventHandlers(eventManager *events.EventManager) {
+	for _, eventType := range []string{events.EventTypeOpsJobStarted, events.EventTypeOpsJobProgress, events.EventTypeOpsJobCompleted, events.EventTypeOpsJobFailed} {
+		events.RegisterGenericFunc(eventManager, events.RegisterGenericFuncRequest[events.OpsJobEventData]{
+			EventType:   eventType,
+			HandlerName: "deployment_order_" + eventType + "_handler",
+			HandlerFunc: s.HandleOpsJobEvent,
+		})
+	}
+}
+
+// HandleOpsJobEvent 处理运维任务事件：记录任务状态和进度，并推进关联的部署订单。
+// 事件可能乱序或重复投递：已结束的任务状态不再被覆盖，任务进度只增不减
+func (s *DeploymentOrderService) HandleOpsJobEvent(ctx context.Context, event *events.GenericEvent[events.OpsJobEventData]) error {
+	data := event.EventData
+	s.logger.Info("Handling ops job event for deployment orders",
+		zap.String("eventType", event.EventType),
+		zap.Int("jobID", data.JobID),
+		zap.String("status", data.Status))
+
+	var details []portal.DeploymentOrderDetail
+	if err := s.db.WithContext(ctx).Where("ops_job_id = ?", data.JobID).Find(&details).Error; err != nil {
+		return err
+	}
+
+	ctx = WithStatusSource(ctx, portal.OrderStatusSourceEvent)
+	var lastErr error
+	for _, detail := range details {
+		if err := s.db.WithContext(ctx).Model(&portal.DeploymentOrderDetail{}).Where(fieldID, detail.ID).
+			Updates(map[string]interface{}{
+				"job_status":   gorm.Expr("CASE WHEN job_status IN ? THEN job_status ELSE ? END", terminalJobStatuses, data.Status),
+				"job_progress": gorm.Expr("CASE WHEN job_progress > ? THEN job_progress ELSE ? END", data.Progress, data.Progress),
+			}).Error; err != nil {
+			lastErr = err
+			continue
+		}
+		// 进度更新只记录任务进度，订单已在任务开始时进入处理中
+		if event.EventType == events.EventTypeOpsJobProgress {
+			continue
+		}
+		if err := s.followJob(ctx, detail.OrderID, data.JobID, data.Status, data.Message); err != nil {
+			s.logger.Error("Failed to advance deployment order",
+				zap.Int("orderID", detail.OrderID),
+				zap.Int("jobID", data.JobID),
+				zap.Error(err))
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// terminalJobStatuses 运维任务的结束状态，任务结束后不再接受其他状态
+var terminalJobStatuses = []string{StatusCompleted, StatusFailed}
+
+// followJob 按运维任务状态推进部署订单：任务运行中时订单进入处理中，任务完成或失败时订单经处理中进入已完成或失败
+func (s *DeploymentOrderService) followJob(ctx context.Context, orderID int, jobID int, jobStatus string, message string) error {
+	var path []portal.OrderStatus
+	switch jobStatus {
+	case StatusRunning:
+		path = []portal.OrderStatus{portal.OrderStatusProcessing}
+	case StatusCompleted:
+		path = []portal.OrderStatus{portal.OrderStatusProcessing, portal.OrderStatusCompleted}
+	case StatusFailed:
+		path = []portal.OrderStatus{portal.OrderStatusProcessing, portal.OrderStatusFailed}
+	default:
+		return nil
+	}
+
+	reason := fmt.Sprintf("运维任务 %d %s", jobID, jobStatus)
+	if message != "" {
+		reason += "：" + message
+	}
+	for _, status := range path {
+		err := RetryOnConflict(func() error {
+			return s.baseService.UpdateOrderStatus(ctx, orderID, status, deploymentEventExecutor, reason)
+		})
+
+		var transitionErr *IllegalTransitionError
+		if errors.As(err, &transitionErr) {
+			if transitionErr.From == status {
+				continue
+			}
+			// 订单已被取消或已结束，不再跟随运维任务
+			s.logger.Info("Deployment order no longer follows its ops job, skip update",
+				zap.Int("orderID", orderID),
+				zap.String("orderStatus", string(transitionErr.From)),
+				zap.String("jobStatus", jobStatus))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitNodes 解析逗号分隔的节点名称
+func splitNodes(nodes string) []string {
+	if nodes == "" {
+		return []string{}
+	}
+	return strings.Split(nodes, ",")
+}
@@ -0,0 +1,211 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+	"navy-ng/server/portal/internal/service/events"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func createTestOpsJob(t *testing.T, db *gorm.DB, status string) *portal.OpsJob {
+	t.Helper()
+	job := &portal.OpsJob{Name: "deploy-app", Status: status}
+	require.NoError(t, db.Create(job).Error)
+	return job
+}
+
+func TestDeploymentOrderCreate(t *testing.T) {
+	db := newOrderTestDB(t)
+	s := NewDeploymentOrderService(db, nil, nil)
+	ctx := context.Background()
+	job := createTestOpsJob(t, db, service.StatusPending)
+	require.NoError(t, db.Create(&portal.Device{BaseModel: portal.BaseModel{ID: 1}, CICode: "node-1"}).Error)
+
+	created, err := s.CreateOrder(ctx, DeploymentOrderCreateDTO{Name: "部署", OpsJobID: job.ID, ClusterID: 3, Devices: []int{1}, Nodes: []string{"node-1", "node-2"}, CreatedBy: "ops"})
+	require.NoError(t, err)
+	assert.Equal(t, portal.OrderTypeDeployment, created.Type)
+	assert.Equal(t, portal.OrderStatusPending, created.Status)
+	assert.NotEmpty(t, created.OrderNumber)
+	assert.Equal(t, job.ID, created.Details.OpsJobID)
+	assert.Equal(t, []string{"node-1", "node-2"}, created.Nodes)
+	require.Len(t, created.Devices, 1)
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, err := s.CreateOrder(ctx, DeploymentOrderCreateDTO{Name: "部署", OpsJobID: job.ID, ClusterID: 3, CreatedBy: "ops"})
+		assert.True(t, service.IsBadRequest(err), "未指定设备和节点")
+
+		_, err = s.CreateOrder(ctx, DeploymentOrderCreateDTO{Name: "部署", OpsJobID: 9999, ClusterID: 3, Nodes: []string{"node-1"}, CreatedBy: "ops"})
+		assert.True(t, service.IsBadRequest(err), "运维任务不存在")
+	})
+
+	t.Run("devices reserved by an active order", func(t *testing.T) {
+		_, err := s.CreateOrder(ctx, DeploymentOrderCreateDTO{Name: "重复部署", OpsJobID: job.ID, ClusterID: 3, Devices: []int{1}, CreatedBy: "ops"})
+		require.True(t, service.IsConflict(err))
+
+		var orders int64
+		require.NoError(t, db.Model(&portal.Order{}).Where("name = ?", "重复部署").Count(&orders).Error)
+		assert.Zero(t, orders)
+
+		reserved, err := service.ReservedDeviceIDs(db, []int{1})
+		require.NoError(t, err)
+		assert.Equal(t, map[int]int{1: created.ID}, reserved)
+	})
+
+	t.Run("job already finished", func(t *testing.T) {
+		done := createTestOpsJob(t, db, service.StatusCompleted)
+		created, err := s.CreateOrder(ctx, DeploymentOrderCreateDTO{Name: "补录", OpsJobID: done.ID, ClusterID: 3, Nodes: []string{"node-1"}, CreatedBy: "ops"})
+		require.NoError(t, err)
+		assert.Equal(t, portal.OrderStatusCompleted, created.Status)
+	})
+
+	t.Run("sync failure after commit still returns the order", func(t *testing.T) {
+		// 进入处理中的守卫出错时，订单已创建，保持待处理并正常返回
+		RegisterOrderStateMachine(portal.OrderTypeDeployment, NewOrderStateMachine(map[portal.OrderStatus][]portal.OrderStatus{
+			portal.OrderStatusPending: {portal.OrderStatusProcessing},
+		}).Guard(portal.OrderStatusProcessing, func(tx *gorm.DB, order *portal.Order, to portal.OrderStatus) error {
+			return errors.New("guard unavailable")
+		}))
+		t.Cleanup(func() {
+			stateMachineMu.Lock()
+			defer stateMachineMu.Unlock()
+			delete(stateMachineRegistry, portal.OrderTypeDeployment)
+		})
+
+		running := createTestOpsJob(t, db, service.StatusRunning)
+		created, err := s.CreateOrder(ctx, DeploymentOrderCreateDTO{Name: "同步失败", OpsJobID: running.ID, ClusterID: 5, Nodes: []string{"node-1"}, CreatedBy: "ops"})
+		require.NoError(t, err)
+		assert.Equal(t, portal.OrderStatusPending, created.Status)
+		assert.Equal(t, service.StatusRunning, created.Details.JobStatus)
+	})
+
+	t.Run("list filters by cluster and ops job", func(t *testing.T) {
+		other := createTestOpsJob(t, db, service.StatusPending)
+		_, err := s.CreateOrder(ctx, DeploymentOrderCreateDTO{Name: "其他集群", OpsJobID: other.ID, ClusterID: 4, Nodes: []string{"node-9"}, CreatedBy: "ops"})
+		require.NoError(t, err)
+
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/orders/deployment?clusterId=3&status=pending", nil)
+		list, total, err := s.ListOrders(ctx, c)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, list, 1)
+		assert.Equal(t, created.ID, list[0].ID)
+
+		c.Request = httptest.NewRequest("GET", "/orders/deployment?opsJobId="+strconv.Itoa(other.ID), nil)
+		list, total, err = s.ListOrders(ctx, c)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, []string{"node-9"}, list[0].Nodes)
+	})
+
+	t.Run("other order types are invisible", func(t *testing.T) {
+		general := createTestOrder(t, db, portal.OrderTypeGeneral, portal.OrderStatusPending)
+		_, err := s.GetOrder(ctx, general.ID)
+		assert.True(t, service.IsNotFound(err))
+	})
+}
+
+func TestDeploymentOrderFollowsOpsJobEvents(t *testing.T) {
+	db := newOrderTestDB(t)
+	em := events.NewEventManager(zap.NewNop(), &events.Config{Timeout: time.Second, BufferSize: 10, Async: false})
+	s := NewDeploymentOrderService(db, nil, em)
+	ctx := context.Background()
+
+	newOrder := func(job *portal.OpsJob) int {
+		created, err := s.CreateOrder(ctx, DeploymentOrderCreateDTO{Name: "部署", OpsJobID: job.ID, ClusterID: 1, Nodes: []string{"node-1"}, CreatedBy: "ops"})
+		require.NoError(t, err)
+		return created.ID
+	}
+	publish := func(eventType string, data events.OpsJobEventData) {
+		require.NoError(t, events.PublishOpsJobEvent(em, ctx, eventType, data))
+	}
+
+	t.Run("completed", func(t *testing.T) {
+		job := createTestOpsJob(t, db, service.StatusPending)
+		id := newOrder(job)
+
+		publish(events.EventTypeOpsJobStarted, events.OpsJobEventData{JobID: job.ID, Status: service.StatusRunning, Progress: 10})
+		assert.Equal(t, portal.OrderStatusProcessing, orderStatus(t, db, id))
+
+		// 运行进度只更新任务进度，不重复推进订单
+		publish(events.EventTypeOpsJobProgress, events.OpsJobEventData{JobID: job.ID, Status: service.StatusRunning, Progress: 60})
+		assert.Equal(t, portal.OrderStatusProcessing, orderStatus(t, db, id))
+		running, err := s.GetOrder(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, 60, running.Details.JobProgress)
+
+		publish(events.EventTypeOpsJobCompleted, events.OpsJobEventData{JobID: job.ID, Status: service.StatusCompleted, Progress: 100})
+		assert.Equal(t, portal.OrderStatusCompleted, orderStatus(t, db, id))
+
+		detail, err := s.GetOrder(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, service.StatusCompleted, detail.Details.JobStatus)
+		assert.Equal(t, 100, detail.Details.JobProgress)
+
+		var history []portal.OrderStatusHistory
+		require.NoError(t, db.Where("order_id = ?", id).Order("id").Find(&history).Error)
+		require.Len(t, history, 3)
+		// 第一条为订单创建记录
+		assert.Empty(t, history[0].FromStatus)
+		assert.Equal(t, portal.OrderStatusSourceAPI, history[0].Source)
+		assert.Equal(t, portal.OrderStatusSourceEvent, history[2].Source)
+	})
+
+	t.Run("out of order events keep the latest job state", func(t *testing.T) {
+		job := createTestOpsJob(t, db, service.StatusPending)
+		id := newOrder(job)
+
+		publish(events.EventTypeOpsJobProgress, events.OpsJobEventData{JobID: job.ID, Status: service.StatusRunning, Progress: 60})
+		publish(events.EventTypeOpsJobProgress, events.OpsJobEventData{JobID: job.ID, Status: service.StatusRunning, Progress: 30})
+		detail, err := s.GetOrder(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, 60, detail.Details.JobProgress, "进度不回退")
+
+		publish(events.EventTypeOpsJobCompleted, events.OpsJobEventData{JobID: job.ID, Status: service.StatusCompleted, Progress: 100})
+		// 迟到的开始和进度事件不覆盖已结束的任务状态，也不改变已完成的订单
+		publish(events.EventTypeOpsJobStarted, events.OpsJobEventData{JobID: job.ID, Status: service.StatusRunning, Progress: 10})
+		publish(events.EventTypeOpsJobProgress, events.OpsJobEventData{JobID: job.ID, Status: service.StatusRunning, Progress: 80})
+
+		detail, err = s.GetOrder(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, service.StatusCompleted, detail.Details.JobStatus)
+		assert.Equal(t, 100, detail.Details.JobProgress)
+		assert.Equal(t, portal.OrderStatusCompleted, detail.Status)
+	})
+
+	t.Run("failed without started event", func(t *testing.T) {
+		job := createTestOpsJob(t, db, service.StatusPending)
+		id := newOrder(job)
+
+		publish(events.EventTypeOpsJobFailed, events.OpsJobEventData{JobID: job.ID, Status: service.StatusFailed, Message: "镜像拉取失败"})
+		assert.Equal(t, portal.OrderStatusFailed, orderStatus(t, db, id))
+
+		var order portal.Order
+		require.NoError(t, db.First(&order, id).Error)
+		assert.Contains(t, order.FailureReason, "镜像拉取失败")
+	})
+
+	t.Run("cancelled orders stop following", func(t *testing.T) {
+		job := createTestOpsJob(t, db, service.StatusPending)
+		id := newOrder(job)
+		require.NoError(t, s.CancelOrder(ctx, id, "ops"))
+
+		require.NoError(t, s.HandleOpsJobEvent(ctx, &events.GenericEvent[events.OpsJobEventData]{
+			EventType: events.EventTypeOpsJobCompleted,
+			EventData: events.OpsJobEventData{JobID: job.ID, Status: service.StatusCompleted, Progress: 100},
+		}))
+		assert.Equal(t, portal.OrderStatusCancelled, orderStatus(t, db, id))
+	})
+}
@@ -6,6 +6,8 @@ import (
 	"navy-ng/models/portal"
 	"time"
 
+	. "navy-ng/server/portal/internal/service"
+
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -64,17 +66,31 @@ type MaintenanceOrderDetailDTO struct {
 	Devices           []portal.Device                `json:"devices"`
 }
 
+// GetBaseOrder 实现了 RichOrder 接口
+func (dto *MaintenanceOrderDetailDTO) GetBaseOrder() *portal.Order {
+	return dto.Order
+}
+
+// MaintenanceOrderQueryDTO 定义了查询维护订单时支持的参数
+type MaintenanceOrderQueryDTO struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"pageSize"`
+	Status   string `form:"status"`
+}
+
 // MaintenanceOrderService 维护订单服务接口
 type MaintenanceOrderService struct {
-	db     *gorm.DB
-	logger *zap.Logger
+	db          *gorm.DB
+	baseService OrderService // 状态更新经由通用订单服务，按维护订单状态机校验
+	logger      *zap.Logger
 }
 
 // NewMaintenanceOrderService 创建维护订单服务
 func NewMaintenanceOrderService(db *gorm.DB, logger *zap.Logger) *MaintenanceOrderService {
 	return &MaintenanceOrderService{
-		db:     db,
-		logger: logger,
+		db:          db,
+		baseService: NewOrderService(db),
+		logger:      logger,
 	}
 }
 
@@ -90,13 +106,8 @@ func (s *MaintenanceOrderService) CreateOrder(ctx context.Context, dto Maintenan
 		CreatedBy:   dto.CreatedBy,
 	}
 
-	if err := s.db.Create(order).Error; err != nil {
-		return nil, fmt.Errorf("创建订单失败: %w", err)
-	}
-
-	// 创建维护订单详情
+	// 维护订单详情，订单ID在订单创建后回填
 	maintenanceDetail := &portal.MaintenanceOrderDetail{
-		OrderID:              order.ID,
 		ClusterID:            dto.ClusterID,
 		MaintenanceStartTime: (*portal.NavyTime)(dto.MaintenanceStartTime),
 		MaintenanceEndTime:   (*portal.NavyTime)(dto.MaintenanceEndTime),
@@ -107,8 +118,33 @@ func (s *MaintenanceOrderService) CreateOrder(ctx context.Context, dto Maintenan
 		Comments:             dto.Comments,
 	}
 
-	if err := s.db.Create(maintenanceDetail).Error; err != nil {
-		return nil, fmt.Errorf("创建维护详情失败: %w", err)
+	// 订单、维护详情、设备关联和设备预留在同一事务中创建，设备已被其他进行中的订单预留时整体回滚
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(order).Error; err != nil {
+			return fmt.Errorf("创建订单失败: %w", err)
+		}
+		if err := RecordOrderCreated(ctx, tx, order); err != nil {
+			return fmt.Errorf("记录订单创建失败: %w", err)
+		}
+
+		maintenanceDetail.OrderID = order.ID
+		if err := tx.Create(maintenanceDetail).Error; err != nil {
+			return fmt.Errorf("创建维护详情失败: %w", err)
+		}
+
+		// 记录订单关联的设备
+		for _, deviceID := range dto.Devices {
+			orderDevice := &portal.OrderDevice{OrderID: order.ID, DeviceID: deviceID, Status: string(portal.OrderStatusPending)}
+			if err := tx.Create(orderDevice).Error; err != nil {
+				return fmt.Errorf("创建订单设备关联失败: %w", err)
+			}
+		}
+
+		// 预留设备
+		return ReserveDevices(tx, order.ID, dto.Devices)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 获取设备信息
@@ -126,17 +162,58 @@ func (s *MaintenanceOrderService) CreateOrder(ctx context.Context, dto Maintenan
 	}, nil
 }
 
-// ListOrders 列出维护订单
-func (s *MaintenanceOrderService) ListOrders(ctx context.Context, filter interface{}) ([]*MaintenanceOrderDetailDTO, int64, error) {
+// GetOrder 获取维护订单详情，包括维护详情和关联设备
+func (s *MaintenanceOrderService) GetOrder(ctx context.Context, id int) (*MaintenanceOrderDetailDTO, error) {
+	db := s.db.WithContext(ctx)
+	if err := CheckOrderType(ctx, db, id, portal.OrderTypeMaintenance); err != nil {
+		return nil, err
+	}
+
+	var order portal.Order
+	if err := db.First(&order, id).Error; err != nil {
+		return nil, HandleDBError(err, "订单", id)
+	}
+
+	var maintenanceDetail portal.MaintenanceOrderDetail
+	if err := db.Where("order_id = ?", id).First(&maintenanceDetail).Error; err != nil {
+		return nil, HandleDBError(err, "维护订单详情", id)
+	}
+
+	var devices []portal.Device
+	if err := db.Where("id IN (?)", db.Model(&portal.OrderDevice{}).Select("device_id").Where("order_id = ?", id)).
+		Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	return &MaintenanceOrderDetailDTO{
+		Order:             &order,
+		MaintenanceDetail: &maintenanceDetail,
+		Devices:           devices,
+	}, nil
+}
+
+// ListOrders 列出维护订单，filter 为 *gin.Context 时按查询参数过滤并分页，为 nil 时返回全部维护订单
+func (s *MaintenanceOrderService) ListOrders(ctx context.Context, filter interface{}) ([]*MaintenanceOrderDetailDTO, int, error) {
+	var params MaintenanceOrderQueryDTO
+	if err := BindListQuery(filter, &params); err != nil {
+		return nil, 0, err
+	}
+
 	var orders []portal.Order
 	var total int64
 
-	query := s.db.Where("type = ?", portal.OrderTypeMaintenance)
+	query := s.db.WithContext(ctx).Model(&portal.Order{}).Where("type = ?", portal.OrderTypeMaintenance)
+	if params.Status != "" {
+		query = query.Where(fieldStatusEq, params.Status)
+	}
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	if err := query.Find(&orders).Error; err != nil {
+	if params.Page > 0 && params.PageSize > 0 {
+		query = query.Offset((params.Page - 1) * params.PageSize).Limit(params.PageSize)
+	}
+	if err := query.Order("id DESC").Find(&orders).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -157,31 +234,62 @@ func (s *MaintenanceOrderService) ListOrders(ctx context.Context, filter interfa
 		})
 	}
 
-	return results, total, nil
+	return results, int(total), nil
+}
+
+// UpdateOrderStatus 更新维护订单状态，按维护订单状态机校验
+func (s *MaintenanceOrderService) UpdateOrderStatus(ctx context.Context, id int, status string, executor string, reason string) error {
+	return s.baseService.UpdateOrderStatus(ctx, id, portal.OrderStatus(status), executor, reason)
+}
+
+// ProcessOrder 将维护订单状态更新为处理中
+func (s *MaintenanceOrderService) ProcessOrder(ctx context.Context, id int, executor string) error {
+	return s.baseService.ProcessOrder(ctx, id, executor)
+}
+
+// CompleteOrder 将维护订单状态更新为已完成
+func (s *MaintenanceOrderService) CompleteOrder(ctx context.Context, id int, executor string) error {
+	return s.baseService.CompleteOrder(ctx, id, executor)
+}
+
+// FailOrder 将维护订单状态更新为失败
+func (s *MaintenanceOrderService) FailOrder(ctx context.Context, id int, executor string, reason string) error {
+	return s.baseService.FailOrder(ctx, id, executor, reason)
 }
 
-// ConfirmMaintenance 确认维护
+// CancelOrder 将维护订单状态更新为已取消
+func (s *MaintenanceOrderService) CancelOrder(ctx context.Context, id int, executor string) error {
+	return s.baseService.CancelOrder(ctx, id, executor)
+}
+
+// ConfirmMaintenance 确认维护，订单进入已安排维护状态
 func (s *MaintenanceOrderService) ConfirmMaintenance(ctx context.Context, orderID int, operatorID string) error {
-	return s.db.Model(&portal.Order{}).Where("id = ?", orderID).Updates(map[string]interface{}{
-		"status":   "confirmed",
-		"executor": operatorID,
-	}).Error
+	return s.baseService.UpdateOrderStatus(ctx, orderID, statusScheduledMaintenance, operatorID, "")
 }
 
 // StartMaintenance 开始维护
 func (s *MaintenanceOrderService) StartMaintenance(ctx context.Context, orderID int, operatorID string) error {
-	return s.db.Model(&portal.Order{}).Where("id = ?", orderID).Updates(map[string]interface{}{
-		"status":   "in_progress",
-		"executor": operatorID,
-	}).Error
+	return s.baseService.UpdateOrderStatus(ctx, orderID, statusMaintenanceInProgress, operatorID, "")
 }
 
-// ExecuteUncordon 执行Uncordon操作
+// ExecuteUncordon 执行Uncordon操作，待处理的Uncordon订单先进入处理中再完成，两次状态转换在同一事务中
 func (s *MaintenanceOrderService) ExecuteUncordon(ctx context.Context, orderID int, operatorID string) error {
-	return s.db.Model(&portal.Order{}).Where("id = ?", orderID).Updates(map[string]interface{}{
-		"status":   portal.OrderStatusCompleted,
-		"executor": operatorID,
-	}).Error
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order portal.Order
+		if err := tx.Select("id", "status").First(&order, orderID).Error; err != nil {
+			return HandleDBError(err, "订单", orderID)
+		}
+		if order.Status == portal.OrderStatusPending {
+			if err := updateOrderStatusTx(ctx, tx, orderID, portal.OrderStatusProcessing, operatorID, ""); err != nil {
+				return err
+			}
+			// 客户端期望的版本对应转换前的订单，进入处理中后版本号加1
+			if expected, ok := expectedVersionFromContext(ctx); ok {
+				ctx = WithExpectedVersion(ctx, expected+1)
+			}
+		}
+		return updateOrderStatusTx(ctx, tx, orderID, portal.OrderStatusCompleted, operatorID, "")
+	})
 }
 
 // CompleteMaintenance 完成维护
@@ -193,7 +301,7 @@ func (s *MaintenanceOrderService) CompleteMaintenance(ctx context.Context, exter
 	}
 
 	// 更新订单状态为完成
-	if err := s.db.Model(&portal.Order{}).Where("id = ?", maintenanceDetail.OrderID).Update("status", portal.OrderStatusCompleted).Error; err != nil {
+	if err := s.baseService.UpdateOrderStatus(ctx, maintenanceDetail.OrderID, portal.OrderStatusCompleted, "external_system", message); err != nil {
 		return nil, fmt.Errorf("更新订单状态失败: %w", err)
 	}
 
@@ -368,7 +476,8 @@ func (s *MaintenanceServiceV2) GetPendingMaintenanceRequests() ([]OrderDetailDTO
 	for _, order := range orders {
 		// 只返回待确认和已安排维护的订单
 		if order.Order.Status == portal.OrderStatusPending ||
-			order.Order.Status == "scheduled_for_maintenance" {
+			order.Order.Status == statusPendingConfirmation ||
+			order.Order.Status == statusScheduledMaintenance {
 
 			// 转换为原有的OrderDetailDTO格式
 			orderDetailDTO := OrderDetailDTO{
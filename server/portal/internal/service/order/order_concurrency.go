@@ -0,0 +1,55 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "navy-ng/server/portal/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// 版本冲突重试参数
+const (
+	maxConflictRetries   = 3
+	conflictRetryBackoff = 20 * time.Millisecond
+)
+
+// expectedVersionKey 客户端期望的订单版本在 context 中的键
+type expectedVersionKey struct{}
+
+// WithExpectedVersion 在 context 中设置客户端期望的订单版本（If-Match），
+// 更新状态时订单当前版本与之不一致则返回前置条件失败错误
+func WithExpectedVersion(ctx context.Context, version int) context.Context {
+	return context.WithValue(ctx, expectedVersionKey{}, version)
+}
+
+// expectedVersionFromContext 获取 context 中客户端期望的订单版本
+func expectedVersionFromContext(ctx context.Context) (int, bool) {
+	version, ok := ctx.Value(expectedVersionKey{}).(int)
+	return version, ok
+}
+
+// nextVersion 版本号加1的更新表达式
+func nextVersion() interface{} {
+	return gorm.Expr(fieldVersion + " + 1")
+}
+
+// newVersionConflictError 创建订单版本冲突错误
+func newVersionConflictError(resource string, id int) error {
+	return NewConflictError(fmt.Sprintf("%s %d 已被其他操作修改，请刷新后重试", resource, id))
+}
+
+// RetryOnConflict 执行 fn，遇到版本冲突时重新执行，最多尝试 maxConflictRetries 次。
+// fn 每次执行都应重新读取最新数据
+func RetryOnConflict(fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= maxConflictRetries; attempt++ {
+		if err = fn(); !IsConflict(err) || attempt == maxConflictRetries {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * conflictRetryBackoff)
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateOrderStatusVersioning(t *testing.T) {
+	db := newOrderTestDB(t)
+	s := NewOrderService(db)
+	ctx := context.Background()
+
+	currentVersion := func(id int) int {
+		var order portal.Order
+		require.NoError(t, db.Select("version").First(&order, id).Error)
+		return order.Version
+	}
+
+	t.Run("each update bumps the version", func(t *testing.T) {
+		order := createTestOrder(t, db, portal.OrderTypeGeneral, portal.OrderStatusPending)
+		assert.Equal(t, 0, currentVersion(order.ID))
+		require.NoError(t, s.ProcessOrder(ctx, order.ID, "tester"))
+		assert.Equal(t, 1, currentVersion(order.ID))
+		require.NoError(t, s.CompleteOrder(ctx, order.ID, "tester"))
+		assert.Equal(t, 2, currentVersion(order.ID))
+	})
+
+	t.Run("matching expected version is applied", func(t *testing.T) {
+		order := createTestOrder(t, db, portal.OrderTypeGeneral, portal.OrderStatusPending)
+		require.NoError(t, s.ProcessOrder(WithExpectedVersion(ctx, 0), order.ID, "tester"))
+		assert.Equal(t, portal.OrderStatusProcessing, orderStatus(t, db, order.ID))
+	})
+
+	t.Run("stale expected version fails the precondition", func(t *testing.T) {
+		order := createTestOrder(t, db, portal.OrderTypeGeneral, portal.OrderStatusPending)
+		require.NoError(t, s.ProcessOrder(ctx, order.ID, "tester"))
+
+		err := s.CancelOrder(WithExpectedVersion(ctx, 0), order.ID, "tester")
+		require.Error(t, err)
+		assert.True(t, service.IsPreconditionFailed(err))
+		assert.Equal(t, portal.OrderStatusProcessing, orderStatus(t, db, order.ID))
+		assert.Equal(t, 1, currentVersion(order.ID))
+	})
+}
+
+func TestRetryOnConflict(t *testing.T) {
+	t.Run("retries conflicts until success", func(t *testing.T) {
+		attempts := 0
+		err := RetryOnConflict(func() error {
+			attempts++
+			if attempts < 2 {
+				return newVersionConflictError("订单", 1)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("gives up after the retry limit", func(t *testing.T) {
+		attempts := 0
+		err := RetryOnConflict(func() error {
+			attempts++
+			return newVersionConflictError("订单", 1)
+		})
+		assert.True(t, service.IsConflict(err))
+		assert.Equal(t, maxConflictRetries, attempts)
+	})
+
+	t.Run("other errors are returned immediately", func(t *testing.T) {
+		attempts := 0
+		err := RetryOnConflict(func() error {
+			attempts++
+			return errors.New("boom")
+		})
+		assert.EqualError(t, err, "boom")
+		assert.Equal(t, 1, attempts)
+	})
+}
@@ -15,7 +15,6 @@ import (
 const (
 	// 预加载字段
 	preloadElasticScalingDetail = "ElasticScalingDetail"
-	preloadDevices              = "Devices"
 
 	// 数据库字段
 	fieldOrderNumber    = "order_number = ?"
@@ -32,6 +31,7 @@ const (
 	fieldExecutionTime  = "execution_time"
 	fieldCompletionTime = "completion_time"
 	fieldFailureReason  = "failure_reason"
+	fieldVersion        = "version"
 
 	// 默认消息
 	msgOrderCancelled = "订单已取消"
@@ -79,6 +79,7 @@ type OrderService interface {
 	UpdateOrderStatus(ctx context.Context, id int, status portal.OrderStatus, executor string, reason string) error
 	ListOrders(ctx context.Context, query OrderQuery) ([]portal.Order, int, error)
 	DeleteOrder(ctx context.Context, id int) error
+	GetOrderTimeline(ctx context.Context, id int) ([]OrderTimelineEntryDTO, error)
 
 	// 订单处理流程
 	ProcessOrder(ctx context.Context, id int, executor string) error
@@ -110,7 +111,12 @@ func (s *orderServiceImpl) CreateOrder(ctx context.Context, order *portal.Order)
 		order.Status = portal.OrderStatusPending
 	}
 
-	return s.db.WithContext(ctx).Create(order).Error
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		return RecordOrderCreated(ctx, tx, order)
+	})
 }
 
 // GetOrderByID 根据ID获取订单
@@ -118,7 +124,6 @@ func (s *orderServiceImpl) GetOrderByID(ctx context.Context, id int) (*portal.Or
 	var order portal.Order
 	err := s.db.WithContext(ctx).
 		Preload(preloadElasticScalingDetail).
-		Preload(preloadDevices).
 		First(&order, id).Error
 
 	if err != nil {
@@ -133,7 +138,6 @@ func (s *orderServiceImpl) GetOrderByNumber(ctx context.Context, orderNumber str
 	var order portal.Order
 	err := s.db.WithContext(ctx).
 		Preload(preloadElasticScalingDetail).
-		Preload(preloadDevices).
 		Where(fieldOrderNumber, orderNumber).First(&order).Error
 
 	if err != nil {
@@ -145,6 +149,13 @@ func (s *orderServiceImpl) GetOrderByNumber(ctx context.Context, orderNumber str
 
 // UpdateOrderStatus 更新订单状态
 func (s *orderServiceImpl) UpdateOrderStatus(ctx context.Context, id int, status portal.OrderStatus, executor string, reason string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return updateOrderStatusTx(ctx, tx, id, status, executor, reason)
+	})
+}
+
+// updateOrderStatusTx 在给定事务中更新订单状态，供需要在同一事务中连续转换状态的调用方复用
+func updateOrderStatusTx(ctx context.Context, tx *gorm.DB, id int, status portal.OrderStatus, executor string, reason string) error {
 	updates := map[string]interface{}{
 		fieldStatus:    status,
 		fieldExecutor:  executor,
@@ -153,7 +164,7 @@ func (s *orderServiceImpl) UpdateOrderStatus(ctx context.Context, id int, status
 
 	// 根据状态设置相应的时间字段
 	switch status {
-	case portal.OrderStatusProcessing:
+	case portal.OrderStatusProcessing, statusMaintenanceInProgress:
 		updates[fieldExecutionTime] = time.Now()
 	case portal.OrderStatusCompleted:
 		updates[fieldCompletionTime] = time.Now()
@@ -184,7 +195,49 @@ func (s *orderServiceImpl) UpdateOrderStatus(ctx context.Context, id int, status
 		// portal.OrderStatusPending 状态不需要特殊处理，只更新基本字段
 	}
 
-	return s.db.WithContext(ctx).Model(&portal.Order{}).Where(fieldID, id).Updates(updates).Error
+	// 按订单类型的状态机校验转换并写入状态变更记录；订单取消、失败、忽略或结束后释放设备预留，重新进入活跃状态时重新预留，与状态更新在同一事务中。
+	// 更新以读取到的版本号为条件，期间订单被其他操作修改时返回版本冲突错误
+	var order portal.Order
+	if err := tx.Select("id", "type", fieldStatus, fieldVersion).First(&order, id).Error; err != nil {
+		return HandleDBError(err, "订单", id)
+	}
+	if expected, ok := expectedVersionFromContext(ctx); ok && expected != order.Version {
+		return NewPreconditionFailedError(fmt.Sprintf("订单 %d 的版本已变更（当前版本 %d），请刷新后重试", id, order.Version))
+	}
+	if err := GetOrderStateMachine(order.Type).Check(tx, &order, status); err != nil {
+		return err
+	}
+
+	updates[fieldVersion] = nextVersion()
+	result := tx.Model(&portal.Order{}).Where(fieldID+" AND "+fieldVersion+" = ?", id, order.Version).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return newVersionConflictError("订单", id)
+	}
+	if err := RecordStatusHistory(ctx, tx, &portal.OrderStatusHistory{
+		OrderID:    id,
+		FromStatus: string(order.Status),
+		ToStatus:   string(status),
+		Executor:   executor,
+		Reason:     reason,
+	}); err != nil {
+		return err
+	}
+	if !portal.IsDeviceReservationActive(status) {
+		return ReleaseDeviceReservations(tx, id)
+	}
+	if portal.IsDeviceReservationActive(order.Status) {
+		return nil
+	}
+
+	// 失败、取消或忽略的订单重新进入待处理时重新预留订单设备，设备已被其他订单占用时回滚本次状态变更
+	var deviceIDs []int
+	if err := tx.Model(&portal.OrderDevice{}).Where(fieldOrderID, id).Pluck("device_id", &deviceIDs).Error; err != nil {
+		return err
+	}
+	return ReserveDevices(tx, id, deviceIDs)
 }
 
 // ListOrders 获取订单列表
@@ -227,7 +280,6 @@ func (s *orderServiceImpl) ListOrders(ctx context.Context, query OrderQuery) ([]
 
 	// 预加载关联数据
 	err := db.Preload(preloadElasticScalingDetail).
-		Preload(preloadDevices).
 		Order(OrderByCreatedAtDesc).
 		Find(&orders).Error
 
@@ -0,0 +1,205 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"navy-ng/models/portal"
+	. "navy-ng/server/portal/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// 维护订单专用状态
+const (
+	statusPendingConfirmation   = portal.OrderStatus(portal.MaintenanceStatusPendingConfirmation)
+	statusScheduledMaintenance  = portal.OrderStatus(portal.MaintenanceStatusScheduled)
+	statusMaintenanceInProgress = portal.OrderStatus(portal.MaintenanceStatusInProgress)
+)
+
+// 弹性伸缩退池订单的操作类型，只有退池订单可以进入归还流程
+const actionTypePoolExit = "pool_exit"
+
+// IllegalTransitionError 非法的订单状态转换
+type IllegalTransitionError struct {
+	OrderID   int
+	OrderType portal.OrderType
+	From      portal.OrderStatus
+	To        portal.OrderStatus
+	Reason    string // 守卫拒绝时的原因，状态表不允许时为空
+}
+
+// Error 实现 error 接口
+func (e *IllegalTransitionError) Error() string {
+	msg := fmt.Sprintf("订单 %d（%s）不允许从 %s 转换为 %s", e.OrderID, e.OrderType, e.From, e.To)
+	if e.Reason != "" {
+		msg += "：" + e.Reason
+	}
+	return msg
+}
+
+// IsIllegalTransition 判断是否为非法的订单状态转换错误
+func IsIllegalTransition(err error) bool {
+	var target *IllegalTransitionError
+	return errors.As(err, &target)
+}
+
+// TransitionGuard 状态转换守卫，在状态更新的事务中执行，返回错误时拒绝转换。
+// 业务上不允许转换时应返回 RejectTransition 构造的错误，其他错误按原样返回给调用方
+type TransitionGuard func(tx *gorm.DB, order *portal.Order, to portal.OrderStatus) error
+
+// RejectTransition 构造守卫拒绝转换的错误
+func RejectTransition(order *portal.Order, to portal.OrderStatus, reason string) error {
+	return &IllegalTransitionError{OrderID: order.ID, OrderType: order.Type, From: order.Status, To: to, Reason: reason}
+}
+
+// OrderStateMachine 订单状态机：声明每个状态允许转换到的目标状态，以及进入目标状态前执行的守卫
+type OrderStateMachine struct {
+	transitions map[portal.OrderStatus][]portal.OrderStatus
+	guards      map[portal.OrderStatus][]TransitionGuard // 目标状态 -> 守卫
+}
+
+// NewOrderStateMachine 根据状态转换表创建状态机
+func NewOrderStateMachine(transitions map[portal.OrderStatus][]portal.OrderStatus) *OrderStateMachine {
+	return &OrderStateMachine{
+		transitions: transitions,
+		guards:      make(map[portal.OrderStatus][]TransitionGuard),
+	}
+}
+
+// Guard 为进入目标状态的转换注册守卫，按注册顺序执行
+func (m *OrderStateMachine) Guard(to portal.OrderStatus, guard TransitionGuard) *OrderStateMachine {
+	m.guards[to] = append(m.guards[to], guard)
+	return m
+}
+
+// CanTransition 判断状态转换表是否允许从 from 转换为 to
+func (m *OrderStateMachine) CanTransition(from, to portal.OrderStatus) bool {
+	for _, allowed := range m.transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedTransitions 返回 from 状态允许转换到的目标状态
+func (m *OrderStateMachine) AllowedTransitions(from portal.OrderStatus) []portal.OrderStatus {
+	return append([]portal.OrderStatus(nil), m.transitions[from]...)
+}
+
+// Check 校验订单转换为 to 状态是否合法：先检查状态转换表，再依次执行守卫
+func (m *OrderStateMachine) Check(tx *gorm.DB, order *portal.Order, to portal.OrderStatus) error {
+	if !m.CanTransition(order.Status, to) {
+		return &IllegalTransitionError{OrderID: order.ID, OrderType: order.Type, From: order.Status, To: to}
+	}
+	for _, guard := range m.guards[to] {
+		if err := guard(tx, order, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultStateMachine 通用订单状态机，未注册状态机的订单类型（通用、部署等）使用
+var defaultStateMachine = NewOrderStateMachine(map[portal.OrderStatus][]portal.OrderStatus{
+	portal.OrderStatusPending:    {portal.OrderStatusProcessing, portal.OrderStatusCancelled, portal.OrderStatusIgnored},
+	portal.OrderStatusProcessing: {portal.OrderStatusCompleted, portal.OrderStatusFailed, portal.OrderStatusCancelled},
+	portal.OrderStatusFailed:     {portal.OrderStatusPending},
+	portal.OrderStatusCancelled:  {portal.OrderStatusPending},
+	portal.OrderStatusIgnored:    {portal.OrderStatusPending},
+})
+
+// newElasticScalingStateMachine 弹性伸缩订单状态机，退池订单处理后可进入归还流程
+func newElasticScalingStateMachine() *OrderStateMachine {
+	returnBranch := func(tx *gorm.DB, order *portal.Order, to portal.OrderStatus) error {
+		var detail portal.ElasticScalingOrderDetail
+		if err := tx.Select("action_type").Where("order_id = ?", order.ID).First(&detail).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return RejectTransition(order, to, "订单缺少弹性伸缩详情")
+			}
+			return err
+		}
+		if detail.ActionType != actionTypePoolExit {
+			return RejectTransition(order, to, "只有退池订单可以进入归还流程")
+		}
+		return nil
+	}
+
+	return NewOrderStateMachine(map[portal.OrderStatus][]portal.OrderStatus{
+		portal.OrderStatusPending:         {portal.OrderStatusProcessing, portal.OrderStatusCancelled, portal.OrderStatusIgnored},
+		portal.OrderStatusProcessing:      {portal.OrderStatusReturning, portal.OrderStatusNoReturn, portal.OrderStatusCompleted, portal.OrderStatusFailed, portal.OrderStatusCancelled},
+		portal.OrderStatusReturning:       {portal.OrderStatusReturnCompleted, portal.OrderStatusNoReturn, portal.OrderStatusFailed, portal.OrderStatusCancelled},
+		portal.OrderStatusReturnCompleted: {portal.OrderStatusCompleted},
+		portal.OrderStatusNoReturn:        {portal.OrderStatusCompleted},
+		portal.OrderStatusFailed:          {portal.OrderStatusPending},
+		portal.OrderStatusCancelled:       {portal.OrderStatusPending},
+		portal.OrderStatusIgnored:         {portal.OrderStatusPending},
+	}).
+		Guard(portal.OrderStatusReturning, returnBranch).
+		Guard(portal.OrderStatusNoReturn, returnBranch)
+}
+
+// maintenanceFreezeGuard 维护订单所在集群处于变更冻结期时，拒绝确认维护和开始维护（Cordon）
+func maintenanceFreezeGuard(tx *gorm.DB, order *portal.Order, to portal.OrderStatus) error {
+	var detail portal.MaintenanceOrderDetail
+	if err := tx.Select("cluster_id").Where("order_id = ?", order.ID).First(&detail).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return RejectTransition(order, to, "订单缺少维护详情")
+		}
+		return err
+	}
+
+	windows, err := NewFreezeCalendarService(tx).ActiveFreezeWindows(tx.Statement.Context, &detail.ClusterID, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(windows) > 0 {
+		return RejectTransition(order, to, fmt.Sprintf("集群处于变更冻结期（%s）", FreezeWindowNames(windows)))
+	}
+	return nil
+}
+
+// newMaintenanceStateMachine 设备维护订单状态机：维护请求经确认、安排后进入维护中，
+// 确认和开始维护时集群不能处于变更冻结期；Uncordon 订单没有确认环节，直接处理
+func newMaintenanceStateMachine() *OrderStateMachine {
+	return NewOrderStateMachine(map[portal.OrderStatus][]portal.OrderStatus{
+		portal.OrderStatusPending:    {statusPendingConfirmation, statusScheduledMaintenance, portal.OrderStatusProcessing, portal.OrderStatusCancelled},
+		statusPendingConfirmation:    {statusScheduledMaintenance, portal.OrderStatusCancelled},
+		statusScheduledMaintenance:   {statusMaintenanceInProgress, portal.OrderStatusCancelled},
+		statusMaintenanceInProgress:  {portal.OrderStatusCompleted, portal.OrderStatusFailed},
+		portal.OrderStatusProcessing: {portal.OrderStatusCompleted, portal.OrderStatusFailed},
+		portal.OrderStatusFailed:     {portal.OrderStatusPending},
+		portal.OrderStatusCancelled:  {portal.OrderStatusPending},
+	}).
+		Guard(statusScheduledMaintenance, maintenanceFreezeGuard).
+		Guard(statusMaintenanceInProgress, maintenanceFreezeGuard)
+}
+
+// stateMachineRegistry 订单类型 -> 状态机，读写由 stateMachineMu 保护
+var (
+	stateMachineMu       sync.RWMutex
+	stateMachineRegistry = map[portal.OrderType]*OrderStateMachine{
+		portal.OrderTypeElasticScaling: newElasticScalingStateMachine(),
+		portal.OrderTypeMaintenance:    newMaintenanceStateMachine(),
+	}
+)
+
+// RegisterOrderStateMachine 注册订单类型的状态机，覆盖已有的注册
+func RegisterOrderStateMachine(orderType portal.OrderType, machine *OrderStateMachine) {
+	stateMachineMu.Lock()
+	defer stateMachineMu.Unlock()
+	stateMachineRegistry[orderType] = machine
+}
+
+// GetOrderStateMachine 获取订单类型的状态机，未注册时返回通用订单状态机
+func GetOrderStateMachine(orderType portal.OrderType) *OrderStateMachine {
+	stateMachineMu.RLock()
+	defer stateMachineMu.RUnlock()
+	if machine, found := stateMachineRegistry[orderType]; found {
+		return machine
+	}
+	return defaultStateMachine
+}
@@ -0,0 +1,291 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newOrderTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), fmt.Sprintf("order_test_%d.db", time.Now().UnixNano()))
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&portal.Order{},
+		&portal.OrderDevice{},
+		&portal.ElasticScalingOrderDetail{},
+		&portal.MaintenanceOrderDetail{},
+		&portal.GeneralOrderDetail{},
+		&portal.DeploymentOrderDetail{},
+		&portal.OpsJob{},
+		&portal.FreezeWindow{},
+		&portal.DeviceReservation{},
+		&portal.Device{},
+		&portal.OrderStatusHistory{},
+		&portal.StrategyExecutionHistory{},
+	))
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func createTestOrder(t *testing.T, db *gorm.DB, orderType portal.OrderType, status portal.OrderStatus) *portal.Order {
+	t.Helper()
+	order := &portal.Order{OrderNumber: fmt.Sprintf("ORD-%d", time.Now().UnixNano()), Type: orderType, Status: status}
+	require.NoError(t, db.Create(order).Error)
+	return order
+}
+
+func orderStatus(t *testing.T, db *gorm.DB, id int) portal.OrderStatus {
+	t.Helper()
+	var order portal.Order
+	require.NoError(t, db.First(&order, id).Error)
+	return order.Status
+}
+
+func TestOrderStateMachineTables(t *testing.T) {
+	es := GetOrderStateMachine(portal.OrderTypeElasticScaling)
+	assert.True(t, es.CanTransition(portal.OrderStatusProcessing, portal.OrderStatusReturning))
+	assert.True(t, es.CanTransition(portal.OrderStatusReturning, portal.OrderStatusReturnCompleted))
+	assert.True(t, es.CanTransition(portal.OrderStatusNoReturn, portal.OrderStatusCompleted))
+	assert.False(t, es.CanTransition(portal.OrderStatusPending, portal.OrderStatusCompleted))
+	assert.False(t, es.CanTransition(portal.OrderStatusCompleted, portal.OrderStatusPending))
+
+	maintenance := GetOrderStateMachine(portal.OrderTypeMaintenance)
+	assert.True(t, maintenance.CanTransition(statusPendingConfirmation, statusScheduledMaintenance))
+	assert.True(t, maintenance.CanTransition(statusScheduledMaintenance, statusMaintenanceInProgress))
+	assert.False(t, maintenance.CanTransition(statusPendingConfirmation, statusMaintenanceInProgress))
+	assert.False(t, maintenance.CanTransition(portal.OrderStatusProcessing, portal.OrderStatusReturning))
+
+	// 未注册状态机的订单类型使用通用状态机，不包含归还流程
+	deployment := GetOrderStateMachine(portal.OrderTypeDeployment)
+	assert.Same(t, GetOrderStateMachine(portal.OrderTypeGeneral), deployment)
+	assert.True(t, deployment.CanTransition(portal.OrderStatusProcessing, portal.OrderStatusCompleted))
+	assert.False(t, deployment.CanTransition(portal.OrderStatusProcessing, portal.OrderStatusReturning))
+}
+
+func TestUpdateOrderStatusEnforcesTransitions(t *testing.T) {
+	db := newOrderTestDB(t)
+	s := NewOrderService(db)
+	ctx := context.Background()
+
+	t.Run("legal transitions are applied", func(t *testing.T) {
+		order := createTestOrder(t, db, portal.OrderTypeGeneral, portal.OrderStatusPending)
+		require.NoError(t, s.ProcessOrder(ctx, order.ID, "tester"))
+		require.NoError(t, s.CompleteOrder(ctx, order.ID, "tester"))
+		assert.Equal(t, portal.OrderStatusCompleted, orderStatus(t, db, order.ID))
+	})
+
+	t.Run("illegal transitions return a typed error", func(t *testing.T) {
+		order := createTestOrder(t, db, portal.OrderTypeGeneral, portal.OrderStatusPending)
+		err := s.CompleteOrder(ctx, order.ID, "tester")
+		require.Error(t, err)
+		assert.True(t, IsIllegalTransition(err))
+
+		var transitionErr *IllegalTransitionError
+		require.ErrorAs(t, err, &transitionErr)
+		assert.Equal(t, portal.OrderStatusPending, transitionErr.From)
+		assert.Equal(t, portal.OrderStatusCompleted, transitionErr.To)
+		assert.Equal(t, portal.OrderStatusPending, orderStatus(t, db, order.ID))
+	})
+
+	t.Run("missing order is not found", func(t *testing.T) {
+		err := s.ProcessOrder(ctx, 9999, "tester")
+		assert.True(t, service.IsNotFound(err))
+		assert.False(t, IsIllegalTransition(err))
+	})
+
+	t.Run("only pool exit orders enter the return branch", func(t *testing.T) {
+		entry := createTestOrder(t, db, portal.OrderTypeElasticScaling, portal.OrderStatusProcessing)
+		require.NoError(t, db.Create(&portal.ElasticScalingOrderDetail{OrderID: entry.ID, ActionType: "pool_entry"}).Error)
+		err := s.UpdateOrderStatus(ctx, entry.ID, portal.OrderStatusReturning, "system", "")
+		require.True(t, IsIllegalTransition(err))
+		assert.Contains(t, err.Error(), "只有退池订单")
+
+		exit := createTestOrder(t, db, portal.OrderTypeElasticScaling, portal.OrderStatusProcessing)
+		require.NoError(t, db.Create(&portal.ElasticScalingOrderDetail{OrderID: exit.ID, ActionType: actionTypePoolExit}).Error)
+		require.NoError(t, s.UpdateOrderStatus(ctx, exit.ID, portal.OrderStatusReturning, "system", ""))
+		require.NoError(t, s.UpdateOrderStatus(ctx, exit.ID, portal.OrderStatusReturnCompleted, "system", ""))
+		require.NoError(t, s.CompleteOrder(ctx, exit.ID, "system"))
+	})
+
+	t.Run("registered guards can reject transitions", func(t *testing.T) {
+		machine := NewOrderStateMachine(map[portal.OrderStatus][]portal.OrderStatus{
+			portal.OrderStatusPending: {portal.OrderStatusProcessing},
+		}).Guard(portal.OrderStatusProcessing, func(tx *gorm.DB, order *portal.Order, to portal.OrderStatus) error {
+			return RejectTransition(order, to, "部署窗口未开放")
+		})
+		RegisterOrderStateMachine(portal.OrderTypeDeployment, machine)
+		t.Cleanup(func() {
+			stateMachineMu.Lock()
+			defer stateMachineMu.Unlock()
+			delete(stateMachineRegistry, portal.OrderTypeDeployment)
+		})
+
+		order := createTestOrder(t, db, portal.OrderTypeDeployment, portal.OrderStatusPending)
+		err := s.ProcessOrder(ctx, order.ID, "tester")
+		require.True(t, IsIllegalTransition(err))
+		assert.Contains(t, err.Error(), "部署窗口未开放")
+	})
+}
+
+func TestMaintenanceOrderLifecycle(t *testing.T) {
+	db := newOrderTestDB(t)
+	s := NewMaintenanceOrderService(db, nil)
+	ctx := context.Background()
+
+	order := createTestOrder(t, db, portal.OrderTypeMaintenance, statusPendingConfirmation)
+	require.NoError(t, db.Create(&portal.MaintenanceOrderDetail{OrderID: order.ID, ExternalTicketID: "T-1", MaintenanceType: string(portal.MaintenanceTypeCordon)}).Error)
+
+	// 未安排维护前不能开始维护
+	assert.True(t, IsIllegalTransition(s.StartMaintenance(ctx, order.ID, "ops")))
+
+	require.NoError(t, s.ConfirmMaintenance(ctx, order.ID, "ops"))
+	assert.Equal(t, statusScheduledMaintenance, orderStatus(t, db, order.ID))
+	require.NoError(t, s.StartMaintenance(ctx, order.ID, "ops"))
+	assert.Equal(t, statusMaintenanceInProgress, orderStatus(t, db, order.ID))
+
+	detail, err := s.CompleteMaintenance(ctx, "T-1", "done")
+	require.NoError(t, err)
+	assert.Equal(t, portal.OrderStatusCompleted, detail.Order.Status)
+
+	uncordon := createTestOrder(t, db, portal.OrderTypeMaintenance, portal.OrderStatusPending)
+	require.NoError(t, s.ExecuteUncordon(ctx, uncordon.ID, "ops"))
+	assert.Equal(t, portal.OrderStatusCompleted, orderStatus(t, db, uncordon.ID))
+
+	t.Run("uncordon rolls back processing when completion is rejected", func(t *testing.T) {
+		RegisterOrderStateMachine(portal.OrderTypeMaintenance, newMaintenanceStateMachine().
+			Guard(portal.OrderStatusCompleted, func(tx *gorm.DB, order *portal.Order, to portal.OrderStatus) error {
+				return RejectTransition(order, to, "节点仍未就绪")
+			}))
+		t.Cleanup(func() { RegisterOrderStateMachine(portal.OrderTypeMaintenance, newMaintenanceStateMachine()) })
+
+		pending := createTestOrder(t, db, portal.OrderTypeMaintenance, portal.OrderStatusPending)
+		err := s.ExecuteUncordon(ctx, pending.ID, "ops")
+		require.True(t, IsIllegalTransition(err))
+		assert.Equal(t, portal.OrderStatusPending, orderStatus(t, db, pending.ID))
+
+		var histories int64
+		require.NoError(t, db.Model(&portal.OrderStatusHistory{}).Where("order_id = ?", pending.ID).Count(&histories).Error)
+		assert.Zero(t, histories)
+	})
+
+	t.Run("create rolls back when devices are reserved by an active order", func(t *testing.T) {
+		active := createTestOrder(t, db, portal.OrderTypeElasticScaling, portal.OrderStatusProcessing)
+		require.NoError(t, service.ReserveDevices(db, active.ID, []int{101}))
+
+		_, err := s.CreateOrder(ctx, MaintenanceOrderDTO{Name: "换盘", Devices: []int{100, 101}, ExternalTicketID: "T-4", MaintenanceType: portal.MaintenanceTypeCordon})
+		require.True(t, service.IsConflict(err))
+
+		var orders, details, orderDevices int64
+		require.NoError(t, db.Model(&portal.Order{}).Where("type = ? AND name = ?", portal.OrderTypeMaintenance, "换盘").Count(&orders).Error)
+		require.NoError(t, db.Model(&portal.MaintenanceOrderDetail{}).Where("external_ticket_id = ?", "T-4").Count(&details).Error)
+		require.NoError(t, db.Model(&portal.OrderDevice{}).Where("device_id IN ?", []int{100, 101}).Count(&orderDevices).Error)
+		assert.Zero(t, orders+details+orderDevices)
+
+		reserved, err := service.ReservedDeviceIDs(db, []int{100, 101})
+		require.NoError(t, err)
+		assert.Equal(t, map[int]int{101: active.ID}, reserved)
+	})
+
+	t.Run("freeze windows block confirming and starting maintenance", func(t *testing.T) {
+		clusterID := 7
+		frozen := createTestOrder(t, db, portal.OrderTypeMaintenance, statusPendingConfirmation)
+		require.NoError(t, db.Create(&portal.MaintenanceOrderDetail{OrderID: frozen.ID, ClusterID: clusterID, ExternalTicketID: "T-2", MaintenanceType: string(portal.MaintenanceTypeCordon)}).Error)
+		start, end := portal.NavyTime(time.Now().Add(-time.Hour)), portal.NavyTime(time.Now().Add(time.Hour))
+		window := &portal.FreezeWindow{Name: "大促封网", ClusterID: &clusterID, RuleType: portal.FreezeRuleTypeRange, StartTime: &start, EndTime: &end, Status: service.FreezeWindowStatusEnabled}
+		require.NoError(t, db.Create(window).Error)
+
+		err := s.ConfirmMaintenance(ctx, frozen.ID, "ops")
+		require.True(t, IsIllegalTransition(err))
+		assert.Contains(t, err.Error(), "大促封网")
+		assert.Equal(t, statusPendingConfirmation, orderStatus(t, db, frozen.ID))
+
+		// 冻结期开始前已确认的维护也不能开始
+		require.NoError(t, db.Model(frozen).Update("status", statusScheduledMaintenance).Error)
+		assert.True(t, IsIllegalTransition(s.StartMaintenance(ctx, frozen.ID, "ops")))
+
+		// 其他集群不受影响
+		other := createTestOrder(t, db, portal.OrderTypeMaintenance, statusPendingConfirmation)
+		require.NoError(t, db.Create(&portal.MaintenanceOrderDetail{OrderID: other.ID, ClusterID: clusterID + 1, ExternalTicketID: "T-3", MaintenanceType: string(portal.MaintenanceTypeCordon)}).Error)
+		require.NoError(t, s.ConfirmMaintenance(ctx, other.ID, "ops"))
+	})
+}
+
+func TestOrderStatusDeviceReservations(t *testing.T) {
+	db := newOrderTestDB(t)
+	s := NewMaintenanceOrderService(db, nil)
+	ctx := context.Background()
+
+	reservedBy := func(deviceID int) int {
+		t.Helper()
+		reserved, err := service.ReservedDeviceIDs(db, []int{deviceID})
+		require.NoError(t, err)
+		return reserved[deviceID]
+	}
+	newMaintenanceOrder := func(ticket string, deviceID int) int {
+		t.Helper()
+		order := createTestOrder(t, db, portal.OrderTypeMaintenance, portal.OrderStatusPending)
+		require.NoError(t, db.Create(&portal.MaintenanceOrderDetail{OrderID: order.ID, ExternalTicketID: ticket, MaintenanceType: string(portal.MaintenanceTypeCordon)}).Error)
+		require.NoError(t, db.Create(&portal.OrderDevice{OrderID: order.ID, DeviceID: deviceID, Status: string(portal.OrderStatusPending)}).Error)
+		require.NoError(t, service.ReserveDevices(db, order.ID, []int{deviceID}))
+		return order.ID
+	}
+
+	t.Run("maintenance statuses keep devices reserved", func(t *testing.T) {
+		id := newMaintenanceOrder("T-R1", 300)
+
+		require.NoError(t, s.UpdateOrderStatus(ctx, id, string(statusPendingConfirmation), "ops", ""))
+		assert.Equal(t, id, reservedBy(300))
+		require.NoError(t, s.ConfirmMaintenance(ctx, id, "ops"))
+		assert.Equal(t, id, reservedBy(300))
+		require.NoError(t, s.StartMaintenance(ctx, id, "ops"))
+		assert.Equal(t, id, reservedBy(300))
+
+		// 维护中的设备不能被其他订单占用
+		other := createTestOrder(t, db, portal.OrderTypeElasticScaling, portal.OrderStatusPending)
+		assert.True(t, service.IsConflict(service.ReserveDevices(db, other.ID, []int{300})))
+
+		require.NoError(t, s.CompleteOrder(ctx, id, "ops"))
+		assert.Zero(t, reservedBy(300))
+	})
+
+	t.Run("reopened orders reserve their devices again", func(t *testing.T) {
+		id := newMaintenanceOrder("T-R2", 301)
+		require.NoError(t, s.CancelOrder(ctx, id, "ops"))
+		assert.Zero(t, reservedBy(301))
+
+		require.NoError(t, s.UpdateOrderStatus(ctx, id, string(portal.OrderStatusPending), "ops", ""))
+		assert.Equal(t, id, reservedBy(301))
+	})
+
+	t.Run("reopening fails when devices were taken meanwhile", func(t *testing.T) {
+		id := newMaintenanceOrder("T-R3", 302)
+		require.NoError(t, s.ProcessOrder(ctx, id, "ops"))
+		require.NoError(t, s.FailOrder(ctx, id, "ops", "节点异常"))
+
+		other := createTestOrder(t, db, portal.OrderTypeElasticScaling, portal.OrderStatusPending)
+		require.NoError(t, service.ReserveDevices(db, other.ID, []int{302}))
+
+		err := s.UpdateOrderStatus(ctx, id, string(portal.OrderStatusPending), "ops", "")
+		require.True(t, service.IsConflict(err))
+		assert.Equal(t, portal.OrderStatusFailed, orderStatus(t, db, id))
+		assert.Equal(t, other.ID, reservedBy(302))
+	})
+}
@@ -0,0 +1,171 @@
+package order
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	. "navy-ng/server/portal/internal/service"
+
+	"navy-ng/models/portal"
+
+	"gorm.io/gorm"
+)
+
+// 订单时间线条目类型
+const (
+	TimelineKindCreated           = "created"            // 订单创建
+	TimelineKindStatus            = "status"             // 订单状态变更
+	TimelineKindDeviceStatus      = "device_status"      // 订单设备状态变更
+	TimelineKindStrategyExecution = "strategy_execution" // 策略执行记录
+)
+
+// OrderTimelineEntryDTO 订单时间线条目
+type OrderTimelineEntryDTO struct {
+	Time         time.Time `json:"time"`
+	Kind         string    `json:"kind"`
+	FromStatus   string    `json:"fromStatus,omitempty"`
+	ToStatus     string    `json:"toStatus,omitempty"`
+	DeviceID     *int      `json:"deviceId,omitempty"`
+	DeviceCICode string    `json:"deviceCiCode,omitempty"`
+	Executor     string    `json:"executor,omitempty"`
+	Source       string    `json:"source,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	StrategyID   *int      `json:"strategyId,omitempty"`
+	Result       string    `json:"result,omitempty"`
+}
+
+// statusSourceKey 状态变更来源在 context 中的键
+type statusSourceKey struct{}
+
+// WithStatusSource 在 context 中标记状态变更来源，写入状态变更记录时使用
+func WithStatusSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, statusSourceKey{}, source)
+}
+
+// WithDefaultStatusSource 在 context 未标记状态变更来源时标记为 source，已标记时保持调用方的来源
+func WithDefaultStatusSource(ctx context.Context, source string) context.Context {
+	if existing, ok := ctx.Value(statusSourceKey{}).(string); ok && existing != "" {
+		return ctx
+	}
+	return WithStatusSource(ctx, source)
+}
+
+// StatusSourceFromContext 获取 context 中的状态变更来源，未标记时视为接口调用
+func StatusSourceFromContext(ctx context.Context) string {
+	if source, ok := ctx.Value(statusSourceKey{}).(string); ok && source != "" {
+		return source
+	}
+	return portal.OrderStatusSourceAPI
+}
+
+// RecordStatusHistory 写入一条状态变更记录，应在状态更新的事务中调用
+func RecordStatusHistory(ctx context.Context, tx *gorm.DB, history *portal.OrderStatusHistory) error {
+	if history.Source == "" {
+		history.Source = StatusSourceFromContext(ctx)
+	}
+	return tx.Create(history).Error
+}
+
+// RecordOrderCreated 写入订单创建的状态变更记录（原状态为空），应在创建订单的事务中调用
+func RecordOrderCreated(ctx context.Context, tx *gorm.DB, order *portal.Order) error {
+	return RecordStatusHistory(ctx, tx, &portal.OrderStatusHistory{
+		OrderID:  order.ID,
+		ToStatus: string(order.Status),
+		Executor: order.CreatedBy,
+	})
+}
+
+// GetOrderTimeline 获取订单的完整时间线：订单创建、订单及设备的状态变更、关联的策略执行记录，按时间排序
+func (s *orderServiceImpl) GetOrderTimeline(ctx context.Context, id int) ([]OrderTimelineEntryDTO, error) {
+	db := s.db.WithContext(ctx)
+
+	var order portal.Order
+	if err := db.First(&order, id).Error; err != nil {
+		return nil, HandleDBError(err, "订单", id)
+	}
+
+	var histories []portal.OrderStatusHistory
+	if err := db.Where("order_id = ?", id).Order("id").Find(&histories).Error; err != nil {
+		return nil, err
+	}
+
+	var executions []portal.StrategyExecutionHistory
+	if err := db.Where("order_id = ?", id).Order("id").Find(&executions).Error; err != nil {
+		return nil, err
+	}
+
+	ciCodes, err := s.historyDeviceCICodes(db, histories)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := make([]OrderTimelineEntryDTO, 0, 1+len(histories)+len(executions))
+	timeline = append(timeline, OrderTimelineEntryDTO{
+		Time:     time.Time(order.CreatedAt),
+		Kind:     TimelineKindCreated,
+		Executor: order.CreatedBy,
+	})
+	for _, history := range histories {
+		// 订单创建记录合并到创建条目，补充创建来源和初始状态
+		if history.DeviceID == nil && history.FromStatus == "" {
+			timeline[0].ToStatus = history.ToStatus
+			timeline[0].Source = history.Source
+			continue
+		}
+		entry := OrderTimelineEntryDTO{
+			Time:       time.Time(history.CreatedAt),
+			Kind:       TimelineKindStatus,
+			FromStatus: history.FromStatus,
+			ToStatus:   history.ToStatus,
+			Executor:   history.Executor,
+			Source:     history.Source,
+			Reason:     history.Reason,
+		}
+		if history.DeviceID != nil {
+			entry.Kind = TimelineKindDeviceStatus
+			entry.DeviceID = history.DeviceID
+			entry.DeviceCICode = ciCodes[*history.DeviceID]
+		}
+		timeline = append(timeline, entry)
+	}
+	for _, execution := range executions {
+		strategyID := execution.StrategyID
+		timeline = append(timeline, OrderTimelineEntryDTO{
+			Time:       time.Time(execution.ExecutionTime),
+			Kind:       TimelineKindStrategyExecution,
+			StrategyID: &strategyID,
+			Result:     execution.Result,
+			Reason:     execution.Reason,
+		})
+	}
+
+	// 同一时刻的条目保持写入顺序
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Time.Before(timeline[j].Time)
+	})
+	return timeline, nil
+}
+
+// historyDeviceCICodes 获取设备状态变更记录中设备的 CI 编码
+func (s *orderServiceImpl) historyDeviceCICodes(db *gorm.DB, histories []portal.OrderStatusHistory) (map[int]string, error) {
+	var deviceIDs []int
+	for _, history := range histories {
+		if history.DeviceID != nil {
+			deviceIDs = append(deviceIDs, *history.DeviceID)
+		}
+	}
+	ciCodes := make(map[int]string, len(deviceIDs))
+	if len(deviceIDs) == 0 {
+		return ciCodes, nil
+	}
+
+	var devices []portal.Device
+	if err := db.Select("id", "ci_code").Where("id IN ?", deviceIDs).Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	for _, device := range devices {
+		ciCodes[device.ID] = device.CICode
+	}
+	return ciCodes, nil
+}
@@ -0,0 +1,78 @@
+package order
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"navy-ng/models/portal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateOrderStatusRecordsHistory(t *testing.T) {
+	db := newOrderTestDB(t)
+	s := NewOrderService(db)
+
+	order := createTestOrder(t, db, portal.OrderTypeGeneral, portal.OrderStatusPending)
+	require.NoError(t, s.ProcessOrder(context.Background(), order.ID, "alice"))
+	require.NoError(t, s.FailOrder(WithStatusSource(context.Background(), portal.OrderStatusSourceEvent), order.ID, "system", "设备操作失败"))
+	// 非法转换不写入记录
+	require.Error(t, s.CompleteOrder(context.Background(), order.ID, "alice"))
+
+	var histories []portal.OrderStatusHistory
+	require.NoError(t, db.Where("order_id = ?", order.ID).Order("id").Find(&histories).Error)
+	require.Len(t, histories, 2)
+
+	assert.Equal(t, "pending", histories[0].FromStatus)
+	assert.Equal(t, "processing", histories[0].ToStatus)
+	assert.Equal(t, "alice", histories[0].Executor)
+	assert.Equal(t, portal.OrderStatusSourceAPI, histories[0].Source)
+	assert.Nil(t, histories[0].DeviceID)
+
+	assert.Equal(t, "processing", histories[1].FromStatus)
+	assert.Equal(t, "failed", histories[1].ToStatus)
+	assert.Equal(t, "设备操作失败", histories[1].Reason)
+	assert.Equal(t, portal.OrderStatusSourceEvent, histories[1].Source)
+	assert.False(t, time.Time(histories[1].CreatedAt).IsZero())
+}
+
+func TestGetOrderTimeline(t *testing.T) {
+	db := newOrderTestDB(t)
+	s := NewOrderService(db)
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour)
+	at := func(minutes int) portal.NavyTime {
+		return portal.NavyTime(base.Add(time.Duration(minutes) * time.Minute))
+	}
+
+	order := &portal.Order{BaseModel: portal.BaseModel{CreatedAt: at(0)}, OrderNumber: "ORD-TIMELINE", Type: portal.OrderTypeElasticScaling, Status: portal.OrderStatusCompleted, CreatedBy: "system"}
+	require.NoError(t, db.Create(order).Error)
+	require.NoError(t, db.Create(&portal.Device{BaseModel: portal.BaseModel{ID: 7}, CICode: "node-7"}).Error)
+
+	deviceID := 7
+	require.NoError(t, db.Create(&portal.OrderStatusHistory{BaseModel: portal.BaseModel{CreatedAt: at(10)}, OrderID: order.ID, FromStatus: "pending", ToStatus: "processing", Executor: "alice", Source: portal.OrderStatusSourceAPI}).Error)
+	require.NoError(t, db.Create(&portal.OrderStatusHistory{BaseModel: portal.BaseModel{CreatedAt: at(20)}, OrderID: order.ID, DeviceID: &deviceID, FromStatus: "pending", ToStatus: "success", Executor: "system", Source: portal.OrderStatusSourceEvent}).Error)
+	require.NoError(t, db.Create(&portal.OrderStatusHistory{BaseModel: portal.BaseModel{CreatedAt: at(30)}, OrderID: order.ID, FromStatus: "processing", ToStatus: "completed", Executor: "system", Source: portal.OrderStatusSourceEvent}).Error)
+	require.NoError(t, db.Create(&portal.StrategyExecutionHistory{StrategyID: 3, ExecutionTime: at(15), Result: "order_processing_started", OrderID: &order.ID}).Error)
+	// 其他订单的记录不出现在时间线中
+	require.NoError(t, db.Create(&portal.OrderStatusHistory{OrderID: order.ID + 1, ToStatus: "processing"}).Error)
+
+	timeline, err := s.GetOrderTimeline(ctx, order.ID)
+	require.NoError(t, err)
+	require.Len(t, timeline, 5)
+
+	kinds := make([]string, 0, len(timeline))
+	for _, entry := range timeline {
+		kinds = append(kinds, entry.Kind)
+	}
+	assert.Equal(t, []string{TimelineKindCreated, TimelineKindStatus, TimelineKindStrategyExecution, TimelineKindDeviceStatus, TimelineKindStatus}, kinds)
+	assert.Equal(t, "node-7", timeline[3].DeviceCICode)
+	assert.Equal(t, 3, *timeline[2].StrategyID)
+	assert.Equal(t, "completed", timeline[4].ToStatus)
+
+	_, err = s.GetOrderTimeline(ctx, order.ID+100)
+	assert.Error(t, err)
+}
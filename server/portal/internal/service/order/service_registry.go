@@ -3,32 +3,29 @@ package order
 import (
 	"fmt"
 	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service/events"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
-// InitializeOrderServices 初始化并注册所有订单服务
-func InitializeOrderServices(db *gorm.DB, logger *zap.Logger) {
-	// 注册弹性伸缩订单服务
-	// elasticScalingService := NewElasticScalingService(db, redisHandler, logger, deviceCache)
-	// RegisterOrderService(string(portal.OrderTypeElasticScaling), elasticScalingService)
+// InitializeOrderServices 初始化并注册本包实现的订单服务。
+// 部署订单服务在 eventManager 不为空时订阅运维任务事件；
+// 弹性伸缩订单服务依赖 Redis 和事件管理器，由创建它的调用方注册
+func InitializeOrderServices(db *gorm.DB, logger *zap.Logger, eventManager *events.EventManager) {
+	// 注册通用订单服务
+	RegisterOrderService(portal.OrderTypeGeneral, NewGeneralOrderService(db))
 
 	// 注册维护订单服务
-	maintenanceOrderService := NewMaintenanceOrderService(db, logger)
-	RegisterOrderService(string(portal.OrderTypeMaintenance), maintenanceOrderService)
+	RegisterOrderService[*MaintenanceOrderDetailDTO, MaintenanceOrderDTO](portal.OrderTypeMaintenance, NewMaintenanceOrderService(db, logger))
 
-	// 可以继续注册其他类型的订单服务
-	// deploymentService := NewDeploymentOrderService(db, logger)
-	// RegisterOrderService(string(portal.OrderTypeDeployment), deploymentService)
-
-	// generalOrderService := NewGeneralOrderService(db, logger)
-	// RegisterOrderService(string(portal.OrderTypeGeneral), generalOrderService)
+	// 注册部署订单服务，订单状态跟随关联运维任务的事件推进
+	RegisterOrderService[*DeploymentOrderDTO, DeploymentOrderCreateDTO](portal.OrderTypeDeployment, NewDeploymentOrderService(db, logger, eventManager))
 }
 
 // GetMaintenanceOrderService 获取维护订单服务
 func GetMaintenanceOrderService() (*MaintenanceOrderService, error) {
-	service, found := GetOrderService(string(portal.OrderTypeMaintenance))
+	service, found := GetTypedOrderService[*MaintenanceOrderDetailDTO, MaintenanceOrderDTO](portal.OrderTypeMaintenance)
 	if !found {
 		return nil, fmt.Errorf("维护订单服务未注册")
 	}
@@ -39,4 +36,4 @@ func GetMaintenanceOrderService() (*MaintenanceOrderService, error) {
 	}
 
 	return maintenanceService, nil
-}
\ No newline at end of file
+}
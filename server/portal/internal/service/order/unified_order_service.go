@@ -2,7 +2,17 @@ package order
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	. "navy-ng/server/portal/internal/service"
+
 	"navy-ng/models/portal"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // RichOrder 是一个所有订单详情DTO都必须实现的接口。
@@ -11,31 +21,165 @@ type RichOrder interface {
 	GetBaseOrder() *portal.Order
 }
 
+// OrderLifecycle 定义了与订单类型无关的状态更新及生命周期快捷操作
+type OrderLifecycle interface {
+	UpdateOrderStatus(ctx context.Context, id int, status string, executor string, reason string) error
+	ProcessOrder(ctx context.Context, id int, executor string) error
+	CompleteOrder(ctx context.Context, id int, executor string) error
+	FailOrder(ctx context.Context, id int, executor string, reason string) error
+	CancelOrder(ctx context.Context, id int, executor string) error
+}
+
 // UnifiedOrderService 定义了统一订单管理服务的契约。
 // 它使用泛型来处理不同订单类型的创建（C）和返回（T）数据结构。
 type UnifiedOrderService[T RichOrder, C any] interface {
 	CreateOrder(ctx context.Context, createDTO C) (T, error)
 	GetOrder(ctx context.Context, id int) (T, error)
+	// ListOrders 的 query 为 *gin.Context 时由服务自行解析查询参数，为 nil 时不做过滤
 	ListOrders(ctx context.Context, query any) ([]T, int, error)
-	UpdateOrderStatus(ctx context.Context, id int, status string, executor string, reason string) error
 
-	// 订单生命周期快捷操作
-	ProcessOrder(ctx context.Context, id int, executor string) error
-	CompleteOrder(ctx context.Context, id int, executor string) error
-	FailOrder(ctx context.Context, id int, executor string, reason string) error
-	CancelOrder(ctx context.Context, id int, executor string) error
+	// 订单状态更新及生命周期快捷操作
+	OrderLifecycle
+}
+
+// OrderTypeService 是按订单类型分发请求时使用的服务，由 RegisterOrderService 包装 UnifiedOrderService 得到。
+// 创建订单的数据结构由具体服务决定，调用方通过 bind 将请求解码到服务提供的结构中
+type OrderTypeService interface {
+	OrderType() portal.OrderType
+	CreateOrder(ctx context.Context, bind func(createDTO any) error) (RichOrder, error)
+	GetOrder(ctx context.Context, id int) (RichOrder, error)
+	ListOrders(ctx context.Context, query any) (any, int, error)
+	OrderLifecycle
+}
+
+// typedOrderService 将泛型的 UnifiedOrderService 适配为 OrderTypeService
+type typedOrderService[T RichOrder, C any] struct {
+	OrderLifecycle
+	orderType portal.OrderType
+	service   UnifiedOrderService[T, C]
+}
+
+// OrderType 返回服务处理的订单类型
+func (s *typedOrderService[T, C]) OrderType() portal.OrderType {
+	return s.orderType
+}
+
+// CreateOrder 将请求解码为服务的创建结构后创建订单
+func (s *typedOrderService[T, C]) CreateOrder(ctx context.Context, bind func(createDTO any) error) (RichOrder, error) {
+	var createDTO C
+	if err := bind(&createDTO); err != nil {
+		return nil, NewBadRequestError(err.Error())
+	}
+	return s.service.CreateOrder(ctx, createDTO)
+}
+
+// GetOrder 获取订单详情
+func (s *typedOrderService[T, C]) GetOrder(ctx context.Context, id int) (RichOrder, error) {
+	return s.service.GetOrder(ctx, id)
+}
+
+// ListOrders 获取订单列表，列表保持服务返回的具体类型以便序列化
+func (s *typedOrderService[T, C]) ListOrders(ctx context.Context, query any) (any, int, error) {
+	return s.service.ListOrders(ctx, query)
 }
 
-// serviceRegistry 用于存储不同订单类型对应的服务实例。
-var serviceRegistry = make(map[string]any)
+// OrderServiceRegistry 订单类型 -> 订单服务的注册表，并发安全
+type OrderServiceRegistry struct {
+	mu       sync.RWMutex
+	services map[portal.OrderType]OrderTypeService
+}
+
+// NewOrderServiceRegistry 创建空的订单服务注册表
+func NewOrderServiceRegistry() *OrderServiceRegistry {
+	return &OrderServiceRegistry{services: make(map[portal.OrderType]OrderTypeService)}
+}
 
-// RegisterOrderService 注册一个订单服务。
-func RegisterOrderService(orderType string, service any) {
-	serviceRegistry[orderType] = service
+// Register 注册订单服务，覆盖同类型已有的注册
+func (r *OrderServiceRegistry) Register(service OrderTypeService) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.services[service.OrderType()] = service
 }
 
-// GetOrderService 从注册表中获取一个订单服务。
-func GetOrderService(orderType string) (any, bool) {
-	service, found := serviceRegistry[orderType]
+// Get 获取订单类型对应的服务
+func (r *OrderServiceRegistry) Get(orderType portal.OrderType) (OrderTypeService, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	service, found := r.services[orderType]
 	return service, found
-}
\ No newline at end of file
+}
+
+// Types 返回已注册的订单类型，按名称排序
+func (r *OrderServiceRegistry) Types() []portal.OrderType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]portal.OrderType, 0, len(r.services))
+	for orderType := range r.services {
+		types = append(types, orderType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// AsOrderTypeService 将 UnifiedOrderService 包装为处理指定订单类型的 OrderTypeService
+func AsOrderTypeService[T RichOrder, C any](orderType portal.OrderType, service UnifiedOrderService[T, C]) OrderTypeService {
+	return &typedOrderService[T, C]{OrderLifecycle: service, orderType: orderType, service: service}
+}
+
+// serviceRegistry 全局订单服务注册表
+var serviceRegistry = NewOrderServiceRegistry()
+
+// RegisterOrderService 在全局注册表中注册一个订单服务。
+func RegisterOrderService[T RichOrder, C any](orderType portal.OrderType, service UnifiedOrderService[T, C]) {
+	serviceRegistry.Register(AsOrderTypeService(orderType, service))
+}
+
+// GetOrderService 从全局注册表中获取一个订单服务。
+func GetOrderService(orderType portal.OrderType) (OrderTypeService, bool) {
+	return serviceRegistry.Get(orderType)
+}
+
+// RegisteredOrderTypes 返回全局注册表中已注册的订单类型
+func RegisteredOrderTypes() []portal.OrderType {
+	return serviceRegistry.Types()
+}
+
+// GetTypedOrderService 从全局注册表中获取指定泛型参数的订单服务，类型不匹配时返回 false
+func GetTypedOrderService[T RichOrder, C any](orderType portal.OrderType) (UnifiedOrderService[T, C], bool) {
+	service, found := serviceRegistry.Get(orderType)
+	if !found {
+		return nil, false
+	}
+	typed, ok := service.(*typedOrderService[T, C])
+	if !ok {
+		return nil, false
+	}
+	return typed.service, true
+}
+
+// CheckOrderType 校验订单存在且属于指定类型，否则返回未找到错误
+func CheckOrderType(ctx context.Context, db *gorm.DB, id int, orderType portal.OrderType) error {
+	var order portal.Order
+	if err := db.WithContext(ctx).Select("id", "type").First(&order, id).Error; err != nil {
+		return HandleDBError(err, "订单", id)
+	}
+	if order.Type != orderType {
+		return NewNotFoundError(fmt.Sprintf("%s 订单", orderType), id)
+	}
+	return nil
+}
+
+// BindListQuery 将订单列表的查询参数解析到 dst：query 为 *gin.Context 时解析 URL 查询参数，为 nil 时保持 dst 不变
+func BindListQuery(query any, dst any) error {
+	switch q := query.(type) {
+	case nil:
+		return nil
+	case *gin.Context:
+		if err := q.ShouldBindQuery(dst); err != nil {
+			return NewBadRequestError(err.Error())
+		}
+		return nil
+	default:
+		return errors.New(errQueryContextInvalid)
+	}
+}
@@ -0,0 +1,81 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"navy-ng/models/portal"
+	"navy-ng/server/portal/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderServiceRegistry(t *testing.T) {
+	db := newOrderTestDB(t)
+	InitializeOrderServices(db, nil, nil)
+	ctx := context.Background()
+
+	assert.Contains(t, RegisteredOrderTypes(), portal.OrderTypeGeneral)
+	assert.Contains(t, RegisteredOrderTypes(), portal.OrderTypeMaintenance)
+	assert.Contains(t, RegisteredOrderTypes(), portal.OrderTypeDeployment)
+
+	maintenanceService, err := GetMaintenanceOrderService()
+	require.NoError(t, err)
+	assert.NotNil(t, maintenanceService)
+
+	_, found := GetTypedOrderService[*GeneralOrderDTO, GeneralOrderCreateDTO](portal.OrderTypeMaintenance)
+	assert.False(t, found, "泛型参数与注册的服务不一致时不应返回服务")
+
+	t.Run("create decodes into the service's own DTO", func(t *testing.T) {
+		s, found := GetOrderService(portal.OrderTypeMaintenance)
+		require.True(t, found)
+
+		created, err := s.CreateOrder(ctx, func(createDTO any) error {
+			return json.Unmarshal([]byte(`{"name":"维护","externalTicketId":"T-9","maintenanceType":"cordon","createdBy":"ops"}`), createDTO)
+		})
+		require.NoError(t, err)
+		require.IsType(t, &MaintenanceOrderDetailDTO{}, created)
+		assert.Equal(t, portal.OrderTypeMaintenance, created.GetBaseOrder().Type)
+
+		detail, err := s.GetOrder(ctx, created.GetBaseOrder().ID)
+		require.NoError(t, err)
+		assert.Equal(t, "T-9", detail.(*MaintenanceOrderDetailDTO).MaintenanceDetail.ExternalTicketID)
+
+		require.NoError(t, s.CancelOrder(ctx, created.GetBaseOrder().ID, "ops"))
+		assert.Equal(t, portal.OrderStatusCancelled, orderStatus(t, db, created.GetBaseOrder().ID))
+	})
+
+	t.Run("bind errors are bad requests", func(t *testing.T) {
+		s, _ := GetOrderService(portal.OrderTypeGeneral)
+		_, err := s.CreateOrder(ctx, func(createDTO any) error {
+			return json.Unmarshal([]byte(`{`), createDTO)
+		})
+		assert.True(t, service.IsBadRequest(err))
+	})
+
+	t.Run("list parses query parameters per type", func(t *testing.T) {
+		createTestOrder(t, db, portal.OrderTypeGeneral, portal.OrderStatusPending)
+		s, _ := GetOrderService(portal.OrderTypeGeneral)
+
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/orders/general?status=pending&pageSize=5", nil)
+		list, total, err := s.ListOrders(ctx, c)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Len(t, list.([]*GeneralOrderDTO), 1)
+	})
+}
+
+func TestCheckOrderType(t *testing.T) {
+	db := newOrderTestDB(t)
+	ctx := context.Background()
+	order := createTestOrder(t, db, portal.OrderTypeGeneral, portal.OrderStatusPending)
+
+	assert.NoError(t, CheckOrderType(ctx, db, order.ID, portal.OrderTypeGeneral))
+	assert.True(t, service.IsNotFound(CheckOrderType(ctx, db, order.ID, portal.OrderTypeMaintenance)))
+	assert.True(t, service.IsNotFound(CheckOrderType(ctx, db, 9999, portal.OrderTypeGeneral)))
+}
@@ -6,19 +6,26 @@ import (
 
 // ResourcePoolDeviceMatchingPolicy 资源池设备匹配策略DTO
 type ResourcePoolDeviceMatchingPolicy struct {
-	ID               int           `json:"id"`                                                       // 主键
-	Name             string        `json:"name" binding:"required"`                                  // 策略名称
-	Description      string        `json:"description"`                                              // 策略描述
-	ResourcePoolType string        `json:"resourcePoolType" binding:"required"`                      // 资源池类型
-	ActionType       string        `json:"actionType" binding:"required,oneof=pool_entry pool_exit"` // 动作类型：pool_entry 或 pool_exit
-	QueryTemplateID  int           `json:"queryTemplateId" binding:"required"`                       // 关联的查询模板ID
-	QueryGroups      []FilterGroup `json:"queryGroups,omitempty"`                                    // 查询条件组（从查询模板获取，非直接存储字段）
-	Status           string        `json:"status" binding:"required,oneof=enabled disabled"`         // 状态：enabled 或 disabled
-	AdditionConds    []string      `json:"additionConds,omitempty"`                                  // 额外动态条件，仅入池时有效
-	CreatedBy        string        `json:"createdBy"`                                                // 创建者
-	UpdatedBy        string        `json:"updatedBy"`                                                // 更新者
-	CreatedAt        time.Time     `json:"createdAt"`                                                // 创建时间
-	UpdatedAt        time.Time     `json:"updatedAt"`                                                // 更新时间
+	ID                   int           `json:"id"`                                                                     // 主键
+	Name                 string        `json:"name" binding:"required"`                                                // 策略名称
+	Description          string        `json:"description"`                                                            // 策略描述
+	ResourcePoolType     string        `json:"resourcePoolType" binding:"required"`                                    // 资源池类型
+	ActionType           string        `json:"actionType" binding:"required,oneof=pool_entry pool_exit"`               // 动作类型：pool_entry 或 pool_exit
+	QueryTemplateID      int           `json:"queryTemplateId" binding:"required"`                                     // 关联的查询模板ID
+	QueryGroups          []FilterGroup `json:"queryGroups,omitempty"`                                                  // 查询条件组（从查询模板获取，非直接存储字段）
+	Status               string        `json:"status" binding:"required,oneof=enabled disabled"`                       // 状态：enabled 或 disabled
+	AdditionConds        []string      `json:"additionConds,omitempty"`                                                // 额外动态条件，仅入池时有效
+	SelectionAlgorithm   string        `json:"selectionAlgorithm" binding:"omitempty,oneof=greedy balanced min_waste"` // 设备选择算法，为空时为 greedy
+	MaxDevicesPerCabinet int           `json:"maxDevicesPerCabinet" binding:"min=0"`                                   // 单次选择每个机柜最多设备数，为0时不限制
+	MaxDevicesPerRoom    int           `json:"maxDevicesPerRoom" binding:"min=0"`                                      // 单次选择每个机房最多设备数，为0时不限制
+	BalanceAcrossRooms   bool          `json:"balanceAcrossRooms"`                                                     // 是否优先在机房之间均衡选择
+	MinDevicesPerCabinet int           `json:"minDevicesPerCabinet" binding:"min=0"`                                   // 出池时每个机柜至少保留的集群设备数，为0时不限制
+	Priority             int           `json:"priority" binding:"min=0"`                                               // 优先级，数值越小越先执行
+	CombineMode          string        `json:"combineMode" binding:"omitempty,oneof=union first_wins fill_remaining"`  // 多个策略的组合模式，为空时为 union，以优先级最高的策略为准
+	CreatedBy            string        `json:"createdBy"`                                                              // 创建者
+	UpdatedBy            string        `json:"updatedBy"`                                                              // 更新者
+	CreatedAt            time.Time     `json:"createdAt"`                                                              // 创建时间
+	UpdatedAt            time.Time     `json:"updatedAt"`                                                              // 更新时间
 
 	// 关联的查询模板信息
 	QueryTemplate *QueryTemplate `json:"queryTemplate,omitempty"` // 关联的查询模板
@@ -34,24 +41,38 @@ type ResourcePoolDeviceMatchingPolicyListResponse struct {
 
 // CreateResourcePoolDeviceMatchingPolicyRequest 创建资源池设备匹配策略请求
 type CreateResourcePoolDeviceMatchingPolicyRequest struct {
-	Name             string   `json:"name" binding:"required"`                                  // 策略名称
-	Description      string   `json:"description"`                                              // 策略描述
-	ResourcePoolType string   `json:"resourcePoolType" binding:"required"`                      // 资源池类型
-	ActionType       string   `json:"actionType" binding:"required,oneof=pool_entry pool_exit"` // 动作类型：pool_entry 或 pool_exit
-	QueryTemplateID  int      `json:"queryTemplateId" binding:"required"`                       // 关联的查询模板ID
-	Status           string   `json:"status" binding:"required,oneof=enabled disabled"`         // 状态：enabled 或 disabled
-	AdditionConds    []string `json:"additionConds,omitempty"`                                  // 额外动态条件，仅入池时有效
+	Name                 string   `json:"name" binding:"required"`                                                // 策略名称
+	Description          string   `json:"description"`                                                            // 策略描述
+	ResourcePoolType     string   `json:"resourcePoolType" binding:"required"`                                    // 资源池类型
+	ActionType           string   `json:"actionType" binding:"required,oneof=pool_entry pool_exit"`               // 动作类型：pool_entry 或 pool_exit
+	QueryTemplateID      int      `json:"queryTemplateId" binding:"required"`                                     // 关联的查询模板ID
+	Status               string   `json:"status" binding:"required,oneof=enabled disabled"`                       // 状态：enabled 或 disabled
+	AdditionConds        []string `json:"additionConds,omitempty"`                                                // 额外动态条件，仅入池时有效
+	SelectionAlgorithm   string   `json:"selectionAlgorithm" binding:"omitempty,oneof=greedy balanced min_waste"` // 设备选择算法，为空时为 greedy
+	MaxDevicesPerCabinet int      `json:"maxDevicesPerCabinet" binding:"min=0"`                                   // 单次选择每个机柜最多设备数，为0时不限制
+	MaxDevicesPerRoom    int      `json:"maxDevicesPerRoom" binding:"min=0"`                                      // 单次选择每个机房最多设备数，为0时不限制
+	BalanceAcrossRooms   bool     `json:"balanceAcrossRooms"`                                                     // 是否优先在机房之间均衡选择
+	MinDevicesPerCabinet int      `json:"minDevicesPerCabinet" binding:"min=0"`                                   // 出池时每个机柜至少保留的集群设备数，为0时不限制
+	Priority             int      `json:"priority" binding:"min=0"`                                               // 优先级，数值越小越先执行
+	CombineMode          string   `json:"combineMode" binding:"omitempty,oneof=union first_wins fill_remaining"`  // 多个策略的组合模式，为空时为 union，以优先级最高的策略为准
 }
 
 // UpdateResourcePoolDeviceMatchingPolicyRequest 更新资源池设备匹配策略请求
 type UpdateResourcePoolDeviceMatchingPolicyRequest struct {
-	Name             string   `json:"name" binding:"required"`                                  // 策略名称
-	Description      string   `json:"description"`                                              // 策略描述
-	ResourcePoolType string   `json:"resourcePoolType" binding:"required"`                      // 资源池类型
-	ActionType       string   `json:"actionType" binding:"required,oneof=pool_entry pool_exit"` // 动作类型：pool_entry 或 pool_exit
-	QueryTemplateID  int      `json:"queryTemplateId" binding:"required"`                       // 关联的查询模板ID
-	Status           string   `json:"status" binding:"required,oneof=enabled disabled"`         // 状态：enabled 或 disabled
-	AdditionConds    []string `json:"additionConds,omitempty"`                                  // 额外动态条件，仅入池时有效
+	Name                 string   `json:"name" binding:"required"`                                                // 策略名称
+	Description          string   `json:"description"`                                                            // 策略描述
+	ResourcePoolType     string   `json:"resourcePoolType" binding:"required"`                                    // 资源池类型
+	ActionType           string   `json:"actionType" binding:"required,oneof=pool_entry pool_exit"`               // 动作类型：pool_entry 或 pool_exit
+	QueryTemplateID      int      `json:"queryTemplateId" binding:"required"`                                     // 关联的查询模板ID
+	Status               string   `json:"status" binding:"required,oneof=enabled disabled"`                       // 状态：enabled 或 disabled
+	AdditionConds        []string `json:"additionConds,omitempty"`                                                // 额外动态条件，仅入池时有效
+	SelectionAlgorithm   string   `json:"selectionAlgorithm" binding:"omitempty,oneof=greedy balanced min_waste"` // 设备选择算法，为空时为 greedy
+	MaxDevicesPerCabinet int      `json:"maxDevicesPerCabinet" binding:"min=0"`                                   // 单次选择每个机柜最多设备数，为0时不限制
+	MaxDevicesPerRoom    int      `json:"maxDevicesPerRoom" binding:"min=0"`                                      // 单次选择每个机房最多设备数，为0时不限制
+	BalanceAcrossRooms   bool     `json:"balanceAcrossRooms"`                                                     // 是否优先在机房之间均衡选择
+	MinDevicesPerCabinet int      `json:"minDevicesPerCabinet" binding:"min=0"`                                   // 出池时每个机柜至少保留的集群设备数，为0时不限制
+	Priority             int      `json:"priority" binding:"min=0"`                                               // 优先级，数值越小越先执行
+	CombineMode          string   `json:"combineMode" binding:"omitempty,oneof=union first_wins fill_remaining"`  // 多个策略的组合模式，为空时为 union，以优先级最高的策略为准
 }
 
 // UpdateResourcePoolDeviceMatchingPolicyStatusRequest 更新资源池设备匹配策略状态请求
@@ -62,49 +83,71 @@ type UpdateResourcePoolDeviceMatchingPolicyStatusRequest struct {
 // ToServiceModel 将创建请求转换为服务模型
 func (req *CreateResourcePoolDeviceMatchingPolicyRequest) ToServiceModel(username string) *ResourcePoolDeviceMatchingPolicy {
 	return &ResourcePoolDeviceMatchingPolicy{
-		Name:             req.Name,
-		Description:      req.Description,
-		ResourcePoolType: req.ResourcePoolType,
-		ActionType:       req.ActionType,
-		QueryTemplateID:  req.QueryTemplateID,
-		Status:           req.Status,
-		AdditionConds:    req.AdditionConds,
-		CreatedBy:        username,
-		UpdatedBy:        username,
+		Name:                 req.Name,
+		Description:          req.Description,
+		ResourcePoolType:     req.ResourcePoolType,
+		ActionType:           req.ActionType,
+		QueryTemplateID:      req.QueryTemplateID,
+		Status:               req.Status,
+		AdditionConds:        req.AdditionConds,
+		SelectionAlgorithm:   req.SelectionAlgorithm,
+		MaxDevicesPerCabinet: req.MaxDevicesPerCabinet,
+		MaxDevicesPerRoom:    req.MaxDevicesPerRoom,
+		BalanceAcrossRooms:   req.BalanceAcrossRooms,
+		MinDevicesPerCabinet: req.MinDevicesPerCabinet,
+		Priority:             req.Priority,
+		CombineMode:          req.CombineMode,
+		CreatedBy:            username,
+		UpdatedBy:            username,
 	}
 }
 
 // ToServiceModel 将更新请求转换为服务模型
 func (req *UpdateResourcePoolDeviceMatchingPolicyRequest) ToServiceModel(id int, username string) *ResourcePoolDeviceMatchingPolicy {
 	return &ResourcePoolDeviceMatchingPolicy{
-		ID:               id,
-		Name:             req.Name,
-		Description:      req.Description,
-		ResourcePoolType: req.ResourcePoolType,
-		ActionType:       req.ActionType,
-		QueryTemplateID:  req.QueryTemplateID,
-		Status:           req.Status,
-		AdditionConds:    req.AdditionConds,
-		UpdatedBy:        username,
+		ID:                   id,
+		Name:                 req.Name,
+		Description:          req.Description,
+		ResourcePoolType:     req.ResourcePoolType,
+		ActionType:           req.ActionType,
+		QueryTemplateID:      req.QueryTemplateID,
+		Status:               req.Status,
+		AdditionConds:        req.AdditionConds,
+		SelectionAlgorithm:   req.SelectionAlgorithm,
+		MaxDevicesPerCabinet: req.MaxDevicesPerCabinet,
+		MaxDevicesPerRoom:    req.MaxDevicesPerRoom,
+		BalanceAcrossRooms:   req.BalanceAcrossRooms,
+		MinDevicesPerCabinet: req.MinDevicesPerCabinet,
+		Priority:             req.Priority,
+		CombineMode:          req.CombineMode,
+		UpdatedBy:            username,
 	}
 }
 
 // ToResponse 将服务模型转换为响应模型
 func (p *ResourcePoolDeviceMatchingPolicy) ToResponse() *ResourcePoolDeviceMatchingPolicy {
 	return &ResourcePoolDeviceMatchingPolicy{
-		ID:               p.ID,
-		Name:             p.Name,
-		Description:      p.Description,
-		ResourcePoolType: p.ResourcePoolType,
-		ActionType:       p.ActionType,
-		QueryTemplateID:  p.QueryTemplateID,
-		QueryGroups:      p.QueryGroups,
-		QueryTemplate:    p.QueryTemplate,
-		Status:           p.Status,
-		CreatedBy:        p.CreatedBy,
-		UpdatedBy:        p.UpdatedBy,
-		CreatedAt:        p.CreatedAt,
-		UpdatedAt:        p.UpdatedAt,
+		ID:                   p.ID,
+		Name:                 p.Name,
+		Description:          p.Description,
+		ResourcePoolType:     p.ResourcePoolType,
+		ActionType:           p.ActionType,
+		QueryTemplateID:      p.QueryTemplateID,
+		QueryGroups:          p.QueryGroups,
+		QueryTemplate:        p.QueryTemplate,
+		Status:               p.Status,
+		AdditionConds:        p.AdditionConds,
+		SelectionAlgorithm:   p.SelectionAlgorithm,
+		MaxDevicesPerCabinet: p.MaxDevicesPerCabinet,
+		MaxDevicesPerRoom:    p.MaxDevicesPerRoom,
+		BalanceAcrossRooms:   p.BalanceAcrossRooms,
+		MinDevicesPerCabinet: p.MinDevicesPerCabinet,
+		Priority:             p.Priority,
+		CombineMode:          p.CombineMode,
+		CreatedBy:            p.CreatedBy,
+		UpdatedBy:            p.UpdatedBy,
+		CreatedAt:            p.CreatedAt,
+		UpdatedAt:            p.UpdatedAt,
 	}
 }
 
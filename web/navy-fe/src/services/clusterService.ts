@@ -104,12 +104,84 @@ export async function getResourcePoolAllocationRate(
   }
 }
 
+// 集群位置映射
+export interface ClusterLocation {
+  id: number;
+  clusterId: number;
+  clusterName: string;
+  idc: string;
+  rooms: string[];
+  netZones: string[];
+  cabinets: string[];
+  description: string;
+  createdBy: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface ClusterLocationRequest {
+  clusterId: number;
+  idc?: string;
+  rooms?: string[];
+  netZones?: string[];
+  cabinets?: string[];
+  description?: string;
+}
+
+export interface ClusterLocationListResponse {
+  list: ClusterLocation[];
+  total: number;
+  page: number;
+  size: number;
+}
+
+const LOCATION_URL = 'cluster-locations';
+
+// 获取集群位置映射列表
+export async function getClusterLocations(params?: {
+  page?: number;
+  size?: number;
+  cluster_id?: number;
+}): Promise<ClusterLocationListResponse> {
+  return request(LOCATION_URL, {
+    method: 'GET',
+    params,
+  });
+}
+
+// 创建集群位置映射
+export async function createClusterLocation(data: ClusterLocationRequest): Promise<ClusterLocation> {
+  return request(LOCATION_URL, {
+    method: 'POST',
+    data,
+  });
+}
+
+// 更新集群位置映射
+export async function updateClusterLocation(id: number, data: ClusterLocationRequest): Promise<ClusterLocation> {
+  return request(`${LOCATION_URL}/${id}`, {
+    method: 'PUT',
+    data,
+  });
+}
+
+// 删除集群位置映射
+export async function deleteClusterLocation(id: number): Promise<void> {
+  return request(`${LOCATION_URL}/${id}`, {
+    method: 'DELETE',
+  });
+}
+
 const clusterService = {
   getClusters,
   getClusterDetail,
   getClusterLocationInfo,
   getClusterResources,
   getResourcePoolAllocationRate,
+  getClusterLocations,
+  createClusterLocation,
+  updateClusterLocation,
+  deleteClusterLocation,
 };
 
 export default clusterService;
@@ -11,7 +11,7 @@ import {
   PaginatedResponse
 } from '../types/elastic-scaling';
 // import { orderService } from './orderService';
-import type { OrderStatusUpdateRequest } from '../types/order';
+import type { OrderStatusUpdateRequest, OrderTimelineEntry } from '../types/order';
 
 const API_BASE = '/fe-v1/elastic-scaling';
 
@@ -98,9 +98,16 @@ export const orderApi = {
     return response.data.data;
   },
 
-  // 更新订单状态
-  updateOrderStatus: async (id: number, statusUpdate: OrderStatusUpdateRequest): Promise<void> => {
-    await axios.put(`${API_BASE}/orders/${id}/status`, statusUpdate);
+  // 更新订单状态，传入订单详情的版本号时携带 If-Match，订单已被修改时返回 412
+  updateOrderStatus: async (id: number, statusUpdate: OrderStatusUpdateRequest, version?: number): Promise<void> => {
+    const headers = version === undefined ? undefined : { 'If-Match': `"${version}"` };
+    await axios.put(`${API_BASE}/orders/${id}/status`, statusUpdate, { headers });
+  },
+
+  // 获取订单时间线
+  getOrderTimeline: async (id: number): Promise<OrderTimelineEntry[]> => {
+    const response = await axios.get(`${API_BASE}/orders/${id}/timeline`);
+    return response.data.data;
   },
 
   // 获取订单设备
@@ -21,6 +21,13 @@ export interface ResourcePoolDeviceMatchingPolicy {
   queryTemplate?: QueryTemplate; // 关联的查询模板
   status: 'enabled' | 'disabled';
   additionConds?: string[];     // 额外动态条件，仅入池时有效
+  selectionAlgorithm?: 'greedy' | 'balanced' | 'min_waste'; // 设备选择算法，为空时为 greedy
+  maxDevicesPerCabinet?: number; // 单次选择每个机柜最多设备数，0 为不限制
+  maxDevicesPerRoom?: number;    // 单次选择每个机房最多设备数，0 为不限制
+  balanceAcrossRooms?: boolean;  // 是否在机房间均衡选择
+  minDevicesPerCabinet?: number; // 出池时每个机柜至少保留的集群设备数，0 为不限制
+  priority?: number;             // 优先级，数值越小越先执行
+  combineMode?: 'union' | 'first_wins' | 'fill_remaining'; // 同一资源池和动作类型多个策略的组合模式，为空时为 union，以优先级最高的策略为准
   createdBy?: string;
   updatedBy?: string;
   createdAt?: string;
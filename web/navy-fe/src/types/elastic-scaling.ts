@@ -13,7 +13,13 @@ export interface Strategy {
   memoryTargetValue?: number;
   conditionLogic: 'AND' | 'OR';
   durationMinutes?: number;
+  durationUnit?: 'day' | 'hour' | 'minute'; // 为空时按历史规则推断
   cooldownMinutes?: number;
+  hysteresisMinutes?: number; // 反向订单防抖窗口（分钟），为0时不启用反向订单防抖
+  exitCeilingLevel?: 'warning' | 'critical'; // 出池护栏上限，为空时为 warning
+  triggerMode?: 'threshold' | 'forecast'; // 为空时为 threshold
+  forecastMethod?: 'linear' | 'holt';
+  forecastHorizonDays?: number;
 
   status: 'enabled' | 'disabled';
   createdBy: string;
@@ -21,6 +27,36 @@ export interface Strategy {
   updatedAt: string;
   clusters: string[];  // 策略列表视图使用
   clusterIds?: number[]; // 创建/编辑时使用
+  clusterOverrides?: StrategyClusterOverride[]; // 集群级覆盖配置
+}
+
+// 策略在单个集群上的覆盖配置，未设置的字段使用策略默认值
+export interface StrategyClusterOverride {
+  clusterId: number;
+  cpuThresholdValue?: number;
+  cpuTargetValue?: number;
+  memoryThresholdValue?: number;
+  memoryTargetValue?: number;
+  durationMinutes?: number; // 单位沿用策略的 durationUnit
+  cooldownMinutes?: number;
+  resourceTypes?: string;
+}
+
+// 策略在单个集群上的生效配置
+export interface StrategyClusterConfig {
+  clusterId: number;
+  clusterName: string;
+  cpuThresholdValue?: number;
+  cpuThresholdType?: 'usage' | 'allocated';
+  cpuTargetValue?: number;
+  memoryThresholdValue?: number;
+  memoryThresholdType?: 'usage' | 'allocated';
+  memoryTargetValue?: number;
+  durationMinutes: number;
+  durationUnit: string;
+  cooldownMinutes: number;
+  resourceTypes: string;
+  overriddenFields: string[];
 }
 
 // 策略执行历史类型定义
@@ -29,13 +65,17 @@ export interface StrategyExecutionHistory {
   executionTime: string;
   triggeredValue: string;
   thresholdValue: string;
-  result: 'order_created' | 'order_created_no_devices' | 'order_created_partial' | 'skipped' | 'failed_check';
+  result: 'order_created' | 'order_created_no_devices' | 'order_created_partial' | 'skipped' | 'failed_check' | 'blocked_anti_flapping' | 'skipped_freeze' | 'budget_exceeded' | 'blocked_exit_guardrail';
   orderId?: number;
   reason: string;
+  forecastInput?: string; // 预测模式下的预测输入与结果（JSON）
+  projectedCrossingDate?: string;
+  matchingTrace?: DeviceMatchingTrace; // 设备匹配后被拦截、未创建订单时的匹配过程
 }
 
 // 策略详情类型定义
 export interface StrategyDetail extends Strategy {
+  clusterConfigs: StrategyClusterConfig[];
   executionHistory: StrategyExecutionHistory[];
   relatedOrders: OrderListItem[];
 }
@@ -86,6 +126,8 @@ export interface Device {
   isSpecial: boolean;
   featureCount: number;
   orderStatus?: string; // 在订单中的状态
+  matchingPolicyId?: number; // 选中该设备的匹配策略ID
+  matchingPolicyName?: string; // 选中该设备的匹配策略名称
 }
 
 // 订单详情类型定义
@@ -100,6 +142,63 @@ export interface OrderDetail extends OrderListItem {
   maintenanceEndTime?: string;
   externalTicketId?: string;
   devices: Device[]; // 订单关联的所有设备
+  matchingTrace?: DeviceMatchingTrace; // 设备匹配过程，手动创建的订单为空
+  candidateCount?: number; // 设备匹配时查询到的候选设备总数
+  guardrailTrimmed?: boolean; // 出池设备是否被出池护栏裁剪
+  version?: number; // 订单版本号，更新状态时作为 If-Match 传回
+}
+
+// 多个匹配策略的组合模式
+export type PolicyCombineMode = 'union' | 'first_wins' | 'fill_remaining';
+
+// 候选设备未进入订单的原因
+export type MatchingRejectReason = 'reserved' | 'claimed' | 'incompatible' | 'do_not_evict' | 'cluster_mismatch' | 'not_selected' | 'assigned' | 'constraint_violated';
+
+// 未选中的候选设备
+export interface RejectedDeviceTrace {
+  deviceId: number;
+  ciCode?: string;
+  reason: MatchingRejectReason;
+  detail?: string;
+}
+
+// 单个匹配策略的匹配过程
+export interface PolicyMatchingTrace {
+  policyId: number;
+  policyName: string;
+  priority: number;
+  queryTemplateId: number;
+  cpuDelta: number; // 该策略需要满足的增量，fill_remaining 模式下为剩余增量
+  memDelta: number;
+  adopted: boolean; // 选择结果是否被订单采用
+  note?: string; // 未执行或未采用的原因
+  filterGroups: unknown[]; // 完整的查询条件，包括注入的集群和机房条件
+  error?: string;
+  queriedCount: number;
+  candidateCount: number; // 排除已预留和已被前序策略选中设备后的候选设备数
+  selectionAlgorithm?: string;
+  selectionScore: number;
+  selectedDeviceIds: number[];
+  rejectedCount: number;
+  rejectedDevices: RejectedDeviceTrace[]; // 最多记录 500 台
+  drainCosts?: Record<number, number>; // 出池时选中设备的排空代价
+}
+
+// 设备匹配过程
+export interface DeviceMatchingTrace {
+  strategyId: number;
+  clusterId: number;
+  resourceType: string;
+  actionType: string;
+  cpuDelta: number;
+  memDelta: number;
+  matchedAt: string;
+  combineMode: PolicyCombineMode;
+  candidateCount: number; // 各匹配策略查询到的不同设备总数
+  policies: PolicyMatchingTrace[]; // 按优先级排序
+  devicePolicies?: Record<number, number>; // 设备ID -> 选中该设备的匹配策略ID
+  orderDeviceIds: number[];
+  notes?: string[];
 }
 
 // 工作台统计数据类型定义
@@ -153,4 +252,75 @@ export interface PaginatedResponse<T> {
   total: number;
   page: number;
   size: number;
-}
\ No newline at end of file
+}
+// 变更冻结窗口类型定义
+export interface FreezeWindow {
+  id: number;
+  name: string;
+  description: string;
+  clusterId?: number | null; // 为空时全局生效
+  ruleType: 'range' | 'weekly' | 'dates';
+  startTime?: string; // range：开始时间
+  endTime?: string;   // range：结束时间
+  weekdays?: number[]; // weekly：0-6，0为周日
+  dates?: string[];    // dates：YYYY-MM-DD 为单日，MM-DD 为每年重复
+  status: 'enabled' | 'disabled';
+  createdBy: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+// 冻结状态查询结果
+export interface FreezeStatus {
+  frozen: boolean;
+  clusterId?: number;
+  checkedAt: string;
+  windows: FreezeWindow[];
+}
+
+// 自动伸缩预算类型定义
+export interface ScalingBudget {
+  id: number;
+  name: string;
+  clusterId?: number | null; // 为空时对所有集群生效
+  resourcePoolType: string;  // 为空时对所有资源池生效
+  actionType: 'pool_entry' | 'pool_exit';
+  period: 'daily' | 'weekly';
+  maxDevices: number; // 0 表示不限制
+  maxOrders: number;  // 0 表示不限制
+  status: 'enabled' | 'disabled';
+  createdBy: string;
+  usedDevices: number; // 当前周期已用设备数
+  usedOrders: number;  // 当前周期已用订单数
+  createdAt: string;
+  updatedAt: string;
+}
+
+// 资源池硬件兼容性规则，入池设备匹配时作为硬性过滤条件，各条件为空时不限制
+export interface ResourcePoolCompatibilityRule {
+  id: number;
+  resourcePoolType: string;
+  archTypes: string[];       // 允许的CPU架构
+  gpuRequirement: '' | 'required' | 'forbidden';
+  minDiskCount: number;      // 最少磁盘数
+  minNetworkSpeed: number;   // 最低网卡速率（Mb/s）
+  models: string[];          // 允许的型号前缀
+  osVersions: string[];      // 允许的操作系统版本前缀
+  description: string;
+  createdBy: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+// 出池排空保护规则：forbid 命中的节点不参与出池，penalize 命中的节点排空代价增加
+export interface DrainProtectionRule {
+  id: number;
+  kind: 'label' | 'taint';
+  key: string;
+  value: string;             // 为空时匹配任意值
+  action: 'forbid' | 'penalize';
+  description: string;
+  createdBy: string;
+  createdAt: string;
+  updatedAt: string;
+}
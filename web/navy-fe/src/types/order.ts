@@ -48,6 +48,21 @@ export interface OrderStatusUpdateRequest {
   type?: OrderType;
 }
 
+// 订单时间线条目
+export interface OrderTimelineEntry {
+  time: string;
+  kind: 'created' | 'status' | 'device_status' | 'strategy_execution';
+  fromStatus?: string;
+  toStatus?: string;
+  deviceId?: number;
+  deviceCiCode?: string;
+  executor?: string;
+  source?: 'api' | 'event' | 'monitor';
+  reason?: string;
+  strategyId?: number;
+  result?: string;
+}
+
 // 订单创建请求
 export interface OrderCreateRequest {
   name: string;